@@ -2,7 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/your-org/ryohi-router/src/lib/config"
@@ -12,15 +16,16 @@ import (
 )
 
 // GetRoutesHandler returns all routes
-func GetRoutesHandler(cfg *config.Config) http.HandlerFunc {
+func GetRoutesHandler(current func() *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(cfg.Routes)
 	}
 }
 
-// CreateRouteHandler creates a new route
-func CreateRouteHandler(cfg *config.Config) http.HandlerFunc {
+// CreateRouteHandler creates a new route, applying it with reload
+func CreateRouteHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var route models.RouteConfig
 		if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
@@ -33,8 +38,11 @@ func CreateRouteHandler(cfg *config.Config) http.HandlerFunc {
 			return
 		}
 		
-		// Add route to config (in memory only for now)
-		cfg.Routes = append(cfg.Routes, route)
+		next := current().Clone()
+		next.Routes = append(next.Routes, route)
+		if !applyConfig(w, next, reload) {
+			return
+		}
 		
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -43,8 +51,9 @@ func CreateRouteHandler(cfg *config.Config) http.HandlerFunc {
 }
 
 // GetRouteHandler returns a specific route
-func GetRouteHandler(cfg *config.Config) http.HandlerFunc {
+func GetRouteHandler(current func() *config.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
 		vars := mux.Vars(r)
 		routeID := vars["id"]
 		
@@ -60,8 +69,8 @@ func GetRouteHandler(cfg *config.Config) http.HandlerFunc {
 	}
 }
 
-// UpdateRouteHandler updates a route
-func UpdateRouteHandler(cfg *config.Config) http.HandlerFunc {
+// UpdateRouteHandler updates a route, applying it with reload
+func UpdateRouteHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		routeID := vars["id"]
@@ -77,9 +86,13 @@ func UpdateRouteHandler(cfg *config.Config) http.HandlerFunc {
 			return
 		}
 		
-		for i, route := range cfg.Routes {
+		next := current().Clone()
+		for i, route := range next.Routes {
 			if route.ID == routeID {
-				cfg.Routes[i] = updatedRoute
+				next.Routes[i] = updatedRoute
+				if !applyConfig(w, next, reload) {
+					return
+				}
 				w.Header().Set("Content-Type", "application/json")
 				json.NewEncoder(w).Encode(updatedRoute)
 				return
@@ -90,35 +103,222 @@ func UpdateRouteHandler(cfg *config.Config) http.HandlerFunc {
 	}
 }
 
-// DeleteRouteHandler deletes a route
-func DeleteRouteHandler(cfg *config.Config) http.HandlerFunc {
+// DeleteRouteHandler archives a route, or deletes it permanently with ?permanent=true,
+// applying the change with reload
+func DeleteRouteHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		routeID := vars["id"]
 		
-		for i, route := range cfg.Routes {
-			if route.ID == routeID {
-				// Remove route from slice
-				cfg.Routes = append(cfg.Routes[:i], cfg.Routes[i+1:]...)
-				w.WriteHeader(http.StatusNoContent)
+		next := current().Clone()
+		if r.URL.Query().Get("permanent") == "true" {
+			index := slices.IndexFunc(next.Routes, func(route models.RouteConfig) bool { return route.ID == routeID })
+			switch {
+			case index >= 0:
+				next.Routes = slices.Delete(next.Routes, index, index+1)
+			case !next.DeleteArchivedRoute(routeID):
+				http.Error(w, "Route not found", http.StatusNotFound)
+				return
+			}
+		} else {
+			if _, ok := next.ArchiveRoute(routeID, time.Now()); !ok {
+				http.Error(w, "Route not found", http.StatusNotFound)
+				return
+			}
+			next.PurgeArchivedRoutes(time.Now())
+		}
+		
+		if !applyConfig(w, next, reload) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetRouteEnabledHandler enables or disables a route without losing its definition.
+// The change is applied with reload, so it takes effect immediately.
+func SetRouteEnabledHandler(current func() *config.Config, reload func(*config.Config) error, enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		vars := mux.Vars(r)
+		routeID := vars["id"]
+		
+		next := cfg.Clone()
+		for i := range next.Routes {
+			if next.Routes[i].ID != routeID {
+				continue
+			}
+			
+			next.Routes[i].Enabled = enabled
+			if !applyConfig(w, next, reload) {
 				return
 			}
+			
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(next.Routes[i])
+			return
 		}
 		
 		http.Error(w, "Route not found", http.StatusNotFound)
 	}
 }
 
-// GetBackendsHandler returns all backends
-func GetBackendsHandler(cfg *config.Config) http.HandlerFunc {
+// applyConfig applies next, a changed clone of the running configuration, with reload. The
+// running configuration is shared with other goroutines, so it is swapped, never changed in
+// place. An invalid configuration is answered with 400 and a failed reload with 500, both
+// leaving the running one in place; it reports whether next was applied.
+func applyConfig(w http.ResponseWriter, next *config.Config, reload func(*config.Config) error) bool {
+	if err := next.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := reload(next); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// GetArchivedRoutesHandler returns all archived routes
+func GetArchivedRoutesHandler(current func() *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Expired routes are purged by the next change that is reloaded, not by a read
+		archived := current().UnexpiredArchivedRoutes(time.Now())
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(archived)
+	}
+}
+
+// RestoreRouteHandler restores an archived route, applying it with reload
+func RestoreRouteHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		routeID := vars["id"]
+		
+		next := current().Clone()
+		next.PurgeArchivedRoutes(time.Now())
+		
+		route, err := next.RestoreRoute(routeID)
+		if errors.Is(err, config.ErrRouteExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Archived route not found", http.StatusNotFound)
+			return
+		}
+		if !applyConfig(w, next, reload) {
+			return
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(route)
+	}
+}
+
+// backendView is a backend's configuration enriched with live runtime state
+type backendView struct {
+	models.BackendService
+	Runtime backendRuntimeView `json:"runtime"`
+}
+
+// backendRuntimeView merges the router's and health checker's view of a backend
+type backendRuntimeView struct {
+	CircuitBreaker string                `json:"circuit_breaker"`
+	Endpoints      []endpointRuntimeView `json:"endpoints"`
+}
+
+// endpointRuntimeView is the live state of a single endpoint
+type endpointRuntimeView struct {
+	router.EndpointRuntime
+	Health    string     `json:"health"` // healthy, unhealthy, unknown
+	LastCheck *time.Time `json:"last_check,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// newBackendView merges a backend's configuration with live state from the router and checker
+func newBackendView(backend models.BackendService, routerService *router.Router, checker *health.Checker) backendView {
+	view := backendView{
+		BackendService: backend,
+		Runtime:        backendRuntimeView{CircuitBreaker: "unknown", Endpoints: []endpointRuntimeView{}},
+	}
+	
+	var runtime router.BackendRuntime
+	if live, exists := routerService.GetBackend(backend.ID); exists {
+		runtime = live.Runtime()
+		view.Runtime.CircuitBreaker = runtime.CircuitBreaker
+	}
+	
+	for _, endpoint := range runtime.Endpoints {
+		endpointView := endpointRuntimeView{EndpointRuntime: endpoint, Health: "unknown"}
+		if health, checked := checker.GetEndpointHealth(backend.ID, endpoint.URL); checked {
+			endpointView.Health = healthState(health.Healthy)
+			endpointView.LastCheck = &health.LastCheck
+			endpointView.LastError = health.Error
+		}
+		view.Runtime.Endpoints = append(view.Runtime.Endpoints, endpointView)
+	}
+	
+	return view
+}
+
+// healthState converts a health flag to its status string
+func healthState(healthy bool) string {
+	if healthy {
+		return "healthy"
+	}
+	return "unhealthy"
+}
+
+// GetBackendsHandler returns all backends with live runtime state.
+// ?raw=true returns the configuration only.
+func GetBackendsHandler(current func() *config.Config, router *router.Router, checker *health.Checker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(cfg.Backends)
+		
+		if r.URL.Query().Get("raw") == "true" {
+			json.NewEncoder(w).Encode(cfg.Backends)
+			return
+		}
+		
+		views := make([]backendView, 0, len(cfg.Backends))
+		for _, backend := range cfg.Backends {
+			views = append(views, newBackendView(backend, router, checker))
+		}
+		json.NewEncoder(w).Encode(views)
+	}
+}
+
+// GetBackendHandler returns a specific backend with live runtime state.
+// ?raw=true returns the configuration only.
+func GetBackendHandler(current func() *config.Config, router *router.Router, checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		vars := mux.Vars(r)
+		backendID := vars["id"]
+		
+		for _, backend := range cfg.Backends {
+			if backend.ID != backendID {
+				continue
+			}
+			
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Query().Get("raw") == "true" {
+				json.NewEncoder(w).Encode(backend)
+				return
+			}
+			json.NewEncoder(w).Encode(newBackendView(backend, router, checker))
+			return
+		}
+		
+		http.Error(w, "Backend not found", http.StatusNotFound)
 	}
 }
 
-// CreateBackendHandler creates a new backend
-func CreateBackendHandler(cfg *config.Config) http.HandlerFunc {
+// CreateBackendHandler creates a new backend, applying it with reload
+func CreateBackendHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var backend models.BackendService
 		if err := json.NewDecoder(r.Body).Decode(&backend); err != nil {
@@ -131,8 +331,11 @@ func CreateBackendHandler(cfg *config.Config) http.HandlerFunc {
 			return
 		}
 		
-		// Add backend to config (in memory only for now)
-		cfg.Backends = append(cfg.Backends, backend)
+		next := current().Clone()
+		next.Backends = append(next.Backends, backend)
+		if !applyConfig(w, next, reload) {
+			return
+		}
 		
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -140,6 +343,92 @@ func CreateBackendHandler(cfg *config.Config) http.HandlerFunc {
 	}
 }
 
+// UpdateBackendHandler updates a backend, applying it with reload
+func UpdateBackendHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		backendID := vars["id"]
+		
+		var updatedBackend models.BackendService
+		if err := json.NewDecoder(r.Body).Decode(&updatedBackend); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		
+		if err := updatedBackend.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		
+		next := current().Clone()
+		for i, backend := range next.Backends {
+			if backend.ID == backendID {
+				// Renaming a backend would leave its routes pointing at the old ID
+				if updatedBackend.ID != backendID {
+					http.Error(w, "Backend ID does not match the path", http.StatusBadRequest)
+					return
+				}
+				next.Backends[i] = updatedBackend
+				if !applyConfig(w, next, reload) {
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(updatedBackend)
+				return
+			}
+		}
+		
+		http.Error(w, "Backend not found", http.StatusNotFound)
+	}
+}
+
+// DeleteBackendHandler deletes a backend, applying the change with reload.
+// Backends still used by an enabled route are kept and answered with 409 listing those routes.
+func DeleteBackendHandler(current func() *config.Config, reload func(*config.Config) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		vars := mux.Vars(r)
+		backendID := vars["id"]
+		
+		index := -1
+		for i, backend := range cfg.Backends {
+			if backend.ID == backendID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			http.Error(w, "Backend not found", http.StatusNotFound)
+			return
+		}
+		
+		routes := []string{}
+		for _, route := range cfg.References().BackendRoutes(backendID) {
+			if route.Enabled {
+				routes = append(routes, route.ID)
+			}
+		}
+		if len(routes) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Code:      "backend_in_use",
+				Message:   "Backend is used by enabled routes",
+				RequestID: r.Header.Get("X-Request-ID"),
+				Details:   map[string]interface{}{"routes": routes},
+			})
+			return
+		}
+		
+		next := cfg.Clone()
+		next.Backends = slices.Delete(next.Backends, index, index+1)
+		if !applyConfig(w, next, reload) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 // GetBackendHealthHandler returns health status for a backend
 func GetBackendHealthHandler(checker *health.Checker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -153,6 +442,10 @@ func GetBackendHealthHandler(checker *health.Checker) http.HandlerFunc {
 			"status":     status.Status,
 			"endpoints":  status.EndpointStatuses,
 		}
+		if reason := checker.DominantFailureReason(backendID); reason != "" {
+			response["dominant_failure_reason"] = reason
+			response["recent_errors"] = status.RecentErrors
+		}
 		
 		if status.Status == "unknown" {
 			w.WriteHeader(http.StatusNotFound)
@@ -163,23 +456,230 @@ func GetBackendHealthHandler(checker *health.Checker) http.HandlerFunc {
 	}
 }
 
-// ReloadConfigHandler reloads the configuration
-func ReloadConfigHandler(cfg *config.Config, router *router.Router) http.HandlerFunc {
+// ReloadConfigHandler reloads the configuration from its file with reload, which publishes
+// the reload event on success. A configuration that fails to load or validate is answered
+// with 500 and the running one kept.
+func ReloadConfigHandler(reload func() error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// In a real implementation, this would reload from file
-		// For now, just acknowledge the request
-		
-		if err := router.Reload(cfg); err != nil {
-			http.Error(w, "Failed to reload configuration", http.StatusInternalServerError)
+		if err := reload(); err != nil {
+			http.Error(w, "Failed to reload configuration: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 		
 		response := map[string]string{
 			"message":   "Configuration reloaded successfully",
-			"timestamp": "2025-09-12T00:00:00Z",
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// circuitBreakerView is a backend's circuit breaker statistics
+type circuitBreakerView struct {
+	BackendID string `json:"backend_id"`
+	Enabled   bool   `json:"enabled"`
+	models.CircuitBreakerStats
+}
+
+// newCircuitBreakerView looks up a backend's circuit breaker statistics
+func newCircuitBreakerView(routerService *router.Router, backendID string) (circuitBreakerView, bool) {
+	backend, exists := routerService.GetBackend(backendID)
+	if !exists {
+		return circuitBreakerView{}, false
+	}
+	
+	return circuitBreakerView{
+		BackendID:           backendID,
+		Enabled:             backend.Config.CircuitBreaker.Enabled,
+		CircuitBreakerStats: backend.CircuitBreaker.GetStats(),
+	}, true
+}
+
+// GetCircuitBreakerHandler returns the circuit breaker state of a backend
+func GetCircuitBreakerHandler(router *router.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		
+		view, exists := newCircuitBreakerView(router, vars["id"])
+		if !exists {
+			http.Error(w, "Backend not found", http.StatusNotFound)
+			return
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+	}
+}
+
+// ResetCircuitBreakerHandler force-closes a backend's circuit breaker, for recovery testing
+func ResetCircuitBreakerHandler(router *router.Router, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		backendID := vars["id"]
+		
+		breaker, exists := router.GetCircuitBreaker(backendID)
+		if !exists {
+			http.Error(w, "Backend not found", http.StatusNotFound)
+			return
+		}
+		
+		before := breaker.GetState()
+		breaker.Reset()
+		logger.Info("Circuit breaker reset",
+			"backend", backendID,
+			"state_before", before,
+			"remote_addr", r.RemoteAddr,
+			"request_id", r.Header.Get("X-Request-ID"),
+		)
+		
+		view, _ := newCircuitBreakerView(router, backendID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+	}
+}
+
+// drainRequest is the body of an endpoint drain toggle
+type drainRequest struct {
+	URL      string `json:"url"`
+	Draining *bool  `json:"draining"` // defaults to true
+}
+
+// DrainEndpointHandler starts or stops draining a backend endpoint. A draining endpoint
+// gets no new requests while those in flight finish; it is still health checked.
+func DrainEndpointHandler(router *router.Router, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		backendID := vars["id"]
+		
+		var req drainRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.URL == "" {
+			http.Error(w, "url is required", http.StatusBadRequest)
+			return
+		}
+		draining := req.Draining == nil || *req.Draining
+		
+		backend, exists := router.GetBackend(backendID)
+		if !exists {
+			http.Error(w, "Backend not found", http.StatusNotFound)
+			return
+		}
+		if !backend.SetDraining(req.URL, draining) {
+			http.Error(w, "Endpoint not found", http.StatusNotFound)
+			return
+		}
+		logger.Info("Endpoint drain state changed",
+			"backend", backendID,
+			"endpoint", req.URL,
+			"draining", draining,
+			"remote_addr", r.RemoteAddr,
+			"request_id", r.Header.Get("X-Request-ID"),
+		)
+		
+		response := map[string]interface{}{
+			"backend":  backendID,
+			"url":      req.URL,
+			"draining": draining,
 		}
 		
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
-}
\ No newline at end of file
+}
+
+// endpointRequest is an endpoint in a backend's endpoint list. Weight defaults to 1 and
+// healthy to true, so registering an instance only takes its URL.
+type endpointRequest struct {
+	URL      string            `json:"url"`
+	Weight   int               `json:"weight"`
+	Healthy  *bool             `json:"healthy"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// updateEndpointsRequest is the body of a backend endpoint list update
+type updateEndpointsRequest struct {
+	Endpoints []endpointRequest `json:"endpoints"`
+}
+
+// UpdateBackendEndpointsHandler replaces a backend's endpoint list on the running router
+// with update, e.g. for autoscaling. Endpoints that stay keep their health, drain state and
+// balancer statistics; only added and removed ones change. Healthy only applies to added endpoints.
+func UpdateBackendEndpointsHandler(update func(backendID string, endpoints []models.EndpointConfig) (router.EndpointChanges, error), logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		backendID := vars["id"]
+		
+		var req updateEndpointsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		
+		endpoints := make([]models.EndpointConfig, len(req.Endpoints))
+		for i, endpoint := range req.Endpoints {
+			endpoints[i] = models.EndpointConfig{
+				URL:      endpoint.URL,
+				Weight:   endpoint.Weight,
+				Healthy:  endpoint.Healthy == nil || *endpoint.Healthy,
+				Metadata: endpoint.Metadata,
+			}
+			if endpoints[i].Weight == 0 {
+				endpoints[i].Weight = 1
+			}
+		}
+		
+		changes, err := update(backendID, endpoints)
+		if errors.Is(err, router.ErrBackendNotFound) {
+			http.Error(w, "Backend not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		
+		logger.Info("Backend endpoints updated",
+			"backend", backendID,
+			"added", changes.Added,
+			"removed", changes.Removed,
+			"updated", changes.Updated,
+			"remote_addr", r.RemoteAddr,
+			"request_id", r.Header.Get("X-Request-ID"),
+		)
+		
+		response := struct {
+			Backend string `json:"backend"`
+			router.EndpointChanges
+		}{Backend: backendID, EndpointChanges: changes}
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// PurgeRouteCacheHandler removes all cached responses for a route
+func PurgeRouteCacheHandler(router *router.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		routeID := vars["id"]
+		
+		purged, exists := router.PurgeCache(routeID)
+		if !exists {
+			http.Error(w, "Route cache not found", http.StatusNotFound)
+			return
+		}
+		
+		response := map[string]interface{}{
+			"route":  routeID,
+			"purged": purged,
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+)
+
+// GetConfigHandler returns the whole effective configuration, with secrets redacted
+func GetConfigHandler(current func() *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cfg.Redacted())
+	}
+}
+
+// ConfigOrphansHandler lists backends and auth policies no route uses, and routes using disabled backends
+func ConfigOrphansHandler(current func() *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		orphans := cfg.Orphans()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"orphans": orphans,
+			"count":   len(orphans),
+			"strict":  cfg.Strict,
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/services/drift"
+)
+
+// ConfigDriftHandler compares the configuration file with the running configuration
+// and returns the differences
+func ConfigDriftHandler(detector *drift.Detector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if detector == nil {
+			http.Error(w, "Config drift detection requires a configuration file", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(detector.Check())
+	}
+}
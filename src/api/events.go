@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/services/events"
+)
+
+// eventsKeepAliveInterval is how often a comment line is sent on idle streams
+const eventsKeepAliveInterval = 15 * time.Second
+
+// EventsHandler streams internal events as server-sent events.
+// Clients may filter with ?topics=health,circuit_breaker and resume with Last-Event-ID.
+func EventsHandler(bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var topics []string
+		if param := r.URL.Query().Get("topics"); param != "" {
+			topics = strings.Split(param, ",")
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		var lastID uint64
+		if lastEventID != "" {
+			id, err := strconv.ParseUint(lastEventID, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+			lastID = id
+		}
+
+		sub, replay, err := bus.Subscribe(topics, lastID)
+		if err != nil {
+			if errors.Is(err, events.ErrTooManySubscribers) {
+				http.Error(w, "Too many event streams", http.StatusServiceUnavailable)
+				return
+			}
+			http.Error(w, "Event stream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		defer sub.Close()
+
+		// Event streams are long-lived, so lift the server write deadline
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		for _, event := range replay {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(eventsKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes a single event in server-sent events format
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/flags"
+)
+
+// flagRequest is the body of a feature flag override
+type flagRequest struct {
+	Name    string `json:"name"`
+	Enabled *bool  `json:"enabled"`
+	Percent *int   `json:"percent,omitempty"`
+}
+
+// auditFlagChange logs a runtime flag change with who made it and publishes it on the bus
+func auditFlagChange(logger *slog.Logger, bus *events.Bus, r *http.Request, action string, before, after flags.Flag) {
+	attrs := []any{
+		"flag", after.Name,
+		"action", action,
+		"enabled_before", before.Enabled,
+		"enabled", after.Enabled,
+		"remote_addr", r.RemoteAddr,
+		"request_id", r.Header.Get("X-Request-ID"),
+	}
+	if after.Percent != nil {
+		attrs = append(attrs, "percent", *after.Percent)
+	}
+	logger.Info("Feature flag changed", attrs...)
+
+	bus.Publish(events.TopicFeatureFlag, map[string]interface{}{
+		"action": action,
+		"before": before,
+		"after":  after,
+	})
+}
+
+// GetFlagsHandler returns all feature flags
+func GetFlagsHandler(store *flags.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(store.All())
+	}
+}
+
+// SetFlagHandler overrides a feature flag at runtime
+func SetFlagHandler(store *flags.Store, logger *slog.Logger, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req flagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Name == "" || req.Enabled == nil {
+			http.Error(w, "name and enabled are required", http.StatusBadRequest)
+			return
+		}
+
+		before := store.Get(req.Name)
+		store.Set(req.Name, *req.Enabled)
+		after := store.Get(req.Name)
+		auditFlagChange(logger, bus, r, "set", before, after)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(after)
+	}
+}
+
+// UpdateFlagHandler overrides the named flag at runtime, optionally with a new rollout percent
+func UpdateFlagHandler(store *flags.Store, logger *slog.Logger, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		var req flagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Enabled == nil {
+			http.Error(w, "enabled is required", http.StatusBadRequest)
+			return
+		}
+		if req.Percent != nil && (*req.Percent < 0 || *req.Percent > 100) {
+			http.Error(w, "percent must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+
+		before := store.Get(name)
+		store.Override(name, *req.Enabled, req.Percent)
+		after := store.Get(name)
+		auditFlagChange(logger, bus, r, "update", before, after)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(after)
+	}
+}
+
+// ResetFlagHandler removes a runtime override so the configured value applies again
+func ResetFlagHandler(store *flags.Store, logger *slog.Logger, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		before := store.Get(name)
+		if !store.Reset(name) {
+			http.Error(w, "Flag override not found", http.StatusNotFound)
+			return
+		}
+		auditFlagChange(logger, bus, r, "reset", before, store.Get(name))
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
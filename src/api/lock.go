@@ -0,0 +1,48 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/services/adminlock"
+)
+
+// AdminLockStatusHandler returns whether admin mutations are locked
+func AdminLockStatusHandler(lock *adminlock.Lock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lock.Status())
+	}
+}
+
+// UnlockAdminHandler allows admin mutations for the unlock window.
+// The request must carry the unlock key in X-Unlock-Key on top of the admin API key.
+func UnlockAdminHandler(lock *adminlock.Lock, unlockKey string, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !lock.ReadOnly() {
+			http.Error(w, "Admin API is not read-only", http.StatusConflict)
+			return
+		}
+
+		key := r.Header.Get("X-Unlock-Key")
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(unlockKey)) != 1 {
+			actor := adminlock.ActorFrom(r)
+			logger.Warn("Admin unlock rejected", "remote_addr", actor.RemoteAddr, "request_id", actor.RequestID)
+			http.Error(w, "Invalid unlock key", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lock.Unlock(adminlock.ActorFrom(r)))
+	}
+}
+
+// RelockAdminHandler ends the unlock window early
+func RelockAdminHandler(lock *adminlock.Lock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lock.Relock(adminlock.ActorFrom(r)))
+	}
+}
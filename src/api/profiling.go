@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/services/profiling"
+)
+
+// startProfilingRequest is the optional body of a profiling start
+type startProfilingRequest struct {
+	Duration string `json:"duration"` // e.g. 10m; the configured default_duration when empty
+}
+
+// ProfilingStatusHandler returns whether continuous profiling is running
+func ProfilingStatusHandler(profiler *profiling.Profiler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if profiler == nil {
+			http.Error(w, "Profiling requires a profiling directory or push_url", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profiler.Status())
+	}
+}
+
+// StartProfilingHandler starts continuous profiling for a limited time, after which it
+// stops by itself. Starting while profiling is running sets a new expiry.
+func StartProfilingHandler(profiler *profiling.Profiler, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if profiler == nil {
+			http.Error(w, "Profiling requires a profiling directory or push_url", http.StatusNotFound)
+			return
+		}
+
+		var req startProfilingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		duration := profiler.DefaultDuration()
+		if req.Duration != "" {
+			parsed, err := time.ParseDuration(req.Duration)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "duration must be a positive duration such as 10m", http.StatusBadRequest)
+				return
+			}
+			duration = parsed
+		}
+
+		status := profiler.Start(duration)
+		logger.Warn("Continuous profiling started",
+			"duration", duration,
+			"remote_addr", r.RemoteAddr,
+			"request_id", r.Header.Get("X-Request-ID"),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// StopProfilingHandler stops continuous profiling
+func StopProfilingHandler(profiler *profiling.Profiler, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if profiler == nil {
+			http.Error(w, "Profiling requires a profiling directory or push_url", http.StatusNotFound)
+			return
+		}
+
+		if profiler.Stop() {
+			logger.Info("Continuous profiling stopped",
+				"remote_addr", r.RemoteAddr,
+				"request_id", r.Header.Get("X-Request-ID"),
+			)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profiler.Status())
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/services/supportbundle"
+)
+
+// supportBundleRequest is the optional body of a support bundle request
+type supportBundleRequest struct {
+	IncludeProfiles bool `json:"include_profiles"` // add goroutine and heap profiles
+}
+
+// SupportBundleHandler streams a zip of the diagnostics to attach to an issue, listed in
+// its manifest.json. Secrets are redacted from every text file, and files that would take
+// the bundle past the configured size cap are left out.
+func SupportBundleHandler(current func() *config.Config, files func(includeProfiles bool) []supportbundle.File, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		var req supportBundleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now().UTC()
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="support-bundle-%s.zip"`, now.Format("20060102T150405Z")))
+
+		// The bundle is streamed, so a failure after the first byte can only be logged
+		manifest, err := supportbundle.Write(w, files(req.IncludeProfiles), supportbundle.Options{
+			MaxBytes: cfg.Admin.SupportBundleMaxBytes,
+			Redact:   cfg.RedactSecrets,
+		}, now)
+		if err != nil {
+			logger.Error("Failed to write support bundle", "error", err, "request_id", r.Header.Get("X-Request-ID"))
+			return
+		}
+
+		included := 0
+		for _, file := range manifest.Files {
+			if file.Included {
+				included++
+			}
+		}
+		logger.Info("Support bundle generated",
+			"files", included,
+			"left_out", len(manifest.Files)-included,
+			"include_profiles", req.IncludeProfiles,
+			"remote_addr", r.RemoteAddr,
+			"request_id", r.Header.Get("X-Request-ID"),
+		)
+	}
+}
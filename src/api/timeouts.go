@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// TimeoutReportHandler compares each route's configured timeout with its observed
+// latency and suggests a timeout. The report is advisory; nothing is changed.
+func TimeoutReportHandler(current func() *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := current()
+		histograms, err := services.RouteLatencyHistograms(prometheus.DefaultGatherer)
+		if err != nil {
+			http.Error(w, "Failed to read latency metrics", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"routes": services.BuildTimeoutReport(cfg.Routes, histograms),
+		})
+	}
+}
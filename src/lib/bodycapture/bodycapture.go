@@ -0,0 +1,333 @@
+// Package bodycapture lets several consumers read a request body while it is
+// still forwarded. The body is teed into a bounded buffer exactly once, and
+// only when some consumer asks for it; otherwise reads pass straight through.
+package bodycapture
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+const (
+	// DefaultMemoryLimit is how much of a captured body is held in memory before spilling to a temp file
+	DefaultMemoryLimit = 1 << 20
+	// DefaultMaxBytes is the most that is captured of a single body
+	DefaultMaxBytes = 10 << 20
+)
+
+var (
+	// ErrTooLarge is returned by Replay when the body exceeds the capture limit
+	ErrTooLarge = errors.New("request body exceeds capture limit")
+	// ErrAlreadyRead is returned by Request when the body was read before capture was requested
+	ErrAlreadyRead = errors.New("request body was read before capture was requested")
+)
+
+// Options configures body capture
+type Options struct {
+	MemoryLimit int64  // bytes kept in memory before spilling to a temp file
+	MaxBytes    int64  // bytes captured in total; later bytes still pass through
+	TempDir     string // directory for spill files, os.TempDir() when empty
+}
+
+// withDefaults fills unset options
+func (o Options) withDefaults() Options {
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = DefaultMaxBytes
+	}
+	if o.MemoryLimit <= 0 {
+		o.MemoryLimit = DefaultMemoryLimit
+	}
+	if o.MemoryLimit > o.MaxBytes {
+		o.MemoryLimit = o.MaxBytes
+	}
+	return o
+}
+
+// Middleware installs a capturing body on requests that have one.
+// It should run before anything that reads the body; bodies nobody asks to
+// capture are passed through untouched, and spill files are removed when the
+// request completes.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	opts = opts.withDefaults()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body := &Body{src: r.Body, opts: opts}
+			r.Body = body
+			defer body.cleanup()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Request asks for the request body to be captured and returns it.
+// It must be called before the body is read, typically once the route is known.
+// The request is flagged through its body, so the flag survives request copies
+// and every consumer shares one capture. It returns nil when there is no body.
+func Request(r *http.Request) (*Body, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, ok := r.Body.(*Body)
+	if !ok {
+		// Without the middleware there is no cleanup hook, so keep the capture in memory
+		opts := Options{MemoryLimit: DefaultMaxBytes, MaxBytes: DefaultMaxBytes}
+		body = &Body{src: r.Body, opts: opts}
+		r.Body = body
+	}
+
+	body.mutex.Lock()
+	defer body.mutex.Unlock()
+
+	if !body.requested && body.read > 0 {
+		return nil, ErrAlreadyRead
+	}
+	body.requested = true
+	return body, nil
+}
+
+// Body is a request body that can tee what is read into a bounded capture
+type Body struct {
+	src  io.ReadCloser
+	opts Options
+
+	requested bool
+	truncated bool
+	closed    bool // the request completed and its spill file is gone
+	eof       bool
+	err       error
+
+	read    int64 // bytes read from src
+	pos     int64 // bytes returned by Read
+	pending []byte
+
+	size int64 // bytes captured
+	mem  bytes.Buffer
+	file *os.File
+
+	mutex sync.Mutex
+}
+
+// Read reads the body, capturing it when requested.
+// Bytes captured ahead of the reader by Replay are served from the capture.
+func (b *Body) Read(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.pos < b.size {
+		n, err := b.readCapturedAt(p, b.pos)
+		b.pos += int64(n)
+		return n, err
+	}
+
+	if len(b.pending) > 0 {
+		n := copy(p, b.pending)
+		b.pending = b.pending[n:]
+		b.pos += int64(n)
+		return n, nil
+	}
+
+	if b.eof {
+		return 0, io.EOF
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.src.Read(p)
+	if n > 0 {
+		b.read += int64(n)
+		b.pos += int64(n)
+		if b.requested {
+			b.capture(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	} else if err != nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// Close closes the underlying body
+func (b *Body) Close() error {
+	return b.src.Close()
+}
+
+// Replay reads the rest of the body into the capture and returns a reader over all of it.
+// Each call returns an independent reader. ErrTooLarge is returned when the body
+// exceeds the capture limit.
+func (b *Body) Replay() (io.ReadCloser, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.drain(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(io.NewSectionReader(capturedReader{b}, 0, b.size)), nil
+}
+
+// Bytes returns a copy of what has been captured so far
+func (b *Body) Bytes() ([]byte, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	data := make([]byte, b.size)
+	if _, err := b.readCapturedAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Truncated reports whether the body was larger than the capture limit
+func (b *Body) Truncated() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.truncated
+}
+
+// drain reads the rest of the body from src into the capture; the caller must hold the mutex
+func (b *Body) drain() error {
+	if b.truncated {
+		return ErrTooLarge
+	}
+
+	buf := make([]byte, 32<<10)
+	for !b.eof {
+		if b.err != nil {
+			return b.err
+		}
+
+		n, err := b.src.Read(buf)
+		if n > 0 {
+			b.read += int64(n)
+			kept := b.capture(buf[:n])
+			if kept < n {
+				// Keep the overflow so the body still reads in full
+				b.pending = append(b.pending, buf[kept:n]...)
+				return ErrTooLarge
+			}
+		}
+		if err == io.EOF {
+			b.eof = true
+		} else if err != nil {
+			b.err = err
+		}
+	}
+	return b.err
+}
+
+// capture appends data to the capture up to the limit and returns how much was kept;
+// the caller must hold the mutex
+func (b *Body) capture(data []byte) int {
+	if b.truncated {
+		return 0
+	}
+	if remaining := b.opts.MaxBytes - b.size; int64(len(data)) > remaining {
+		data = data[:remaining]
+		b.truncated = true
+	}
+
+	kept := 0
+	if room := b.opts.MemoryLimit - int64(b.mem.Len()); room > 0 {
+		n := min(int64(len(data)), room)
+		b.mem.Write(data[:n])
+		kept = int(n)
+	}
+
+	if kept < len(data) {
+		if b.file == nil {
+			if b.closed {
+				// A transport can still be reading after the handler returned,
+				// e.g. when the client went away; a new spill file would leak
+				b.truncated = true
+				b.size += int64(kept)
+				return kept
+			}
+			file, err := os.CreateTemp(b.opts.TempDir, "bodycapture-*")
+			if err != nil {
+				b.truncated = true
+				b.size += int64(kept)
+				return kept
+			}
+			b.file = file
+		}
+		n, err := b.file.Write(data[kept:])
+		kept += n
+		if err != nil {
+			b.truncated = true
+		}
+	}
+
+	b.size += int64(kept)
+	return kept
+}
+
+// readCapturedAt reads captured bytes at off; the caller must hold the mutex
+func (b *Body) readCapturedAt(p []byte, off int64) (int, error) {
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.size-off {
+		p = p[:b.size-off]
+	}
+
+	n := 0
+	memLen := int64(b.mem.Len())
+	if off < memLen {
+		n = copy(p, b.mem.Bytes()[off:])
+	}
+	if n < len(p) {
+		if b.file == nil {
+			// The spill file is gone once the request has completed
+			return n, os.ErrClosed
+		}
+		m, err := b.file.ReadAt(p[n:], off+int64(n)-memLen)
+		n += m
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// cleanup removes the spill file
+func (b *Body) cleanup() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+}
+
+// capturedReader reads the capture at an offset for replay readers
+type capturedReader struct {
+	b *Body
+}
+
+// ReadAt implements io.ReaderAt
+func (c capturedReader) ReadAt(p []byte, off int64) (int, error) {
+	c.b.mutex.Lock()
+	defer c.b.mutex.Unlock()
+
+	n, err := c.b.readCapturedAt(p, off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
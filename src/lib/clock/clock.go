@@ -0,0 +1,54 @@
+// Package clock is the time source shared by time-dependent components such as
+// circuit breakers and the router, so tests can drive them with a virtual clock.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+// realClock reads the system clock
+type realClock struct{}
+
+// Now implements Clock
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Since returns the time elapsed on the clock since t
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Virtual is a clock that only moves when it is advanced
+type Virtual struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+// NewVirtual creates a virtual clock stopped at start
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+// Now implements Clock
+func (v *Virtual) Now() time.Time {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.now
+}
+
+// Advance moves the clock forward by d
+func (v *Virtual) Advance(d time.Duration) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.now = v.now.Add(d)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// ErrRouteExists is returned when restoring a route whose ID is in use
+var ErrRouteExists = errors.New("a route with this ID already exists")
+
+// ArchiveRoute moves a route into the archive. It returns false if the route does not exist.
+func (c *Config) ArchiveRoute(id string, now time.Time) (*models.ArchivedRoute, bool) {
+	for i, route := range c.Routes {
+		if route.ID != id {
+			continue
+		}
+
+		archived := models.ArchivedRoute{RouteConfig: route, ArchivedAt: now}
+		c.Routes = append(c.Routes[:i], c.Routes[i+1:]...)
+
+		// Archiving the same ID again replaces the older copy
+		c.removeArchivedRoute(id)
+		c.ArchivedRoutes = append(c.ArchivedRoutes, archived)
+		return &archived, true
+	}
+
+	return nil, false
+}
+
+// RestoreRoute moves an archived route back into the active routes
+func (c *Config) RestoreRoute(id string) (*models.RouteConfig, error) {
+	var archived *models.ArchivedRoute
+	for i := range c.ArchivedRoutes {
+		if c.ArchivedRoutes[i].ID == id {
+			archived = &c.ArchivedRoutes[i]
+			break
+		}
+	}
+	if archived == nil {
+		return nil, fmt.Errorf("archived route %s not found", id)
+	}
+
+	for _, route := range c.Routes {
+		if route.ID == id {
+			return nil, ErrRouteExists
+		}
+	}
+
+	route := archived.RouteConfig
+	c.removeArchivedRoute(id)
+	c.Routes = append(c.Routes, route)
+	return &route, nil
+}
+
+// DeleteArchivedRoute permanently removes an archived route
+func (c *Config) DeleteArchivedRoute(id string) bool {
+	return c.removeArchivedRoute(id)
+}
+
+// PurgeArchivedRoutes removes archived routes older than the retention period.
+// A zero retention keeps archived routes indefinitely.
+func (c *Config) PurgeArchivedRoutes(now time.Time) int {
+	if c.Admin.ArchiveRetention <= 0 {
+		return 0
+	}
+
+	kept := c.UnexpiredArchivedRoutes(now)
+	purged := len(c.ArchivedRoutes) - len(kept)
+	c.ArchivedRoutes = kept
+	return purged
+}
+
+// UnexpiredArchivedRoutes returns the archived routes within the retention period
+// without purging the others, so it is safe on a shared config.
+func (c *Config) UnexpiredArchivedRoutes(now time.Time) []models.ArchivedRoute {
+	cutoff := now.Add(-c.Admin.ArchiveRetention)
+	kept := []models.ArchivedRoute{}
+	for _, archived := range c.ArchivedRoutes {
+		if c.Admin.ArchiveRetention <= 0 || archived.ArchivedAt.After(cutoff) {
+			kept = append(kept, archived)
+		}
+	}
+	return kept
+}
+
+// removeArchivedRoute removes an archived route by ID
+func (c *Config) removeArchivedRoute(id string) bool {
+	for i, archived := range c.ArchivedRoutes {
+		if archived.ID == id {
+			c.ArchivedRoutes = append(c.ArchivedRoutes[:i], c.ArchivedRoutes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
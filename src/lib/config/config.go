@@ -1,101 +1,214 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 	"github.com/your-org/ryohi-router/src/models"
+	"golang.org/x/net/http/httpguts"
 )
 
 // Config represents the complete router configuration
 type Config struct {
-	Version  string                   `yaml:"version" mapstructure:"version"`
-	Router   RouterConfig             `yaml:"router" mapstructure:"router"`
-	Admin    AdminConfig              `yaml:"admin" mapstructure:"admin"`
-	Logging  LoggingConfig            `yaml:"logging" mapstructure:"logging"`
-	Metrics  MetricsConfig            `yaml:"metrics" mapstructure:"metrics"`
-	Backends []models.BackendService  `yaml:"backends" mapstructure:"backends"`
-	Routes   []models.RouteConfig     `yaml:"routes" mapstructure:"routes"`
-	Middleware MiddlewareConfig       `yaml:"middleware" mapstructure:"middleware"`
+	Version  string                   `json:"version" yaml:"version" mapstructure:"version"`
+	Router   RouterConfig             `json:"router" yaml:"router" mapstructure:"router"`
+	Admin    AdminConfig              `json:"admin" yaml:"admin" mapstructure:"admin"`
+	Logging  LoggingConfig            `json:"logging" yaml:"logging" mapstructure:"logging"`
+	Metrics  MetricsConfig            `json:"metrics" yaml:"metrics" mapstructure:"metrics"`
+	Tracing  TracingConfig            `json:"tracing" yaml:"tracing" mapstructure:"tracing"`
+	Profiling ProfilingConfig         `json:"profiling" yaml:"profiling" mapstructure:"profiling"`
+	Backends []models.BackendService  `json:"backends" yaml:"backends" mapstructure:"backends"`
+	Routes   []models.RouteConfig     `json:"routes" yaml:"routes" mapstructure:"routes"`
+	AuthPolicies []models.AuthPolicy  `json:"auth_policies" yaml:"auth_policies" mapstructure:"auth_policies"`
+	ArchivedRoutes []models.ArchivedRoute `json:"archived_routes" yaml:"archived_routes" mapstructure:"archived_routes"`
+	Middleware MiddlewareConfig       `json:"middleware" yaml:"middleware" mapstructure:"middleware"`
+	Memory   MemoryConfig             `json:"memory" yaml:"memory" mapstructure:"memory"`
+	FeatureFlags map[string]bool      `json:"feature_flags" yaml:"feature_flags" mapstructure:"feature_flags"`
+	FlagRollouts map[string]models.FlagRollout `json:"flag_rollouts" yaml:"flag_rollouts" mapstructure:"flag_rollouts"` // flags on for part of the traffic
+	// Strict rejects configurations with orphans (see Orphans) instead of only warning about them
+	Strict bool `json:"strict" yaml:"strict" mapstructure:"strict"`
+
+	path string // file the configuration was loaded from
 }
 
 // RouterConfig represents router-specific configuration
 type RouterConfig struct {
-	Port            int           `yaml:"port" mapstructure:"port"`
-	ReadTimeout     time.Duration `yaml:"read_timeout" mapstructure:"read_timeout"`
-	WriteTimeout    time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`
-	IdleTimeout     time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
-	MaxHeaderBytes  int           `yaml:"max_header_bytes" mapstructure:"max_header_bytes"`
+	Port             int           `json:"port" yaml:"port" mapstructure:"port"`
+	ReadTimeout      time.Duration `json:"read_timeout" yaml:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout     time.Duration `json:"write_timeout" yaml:"write_timeout" mapstructure:"write_timeout"`
+	IdleTimeout      time.Duration `json:"idle_timeout" yaml:"idle_timeout" mapstructure:"idle_timeout"`
+	MaxHeaderBytes   int           `json:"max_header_bytes" yaml:"max_header_bytes" mapstructure:"max_header_bytes"`
+	ServedByHeader   bool          `json:"served_by_header" yaml:"served_by_header" mapstructure:"served_by_header"`
+	AllowInsecureTLS bool          `json:"allow_insecure_tls" yaml:"allow_insecure_tls" mapstructure:"allow_insecure_tls"`
+	WatchConfig      bool          `json:"watch_config" yaml:"watch_config" mapstructure:"watch_config"` // apply changes to the config file without a restart
+	// MaxBufferedBodyBytes caps the request body buffered for replay by fallbacks;
+	// larger bodies are forwarded once without a fallback. 0 uses the 10MB default
+	MaxBufferedBodyBytes int64 `json:"max_buffered_body_bytes" yaml:"max_buffered_body_bytes" mapstructure:"max_buffered_body_bytes"`
+	// MaxBodyBytes caps request bodies on routes without their own max_body_bytes; 0 for no cap.
+	// MaxBodyBytesCeiling is the most any route may allow; 0 for no ceiling
+	MaxBodyBytes        int64 `json:"max_body_bytes" yaml:"max_body_bytes" mapstructure:"max_body_bytes"`
+	MaxBodyBytesCeiling int64 `json:"max_body_bytes_ceiling" yaml:"max_body_bytes_ceiling" mapstructure:"max_body_bytes_ceiling"`
+	// DrainTimeout is how long requests on a backend replaced by a reload may run before
+	// they are cancelled and the backend discarded. 0 uses the 30s default
+	DrainTimeout time.Duration `json:"drain_timeout" yaml:"drain_timeout" mapstructure:"drain_timeout"`
+	RequestIDPrefix      string `json:"request_id_prefix" yaml:"request_id_prefix" mapstructure:"request_id_prefix"` // prepended to generated request IDs, e.g. a region code
+	RequestIDFormat      string `json:"request_id_format" yaml:"request_id_format" mapstructure:"request_id_format"` // uuid or hex
+	// IgnoreInboundRequestID always generates the request ID instead of trusting the client's
+	IgnoreInboundRequestID bool `json:"ignore_inbound_request_id" yaml:"ignore_inbound_request_id" mapstructure:"ignore_inbound_request_id"`
+	// CorrelationIDHeaders carry the request ID alongside X-Request-ID, e.g. X-Correlation-ID
+	CorrelationIDHeaders []string `json:"correlation_id_headers" yaml:"correlation_id_headers" mapstructure:"correlation_id_headers"`
+	// AllowedMethods rejects requests with any other method with 405 before routing; empty allows all
+	AllowedMethods []string `json:"allowed_methods" yaml:"allowed_methods" mapstructure:"allowed_methods"`
+	// Zone is the router's own zone. When set, backends prefer endpoints whose zone metadata
+	// matches and spill over to other zones only while fewer than ZoneMinHealthy of those are
+	// available. 0 uses the default of 1
+	Zone           string `json:"zone" yaml:"zone" mapstructure:"zone"`
+	ZoneMinHealthy int    `json:"zone_min_healthy" yaml:"zone_min_healthy" mapstructure:"zone_min_healthy"`
+	// OverrideTokens are accepted in X-Backend-Override-Token to send a request to an
+	// overridable backend; empty disables overrides. They must differ from the admin keys
+	OverrideTokens []string `json:"override_tokens" yaml:"override_tokens" mapstructure:"override_tokens"`
 }
 
 // AdminConfig represents admin API configuration
 type AdminConfig struct {
-	Enabled bool   `yaml:"enabled" mapstructure:"enabled"`
-	APIKey  string `yaml:"api_key" mapstructure:"api_key"`
-	Port    int    `yaml:"port" mapstructure:"port"`
+	Enabled          bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	APIKey           string `json:"api_key" yaml:"api_key" mapstructure:"api_key"`
+	Port             int    `json:"port" yaml:"port" mapstructure:"port"`
+	EventBacklogSize int    `json:"event_backlog_size" yaml:"event_backlog_size" mapstructure:"event_backlog_size"`
+	MaxEventStreams  int    `json:"max_event_streams" yaml:"max_event_streams" mapstructure:"max_event_streams"`
+	ArchiveRetention time.Duration `json:"archive_retention" yaml:"archive_retention" mapstructure:"archive_retention"`
+	DriftCheckInterval time.Duration `json:"drift_check_interval" yaml:"drift_check_interval" mapstructure:"drift_check_interval"` // 0 disables
+	// ReadOnly rejects admin mutations except for UnlockWindow after POST /admin/unlock with UnlockKey
+	ReadOnly     bool          `json:"read_only" yaml:"read_only" mapstructure:"read_only"`
+	UnlockKey    string        `json:"unlock_key" yaml:"unlock_key" mapstructure:"unlock_key"`
+	UnlockWindow time.Duration `json:"unlock_window" yaml:"unlock_window" mapstructure:"unlock_window"`
+	// SupportBundleMaxBytes caps POST /admin/support-bundle; 0 uses the 32MB default
+	SupportBundleMaxBytes int64 `json:"support_bundle_max_bytes" yaml:"support_bundle_max_bytes" mapstructure:"support_bundle_max_bytes"`
 }
 
 // LoggingConfig represents logging configuration
 type LoggingConfig struct {
-	Level    string `yaml:"level" mapstructure:"level"`
-	Format   string `yaml:"format" mapstructure:"format"`
-	Output   string `yaml:"output" mapstructure:"output"`
-	FilePath string `yaml:"file_path" mapstructure:"file_path"`
+	Level    string `json:"level" yaml:"level" mapstructure:"level"`
+	Format   string `json:"format" yaml:"format" mapstructure:"format"`
+	Output   string `json:"output" yaml:"output" mapstructure:"output"`
+	FilePath string `json:"file_path" yaml:"file_path" mapstructure:"file_path"`
+	// Rotation of the log file when Output is file
+	MaxSizeMB  int `json:"max_size_mb" yaml:"max_size_mb" mapstructure:"max_size_mb"`    // size that starts a new file; 0 never rotates
+	MaxBackups int `json:"max_backups" yaml:"max_backups" mapstructure:"max_backups"`    // rotated files kept; 0 keeps all
+	MaxAgeDays int `json:"max_age_days" yaml:"max_age_days" mapstructure:"max_age_days"` // rotated files older than this are removed; 0 keeps them
 }
 
 // MetricsConfig represents metrics configuration
 type MetricsConfig struct {
-	Enabled bool   `yaml:"enabled" mapstructure:"enabled"`
-	Path    string `yaml:"path" mapstructure:"path"`
-	Port    int    `yaml:"port" mapstructure:"port"`
+	Enabled bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	Path    string `json:"path" yaml:"path" mapstructure:"path"`
+	Port    int    `json:"port" yaml:"port" mapstructure:"port"`
+	// Exemplars attaches sampled trace IDs to latency histograms and serves OpenMetrics
+	Exemplars bool `json:"exemplars" yaml:"exemplars" mapstructure:"exemplars"`
+}
+
+// TracingConfig configures exporting request and backend spans to an OpenTelemetry collector
+type TracingConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	OTLPEndpoint string `json:"otlp_endpoint" yaml:"otlp_endpoint" mapstructure:"otlp_endpoint"` // OTLP/HTTP base URL, e.g. http://localhost:4318
+	ServiceName  string `json:"service_name" yaml:"service_name" mapstructure:"service_name"`
+}
+
+// ProfilingConfig configures continuous profiling: a CPU and a heap profile every interval,
+// written to Directory and/or pushed to a Pyroscope-compatible server at PushURL. Profiling
+// is off unless Enabled, or started through the admin API; either needs a destination.
+type ProfilingConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"` // profile from startup until stopped
+	Directory string `json:"directory" yaml:"directory" mapstructure:"directory"`
+	PushURL   string `json:"push_url" yaml:"push_url" mapstructure:"push_url"` // e.g. http://localhost:4040
+	AppName   string `json:"app_name" yaml:"app_name" mapstructure:"app_name"` // application name profiles are pushed under
+	Interval    time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`             // between snapshots
+	CPUDuration time.Duration `json:"cpu_duration" yaml:"cpu_duration" mapstructure:"cpu_duration"` // CPU time profiled per snapshot
+	// MaxFiles and MaxAge bound the snapshots kept in Directory, per profile type; 0 keeps them all
+	MaxFiles int           `json:"max_files" yaml:"max_files" mapstructure:"max_files"`
+	MaxAge   time.Duration `json:"max_age" yaml:"max_age" mapstructure:"max_age"`
+	// DefaultDuration is how long profiling started through the admin API runs without a duration
+	DefaultDuration time.Duration `json:"default_duration" yaml:"default_duration" mapstructure:"default_duration"`
+}
+
+// Configured reports whether profiling has somewhere to send snapshots
+func (p ProfilingConfig) Configured() bool {
+	return p.Directory != "" || p.PushURL != ""
+}
+
+// MemoryConfig configures the memory watchdog and emergency load shedding
+type MemoryConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// Limit is the memory ceiling in bytes; 0 reads it from the cgroup, and the
+	// watchdog is disabled when no limit is found
+	Limit     int64         `json:"limit" yaml:"limit" mapstructure:"limit"`
+	HighWater float64       `json:"high_water" yaml:"high_water" mapstructure:"high_water"` // fraction of the limit that starts shedding
+	LowWater  float64       `json:"low_water" yaml:"low_water" mapstructure:"low_water"`   // fraction of the limit that stops shedding
+	Interval  time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
 }
 
 // MiddlewareConfig represents middleware configuration
 type MiddlewareConfig struct {
-	Logging     MiddlewareLoggingConfig     `yaml:"logging" mapstructure:"logging"`
-	CORS        CORSConfig                  `yaml:"cors" mapstructure:"cors"`
-	Compression CompressionConfig           `yaml:"compression" mapstructure:"compression"`
-	Security    SecurityConfig              `yaml:"security" mapstructure:"security"`
+	Logging     MiddlewareLoggingConfig     `json:"logging" yaml:"logging" mapstructure:"logging"`
+	CORS        CORSConfig                  `json:"cors" yaml:"cors" mapstructure:"cors"`
+	Compression CompressionConfig           `json:"compression" yaml:"compression" mapstructure:"compression"`
+	Security    SecurityConfig              `json:"security" yaml:"security" mapstructure:"security"`
+	RateLimit   RateLimitStoreConfig        `json:"rate_limit" yaml:"rate_limit" mapstructure:"rate_limit"`
+}
+
+// RateLimitStoreConfig selects where route rate limit buckets are kept
+type RateLimitStoreConfig struct {
+	Store string      `json:"store" yaml:"store" mapstructure:"store"` // memory, redis
+	Redis RedisConfig `json:"redis" yaml:"redis" mapstructure:"redis"`
+	// FailClosed rejects requests when the store is unreachable instead of letting them through
+	FailClosed bool `json:"fail_closed" yaml:"fail_closed" mapstructure:"fail_closed"`
+	// StateFile keeps the memory store's buckets across graceful restarts; empty disables it
+	StateFile string `json:"state_file" yaml:"state_file" mapstructure:"state_file"`
+	// StateMaxAge is how long after shutdown the state file may still be restored
+	StateMaxAge time.Duration `json:"state_max_age" yaml:"state_max_age" mapstructure:"state_max_age"`
+}
+
+// RedisConfig represents a Redis connection
+type RedisConfig struct {
+	Address   string        `json:"address" yaml:"address" mapstructure:"address"`
+	Password  string        `json:"password" yaml:"password" mapstructure:"password"`
+	DB        int           `json:"db" yaml:"db" mapstructure:"db"`
+	KeyPrefix string        `json:"key_prefix" yaml:"key_prefix" mapstructure:"key_prefix"`
+	Timeout   time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
 }
 
 // MiddlewareLoggingConfig represents logging middleware configuration
 type MiddlewareLoggingConfig struct {
-	Enabled     bool     `yaml:"enabled" mapstructure:"enabled"`
-	SkipPaths   []string `yaml:"skip_paths" mapstructure:"skip_paths"`
-	LogBody     bool     `yaml:"log_body" mapstructure:"log_body"`
-	LogHeaders  bool     `yaml:"log_headers" mapstructure:"log_headers"`
+	Enabled     bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	SkipPaths   []string `json:"skip_paths" yaml:"skip_paths" mapstructure:"skip_paths"`
+	LogBody     bool     `json:"log_body" yaml:"log_body" mapstructure:"log_body"`
+	LogHeaders  bool     `json:"log_headers" yaml:"log_headers" mapstructure:"log_headers"`
 }
 
-// CORSConfig represents CORS configuration
-type CORSConfig struct {
-	Enabled          bool     `yaml:"enabled" mapstructure:"enabled"`
-	AllowedOrigins   []string `yaml:"allowed_origins" mapstructure:"allowed_origins"`
-	AllowedMethods   []string `yaml:"allowed_methods" mapstructure:"allowed_methods"`
-	AllowedHeaders   []string `yaml:"allowed_headers" mapstructure:"allowed_headers"`
-	ExposedHeaders   []string `yaml:"exposed_headers" mapstructure:"exposed_headers"`
-	AllowCredentials bool     `yaml:"allow_credentials" mapstructure:"allow_credentials"`
-	MaxAge           int      `yaml:"max_age" mapstructure:"max_age"`
-}
+// CORSConfig represents CORS configuration; routes can override it with their own
+type CORSConfig = models.CORSConfig
 
 // CompressionConfig represents compression configuration
 type CompressionConfig struct {
-	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
-	Level   int  `yaml:"level" mapstructure:"level"`
-	MinSize int  `yaml:"min_size" mapstructure:"min_size"`
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	Level   int  `json:"level" yaml:"level" mapstructure:"level"`
+	MinSize int  `json:"min_size" yaml:"min_size" mapstructure:"min_size"`
 }
 
 // SecurityConfig represents security configuration
 type SecurityConfig struct {
-	Enabled                 bool   `yaml:"enabled" mapstructure:"enabled"`
-	FrameDeny               bool   `yaml:"frame_deny" mapstructure:"frame_deny"`
-	ContentTypeNosniff      bool   `yaml:"content_type_nosniff" mapstructure:"content_type_nosniff"`
-	BrowserXSSFilter        bool   `yaml:"browser_xss_filter" mapstructure:"browser_xss_filter"`
-	ContentSecurityPolicy   string `yaml:"content_security_policy" mapstructure:"content_security_policy"`
-	HSTSMaxAge              int    `yaml:"hsts_max_age" mapstructure:"hsts_max_age"`
-	HSTSIncludeSubdomains   bool   `yaml:"hsts_include_subdomains" mapstructure:"hsts_include_subdomains"`
+	Enabled                 bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	FrameDeny               bool   `json:"frame_deny" yaml:"frame_deny" mapstructure:"frame_deny"`
+	ContentTypeNosniff      bool   `json:"content_type_nosniff" yaml:"content_type_nosniff" mapstructure:"content_type_nosniff"`
+	BrowserXSSFilter        bool   `json:"browser_xss_filter" yaml:"browser_xss_filter" mapstructure:"browser_xss_filter"`
+	ContentSecurityPolicy   string `json:"content_security_policy" yaml:"content_security_policy" mapstructure:"content_security_policy"`
+	HSTSMaxAge              int    `json:"hsts_max_age" yaml:"hsts_max_age" mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains   bool   `json:"hsts_include_subdomains" yaml:"hsts_include_subdomains" mapstructure:"hsts_include_subdomains"`
 }
 
 // Load loads configuration from a file
@@ -112,36 +225,50 @@ func Load(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Unmarshal configuration
+	// Unmarshal configuration, expanding ${VAR} references in values
 	var config Config
-	if err := v.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config, viper.DecodeHook(decodeHook)); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
 	// Override with environment variables
 	overrideWithEnv(&config)
 
+	config.path = configFile
 	return &config, nil
 }
 
-// LoadWithWatcher loads configuration and watches for changes
+// Path returns the file the configuration was loaded from, or "" if it wasn't loaded from a file
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Clone returns a copy whose routes, archived routes and backends can be added, removed or
+// replaced without changing c. Settings the entries point to are shared.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Routes = slices.Clone(c.Routes)
+	clone.ArchivedRoutes = slices.Clone(c.ArchivedRoutes)
+	clone.Backends = slices.Clone(c.Backends)
+	return &clone
+}
+
+// LoadWithWatcher loads configuration and calls onChange with each valid change to the file.
+// Changes that fail to load or validate are skipped.
 func LoadWithWatcher(configFile string, onChange func(*Config)) (*Config, error) {
 	config, err := Load(configFile)
 	if err != nil {
 		return nil, err
 	}
 
-	v := viper.New()
-	v.SetConfigFile(configFile)
-	v.SetConfigType("yaml")
-
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
-		newConfig, err := Load(configFile)
+	err = Watch(context.Background(), configFile, func(newConfig *Config, err error) {
 		if err == nil {
 			onChange(newConfig)
 		}
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
@@ -152,6 +279,54 @@ func (c *Config) Validate() error {
 	if c.Router.Port <= 0 || c.Router.Port > 65535 {
 		return fmt.Errorf("invalid router port: %d", c.Router.Port)
 	}
+	if c.Router.MaxBufferedBodyBytes < 0 {
+		return fmt.Errorf("invalid router max_buffered_body_bytes: %d", c.Router.MaxBufferedBodyBytes)
+	}
+	if c.Router.DrainTimeout < 0 {
+		return fmt.Errorf("invalid router drain_timeout: %s", c.Router.DrainTimeout)
+	}
+	if c.Router.MaxBodyBytes < 0 {
+		return fmt.Errorf("invalid router max_body_bytes: %d", c.Router.MaxBodyBytes)
+	}
+	if c.Router.MaxBodyBytesCeiling < 0 {
+		return fmt.Errorf("invalid router max_body_bytes_ceiling: %d", c.Router.MaxBodyBytesCeiling)
+	}
+	if ceiling := c.Router.MaxBodyBytesCeiling; ceiling > 0 && c.Router.MaxBodyBytes > ceiling {
+		return fmt.Errorf("router max_body_bytes %d exceeds max_body_bytes_ceiling %d", c.Router.MaxBodyBytes, ceiling)
+	}
+	switch c.Router.RequestIDFormat {
+	case "", "uuid", "hex":
+	default:
+		return fmt.Errorf("invalid router request_id_format: %s (use uuid or hex)", c.Router.RequestIDFormat)
+	}
+	for _, name := range c.Router.CorrelationIDHeaders {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid router correlation_id_headers entry: %q", name)
+		}
+	}
+	for _, method := range c.Router.AllowedMethods {
+		// A method is a token, like a header name
+		if !httpguts.ValidHeaderFieldName(method) {
+			return fmt.Errorf("invalid router allowed_methods entry: %q", method)
+		}
+	}
+	if c.Router.ZoneMinHealthy < 0 {
+		return fmt.Errorf("invalid router zone_min_healthy: %d", c.Router.ZoneMinHealthy)
+	}
+	for _, token := range c.Router.OverrideTokens {
+		if token == "" {
+			return fmt.Errorf("router override_tokens cannot be empty")
+		}
+		// The data plane must never accept an admin credential
+		if token == c.Admin.APIKey || token == c.Admin.UnlockKey {
+			return fmt.Errorf("router override_tokens must differ from the admin api_key and unlock_key")
+		}
+	}
+	for _, ch := range c.Router.RequestIDPrefix {
+		if ch <= ' ' || ch > '~' {
+			return fmt.Errorf("invalid router request_id_prefix %q: only printable ASCII without spaces is allowed", c.Router.RequestIDPrefix)
+		}
+	}
 
 	// Validate admin config
 	if c.Admin.Enabled {
@@ -161,9 +336,39 @@ func (c *Config) Validate() error {
 		if c.Admin.Port <= 0 || c.Admin.Port > 65535 {
 			return fmt.Errorf("invalid admin port: %d", c.Admin.Port)
 		}
+		if c.Admin.ReadOnly {
+			if c.Admin.UnlockKey == "" {
+				return fmt.Errorf("admin unlock_key is required when read_only is enabled")
+			}
+			if c.Admin.UnlockKey == c.Admin.APIKey {
+				return fmt.Errorf("admin unlock_key must differ from api_key")
+			}
+			if c.Admin.UnlockWindow == 0 {
+				c.Admin.UnlockWindow = 15 * time.Minute // Default unlock window
+			} else if c.Admin.UnlockWindow < 0 || c.Admin.UnlockWindow > 24*time.Hour {
+				return fmt.Errorf("invalid admin unlock_window: %s (must be between 0 and 24h)", c.Admin.UnlockWindow)
+			}
+		}
 		if c.Admin.Port == c.Router.Port {
 			return fmt.Errorf("admin port cannot be the same as router port")
 		}
+		if c.Admin.SupportBundleMaxBytes < 0 {
+			return fmt.Errorf("invalid admin support_bundle_max_bytes: %d", c.Admin.SupportBundleMaxBytes)
+		}
+	}
+
+	// Validate logging
+	switch c.Logging.Output {
+	case "", "stdout", "stderr":
+	case "file":
+		if c.Logging.FilePath == "" {
+			return fmt.Errorf("logging file_path is required when output is file")
+		}
+	default:
+		return fmt.Errorf("invalid logging output: %s (must be stdout, stderr or file)", c.Logging.Output)
+	}
+	if c.Logging.MaxSizeMB < 0 || c.Logging.MaxBackups < 0 || c.Logging.MaxAgeDays < 0 {
+		return fmt.Errorf("logging max_size_mb, max_backups and max_age_days cannot be negative")
 	}
 
 	// Validate metrics config
@@ -176,6 +381,90 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate tracing
+	if c.Tracing.Enabled {
+		endpoint, err := url.Parse(c.Tracing.OTLPEndpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("invalid tracing otlp_endpoint: %q (must be an http or https URL)", c.Tracing.OTLPEndpoint)
+		}
+		if c.Tracing.ServiceName == "" {
+			c.Tracing.ServiceName = "ryohi-router"
+		}
+	}
+
+	// Validate profiling
+	if c.Profiling.Enabled && !c.Profiling.Configured() {
+		return fmt.Errorf("profiling requires a directory or push_url")
+	}
+	if c.Profiling.Configured() {
+		if c.Profiling.PushURL != "" {
+			pushURL, err := url.Parse(c.Profiling.PushURL)
+			if err != nil || (pushURL.Scheme != "http" && pushURL.Scheme != "https") || pushURL.Host == "" {
+				return fmt.Errorf("invalid profiling push_url: %q (must be an http or https URL)", c.Profiling.PushURL)
+			}
+		}
+		if c.Profiling.AppName == "" {
+			c.Profiling.AppName = "ryohi-router"
+		}
+		if c.Profiling.Interval == 0 {
+			c.Profiling.Interval = time.Minute
+		}
+		if c.Profiling.CPUDuration == 0 {
+			c.Profiling.CPUDuration = 10 * time.Second
+		}
+		if c.Profiling.DefaultDuration == 0 {
+			c.Profiling.DefaultDuration = 15 * time.Minute
+		}
+		if c.Profiling.Interval < 0 || c.Profiling.CPUDuration < 0 || c.Profiling.DefaultDuration < 0 || c.Profiling.MaxAge < 0 || c.Profiling.MaxFiles < 0 {
+			return fmt.Errorf("profiling durations and max_files cannot be negative")
+		}
+		if c.Profiling.CPUDuration >= c.Profiling.Interval {
+			return fmt.Errorf("profiling cpu_duration (%s) must be shorter than interval (%s)", c.Profiling.CPUDuration, c.Profiling.Interval)
+		}
+	}
+
+	// Validate memory watchdog
+	if c.Memory.Enabled {
+		if c.Memory.Limit < 0 {
+			return fmt.Errorf("memory limit cannot be negative")
+		}
+		if c.Memory.HighWater == 0 {
+			c.Memory.HighWater = 0.9
+		}
+		if c.Memory.LowWater == 0 {
+			c.Memory.LowWater = 0.8
+		}
+		if c.Memory.LowWater <= 0 || c.Memory.LowWater >= c.Memory.HighWater || c.Memory.HighWater >= 1 {
+			return fmt.Errorf("memory watermarks must satisfy 0 < low_water < high_water < 1")
+		}
+		if c.Memory.Interval == 0 {
+			c.Memory.Interval = time.Second
+		} else if c.Memory.Interval < 0 {
+			return fmt.Errorf("memory check interval cannot be negative")
+		}
+	}
+
+	// Validate rate limit store
+	switch c.Middleware.RateLimit.Store {
+	case "", "memory":
+	case "redis":
+		if c.Middleware.RateLimit.Redis.Address == "" {
+			return fmt.Errorf("redis address is required for the redis rate limit store")
+		}
+	default:
+		return fmt.Errorf("invalid rate limit store: %s", c.Middleware.RateLimit.Store)
+	}
+	if c.Middleware.RateLimit.StateFile != "" {
+		if c.Middleware.RateLimit.Store == "redis" {
+			return fmt.Errorf("rate limit state_file only applies to the memory store")
+		}
+		if c.Middleware.RateLimit.StateMaxAge == 0 {
+			c.Middleware.RateLimit.StateMaxAge = time.Hour // Default freshness cutoff
+		} else if c.Middleware.RateLimit.StateMaxAge < 0 {
+			return fmt.Errorf("invalid rate limit state_max_age: %s", c.Middleware.RateLimit.StateMaxAge)
+		}
+	}
+
 	// Validate backends
 	backendIDs := make(map[string]bool)
 	for i, backend := range c.Backends {
@@ -185,11 +474,27 @@ func (c *Config) Validate() error {
 		if backendIDs[backend.ID] {
 			return fmt.Errorf("duplicate backend ID: %s", backend.ID)
 		}
+		if backend.TLS != nil && backend.TLS.InsecureSkipVerify && !c.Router.AllowInsecureTLS {
+			return fmt.Errorf("backend %s: insecure_skip_verify requires router.allow_insecure_tls", backend.ID)
+		}
 		backendIDs[backend.ID] = true
 	}
 
+	// Validate auth policies
+	policyIDs := make(map[string]bool)
+	for i := range c.AuthPolicies {
+		if err := c.AuthPolicies[i].Validate(); err != nil {
+			return fmt.Errorf("invalid auth policy %d: %w", i, err)
+		}
+		if policyIDs[c.AuthPolicies[i].ID] {
+			return fmt.Errorf("duplicate auth policy ID: %s", c.AuthPolicies[i].ID)
+		}
+		policyIDs[c.AuthPolicies[i].ID] = true
+	}
+
 	// Validate routes
 	routeIDs := make(map[string]bool)
+	versionSources := make(map[string]*models.RouteConfig) // versioned route by literal path prefix
 	for i, route := range c.Routes {
 		if err := route.Validate(); err != nil {
 			return fmt.Errorf("invalid route %d: %w", i, err)
@@ -197,11 +502,61 @@ func (c *Config) Validate() error {
 		if routeIDs[route.ID] {
 			return fmt.Errorf("duplicate route ID: %s", route.ID)
 		}
+		if ceiling := c.Router.MaxBodyBytesCeiling; ceiling > 0 && route.MaxBodyBytes > ceiling {
+			return fmt.Errorf("route %s max_body_bytes %d exceeds max_body_bytes_ceiling %d", route.ID, route.MaxBodyBytes, ceiling)
+		}
 		routeIDs[route.ID] = true
 
-		// Check that backend exists
-		if !backendIDs[route.Backend] {
-			return fmt.Errorf("route %s references non-existent backend: %s", route.ID, route.Backend)
+		// Check that backend exists. Disabled routes aren't served, so they may keep
+		// pointing at a deleted backend; enabling them again requires it to exist.
+		if route.Enabled {
+			if !backendIDs[route.Backend] {
+				return fmt.Errorf("route %s references non-existent backend: %s", route.ID, route.Backend)
+			}
+			if route.CanaryBackend != "" && !backendIDs[route.CanaryBackend] {
+				return fmt.Errorf("route %s references non-existent canary backend: %s", route.ID, route.CanaryBackend)
+			}
+			if route.FallbackBackend != "" && !backendIDs[route.FallbackBackend] {
+				return fmt.Errorf("route %s references non-existent fallback backend: %s", route.ID, route.FallbackBackend)
+			}
+		}
+		if route.AuthPolicy != "" && !policyIDs[route.AuthPolicy] {
+			return fmt.Errorf("route %s references non-existent auth policy: %s", route.ID, route.AuthPolicy)
+		}
+		if route.RateLimit != nil && route.RateLimit.Enabled && route.RateLimit.Strategy == models.StrategySlidingWindow &&
+			c.Middleware.RateLimit.Store == "redis" {
+			return fmt.Errorf("route %s: the redis rate limit store only supports the token_bucket strategy", route.ID)
+		}
+		if route.Version != nil {
+			// Routes sharing a prefix must agree on where the version is, or a request could carry two
+			if other, ok := versionSources[route.LiteralPrefix()]; ok && other.Version.Source() != route.Version.Source() {
+				return fmt.Errorf("route %s reads the version from %s but route %s with the same prefix reads it from %s",
+					route.ID, route.Version.Source(), other.ID, other.Version.Source())
+			}
+			versionSources[route.LiteralPrefix()] = &c.Routes[i]
+		}
+	}
+
+	// Validate flag rollouts
+	for name, rollout := range c.FlagRollouts {
+		if err := rollout.Validate(); err != nil {
+			return fmt.Errorf("invalid rollout for flag %s: %w", name, err)
+		}
+		for _, id := range rollout.Routes {
+			if !routeIDs[id] {
+				return fmt.Errorf("rollout for flag %s references non-existent route: %s", name, id)
+			}
+		}
+		for _, id := range rollout.Backends {
+			if !backendIDs[id] {
+				return fmt.Errorf("rollout for flag %s references non-existent backend: %s", name, id)
+			}
+		}
+	}
+
+	if c.Strict {
+		if orphans := c.Orphans(); len(orphans) > 0 {
+			return orphansError(orphans)
 		}
 	}
 
@@ -210,33 +565,75 @@ func (c *Config) Validate() error {
 
 // setDefaults sets default configuration values
 func setDefaults(v *viper.Viper) {
+	v.SetDefault("strict", false)
+
 	// Router defaults
 	v.SetDefault("router.port", 8080)
 	v.SetDefault("router.read_timeout", "30s")
 	v.SetDefault("router.write_timeout", "30s")
 	v.SetDefault("router.idle_timeout", "120s")
 	v.SetDefault("router.max_header_bytes", 1048576)
+	v.SetDefault("router.served_by_header", false)
+	v.SetDefault("router.allow_insecure_tls", false)
+	v.SetDefault("router.watch_config", false)
+	v.SetDefault("router.max_buffered_body_bytes", 10485760)
+	v.SetDefault("router.drain_timeout", "30s")
+	v.SetDefault("router.request_id_prefix", "")
+	v.SetDefault("router.request_id_format", "uuid")
+	v.SetDefault("router.ignore_inbound_request_id", false)
 
 	// Admin defaults
 	v.SetDefault("admin.enabled", false)
 	v.SetDefault("admin.port", 8081)
+	v.SetDefault("admin.event_backlog_size", 256)
+	v.SetDefault("admin.max_event_streams", 32)
+	v.SetDefault("admin.archive_retention", "720h")
+	v.SetDefault("admin.drift_check_interval", "1m")
+	v.SetDefault("admin.read_only", false)
+	v.SetDefault("admin.unlock_window", "15m")
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
+	v.SetDefault("logging.max_size_mb", 100)
+	v.SetDefault("logging.max_backups", 0)
+	v.SetDefault("logging.max_age_days", 0)
 
 	// Metrics defaults
 	v.SetDefault("metrics.enabled", true)
 	v.SetDefault("metrics.path", "/metrics")
 	v.SetDefault("metrics.port", 9090)
 
+	// Tracing defaults
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.service_name", "ryohi-router")
+
+	// Profiling defaults; it stays off until enabled or started through the admin API
+	v.SetDefault("profiling.enabled", false)
+	v.SetDefault("profiling.app_name", "ryohi-router")
+	v.SetDefault("profiling.interval", "1m")
+	v.SetDefault("profiling.cpu_duration", "10s")
+	v.SetDefault("profiling.max_files", 100)
+	v.SetDefault("profiling.default_duration", "15m")
+
 	// Middleware defaults
 	v.SetDefault("middleware.logging.enabled", true)
 	v.SetDefault("middleware.cors.enabled", true)
 	v.SetDefault("middleware.compression.enabled", true)
 	v.SetDefault("middleware.compression.level", 5)
 	v.SetDefault("middleware.security.enabled", true)
+	v.SetDefault("middleware.rate_limit.store", "memory")
+	v.SetDefault("middleware.rate_limit.state_file", "")
+	v.SetDefault("middleware.rate_limit.state_max_age", "1h")
+
+	// Memory watchdog defaults; it stays off unless a limit is configured or found in the cgroup
+	v.SetDefault("memory.enabled", true)
+	v.SetDefault("memory.high_water", 0.9)
+	v.SetDefault("memory.low_water", 0.8)
+	v.SetDefault("memory.interval", "1s")
+	v.SetDefault("middleware.rate_limit.redis.key_prefix", "ryohi:ratelimit:")
+	v.SetDefault("middleware.rate_limit.redis.timeout", "100ms")
 }
 
 // overrideWithEnv overrides configuration with environment variables
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// Change types reported by Diff
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Change is one difference between two configurations.
+// Path names a top-level section, or a backend, route or feature flag by ID.
+type Change struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// String formats the change as "path type"
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s", c.Path, c.Type)
+}
+
+// Diff returns the changes that turn from into to, sorted by path.
+// Backends, routes, auth policies and archived routes are compared by ID so reordering them is not a change.
+func Diff(from, to *Config) []Change {
+	var changes []Change
+
+	sections := []struct {
+		path     string
+		from, to interface{}
+	}{
+		{"version", from.Version, to.Version},
+		{"router", from.Router, to.Router},
+		{"admin", from.Admin, to.Admin},
+		{"logging", from.Logging, to.Logging},
+		{"metrics", from.Metrics, to.Metrics},
+		{"middleware", from.Middleware, to.Middleware},
+		{"memory", from.Memory, to.Memory},
+	}
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.from, section.to) {
+			changes = append(changes, Change{Path: section.path, Type: ChangeChanged})
+		}
+	}
+
+	changes = append(changes, diffByID("backends", indexBy(from.Backends, backendID), indexBy(to.Backends, backendID))...)
+	changes = append(changes, diffByID("routes", indexBy(from.Routes, routeID), indexBy(to.Routes, routeID))...)
+	changes = append(changes, diffByID("auth_policies", indexBy(from.AuthPolicies, authPolicyID), indexBy(to.AuthPolicies, authPolicyID))...)
+	changes = append(changes, diffByID("archived_routes", indexBy(from.ArchivedRoutes, archivedRouteID), indexBy(to.ArchivedRoutes, archivedRouteID))...)
+	changes = append(changes, diffByID("feature_flags", from.FeatureFlags, to.FeatureFlags)...)
+	changes = append(changes, diffByID("flag_rollouts", from.FlagRollouts, to.FlagRollouts)...)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// diffByID compares two sets of items keyed by ID
+func diffByID[T any](section string, from, to map[string]T) []Change {
+	var changes []Change
+	for id, item := range from {
+		other, exists := to[id]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Path: section + "." + id, Type: ChangeRemoved})
+		case !reflect.DeepEqual(item, other):
+			changes = append(changes, Change{Path: section + "." + id, Type: ChangeChanged})
+		}
+	}
+	for id := range to {
+		if _, exists := from[id]; !exists {
+			changes = append(changes, Change{Path: section + "." + id, Type: ChangeAdded})
+		}
+	}
+	return changes
+}
+
+// indexBy maps items by their ID
+func indexBy[T any](items []T, id func(T) string) map[string]T {
+	index := make(map[string]T, len(items))
+	for _, item := range items {
+		index[id(item)] = item
+	}
+	return index
+}
+
+func backendID(b models.BackendService) string      { return b.ID }
+func routeID(r models.RouteConfig) string           { return r.ID }
+func archivedRouteID(r models.ArchivedRoute) string { return r.ID }
+func authPolicyID(p models.AuthPolicy) string       { return p.ID }
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// expandEnvHook expands environment variables in every string value as it is decoded,
+// so nested fields such as endpoint URLs and secrets can be kept out of the file
+func expandEnvHook(from reflect.Type, _ reflect.Type, data interface{}) (interface{}, error) {
+	s, ok := data.(string)
+	if from.Kind() != reflect.String || !ok {
+		return data, nil
+	}
+	return expandEnv(s)
+}
+
+// decodeHook is viper's default decode hook with environment variable expansion in front
+var decodeHook = mapstructure.ComposeDecodeHookFunc(
+	expandEnvHook,
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToWeakSliceHookFunc(","),
+)
+
+// expandEnv replaces ${VAR} with the variable's value and ${VAR:-default} with the
+// value, or the default when the variable is unset or empty. $$ is a literal dollar.
+// A variable that is unset and has no default is an error, so a missing secret
+// fails the load instead of becoming an empty string.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+
+		switch s[0] {
+		case '$':
+			b.WriteByte('$')
+			s = s[1:]
+		case '{':
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", "$"+s)
+			}
+			value, err := lookupEnv(s[1:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			s = s[end+1:]
+		default:
+			// A lone $ is kept as it is
+			b.WriteByte('$')
+		}
+	}
+}
+
+// lookupEnv resolves the inside of a ${...} reference
+func lookupEnv(ref string) (string, error) {
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	if name == "" {
+		return "", fmt.Errorf("empty variable name in ${%s}", ref)
+	}
+
+	value, set := os.LookupEnv(name)
+	if hasDefault && value == "" {
+		return def, nil
+	}
+	if !set {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// RedactedValue replaces secrets in a redacted configuration
+const RedactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration that is safe to show, with the admin
+// keys, the Redis password and the API keys of flag rollouts replaced by RedactedValue.
+// Unset secrets stay empty so it is still visible whether they are configured.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Admin.APIKey = redact(c.Admin.APIKey)
+	redacted.Admin.UnlockKey = redact(c.Admin.UnlockKey)
+	redacted.Middleware.RateLimit.Redis.Password = redact(c.Middleware.RateLimit.Redis.Password)
+	if c.Router.OverrideTokens != nil {
+		redacted.Router.OverrideTokens = make([]string, len(c.Router.OverrideTokens))
+		for i := range redacted.Router.OverrideTokens {
+			redacted.Router.OverrideTokens[i] = RedactedValue
+		}
+	}
+
+	if c.FlagRollouts != nil {
+		redacted.FlagRollouts = make(map[string]models.FlagRollout, len(c.FlagRollouts))
+		for name, rollout := range c.FlagRollouts {
+			if len(rollout.AllowAPIKeys) > 0 {
+				keys := make([]string, len(rollout.AllowAPIKeys))
+				for i := range keys {
+					keys[i] = RedactedValue
+				}
+				rollout.AllowAPIKeys = keys
+			}
+			redacted.FlagRollouts[name] = rollout
+		}
+	}
+	return &redacted
+}
+
+// redact hides a secret, leaving an unset one empty
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// RedactSecrets replaces every secret Redacted hides with RedactedValue wherever it appears
+// in data, for text that may quote one, such as the logs in a support bundle
+func (c *Config) RedactSecrets(data []byte) []byte {
+	for _, secret := range c.secrets() {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(RedactedValue))
+	}
+	return data
+}
+
+// secrets returns the configured secrets, longest first so one containing another is replaced whole
+func (c *Config) secrets() []string {
+	secrets := []string{c.Admin.APIKey, c.Admin.UnlockKey, c.Middleware.RateLimit.Redis.Password}
+	secrets = append(secrets, c.Router.OverrideTokens...)
+	for _, rollout := range c.FlagRollouts {
+		secrets = append(secrets, rollout.AllowAPIKeys...)
+	}
+	secrets = slices.DeleteFunc(secrets, func(secret string) bool { return secret == "" })
+	slices.SortFunc(secrets, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	return secrets
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// Kinds of orphans reported by Orphans
+const (
+	OrphanUnusedBackend        = "unused_backend"         // no route uses the backend
+	OrphanUnusedAuthPolicy     = "unused_auth_policy"     // no route uses the auth policy
+	OrphanRouteDisabledBackend = "route_disabled_backend" // a route sends traffic to a disabled backend
+)
+
+// References is the cross-reference graph of a configuration: the routes using each
+// backend, as primary, canary or fallback, and each auth policy
+type References struct {
+	backends map[string][]*models.RouteConfig
+	policies map[string][]*models.RouteConfig
+}
+
+// References builds the configuration's cross-reference graph. It points into the
+// configuration, so it must be rebuilt after the configuration changes.
+func (c *Config) References() *References {
+	refs := &References{
+		backends: make(map[string][]*models.RouteConfig),
+		policies: make(map[string][]*models.RouteConfig),
+	}
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		for _, backendID := range route.BackendIDs() {
+			refs.backends[backendID] = append(refs.backends[backendID], route)
+		}
+		if route.AuthPolicy != "" {
+			refs.policies[route.AuthPolicy] = append(refs.policies[route.AuthPolicy], route)
+		}
+	}
+	return refs
+}
+
+// BackendRoutes returns the routes using the backend
+func (r *References) BackendRoutes(backendID string) []*models.RouteConfig {
+	return r.backends[backendID]
+}
+
+// PolicyRoutes returns the routes using the auth policy
+func (r *References) PolicyRoutes(policyID string) []*models.RouteConfig {
+	return r.policies[policyID]
+}
+
+// Orphan is a part of the configuration that is defined but does nothing, or that
+// routes traffic nowhere
+type Orphan struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	File    string `json:"file,omitempty"` // empty when the configuration wasn't loaded from a file
+	Section string `json:"section"`        // e.g. backends[2]
+	Message string `json:"message"`
+}
+
+// String formats the orphan for logs and errors
+func (o Orphan) String() string {
+	if o.File != "" {
+		return fmt.Sprintf("%s (%s: %s)", o.Message, o.File, o.Section)
+	}
+	return fmt.Sprintf("%s (%s)", o.Message, o.Section)
+}
+
+// Orphans lists backends no route uses, except overridable ones, auth policies no route
+// uses, and routes using disabled backends, in the order they appear in the configuration
+func (c *Config) Orphans() []Orphan {
+	refs := c.References()
+	orphans := []Orphan{}
+
+	enabled := make(map[string]bool, len(c.Backends))
+	for i, backend := range c.Backends {
+		enabled[backend.ID] = backend.Enabled
+		// Overridable backends are reached by X-Backend-Override rather than by a route
+		if len(refs.BackendRoutes(backend.ID)) == 0 && !backend.Overridable {
+			orphans = append(orphans, Orphan{
+				Kind:    OrphanUnusedBackend,
+				ID:      backend.ID,
+				File:    c.path,
+				Section: fmt.Sprintf("backends[%d]", i),
+				Message: fmt.Sprintf("backend %s is not used by any route", backend.ID),
+			})
+		}
+	}
+
+	for i, policy := range c.AuthPolicies {
+		if len(refs.PolicyRoutes(policy.ID)) == 0 {
+			orphans = append(orphans, Orphan{
+				Kind:    OrphanUnusedAuthPolicy,
+				ID:      policy.ID,
+				File:    c.path,
+				Section: fmt.Sprintf("auth_policies[%d]", i),
+				Message: fmt.Sprintf("auth policy %s is not used by any route", policy.ID),
+			})
+		}
+	}
+
+	for i, route := range c.Routes {
+		for _, backendID := range route.BackendIDs() {
+			if isEnabled, exists := enabled[backendID]; exists && !isEnabled {
+				orphans = append(orphans, Orphan{
+					Kind:    OrphanRouteDisabledBackend,
+					ID:      route.ID,
+					File:    c.path,
+					Section: fmt.Sprintf("routes[%d]", i),
+					Message: fmt.Sprintf("route %s uses disabled backend %s", route.ID, backendID),
+				})
+			}
+		}
+	}
+	return orphans
+}
+
+// orphansError reports the orphans of a strict configuration as one error
+func orphansError(orphans []Orphan) error {
+	descriptions := make([]string, len(orphans))
+	for i, orphan := range orphans {
+		descriptions[i] = orphan.String()
+	}
+	return fmt.Errorf("strict mode: %s", strings.Join(descriptions, "; "))
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the file must stay quiet before it is reloaded,
+// so a file written in several steps is never read half-written
+const watchDebounce = 100 * time.Millisecond
+
+// Watch reloads the configuration file whenever it changes, until ctx is cancelled.
+// onChange receives each configuration that loads and validates, or the error that
+// kept a change from loading. The file's directory is watched so files replaced
+// by a rename, as editors and deploy tools do, are picked up too.
+func Watch(ctx context.Context, configFile string, onChange func(*Config, error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Clean(configFile)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		debounce := time.NewTimer(watchDebounce)
+		debounce.Stop()
+		defer debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create) {
+					debounce.Reset(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onChange(nil, err)
+			case <-debounce.C:
+				onChange(loadValidated(path))
+			}
+		}
+	}()
+	return nil
+}
+
+// loadValidated loads and validates a configuration file
+func loadValidated(path string) (*Config, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,181 @@
+// Package jwks fetches and caches the signing keys an issuer publishes as a JSON Web Key Set.
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long fetched keys are used before the set is fetched again
+	DefaultTTL = 5 * time.Minute
+	// minRefreshInterval limits refetches triggered by unknown key IDs
+	minRefreshInterval = 10 * time.Second
+)
+
+// ErrKeyNotFound is returned when the key set has no key with the requested ID
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeySet is the cached key set of one issuer
+type KeySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	mutex     sync.Mutex
+}
+
+// NewKeySet creates a key set fetched from url and cached for ttl (DefaultTTL when 0)
+func NewKeySet(url string, ttl time.Duration, client *http.Client) *KeySet {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &KeySet{url: url, ttl: ttl, client: client}
+}
+
+// Key returns the public key with the given key ID.
+// An unknown ID refetches the set, so rotated keys are picked up before the TTL expires.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	stale := time.Since(k.fetchedAt) > k.ttl
+	key, found := k.keys[kid]
+	if found && !stale {
+		return key, nil
+	}
+	if !stale && time.Since(k.fetchedAt) < minRefreshInterval {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+
+	if err := k.fetch(ctx); err != nil {
+		// Keep using the keys we have while the issuer is unreachable
+		if found {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, found = k.keys[kid]; !found {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+// fetch downloads and parses the key set; the caller must hold the mutex
+func (k *KeySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the whole set
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	return nil
+}
+
+// jsonWebKey is an RSA or EC public key as published in a JWKS (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes the key
+func (j jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", j.Crv)
+		}
+		x, err := decodeInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", j.Kty)
+	}
+}
+
+// decodeInt decodes a base64url big-endian integer
+func decodeInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
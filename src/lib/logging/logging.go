@@ -0,0 +1,64 @@
+// Package logging builds the router's logger from its logging configuration.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+)
+
+// nopCloser is returned when the logger doesn't own its output
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// New creates the logger the configuration describes. With output file it writes to
+// a RotatingFile at FilePath, which the returned closer closes on shutdown.
+func New(cfg config.LoggingConfig) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var output io.Writer
+	var closer io.Closer = nopCloser{}
+	switch cfg.Output {
+	case "", "stdout":
+		output = os.Stdout
+	case "stderr":
+		output = os.Stderr
+	case "file":
+		file, err := OpenRotatingFile(cfg.FilePath, cfg.MaxSizeMB, cfg.MaxBackups, cfg.MaxAgeDays)
+		if err != nil {
+			return nil, nil, err
+		}
+		output, closer = file, file
+	default:
+		return nil, nil, fmt.Errorf("unknown log output: %s", cfg.Output)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(output, options)), closer, nil
+	}
+	return slog.New(slog.NewJSONHandler(output, options)), closer, nil
+}
+
+// ParseLevel parses debug, info, warn or error; empty is info
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level: %s", level)
+}
@@ -0,0 +1,174 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is a log record kept by a Ring
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Ring keeps the most recent log records at or above a level that pass its filter,
+// e.g. the last errors for a support bundle
+type Ring struct {
+	level  slog.Level
+	filter func(slog.Record) bool // nil keeps every record at the level
+
+	mutex   sync.Mutex
+	entries []Entry
+	next    int // where the next entry goes once the ring is full
+}
+
+// NewRing creates a ring keeping up to size records
+func NewRing(size int, level slog.Level, filter func(slog.Record) bool) *Ring {
+	return &Ring{level: level, filter: filter, entries: make([]Entry, 0, size)}
+}
+
+// Entries returns the kept records, oldest first
+func (r *Ring) Entries() []Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := make([]Entry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// keeps reports whether the ring wants the record
+func (r *Ring) keeps(record slog.Record) bool {
+	return record.Level >= r.level && (r.filter == nil || r.filter(record))
+}
+
+// add stores an entry, replacing the oldest once the ring is full
+func (r *Ring) add(entry Entry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	if len(r.entries) == 0 {
+		return
+	}
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// teeHandler passes records to a handler and keeps them in rings
+type teeHandler struct {
+	handler slog.Handler
+	rings   []*Ring
+	attrs   []slog.Attr // added with WithAttrs, keys prefixed by their group
+	group   string      // prefix for the record's own attributes
+}
+
+// Tee returns a handler passing records on to handler and keeping those the rings
+// want in them, whether or not handler's level lets them through
+func Tee(handler slog.Handler, rings ...*Ring) slog.Handler {
+	return &teeHandler{handler: handler, rings: rings}
+}
+
+// Enabled implements slog.Handler
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, ring := range h.rings {
+		if level >= ring.level {
+			return true
+		}
+	}
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var entry *Entry
+	for _, ring := range h.rings {
+		if !ring.keeps(record) {
+			continue
+		}
+		if entry == nil {
+			entry = h.entry(record)
+		}
+		ring.add(*entry)
+	}
+
+	if !h.handler.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+// entry converts a record to an Entry, flattening groups into dotted keys
+func (h *teeHandler) entry(record slog.Record) *Entry {
+	entry := &Entry{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+		Attrs:   make(map[string]interface{}, len(h.attrs)+record.NumAttrs()),
+	}
+	for _, attr := range h.attrs {
+		addAttr(entry.Attrs, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addAttr(entry.Attrs, h.group, attr)
+		return true
+	})
+	return entry
+}
+
+// addAttr adds an attribute to attrs under prefix and its key
+func addAttr(attrs map[string]interface{}, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	key := prefix + attr.Key
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			key += "."
+		}
+		for _, member := range value.Group() {
+			addAttr(attrs, key, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	switch v := value.Any().(type) {
+	case error:
+		// errors have no exported fields and would encode as {}
+		attrs[key] = v.Error()
+	case time.Duration:
+		attrs[key] = v.String()
+	default:
+		attrs[key] = v
+	}
+}
+
+// WithAttrs implements slog.Handler
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.handler = h.handler.WithAttrs(attrs)
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		clone.attrs = append(clone.attrs, slog.Attr{Key: h.group + attr.Key, Value: attr.Value})
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.handler = h.handler.WithGroup(name)
+	clone.group = h.group + name + "."
+	return &clone
+}
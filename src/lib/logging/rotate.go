@@ -0,0 +1,178 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files; it sorts in time order
+const backupTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// RotatingFile is a log file that is rotated when it would grow past its size limit.
+// Rotated files are renamed with the rotation time, e.g. router-2026-10-16T09-30-00.000000000.log,
+// and the oldest are removed beyond the backup count or age limit.
+type RotatingFile struct {
+	path       string
+	maxSize    int64         // 0 never rotates
+	maxBackups int           // 0 keeps every backup
+	maxAge     time.Duration // 0 keeps backups regardless of age
+
+	file  *os.File
+	size  int64
+	mutex sync.Mutex
+}
+
+// OpenRotatingFile opens path for appending, creating it and its directory if needed
+func OpenRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*RotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log file path is required")
+	}
+
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the current file, continuing from its size
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write implements io.Writer. A write that would take the file past its size limit
+// rotates it first, so log lines are never split across files.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new file now, e.g. on SIGHUP
+func (f *RotatingFile) Rotate() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.rotate()
+}
+
+// rotate renames the current file to a backup, opens a new one and prunes old backups
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	// If the rename fails the current file is reopened and keeps growing, rather than losing lines
+	renameErr := os.Rename(f.path, f.backupName(time.Now()))
+	if err := f.open(); err != nil {
+		return err
+	}
+	if renameErr == nil {
+		f.prune()
+	}
+	return nil
+}
+
+// backupName returns the name of a backup rotated at t
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// rotatedAt returns when the file with the given base name was rotated, or false if it isn't a backup
+func (f *RotatingFile) rotatedAt(name string) (time.Time, bool) {
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+	return t, err == nil
+}
+
+// Backups returns the rotated files, oldest first
+func (f *RotatingFile) Backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		if _, ok := f.rotatedAt(entry.Name()); ok && !entry.IsDir() {
+			backups = append(backups, filepath.Join(filepath.Dir(f.path), entry.Name()))
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// prune removes backups beyond the count limit and older than the age limit.
+// Failures only leave extra files behind, so they are ignored.
+func (f *RotatingFile) prune() {
+	if f.maxBackups == 0 && f.maxAge == 0 {
+		return
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-f.maxAge)
+	for i, backup := range backups {
+		excess := f.maxBackups > 0 && i < len(backups)-f.maxBackups
+		rotatedAt, _ := f.rotatedAt(filepath.Base(backup))
+		expired := f.maxAge > 0 && rotatedAt.Before(cutoff)
+		if excess || expired {
+			os.Remove(backup)
+		}
+	}
+}
+
+// Close closes the current file
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
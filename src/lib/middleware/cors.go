@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// IsPreflight reports whether the request is a CORS preflight
+func IsPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// CORS answers preflights itself and adds CORS headers to responses for allowed origins.
+// The headers are set when the response is written, so they replace any the backend sent.
+// Requests from other origins pass through without CORS headers, and their preflights get 403.
+func CORS(config *models.CORSConfig) func(http.Handler) http.Handler {
+	methods := strings.Join(config.AllowedMethods, ", ")
+	exposed := strings.Join(config.ExposedHeaders, ", ")
+	anyHeader := slices.Contains(config.AllowedHeaders, "*")
+	headers := strings.Join(config.AllowedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Origin")
+
+			if IsPreflight(r) {
+				if !config.AllowsOrigin(origin) {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+
+				h := w.Header()
+				setCORSOrigin(h, config, origin)
+				if methods != "" {
+					h.Set("Access-Control-Allow-Methods", methods)
+				} else {
+					h.Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+				}
+				if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" && (anyHeader || headers == "") {
+					h.Set("Access-Control-Allow-Headers", requested)
+				} else if headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				if config.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if !config.AllowsOrigin(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(&corsWriter{ResponseWriter: w, config: config, origin: origin, exposed: exposed}, r)
+		})
+	}
+}
+
+// setCORSOrigin sets the allowed origin and credentials headers.
+// Credentialed responses can't use a wildcard, so the origin is echoed for them.
+func setCORSOrigin(h http.Header, config *models.CORSConfig, origin string) {
+	if slices.Contains(config.AllowedOrigins, "*") && !config.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	} else {
+		h.Del("Access-Control-Allow-Credentials")
+	}
+}
+
+// corsWriter sets CORS headers on the response just before its header is written
+type corsWriter struct {
+	http.ResponseWriter
+	config      *models.CORSConfig
+	origin      string
+	exposed     string
+	wroteHeader bool
+}
+
+func (cw *corsWriter) WriteHeader(code int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		h := cw.Header()
+		setCORSOrigin(h, cw.config, cw.origin)
+		if cw.exposed != "" {
+			h.Set("Access-Control-Expose-Headers", cw.exposed)
+		} else {
+			h.Del("Access-Control-Expose-Headers")
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *corsWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter so http.ResponseController
+// can reach Flush and deadline controls for streaming responses
+func (cw *corsWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// requestIDKey is the context key of the request's ID
+type requestIDKey struct{}
+
+// withRequestID returns the request with its ID stored in the context
+func withRequestID(r *http.Request, requestID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID))
+}
+
+// RequestIDFromContext returns the ID the RequestID middleware gave the request, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request ID to records logged with a request's context
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewContextLogger returns a logger that adds the request ID to every line logged
+// through its Context methods (InfoContext etc.) with a request's context
+func NewContextLogger(logger *slog.Logger) *slog.Logger {
+	if _, ok := logger.Handler().(contextHandler); ok {
+		return logger
+	}
+	return slog.New(contextHandler{Handler: logger.Handler()})
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// AllowMethods rejects requests whose method isn't listed with 405 and an Allow header,
+// before they are routed. It keeps methods like TRACE and CONNECT away from every route.
+func AllowMethods(methods []string) func(http.Handler) http.Handler {
+	allow := strings.Join(methods, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(methods, r.Method) {
+				w.Header().Set("Allow", allow)
+				http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
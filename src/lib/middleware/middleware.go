@@ -1,13 +1,19 @@
 package middleware
 
 import (
+	"context"
+	"encoding/hex"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
 )
 
 // Chain applies multiple middleware to a handler
@@ -18,21 +24,132 @@ func Chain(h http.Handler, middleware ...func(http.Handler) http.Handler) http.H
 	return h
 }
 
-// RequestID adds a request ID to the context
-func RequestID() func(http.Handler) http.Handler {
+// Request ID formats
+const (
+	RequestIDFormatUUID = "uuid" // 8-4-4-4-12 hex digits
+	RequestIDFormatHex  = "hex"  // 32 hex digits without dashes
+)
+
+// NewRequestID generates a request ID in the given format with the prefix prepended
+func NewRequestID(prefix, format string) string {
+	id := uuid.New()
+	if format == RequestIDFormatHex {
+		return prefix + hex.EncodeToString(id[:])
+	}
+	return prefix + id.String()
+}
+
+// RequestIDOptions configures how request IDs are accepted and generated
+type RequestIDOptions struct {
+	Prefix string // prepended to generated IDs
+	Format string // RequestIDFormatUUID or RequestIDFormatHex
+	// TrustInbound keeps an ID sent by the client instead of always generating one
+	TrustInbound bool
+	// CorrelationHeaders carry the same ID as X-Request-ID, to the backend and back to the client.
+	// An inbound value in one of them is used when X-Request-ID is missing.
+	CorrelationHeaders []string
+}
+
+// RequestID gives every request a single ID, set as X-Request-ID and each correlation header
+// on the request and the response, and stored in the request context for NewContextLogger.
+// Everything downstream reads it from X-Request-ID.
+func RequestID(opts RequestIDOptions) func(http.Handler) http.Handler {
+	headers := []string{"X-Request-ID"}
+	for _, name := range opts.CorrelationHeaders {
+		headers = append(headers, http.CanonicalHeaderKey(name))
+	}
+	
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			requestID := r.Header.Get("X-Request-ID")
+			requestID := ""
+			if opts.TrustInbound {
+				for _, name := range headers {
+					if requestID = r.Header.Get(name); requestID != "" {
+						break
+					}
+				}
+			}
 			if requestID == "" {
-				requestID = uuid.New().String()
+				requestID = NewRequestID(opts.Prefix, opts.Format)
 			}
 			
-			w.Header().Set("X-Request-ID", requestID)
-			next.ServeHTTP(w, r)
+			for _, name := range headers {
+				r.Header.Set(name, requestID)
+				w.Header().Set(name, requestID)
+			}
+			next.ServeHTTP(w, withRequestID(r, requestID))
 		})
 	}
 }
 
+// upstreamKey is the context key for what the request log reports about the upstream
+type upstreamKey struct{}
+
+// upstream is what the proxy records about a request for the request log
+type upstream struct {
+	backend    string
+	endpoint   string
+	errorClass string
+	pathParams map[string]string
+	queueWait  time.Duration // time spent waiting for a backend concurrency slot
+	override   bool          // the backend was chosen by an X-Backend-Override header
+}
+
+// upstreamInfo returns the request's upstream record, or nil outside the Logger middleware
+func upstreamInfo(r *http.Request) *upstream {
+	info, _ := r.Context().Value(upstreamKey{}).(*upstream)
+	return info
+}
+
+// SetServedBy records which backend served the request for the request log
+func SetServedBy(r *http.Request, backend string) {
+	if info := upstreamInfo(r); info != nil {
+		info.backend = backend
+	}
+}
+
+// SetEndpoint records which backend endpoint the request was sent to for the request log
+func SetEndpoint(r *http.Request, endpoint string) {
+	if info := upstreamInfo(r); info != nil {
+		info.endpoint = endpoint
+	}
+}
+
+// SetErrorClass records why the upstream request failed for the request log
+func SetErrorClass(r *http.Request, class string) {
+	if info := upstreamInfo(r); info != nil {
+		info.errorClass = class
+	}
+}
+
+// SetQueueWait records how long the request waited for a backend concurrency slot for the request log
+func SetQueueWait(r *http.Request, wait time.Duration) {
+	if info := upstreamInfo(r); info != nil {
+		info.queueWait = wait
+	}
+}
+
+// SetBackendOverride records that an X-Backend-Override header chose the backend for the request log
+func SetBackendOverride(r *http.Request) {
+	if info := upstreamInfo(r); info != nil {
+		info.override = true
+	}
+}
+
+// Detach returns a copy of the request for work that outlives it, such as a background
+// cache refresh. Its context is not canceled with the request's and it records the
+// upstream separately, so the request log isn't changed after it is written.
+func Detach(r *http.Request) *http.Request {
+	ctx := context.WithoutCancel(r.Context())
+	if upstreamInfo(r) != nil {
+		ctx = context.WithValue(ctx, upstreamKey{}, &upstream{})
+	}
+	return r.Clone(ctx)
+}
+
+// AccessLogMessage is the message of the line Logger writes for each request
+const AccessLogMessage = "HTTP Request"
+
 // Logger logs HTTP requests
 func Logger(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -42,17 +159,41 @@ func Logger(logger *slog.Logger) func(http.Handler) http.Handler {
 			// Wrap response writer to capture status code
 			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 			
+			info := &upstream{}
+			r = r.WithContext(context.WithValue(r.Context(), upstreamKey{}, info))
+			
 			next.ServeHTTP(wrapped, r)
 			
 			duration := time.Since(start)
 			
-			logger.Info("HTTP Request",
+			attrs := []any{
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", wrapped.statusCode,
+				"bytes", wrapped.bytes,
 				"duration", duration.String(),
 				"remote_addr", r.RemoteAddr,
-			)
+			}
+			if info.backend != "" {
+				attrs = append(attrs, "backend", info.backend)
+			}
+			if info.endpoint != "" {
+				attrs = append(attrs, "endpoint", info.endpoint)
+			}
+			if info.override {
+				attrs = append(attrs, "backend_override", true)
+			}
+			if info.errorClass != "" {
+				attrs = append(attrs, "error_class", info.errorClass)
+			}
+			if info.queueWait > 0 {
+				attrs = append(attrs, "queue_wait", info.queueWait.String())
+			}
+			if len(info.pathParams) > 0 {
+				attrs = append(attrs, "path_params", info.pathParams)
+			}
+			
+			logger.InfoContext(r.Context(), AccessLogMessage, attrs...)
 		})
 	}
 }
@@ -63,7 +204,11 @@ func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
-					logger.Error("Panic recovered",
+					if err == http.ErrAbortHandler {
+						// Deliberate abort, e.g. the client went away mid-response
+						panic(err)
+					}
+					logger.ErrorContext(r.Context(), "Panic recovered",
 						"error", err,
 						"path", r.URL.Path,
 						"method", r.Method,
@@ -78,34 +223,74 @@ func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-// Metrics collects request metrics
+// Metrics collects request metrics.
+// It must run after route matching (mux.Router.Use) so requests are labelled
+// with the matched route pattern instead of the raw URL path. These requests
+// aren't served by a configured route, so their route label is services.NoRoute.
 func Metrics() func(http.Handler) http.Handler {
+	return metrics(routePattern, services.NoRoute)
+}
+
+// RouteMetrics collects request metrics labelled with a fixed route pattern and route ID.
+// It is used where routes are picked without mux, such as the main route dispatcher.
+func RouteMetrics(pattern, routeID string) func(http.Handler) http.Handler {
+	return metrics(func(*http.Request) string { return pattern }, routeID)
+}
+
+// metrics collects request metrics labelled with the pattern returned by label and the route ID
+func metrics(label func(*http.Request) string, routeID string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// TODO: Implement metrics collection
-			next.ServeHTTP(w, r)
+			start := time.Now()
+			
+			services.HTTPRequestsInFlight.Inc()
+			defer services.HTTPRequestsInFlight.Dec()
+			
+			// Wrap response writer to capture status code
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			
+			next.ServeHTTP(wrapped, r)
+			
+			services.RecordHTTPRequest(r.Method, label(r), routeID,
+				strconv.Itoa(wrapped.statusCode), time.Since(start).Seconds(), SampledTraceID(r))
 		})
 	}
 }
 
-// RateLimit implements rate limiting
-func RateLimit(config *models.RateLimitConfig) func(http.Handler) http.Handler {
-	limiter := models.NewRateLimiter(config)
+// routePattern returns the path template of the matched route
+func routePattern(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unmatched"
+	}
 	
+	if template, err := route.GetPathTemplate(); err == nil {
+		return template
+	}
+	return "unmatched"
+}
+
+// RateLimit implements rate limiting with buckets kept in memory
+func RateLimit(config *models.RateLimitConfig) func(http.Handler) http.Handler {
+	return RateLimitWithStore(config, models.NewRateLimiter(config), false, slog.Default())
+}
+
+// RateLimitWithStore implements rate limiting with buckets kept in the given store.
+// When the store fails, requests are let through unless failClosed is set.
+func RateLimitWithStore(config *models.RateLimitConfig, store models.RateLimitStore, failClosed bool, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract key based on key type
-			var key string
-			switch config.KeyType {
-			case "IP":
-				key = getClientIP(r)
-			case "API_KEY":
-				key = r.Header.Get("X-API-Key")
-			default:
-				key = "global"
+			allowed, err := store.Take(r.Context(), rateLimitKey(config.KeyType, r, logger))
+			if err != nil {
+				logger.WarnContext(r.Context(), "Rate limit store unavailable", "fail_closed", failClosed, "error", err)
+				if failClosed {
+					http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				allowed = true
 			}
 			
-			if !limiter.Allow(key) {
+			if !allowed {
 				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
 			}
@@ -115,6 +300,25 @@ func RateLimit(config *models.RateLimitConfig) func(http.Handler) http.Handler {
 	}
 }
 
+// rateLimitKey returns the bucket key of a request for the given key type.
+// USER_ID falls back to the client IP when the request is not authenticated.
+func rateLimitKey(keyType string, r *http.Request, logger *slog.Logger) string {
+	switch keyType {
+	case "IP":
+		return getClientIP(r)
+	case "API_KEY":
+		return r.Header.Get("X-API-Key")
+	case "USER_ID":
+		if auth := AuthContextFrom(r); auth != nil && auth.Authenticated && auth.UserID != "" {
+			return "user:" + auth.UserID
+		}
+		logger.WarnContext(r.Context(), "No authenticated user for USER_ID rate limit, keying by IP", "path", r.URL.Path)
+		return getClientIP(r)
+	default:
+		return "global"
+	}
+}
+
 // Auth implements authentication
 func Auth(config *models.AuthConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -170,6 +374,7 @@ func APIKeyAuth(validKey string) func(http.Handler) http.Handler {
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int64 // body bytes written
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -177,6 +382,18 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter so http.ResponseController
+// can reach Flush and deadline controls for streaming responses
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // getClientIP extracts the client IP address from the request
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header
@@ -201,4 +418,14 @@ func getClientIP(r *http.Request) string {
 	}
 	
 	return addr
-}
\ No newline at end of file
+}
+
+// remoteIP returns the address of the connection's peer. Unlike getClientIP it ignores
+// X-Forwarded-For and X-Real-IP, which any client can set, so it is safe to grant access by.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// pathParamsKey is the context key for the matched route's path parameters
+type pathParamsKey struct{}
+
+// WithPathParams attaches the {name} values extracted from the request path.
+// They are also recorded for the request log.
+func WithPathParams(r *http.Request, params map[string]string) *http.Request {
+	if len(params) == 0 {
+		return r
+	}
+	if info := upstreamInfo(r); info != nil {
+		info.pathParams = params
+	}
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
+
+// PathParams returns the path parameters of the matched route, or nil when it has none
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// PathParam returns the named path parameter, or "" when the route doesn't define it
+func PathParam(r *http.Request, name string) string {
+	return PathParams(r)[name]
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NormalizePath cleans request paths before they are routed: repeated slashes are collapsed
+// and "." and ".." segments resolved, so /api//v1/../v1/users is routed and proxied as
+// /api/v1/users. A trailing slash is kept. Paths whose ".." segments climb above the root,
+// including percent-encoded ones, are rejected with 400.
+func NormalizePath() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			escaped, ok := normalizePath(r.URL.EscapedPath())
+			if !ok {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			if escaped == r.URL.EscapedPath() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decoded, err := url.PathUnescape(escaped)
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+
+			// The original request keeps its path for the access log
+			normalized := new(http.Request)
+			*normalized = *r
+			u := *r.URL
+			u.Path, u.RawPath = decoded, escaped
+			normalized.URL = &u
+			next.ServeHTTP(w, normalized)
+		})
+	}
+}
+
+// normalizePath resolves the segments of an escaped path, reporting false if ".." climbs above
+// the root or hides behind an encoded slash. Paths not starting with a slash, like the * of
+// OPTIONS *, are left alone.
+func normalizePath(escaped string) (string, bool) {
+	if !strings.HasPrefix(escaped, "/") {
+		return escaped, true
+	}
+	// Most paths have nothing to resolve; skip splitting them
+	if !strings.Contains(escaped, "//") && !strings.Contains(escaped, "/.") && !strings.ContainsAny(escaped, "%\\") {
+		return escaped, true
+	}
+
+	segments := strings.Split(escaped, "/")
+	kept := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		name, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", false
+		}
+		switch name {
+		case "", ".":
+		case "..":
+			if len(kept) == 0 {
+				return "", false
+			}
+			kept = kept[:len(kept)-1]
+		default:
+			// A backend decoding %2F would see ..%2F.. as dot segments of its own
+			for _, part := range strings.FieldsFunc(name, func(c rune) bool { return c == '/' || c == '\\' }) {
+				if part == "." || part == ".." {
+					return "", false
+				}
+			}
+			kept = append(kept, segment)
+		}
+	}
+
+	// Like path.Clean, but a trailing slash is kept as the routes may tell it apart
+	cleaned := "/" + strings.Join(kept, "/")
+	if len(kept) > 0 && strings.HasSuffix(escaped, "/") {
+		cleaned += "/"
+	}
+	return cleaned, true
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// Shedder decides whether a request should be rejected to relieve the process
+type Shedder interface {
+	ShouldShed() bool
+}
+
+// Shed rejects the requests the shedder picks with 503 and Retry-After.
+// It belongs in front of a route's other middleware so shed requests cost as little as possible.
+func Shed(shedder Shedder, routeID string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if shedder.ShouldShed() {
+				services.RecordRequestShed(routeID)
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
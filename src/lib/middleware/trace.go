@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/lib/tracing"
+)
+
+// Tracing starts a server span for every request, continuing the client's trace when
+// it sent a traceparent. The request's traceparent is replaced with the span's, so
+// metrics exemplars and the backend spans the router starts point at it.
+func Tracing(provider *tracing.Provider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := provider.Start(tracing.Extract(r.Context(), r.Header), "HTTP "+r.Method, tracing.SpanKindServer)
+			defer span.End()
+
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("url.path", r.URL.Path)
+			if requestID := RequestIDFromContext(ctx); requestID != "" {
+				span.SetAttribute("request_id", requestID)
+			}
+			r = r.WithContext(ctx)
+			tracing.Inject(ctx, r.Header)
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			span.SetAttribute("http.response.status_code", wrapped.statusCode)
+			if wrapped.statusCode >= http.StatusInternalServerError {
+				span.SetError(http.StatusText(wrapped.statusCode))
+			}
+		})
+	}
+}
+
+// SampledTraceID returns the trace ID of a request whose W3C traceparent header
+// marks it as sampled, or "" when the request is untraced or not sampled.
+// The header is forwarded to backends unchanged, so the router's metrics can
+// point at the same trace the client and backends report.
+func SampledTraceID(r *http.Request) string {
+	// traceparent: version-traceid-parentid-flags, e.g. 00-<32 hex>-<16 hex>-01
+	parts := strings.Split(r.Header.Get("traceparent"), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return ""
+	}
+
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if len(traceID) != 32 || !isHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+	if len(parentID) != 16 || !isHex(parentID) || len(flags) != 2 || !isHex(flags) {
+		return ""
+	}
+
+	// The sampled flag is the lowest bit of the trace flags
+	if !strings.ContainsRune("13579bdf", rune(flags[1])) {
+		return ""
+	}
+	return traceID
+}
+
+// isHex reports whether s contains only lowercase hex digits
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// stateVersion is the version of the state file format
+const stateVersion = 1
+
+// ErrStaleState is returned for a state file saved longer ago than it may be restored
+var ErrStaleState = errors.New("rate limit state is stale")
+
+// stateFile is the on-disk form of the in-memory rate limiters
+type stateFile struct {
+	Version int                              `json:"version"`
+	SavedAt time.Time                        `json:"saved_at"`
+	Routes  map[string]models.RateLimitState `json:"routes"` // by route ID
+}
+
+// SaveState writes the state of the limiters, keyed by route ID, to path.
+// The file is replaced atomically so a crash never leaves half a file behind.
+func SaveState(path string, limiters map[string]*models.RateLimiter, now time.Time) error {
+	state := stateFile{Version: stateVersion, SavedAt: now, Routes: make(map[string]models.RateLimitState, len(limiters))}
+	for routeID, limiter := range limiters {
+		state.Routes[routeID] = limiter.Snapshot()
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadState reads the limiter state saved at path, keyed by route ID. A missing file
+// has no state; a file saved more than maxAge before now returns ErrStaleState.
+func LoadState(path string, maxAge time.Duration, now time.Time) (map[string]models.RateLimitState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("corrupt rate limit state: %w", err)
+	}
+	if state.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported rate limit state version: %d", state.Version)
+	}
+	if age := now.Sub(state.SavedAt); age > maxAge {
+		return nil, fmt.Errorf("%w: saved %s ago", ErrStaleState, age.Round(time.Second))
+	}
+	return state.Routes, nil
+}
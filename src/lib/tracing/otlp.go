@@ -0,0 +1,252 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP exporter batching
+const (
+	otlpExportInterval = 5 * time.Second
+	otlpBatchSize      = 512  // spans that trigger an export before the interval
+	otlpMaxQueued      = 4096 // spans held while the collector is slow; more are dropped
+	otlpTimeout        = 10 * time.Second
+)
+
+// OTLP span status codes
+const (
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+// OTLPExporter sends spans in batches to an OpenTelemetry collector over OTLP/HTTP with JSON encoding
+type OTLPExporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+	logger      *slog.Logger
+
+	pending []SpanData
+	dropped int
+	mutex   sync.Mutex
+
+	flush    chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewOTLPExporter creates an exporter posting to the collector's /v1/traces at endpoint,
+// e.g. http://localhost:4318, and starts its export loop
+func NewOTLPExporter(endpoint, serviceName string, logger *slog.Logger) *OTLPExporter {
+	e := &OTLPExporter{
+		url:         strings.TrimRight(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: otlpTimeout},
+		logger:      logger,
+		flush:       make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ExportSpans implements Exporter. Spans are queued and sent by the export loop.
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, span := range spans {
+		if len(e.pending) >= otlpMaxQueued {
+			e.dropped++
+			continue
+		}
+		e.pending = append(e.pending, span)
+	}
+	if len(e.pending) >= otlpBatchSize {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Shutdown implements Exporter, sending the queued spans before it returns
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.done) })
+	select {
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run sends the queued spans every interval, when a batch fills, and on shutdown
+func (e *OTLPExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(otlpExportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flush:
+		case <-e.done:
+			e.send()
+			return
+		}
+		e.send()
+	}
+}
+
+// send posts the queued spans to the collector
+func (e *OTLPExporter) send() {
+	e.mutex.Lock()
+	spans, dropped := e.pending, e.dropped
+	e.pending, e.dropped = nil, 0
+	e.mutex.Unlock()
+
+	if dropped > 0 {
+		e.logger.Warn("Trace spans dropped, collector too slow", "dropped", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	if err := e.post(spans); err != nil {
+		e.logger.Warn("Failed to export trace spans", "spans", len(spans), "error", err)
+	}
+}
+
+// post sends one export request
+func (e *OTLPExporter) post(spans []SpanData) error {
+	body, err := json.Marshal(encodeOTLP(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON encoding of an export request; IDs are hex and 64-bit integers strings
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// encodeOTLP builds the export request for the spans of one service
+func encodeOTLP(serviceName string, spans []SpanData) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.Context.TraceID.String(),
+			SpanID:            span.Context.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.Attributes),
+			Status:            otlpStatus{Code: otlpStatusUnset},
+		}
+		if span.Parent != (SpanID{}) {
+			s.ParentSpanID = span.Parent.String()
+		}
+		if span.Error != "" {
+			s.Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+		}
+		encoded = append(encoded, s)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes(map[string]any{"service.name": serviceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "ryohi-router"}, Spans: encoded}},
+	}}}
+}
+
+// encodeAttributes encodes attributes sorted by key; other types are sent as their string form
+func encodeAttributes(attributes map[string]any) []otlpKeyValue {
+	encoded := make([]otlpKeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		var v otlpAnyValue
+		switch value := value.(type) {
+		case string:
+			v.StringValue = &value
+		case bool:
+			v.BoolValue = &value
+		case int:
+			s := strconv.Itoa(value)
+			v.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(value, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &value
+		default:
+			s := fmt.Sprint(value)
+			v.StringValue = &s
+		}
+		encoded = append(encoded, otlpKeyValue{Key: key, Value: v})
+	}
+	sort.Slice(encoded, func(i, j int) bool { return encoded[i].Key < encoded[j].Key })
+	return encoded
+}
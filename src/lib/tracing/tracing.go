@@ -0,0 +1,239 @@
+// Package tracing records request spans in the OpenTelemetry data model and
+// propagates their context to backends with the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
+)
+
+// TraceparentHeader is the W3C trace context header
+const TraceparentHeader = "traceparent"
+
+// SpanKind says which side of a call a span describes, numbered as in OTLP
+type SpanKind int
+
+// Span kinds the router records
+const (
+	SpanKindServer SpanKind = 2 // a request the router serves
+	SpanKindClient SpanKind = 3 // a call the router makes to a backend
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// String returns the ID as lowercase hex
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// String returns the ID as lowercase hex
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is what is propagated to identify a span across processes
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats the span context as a traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a traceparent header value, reporting false when it isn't valid
+func ParseTraceparent(value string) (SpanContext, bool) {
+	// traceparent: version-traceid-parentid-flags, e.g. 00-<32 hex>-<16 hex>-01
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// SpanData is a finished span as handed to an exporter
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID // zero for a root span
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+	Error      string // empty unless the span failed
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Provider starts spans and hands them to its exporter when they end
+type Provider struct {
+	exporter Exporter
+	clock    clock.Clock
+}
+
+// NewProvider creates a provider exporting sampled spans to exporter
+func NewProvider(exporter Exporter) *Provider {
+	return &Provider{exporter: exporter, clock: clock.Real}
+}
+
+// SetClock sets the clock span start and end times are read from
+func (p *Provider) SetClock(c clock.Clock) {
+	p.clock = c
+}
+
+// Shutdown exports any spans the exporter still holds
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.exporter.Shutdown(ctx)
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// remoteParentKey is the context key of a span context received from a client
+type remoteParentKey struct{}
+
+// SpanFromContext returns the span started into ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns ctx with the span context a client sent,
+// which the next span started from ctx continues
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteParentKey{}, sc)
+}
+
+// Extract returns ctx with the span context of a traceparent header, if it has a valid one
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if sc, ok := ParseTraceparent(header.Get(TraceparentHeader)); ok {
+		return ContextWithRemoteParent(ctx, sc)
+	}
+	return ctx
+}
+
+// Inject sets the traceparent header to the span started into ctx; without one the header is left alone
+func Inject(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceparentHeader, span.data.Context.Traceparent())
+	}
+}
+
+// Start starts a span as a child of the span in ctx or the remote parent, or as a new
+// sampled trace, and returns ctx with the span. The span must be ended.
+func (p *Provider) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	data := SpanData{Name: name, Kind: kind, Start: p.clock.Now(), Attributes: make(map[string]any)}
+
+	var parent SpanContext
+	if span := SpanFromContext(ctx); span != nil {
+		parent = span.data.Context
+	} else if remote, ok := ctx.Value(remoteParentKey{}).(SpanContext); ok {
+		parent = remote
+	}
+	if parent.IsValid() {
+		data.Context = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+		data.Parent = parent.SpanID
+	} else {
+		rand.Read(data.Context.TraceID[:])
+		data.Context.Sampled = true
+	}
+	rand.Read(data.Context.SpanID[:])
+
+	span := &Span{provider: p, data: data}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Span is a span being recorded. Its methods are safe for concurrent use.
+type Span struct {
+	provider *Provider
+	data     SpanData
+	ended    bool
+	mutex    sync.Mutex
+}
+
+// SpanContext returns the span's propagated identity
+func (s *Span) SpanContext() SpanContext {
+	return s.data.Context
+}
+
+// SetAttribute records a string, bool, integer or float attribute until the span ends
+func (s *Span) SetAttribute(key string, value any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+// RecordError marks the span as failed with err
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetError(err.Error())
+}
+
+// SetError marks the span as failed with the description
+func (s *Span) SetError(description string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.ended {
+		s.data.Error = description
+	}
+}
+
+// End finishes the span and exports it if sampled; later calls do nothing
+func (s *Span) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.provider.clock.Now()
+	data := s.data
+	s.mutex.Unlock()
+
+	if data.Context.Sampled {
+		s.provider.exporter.ExportSpans(context.Background(), []SpanData{data})
+	}
+}
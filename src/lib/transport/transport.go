@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// ForBackend creates the transport used to reach a backend, so the proxy and
+// health checks connect the same way. It returns nil when the default
+// transport is sufficient.
+func ForBackend(backend models.BackendService) (http.RoundTripper, error) {
+	if backend.IsH2C() {
+		h2c := NewH2C()
+		if backend.Transport != nil {
+			applyH2C(h2c, backend.Transport)
+		}
+		return h2c, nil
+	}
+
+	if backend.TLS == nil && backend.Transport == nil {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if backend.TLS != nil {
+		tlsConfig, err := NewTLSConfig(backend.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if backend.Transport != nil {
+		apply(transport, backend.Transport)
+	}
+	return transport, nil
+}
+
+// apply sets the configured connection limits and timeouts on the transport
+func apply(transport *http.Transport, cfg *models.TransportConfig) {
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+	transport.DisableKeepAlives = cfg.DisableKeepAlives
+}
+
+// applyH2C sets the timeouts that apply to an h2c transport
+func applyH2C(transport *http2.Transport, cfg *models.TransportConfig) {
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: cfg.DialTimeout}
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+}
+
+// NewH2C creates an HTTP/2 transport that speaks cleartext HTTP/2 (h2c) with prior knowledge.
+// It is used for backends such as gRPC services that require HTTP/2 without TLS.
+func NewH2C() *http2.Transport {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// NewTLSConfig builds a client TLS configuration from backend TLS settings
+func NewTLSConfig(cfg *models.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"net/url"
+	"time"
 )
 
 // AuthConfig represents authentication configuration
@@ -200,4 +202,60 @@ func (j *JWTConfig) Validate() error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// AuthPolicy is a JWT policy shared by a group of routes, typically one per tenant.
+// Tokens are verified against the issuer's JWKS; routes opt in with auth_policy.
+type AuthPolicy struct {
+	ID       string        `json:"id" yaml:"id"`
+	Issuer   string        `json:"issuer" yaml:"issuer"`
+	JWKSURL  string        `json:"jwks_url" yaml:"jwks_url" mapstructure:"jwks_url"`
+	Audience string        `json:"audience,omitempty" yaml:"audience,omitempty"`
+	CacheTTL time.Duration `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty" mapstructure:"cache_ttl"`
+	// RolesClaim names the token claim holding the caller's roles
+	RolesClaim string `json:"roles_claim,omitempty" yaml:"roles_claim,omitempty" mapstructure:"roles_claim"`
+	// RoleMappings maps issuer roles to router roles; unmapped roles are kept as they are
+	RoleMappings map[string]string `json:"role_mappings,omitempty" yaml:"role_mappings,omitempty" mapstructure:"role_mappings"`
+	// Roles, when set, requires the caller to have at least one of them after mapping
+	Roles []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+}
+
+// Validate validates the auth policy
+func (p *AuthPolicy) Validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("auth policy ID is required")
+	}
+	
+	if p.Issuer == "" {
+		return fmt.Errorf("issuer is required")
+	}
+	
+	jwksURL, err := url.Parse(p.JWKSURL)
+	if err != nil || (jwksURL.Scheme != "http" && jwksURL.Scheme != "https") || jwksURL.Host == "" {
+		return fmt.Errorf("invalid JWKS URL: %q", p.JWKSURL)
+	}
+	
+	if p.CacheTTL == 0 {
+		p.CacheTTL = 5 * time.Minute // Default key cache lifetime
+	} else if p.CacheTTL < 0 {
+		return fmt.Errorf("cache TTL cannot be negative")
+	}
+	
+	if p.RolesClaim == "" {
+		p.RolesClaim = "roles" // Default roles claim
+	}
+	
+	return nil
+}
+
+// MapRoles translates issuer roles to router roles
+func (p *AuthPolicy) MapRoles(roles []string) []string {
+	mapped := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if to, ok := p.RoleMappings[role]; ok {
+			role = to
+		}
+		mapped = append(mapped, role)
+	}
+	return mapped
+}
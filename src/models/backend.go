@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -13,8 +14,23 @@ type BackendService struct {
 	Endpoints      []EndpointConfig      `json:"endpoints" yaml:"endpoints"`
 	LoadBalancer   LoadBalancerConfig    `json:"load_balancer" yaml:"load_balancer"`
 	HealthCheck    HealthCheckConfig     `json:"health_check" yaml:"health_check"`
+	// PassiveHealthCheck ejects endpoints that keep failing proxied requests, nil to disable
+	PassiveHealthCheck *PassiveHealthCheckConfig `json:"passive_health_check,omitempty" yaml:"passive_health_check,omitempty" mapstructure:"passive_health_check"`
 	CircuitBreaker CircuitBreakerConfig  `json:"circuit_breaker" yaml:"circuit_breaker"`
 	RetryPolicy    RetryPolicyConfig     `json:"retry_policy" yaml:"retry_policy"`
+	Protocol       string                `json:"protocol,omitempty" yaml:"protocol,omitempty"` // http (default) or h2c
+	TLS            *TLSConfig            `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Transport      *TransportConfig      `json:"transport,omitempty" yaml:"transport,omitempty"`
+	// MaxConcurrentRequests caps the requests in flight to the backend, 0 for no cap.
+	// Requests over the cap wait up to QueueTimeout for a slot before being answered with 503.
+	MaxConcurrentRequests int           `json:"max_concurrent_requests,omitempty" yaml:"max_concurrent_requests,omitempty" mapstructure:"max_concurrent_requests"`
+	QueueTimeout          time.Duration `json:"queue_timeout,omitempty" yaml:"queue_timeout,omitempty" mapstructure:"queue_timeout"`
+	// HeaderLimits rejects requests whose headers the backend would refuse, with 431
+	HeaderLimits *HeaderLimitsConfig `json:"header_limits,omitempty" yaml:"header_limits,omitempty" mapstructure:"header_limits"`
+	Logging      *BackendLoggingConfig `json:"logging,omitempty" yaml:"logging,omitempty"` // verbose logging for this backend only
+	// Overridable lets internal users send any route's requests here with an X-Backend-Override
+	// header and the admin API key, e.g. to try a staging backend with production traffic
+	Overridable bool `json:"overridable,omitempty" yaml:"overridable,omitempty"`
 	Enabled        bool                  `json:"enabled" yaml:"enabled"`
 	CreatedAt      time.Time             `json:"created_at" yaml:"created_at"`
 	UpdatedAt      time.Time             `json:"updated_at" yaml:"updated_at"`
@@ -25,13 +41,95 @@ type EndpointConfig struct {
 	URL      string            `json:"url" yaml:"url"`
 	Weight   int               `json:"weight" yaml:"weight"`
 	Healthy  bool              `json:"healthy" yaml:"healthy"`
+	Draining bool              `json:"draining,omitempty" yaml:"draining,omitempty"` // takes no new requests; health checks continue
 	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 }
 
+// Available reports whether the endpoint may be selected for a new request
+func (e *EndpointConfig) Available() bool {
+	return e.Healthy && !e.Draining
+}
+
+// ZoneMetadataKey is the endpoint metadata key holding the endpoint's zone, e.g. tokyo-a
+const ZoneMetadataKey = "zone"
+
+// Zone returns the endpoint's zone from its metadata, or "" if it has none
+func (e *EndpointConfig) Zone() string {
+	return e.Metadata[ZoneMetadataKey]
+}
+
+// TLSConfig represents TLS settings for connecting to https endpoints
+type TLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty" mapstructure:"ca_file"`
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty" mapstructure:"cert_file"`
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty" mapstructure:"key_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty" mapstructure:"insecure_skip_verify"`
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty" mapstructure:"server_name"`
+}
+
+// TransportConfig tunes the connections to a backend; zero values keep Go's defaults.
+// h2c backends use only the dial and idle timeouts.
+type TransportConfig struct {
+	MaxIdleConns          int           `json:"max_idle_conns,omitempty" yaml:"max_idle_conns,omitempty" mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost   int           `json:"max_idle_conns_per_host,omitempty" yaml:"max_idle_conns_per_host,omitempty" mapstructure:"max_idle_conns_per_host"`
+	IdleConnTimeout       time.Duration `json:"idle_conn_timeout,omitempty" yaml:"idle_conn_timeout,omitempty" mapstructure:"idle_conn_timeout"`
+	DialTimeout           time.Duration `json:"dial_timeout,omitempty" yaml:"dial_timeout,omitempty" mapstructure:"dial_timeout"`
+	TLSHandshakeTimeout   time.Duration `json:"tls_handshake_timeout,omitempty" yaml:"tls_handshake_timeout,omitempty" mapstructure:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `json:"response_header_timeout,omitempty" yaml:"response_header_timeout,omitempty" mapstructure:"response_header_timeout"`
+	DisableKeepAlives     bool          `json:"disable_keep_alives,omitempty" yaml:"disable_keep_alives,omitempty" mapstructure:"disable_keep_alives"`
+}
+
+// BackendLoggingConfig turns on detailed logging of one backend's traffic, e.g. while debugging it.
+// Credentials in Authorization, Cookie and similar headers are redacted.
+type BackendLoggingConfig struct {
+	Debug        bool `json:"debug" yaml:"debug"`                                                                        // log each request and response with its headers
+	LogBody      bool `json:"log_body,omitempty" yaml:"log_body,omitempty" mapstructure:"log_body"`                      // also log bodies, up to MaxBodyBytes
+	MaxBodyBytes int  `json:"max_body_bytes,omitempty" yaml:"max_body_bytes,omitempty" mapstructure:"max_body_bytes"` // 0 for 4KB
+}
+
+// Validate validates the backend logging configuration
+func (l *BackendLoggingConfig) Validate() error {
+	if l.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes cannot be negative")
+	}
+	return nil
+}
+
 // LoadBalancerConfig represents load balancer configuration
 type LoadBalancerConfig struct {
 	Algorithm     string `json:"algorithm" yaml:"algorithm"`
 	StickySession bool   `json:"sticky_session" yaml:"sticky_session"`
+	// HashKey is the request attribute consistent-hash balancing is keyed on:
+	// path (default), header:<name> or query:<name>
+	HashKey string `json:"hash_key,omitempty" yaml:"hash_key,omitempty" mapstructure:"hash_key"`
+	// VirtualNodes is the number of points each endpoint gets on the hash ring, 0 for the default
+	VirtualNodes int `json:"virtual_nodes,omitempty" yaml:"virtual_nodes,omitempty" mapstructure:"virtual_nodes"`
+	// LatencyDecay is the half-life of a least-latency score while the endpoint isn't used, 0 for the default
+	LatencyDecay time.Duration `json:"latency_decay,omitempty" yaml:"latency_decay,omitempty" mapstructure:"latency_decay"`
+}
+
+// Hash key sources for consistent-hash balancing
+const (
+	HashKeyPath   = "path"
+	HashKeyHeader = "header"
+	HashKeyQuery  = "query"
+)
+
+// ParseHashKey splits a hash key into its source and the header or query parameter name
+func ParseHashKey(key string) (source, name string, err error) {
+	if key == "" || key == HashKeyPath {
+		return HashKeyPath, "", nil
+	}
+
+	source, name, _ = strings.Cut(key, ":")
+	switch source {
+	case HashKeyHeader, HashKeyQuery:
+		if name == "" {
+			return "", "", fmt.Errorf("hash key %q needs a name after %q", key, source+":")
+		}
+		return source, name, nil
+	}
+	return "", "", fmt.Errorf("invalid hash key %q: must be path, header:<name> or query:<name>", key)
 }
 
 // RetryPolicyConfig represents retry policy configuration
@@ -67,6 +165,51 @@ func (b *BackendService) Validate() error {
 		}
 	}
 	
+	if b.Protocol == "" {
+		b.Protocol = "http"
+	}
+	validProtocols := []string{"http", "h2c"}
+	valid := false
+	for _, protocol := range validProtocols {
+		if b.Protocol == protocol {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid backend protocol: %s", b.Protocol)
+	}
+	
+	if b.TLS != nil {
+		if b.IsH2C() {
+			return fmt.Errorf("tls settings cannot be used with the h2c protocol")
+		}
+		if err := b.TLS.Validate(); err != nil {
+			return fmt.Errorf("invalid tls config: %w", err)
+		}
+	}
+	
+	if b.Transport != nil {
+		if err := b.Transport.Validate(); err != nil {
+			return fmt.Errorf("invalid transport config: %w", err)
+		}
+	}
+	
+	if b.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("max concurrent requests cannot be negative")
+	}
+	if b.QueueTimeout < 0 {
+		return fmt.Errorf("queue timeout cannot be negative")
+	}
+	
+	if b.IsH2C() {
+		for i, endpoint := range b.Endpoints {
+			if u, _ := url.Parse(endpoint.URL); u.Scheme != "http" {
+				return fmt.Errorf("endpoint %d must use the http scheme for h2c", i)
+			}
+		}
+	}
+	
 	if err := b.LoadBalancer.Validate(); err != nil {
 		return fmt.Errorf("invalid load balancer config: %w", err)
 	}
@@ -75,6 +218,12 @@ func (b *BackendService) Validate() error {
 		return fmt.Errorf("invalid health check config: %w", err)
 	}
 	
+	if b.PassiveHealthCheck != nil {
+		if err := b.PassiveHealthCheck.Validate(); err != nil {
+			return fmt.Errorf("invalid passive health check config: %w", err)
+		}
+	}
+	
 	if err := b.CircuitBreaker.Validate(); err != nil {
 		return fmt.Errorf("invalid circuit breaker config: %w", err)
 	}
@@ -83,9 +232,26 @@ func (b *BackendService) Validate() error {
 		return fmt.Errorf("invalid retry policy config: %w", err)
 	}
 	
+	if b.HeaderLimits != nil {
+		if err := b.HeaderLimits.Validate(); err != nil {
+			return fmt.Errorf("invalid header limits: %w", err)
+		}
+	}
+	
+	if b.Logging != nil {
+		if err := b.Logging.Validate(); err != nil {
+			return fmt.Errorf("invalid logging config: %w", err)
+		}
+	}
+	
 	return nil
 }
 
+// IsH2C reports whether the backend is reached over cleartext HTTP/2
+func (b *BackendService) IsH2C() bool {
+	return b.Protocol == "h2c"
+}
+
 // GetHealthyEndpoints returns only healthy endpoints
 func (b *BackendService) GetHealthyEndpoints() []EndpointConfig {
 	var healthy []EndpointConfig
@@ -119,9 +285,30 @@ func (e *EndpointConfig) Validate() error {
 	return nil
 }
 
+// Validate validates the TLS configuration
+func (t *TLSConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// Validate validates the transport configuration
+func (t *TransportConfig) Validate() error {
+	if t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("idle connection limits cannot be negative")
+	}
+	
+	if t.IdleConnTimeout < 0 || t.DialTimeout < 0 || t.TLSHandshakeTimeout < 0 || t.ResponseHeaderTimeout < 0 {
+		return fmt.Errorf("transport timeouts cannot be negative")
+	}
+	
+	return nil
+}
+
 // Validate validates the load balancer configuration
 func (l *LoadBalancerConfig) Validate() error {
-	validAlgorithms := []string{"round-robin", "weighted", "least-conn", "ip-hash", "random"}
+	validAlgorithms := []string{"round-robin", "weighted", "least-conn", "least-response-time", "least-latency", "ip-hash", "random", "consistent-hash"}
 	valid := false
 	for _, algo := range validAlgorithms {
 		if l.Algorithm == algo {
@@ -138,6 +325,20 @@ func (l *LoadBalancerConfig) Validate() error {
 		}
 	}
 	
+	if l.Algorithm == "consistent-hash" {
+		if _, _, err := ParseHashKey(l.HashKey); err != nil {
+			return err
+		}
+	}
+	
+	if l.VirtualNodes < 0 {
+		return fmt.Errorf("virtual_nodes cannot be negative")
+	}
+	
+	if l.LatencyDecay < 0 {
+		return fmt.Errorf("latency_decay cannot be negative")
+	}
+	
 	return nil
 }
 
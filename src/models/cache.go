@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// CacheConfig represents response caching configuration for a route
+type CacheConfig struct {
+	Enabled     bool          `json:"enabled" yaml:"enabled"`
+	TTL         time.Duration `json:"ttl" yaml:"ttl"`
+	MaxEntries  int           `json:"max_entries" yaml:"max_entries"`
+	VaryHeaders []string      `json:"vary_headers,omitempty" yaml:"vary_headers,omitempty"`
+
+	// StaleWhileRevalidate serves an expired entry for this long after its TTL while it is refreshed in the background
+	StaleWhileRevalidate time.Duration `json:"stale_while_revalidate,omitempty" yaml:"stale_while_revalidate,omitempty" mapstructure:"stale_while_revalidate"`
+	// StaleIfError serves an expired entry for this long after its TTL when the backend fails
+	StaleIfError time.Duration `json:"stale_if_error,omitempty" yaml:"stale_if_error,omitempty" mapstructure:"stale_if_error"`
+	// Authenticated caches requests with credentials and on routes with auth, sharing one
+	// response between callers; only for responses that are the same for everyone
+	Authenticated bool `json:"authenticated,omitempty" yaml:"authenticated,omitempty"`
+}
+
+// StaleFor returns how long an entry is kept after its TTL for stale serving
+func (c *CacheConfig) StaleFor() time.Duration {
+	return max(c.StaleWhileRevalidate, c.StaleIfError)
+}
+
+// Validate validates the cache configuration
+func (c *CacheConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.TTL == 0 {
+		c.TTL = 60 * time.Second // Default TTL
+	} else if c.TTL < 0 {
+		return fmt.Errorf("cache TTL must be positive")
+	}
+
+	if c.MaxEntries == 0 {
+		c.MaxEntries = 1000 // Default max entries
+	} else if c.MaxEntries < 0 {
+		return fmt.Errorf("cache max entries must be positive")
+	}
+
+	if c.StaleWhileRevalidate < 0 {
+		return fmt.Errorf("cache stale_while_revalidate must not be negative")
+	}
+	if c.StaleIfError < 0 {
+		return fmt.Errorf("cache stale_if_error must not be negative")
+	}
+
+	return nil
+}
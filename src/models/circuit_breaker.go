@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
 )
 
 // CircuitBreakerConfig represents circuit breaker configuration
@@ -76,6 +78,11 @@ type CircuitBreaker struct {
 	lastFailureTime time.Time
 	nextAttemptTime time.Time
 	intervalStart   time.Time
+	
+	// Called on every state transition
+	onStateChange func(from, to CircuitBreakerState)
+	
+	clock clock.Clock
 }
 
 // NewCircuitBreaker creates a new circuit breaker
@@ -84,9 +91,26 @@ func NewCircuitBreaker(config *CircuitBreakerConfig) *CircuitBreaker {
 		config:        config,
 		state:         StateClosed,
 		intervalStart: time.Now(),
+		clock:         clock.Real,
 	}
 }
 
+// SetClock sets the clock that timeouts and intervals are measured on
+func (cb *CircuitBreaker) SetClock(c clock.Clock) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.clock = c
+	cb.intervalStart = c.Now()
+}
+
+// SetStateChangeHandler registers a function called on every state transition.
+// It is called with the breaker's lock held and must not call back into the breaker.
+func (cb *CircuitBreaker) SetStateChangeHandler(fn func(from, to CircuitBreakerState)) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.onStateChange = fn
+}
+
 // Call executes the given function if the circuit breaker allows it
 func (cb *CircuitBreaker) Call(fn func() error) error {
 	if !cb.config.Enabled {
@@ -107,7 +131,7 @@ func (cb *CircuitBreaker) CanExecute() bool {
 	cb.mutex.RLock()
 	defer cb.mutex.RUnlock()
 	
-	now := time.Now()
+	now := cb.clock.Now()
 	
 	switch cb.state {
 	case StateClosed:
@@ -119,7 +143,7 @@ func (cb *CircuitBreaker) CanExecute() bool {
 			// Transition to half-open
 			cb.mutex.RUnlock()
 			cb.mutex.Lock()
-			cb.state = StateHalfOpen
+			cb.setState(StateHalfOpen)
 			cb.consecutiveSuccesses = 0
 			cb.mutex.Unlock()
 			cb.mutex.RLock()
@@ -141,7 +165,7 @@ func (cb *CircuitBreaker) RecordResult(success bool) {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
 	
-	now := time.Now()
+	now := cb.clock.Now()
 	
 	// Reset counters if interval has passed
 	if now.Sub(cb.intervalStart) > cb.config.Interval {
@@ -193,18 +217,37 @@ func (cb *CircuitBreaker) RecordResult(success bool) {
 
 // openCircuit transitions the circuit to open state
 func (cb *CircuitBreaker) openCircuit() {
-	cb.state = StateOpen
-	cb.nextAttemptTime = time.Now().Add(cb.config.Timeout)
+	cb.setState(StateOpen)
+	cb.nextAttemptTime = cb.clock.Now().Add(cb.config.Timeout)
 	cb.consecutiveSuccesses = 0
 }
 
 // closeCircuit transitions the circuit to closed state
 func (cb *CircuitBreaker) closeCircuit() {
-	cb.state = StateClosed
+	cb.setState(StateClosed)
 	cb.consecutiveFailures = 0
 	cb.requests = 0
 	cb.failures = 0
-	cb.intervalStart = time.Now()
+	cb.intervalStart = cb.clock.Now()
+}
+
+// setState changes the state and notifies the state change handler
+func (cb *CircuitBreaker) setState(state CircuitBreakerState) {
+	from := cb.state
+	cb.state = state
+	if from != state && cb.onStateChange != nil {
+		cb.onStateChange(from, state)
+	}
+}
+
+// Reset force-closes the circuit and clears its counters
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	
+	cb.closeCircuit()
+	cb.consecutiveSuccesses = 0
+	cb.nextAttemptTime = time.Time{}
 }
 
 // GetState returns the current state of the circuit breaker
@@ -214,6 +257,18 @@ func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	return cb.state
 }
 
+// Rejecting reports whether the circuit is open and still rejecting requests. Unlike
+// CanExecute it never moves the circuit to half-open, so it can be polled freely.
+func (cb *CircuitBreaker) Rejecting() bool {
+	if !cb.config.Enabled {
+		return false
+	}
+	
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.state == StateOpen && !cb.clock.Now().After(cb.nextAttemptTime)
+}
+
 // GetStats returns statistics about the circuit breaker
 func (cb *CircuitBreaker) GetStats() CircuitBreakerStats {
 	cb.mutex.RLock()
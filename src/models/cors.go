@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CORSConfig represents CORS configuration.
+// "*" in AllowedOrigins or AllowedHeaders allows any origin or header.
+type CORSConfig struct {
+	Enabled          bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	AllowedOrigins   []string `json:"allowed_origins,omitempty" yaml:"allowed_origins" mapstructure:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods,omitempty" yaml:"allowed_methods" mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty" yaml:"allowed_headers" mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers,omitempty" yaml:"exposed_headers" mapstructure:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty" yaml:"allow_credentials" mapstructure:"allow_credentials"`
+	MaxAge           int      `json:"max_age,omitempty" yaml:"max_age" mapstructure:"max_age"` // seconds browsers may cache a preflight
+}
+
+// Validate validates the CORS configuration
+func (c *CORSConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.AllowedOrigins) == 0 {
+		return fmt.Errorf("at least one allowed origin is required")
+	}
+
+	for _, method := range c.AllowedMethods {
+		if !isValidHTTPMethod(method) || method == "*" {
+			return fmt.Errorf("invalid allowed method: %s", method)
+		}
+	}
+
+	if c.MaxAge < 0 {
+		return fmt.Errorf("max age cannot be negative")
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether requests from the origin are allowed
+func (c *CORSConfig) AllowsOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
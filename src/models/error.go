@@ -0,0 +1,9 @@
+package models
+
+// ErrorResponse is the JSON body of an error answered by the router itself
+type ErrorResponse struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
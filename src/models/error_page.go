@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// ErrorPage is a custom response served in place of an error the router generates
+type ErrorPage struct {
+	ContentType string `json:"content_type,omitempty" yaml:"content_type,omitempty" mapstructure:"content_type"` // default text/html; charset=utf-8
+	Body        string `json:"body" yaml:"body"`
+}
+
+// errorPageStatuses are the gateway errors a route can replace with its own page
+var errorPageStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// ValidateErrorPages validates a route's error pages keyed by status code
+func ValidateErrorPages(pages map[int]ErrorPage) error {
+	for status, page := range pages {
+		valid := false
+		for _, allowed := range errorPageStatuses {
+			if status == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("error pages can only replace 502, 503 and 504, not %d", status)
+		}
+		if page.Body == "" {
+			return fmt.Errorf("error page for %d needs a body", status)
+		}
+	}
+	return nil
+}
+
+// Route content types
+const (
+	// ContentTypeHTML marks a route serving pages to browsers
+	ContentTypeHTML = "html"
+)
+
+// HTMLFallbackConfig is the page an html route shows browsers in place of the JSON
+// error for the 502, 503 and 504 the router generates
+type HTMLFallbackConfig struct {
+	// Template is an html/template rendered with .Status, .StatusText, .RequestID and
+	// .RetryURL; empty uses DefaultHTMLFallback
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+}
+
+// DefaultHTMLFallback is the fallback page used when a route doesn't provide its own
+const DefaultHTMLFallback = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.StatusText}}</title></head>
+<body>
+<h1>This page is temporarily unavailable</h1>
+<p>Please try again in a moment ({{.Status}} {{.StatusText}}).</p>
+<p><a href="{{.RetryURL}}">Try again</a></p>
+{{if .RequestID}}<p><small>Request ID: {{.RequestID}}</small></p>{{end}}
+</body>
+</html>
+`
+
+// ParseHTMLFallback parses the fallback page's template
+func ParseHTMLFallback(config *HTMLFallbackConfig) (*template.Template, error) {
+	text := config.Template
+	if text == "" {
+		text = DefaultHTMLFallback
+	}
+	return template.New("html_fallback").Parse(text)
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// FailureReason classifies why a health check or proxied request failed
+type FailureReason string
+
+// Failure reasons, reported on endpoint health, in recent errors and in backend metrics
+const (
+	FailureDNS               FailureReason = "dns"
+	FailureConnectionRefused FailureReason = "connection_refused"
+	FailureConnectionReset   FailureReason = "connection_reset"
+	FailureTLS               FailureReason = "tls"
+	FailureTimeout           FailureReason = "timeout"
+	FailureCanceled          FailureReason = "canceled"
+	FailureBadStatus         FailureReason = "bad_status"
+	FailureOther             FailureReason = "other"
+)
+
+// ErrUnexpectedStatus is wrapped by health check errors for responses with an unexpected status
+var ErrUnexpectedStatus = errors.New("unexpected status")
+
+// ClassifyFailure returns the reason for a failed check or request, or "" for a nil error.
+// DNS errors are reported as such even when the lookup timed out, since the fix is the same.
+func ClassifyFailure(err error) FailureReason {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureDNS
+	}
+	if errors.Is(err, ErrUnexpectedStatus) {
+		return FailureBadStatus
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return FailureTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return FailureCanceled
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE):
+		return FailureConnectionReset
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return FailureTLS
+	}
+	return FailureOther
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// FlagRollout limits a feature flag to part of the traffic.
+// Requests are bucketed by API key, or by client IP when they carry none, so a
+// client stays on the same side of the rollout from one request to the next.
+type FlagRollout struct {
+	// Percent is the share of clients the flag is on for, 0-100
+	Percent int `json:"percent" yaml:"percent"`
+	// Routes and Backends limit the flag to those route and backend IDs; empty for all
+	Routes   []string `json:"routes,omitempty" yaml:"routes,omitempty"`
+	Backends []string `json:"backends,omitempty" yaml:"backends,omitempty"`
+	// API keys and client addresses or CIDRs that are always in the rollout
+	AllowAPIKeys []string `json:"allow_api_keys,omitempty" yaml:"allow_api_keys,omitempty" mapstructure:"allow_api_keys"`
+	AllowIPs     []string `json:"allow_ips,omitempty" yaml:"allow_ips,omitempty" mapstructure:"allow_ips"`
+}
+
+// Validate validates the rollout configuration
+func (f *FlagRollout) Validate() error {
+	if f.Percent < 0 || f.Percent > 100 {
+		return fmt.Errorf("rollout percent must be between 0 and 100")
+	}
+
+	for _, ip := range f.AllowIPs {
+		if _, err := ParseIPPrefix(ip); err != nil {
+			return fmt.Errorf("invalid allowed IP %q: %w", ip, err)
+		}
+	}
+	return nil
+}
+
+// ParseIPPrefix parses an IP address or CIDR; an address is a prefix of its full length
+func ParseIPPrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// ForwardHeadersConfig controls which client request headers a route forwards to
+// its backend. Hop-by-hop headers (RFC 7230 section 6.1) are always removed.
+type ForwardHeadersConfig struct {
+	// Remove lists headers that are never forwarded, e.g. Authorization for a public backend
+	Remove []string `json:"remove,omitempty" yaml:"remove,omitempty"`
+	// Allow, when set, lists the only client headers that are forwarded.
+	// X-Request-ID and the correlation ID headers, which the router sets itself, are always forwarded.
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// StripOverLimit lists headers, such as Cookie, that are dropped rather than
+	// rejecting the request when they break the backend's header limits
+	StripOverLimit []string `json:"strip_over_limit,omitempty" yaml:"strip_over_limit,omitempty" mapstructure:"strip_over_limit"`
+}
+
+// Validate validates the header forwarding configuration
+func (f *ForwardHeadersConfig) Validate() error {
+	for _, name := range slices.Concat(f.Remove, f.Allow, f.StripOverLimit) {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+	}
+	return nil
+}
+
+// StripsOverLimit reports whether the header is dropped when it breaks a backend's header limits
+func (f *ForwardHeadersConfig) StripsOverLimit(name string) bool {
+	if f == nil {
+		return false
+	}
+	for _, strip := range f.StripOverLimit {
+		if strings.EqualFold(strip, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderLimitsConfig caps the request headers forwarded to a backend, 0 for no limit.
+// The limits apply to the headers as forwarded, after hop-by-hop and route filtering.
+type HeaderLimitsConfig struct {
+	MaxTotalBytes int `json:"max_total_bytes,omitempty" yaml:"max_total_bytes,omitempty" mapstructure:"max_total_bytes"` // names, values and line overhead
+	MaxValueBytes int `json:"max_value_bytes,omitempty" yaml:"max_value_bytes,omitempty" mapstructure:"max_value_bytes"` // any single value
+	MaxCount      int `json:"max_count,omitempty" yaml:"max_count,omitempty" mapstructure:"max_count"`                   // header lines
+}
+
+// Validate validates the header limits
+func (l *HeaderLimitsConfig) Validate() error {
+	if l.MaxTotalBytes < 0 || l.MaxValueBytes < 0 || l.MaxCount < 0 {
+		return fmt.Errorf("header limits cannot be negative")
+	}
+	return nil
+}
+
+// DefaultResponseHeaderDenylist lists backend response headers that reveal internal
+// details and are removed on every route unless DisableDefaults is set
+var DefaultResponseHeaderDenylist = []string{
+	"Server",
+	"X-Powered-By",
+	"X-AspNet-Version",
+	"X-AspNetMvc-Version",
+	"X-Runtime",
+	"X-Debug-*",
+}
+
+// ResponseHeadersConfig controls which backend response headers a route returns
+// to clients. Names ending in * match any header with that prefix, e.g. X-Debug-*.
+type ResponseHeadersConfig struct {
+	// Remove lists headers that are never returned, in addition to the default denylist
+	Remove []string `json:"remove,omitempty" yaml:"remove,omitempty"`
+	// Allow, when set, lists the only backend headers that are returned.
+	// Content-Type, Content-Length and Content-Encoding are always returned.
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// DisableDefaults returns the headers on DefaultResponseHeaderDenylist
+	DisableDefaults bool `json:"disable_defaults,omitempty" yaml:"disable_defaults,omitempty" mapstructure:"disable_defaults"`
+}
+
+// Validate validates the response header configuration
+func (f *ResponseHeadersConfig) Validate() error {
+	for _, name := range append(append([]string{}, f.Remove...), f.Allow...) {
+		if !httpguts.ValidHeaderFieldName(strings.TrimSuffix(name, "*")) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+	}
+	return nil
+}
+
+// SetHeader is a response header a route adds to every proxied response
+type SetHeader struct {
+	Value string `json:"value" yaml:"value"`
+	// Override replaces the header when the backend sent it too; otherwise the backend's value is kept
+	Override bool `json:"override" yaml:"override"`
+}
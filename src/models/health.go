@@ -8,12 +8,43 @@ import (
 // HealthCheckConfig represents health check configuration
 type HealthCheckConfig struct {
 	Enabled            bool          `json:"enabled" yaml:"enabled"`
+	Type               string        `json:"type" yaml:"type"` // http, tcp, grpc
 	Path               string        `json:"path" yaml:"path"`
 	Interval           time.Duration `json:"interval" yaml:"interval"`
 	Timeout            time.Duration `json:"timeout" yaml:"timeout"`
 	HealthyThreshold   int           `json:"healthy_threshold" yaml:"healthy_threshold"`
 	UnhealthyThreshold int           `json:"unhealthy_threshold" yaml:"unhealthy_threshold"`
 	ExpectedStatus     []int         `json:"expected_status" yaml:"expected_status"`
+	// Endpoints failing this many checks in a row are checked exponentially less often; 0 disables backoff
+	BackoffAfter       int           `json:"backoff_after,omitempty" yaml:"backoff_after,omitempty" mapstructure:"backoff_after"`
+	MaxInterval        time.Duration `json:"max_interval,omitempty" yaml:"max_interval,omitempty" mapstructure:"max_interval"`
+}
+
+// PassiveHealthCheckConfig ejects endpoints that fail real traffic: after ConsecutiveErrors
+// 5xx responses or connection errors in a row an endpoint takes no requests for EjectionTime,
+// then is admitted again on probation, where its first failure ejects it again
+type PassiveHealthCheckConfig struct {
+	Enabled           bool          `json:"enabled" yaml:"enabled"`
+	ConsecutiveErrors int           `json:"consecutive_errors,omitempty" yaml:"consecutive_errors,omitempty" mapstructure:"consecutive_errors"`
+	EjectionTime      time.Duration `json:"ejection_time,omitempty" yaml:"ejection_time,omitempty" mapstructure:"ejection_time"`
+}
+
+// Validate validates the passive health check configuration
+func (p *PassiveHealthCheckConfig) Validate() error {
+	if p.ConsecutiveErrors < 0 {
+		return fmt.Errorf("passive health check consecutive_errors must not be negative")
+	}
+	if p.EjectionTime < 0 {
+		return fmt.Errorf("passive health check ejection_time must not be negative")
+	}
+	
+	if p.ConsecutiveErrors == 0 {
+		p.ConsecutiveErrors = 5 // Default error threshold
+	}
+	if p.EjectionTime == 0 {
+		p.EjectionTime = 30 * time.Second // Default cool-down
+	}
+	return nil
 }
 
 // Validate validates the health check configuration
@@ -22,6 +53,22 @@ func (h *HealthCheckConfig) Validate() error {
 		return nil
 	}
 	
+	if h.Type == "" {
+		h.Type = "http" // Default type
+	}
+	
+	validTypes := []string{"http", "tcp", "grpc"}
+	isValidType := false
+	for _, t := range validTypes {
+		if h.Type == t {
+			isValidType = true
+			break
+		}
+	}
+	if !isValidType {
+		return fmt.Errorf("invalid health check type: %s", h.Type)
+	}
+	
 	if h.Path == "" {
 		h.Path = "/health" // Default path
 	}
@@ -56,9 +103,38 @@ func (h *HealthCheckConfig) Validate() error {
 		}
 	}
 	
+	if h.BackoffAfter < 0 {
+		return fmt.Errorf("health check backoff_after must not be negative")
+	}
+	if h.BackoffAfter > 0 {
+		if h.MaxInterval == 0 {
+			h.MaxInterval = 5 * time.Minute // Default backoff ceiling
+		} else if h.MaxInterval < h.Interval {
+			return fmt.Errorf("health check max_interval must not be less than interval")
+		}
+	}
+	
 	return nil
 }
 
+// EffectiveInterval returns the check interval for an endpoint after the given
+// number of consecutive failures. Once BackoffAfter failures are reached the
+// interval doubles with each further failure, up to MaxInterval.
+func (h *HealthCheckConfig) EffectiveInterval(consecutiveFail int) time.Duration {
+	if h.BackoffAfter <= 0 || consecutiveFail < h.BackoffAfter {
+		return h.Interval
+	}
+	
+	interval := h.Interval
+	for i := h.BackoffAfter; i <= consecutiveFail; i++ {
+		interval *= 2
+		if interval >= h.MaxInterval {
+			return h.MaxInterval
+		}
+	}
+	return interval
+}
+
 // IsExpectedStatus checks if the given status code is expected
 func (h *HealthCheckConfig) IsExpectedStatus(statusCode int) bool {
 	for _, expected := range h.ExpectedStatus {
@@ -79,6 +155,18 @@ type HealthStatus struct {
 	ResponseTime     time.Duration          `json:"response_time"`
 	Message          string                 `json:"message,omitempty"`
 	EndpointStatuses map[string]*EndpointHealth `json:"endpoint_statuses,omitempty"`
+	RecentErrors     []HealthError          `json:"recent_errors,omitempty"` // oldest first
+}
+
+// maxRecentErrors is how many failed checks a HealthStatus keeps
+const maxRecentErrors = 20
+
+// HealthError is a failed health check kept in a service's recent errors
+type HealthError struct {
+	Time     time.Time     `json:"time"`
+	Endpoint string        `json:"endpoint"`
+	Reason   FailureReason `json:"reason"`
+	Error    string        `json:"error"`
 }
 
 // EndpointHealth represents the health status of a single endpoint
@@ -91,6 +179,9 @@ type EndpointHealth struct {
 	ConsecutiveOK int           `json:"consecutive_ok"`
 	ConsecutiveFail int         `json:"consecutive_fail"`
 	Error         string        `json:"error,omitempty"`
+	Reason        FailureReason `json:"reason,omitempty"` // why the last check failed
+	EffectiveInterval time.Duration `json:"effective_interval"` // current check interval, stretched while failing
+	NextCheck     time.Time     `json:"next_check"`
 }
 
 // Update updates the health status based on a check result
@@ -122,6 +213,28 @@ func (h *HealthStatus) IsHealthy() bool {
 	return h.Status == "healthy"
 }
 
+// RecordError adds a failed check to the recent errors, dropping the oldest when full
+func (h *HealthStatus) RecordError(err HealthError) {
+	if len(h.RecentErrors) >= maxRecentErrors {
+		h.RecentErrors = append(h.RecentErrors[:0:0], h.RecentErrors[len(h.RecentErrors)-maxRecentErrors+1:]...)
+	}
+	h.RecentErrors = append(h.RecentErrors, err)
+}
+
+// DominantReason returns the most common reason among the recent errors,
+// preferring the latest on a tie, or "" when there are none
+func (h *HealthStatus) DominantReason() FailureReason {
+	counts := make(map[FailureReason]int)
+	var dominant FailureReason
+	for _, err := range h.RecentErrors {
+		counts[err.Reason]++
+		if counts[err.Reason] >= counts[dominant] {
+			dominant = err.Reason
+		}
+	}
+	return dominant
+}
+
 // UpdateEndpoint updates the health status of a specific endpoint
 func (h *HealthStatus) UpdateEndpoint(url string, health *EndpointHealth) {
 	if h.EndpointStatuses == nil {
@@ -1,11 +1,20 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 )
 
+// Rate limiting strategies
+const (
+	// StrategyTokenBucket refills tokens continuously and allows bursts up to BurstSize
+	StrategyTokenBucket = "token_bucket"
+	// StrategySlidingWindow allows at most Rate requests in any trailing Period
+	StrategySlidingWindow = "sliding_window"
+)
+
 // RateLimitConfig represents rate limiting configuration
 type RateLimitConfig struct {
 	Enabled   bool     `json:"enabled" yaml:"enabled"`
@@ -14,6 +23,8 @@ type RateLimitConfig struct {
 	BurstSize int      `json:"burst_size" yaml:"burst_size"`
 	KeyType   string   `json:"key_type" yaml:"key_type"`
 	WhiteList []string `json:"white_list" yaml:"white_list"`
+	// Strategy is token_bucket (default) or sliding_window; BurstSize only applies to token_bucket
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
 }
 
 // Validate validates the rate limit configuration
@@ -46,6 +57,14 @@ func (r *RateLimitConfig) Validate() error {
 		r.BurstSize = r.Rate // Default burst size equals rate
 	}
 	
+	switch r.Strategy {
+	case "":
+		r.Strategy = StrategyTokenBucket // Default strategy
+	case StrategyTokenBucket, StrategySlidingWindow:
+	default:
+		return fmt.Errorf("invalid strategy: %s (must be token_bucket or sliding_window)", r.Strategy)
+	}
+	
 	validKeyTypes := []string{"IP", "API_KEY", "USER_ID", "GLOBAL"}
 	valid = false
 	for _, kt := range validKeyTypes {
@@ -89,10 +108,18 @@ func (r *RateLimitConfig) IsWhitelisted(key string) bool {
 	return false
 }
 
-// RateLimiter implements token bucket algorithm for rate limiting
+// RateLimitStore keeps the token buckets of a rate limit.
+// RateLimiter keeps them in memory; shared stores enforce one limit across router instances.
+type RateLimitStore interface {
+	// Take takes a token from the key's bucket and reports whether one was available
+	Take(ctx context.Context, key string) (bool, error)
+}
+
+// RateLimiter implements rate limiting with a token bucket or sliding window per key
 type RateLimiter struct {
 	config    *RateLimitConfig
 	buckets   map[string]*TokenBucket
+	windows   map[string]*SlidingWindow
 	mutex     sync.RWMutex
 	cleanupAt time.Time
 }
@@ -111,6 +138,7 @@ func NewRateLimiter(config *RateLimitConfig) *RateLimiter {
 	return &RateLimiter{
 		config:    config,
 		buckets:   make(map[string]*TokenBucket),
+		windows:   make(map[string]*SlidingWindow),
 		cleanupAt: time.Now().Add(1 * time.Hour),
 	}
 }
@@ -127,10 +155,18 @@ func (rl *RateLimiter) Allow(key string) bool {
 	
 	rl.cleanup()
 	
+	if rl.config.Strategy == StrategySlidingWindow {
+		return rl.getWindow(key).Allow(1)
+	}
 	bucket := rl.getBucket(key)
 	return bucket.Allow(1)
 }
 
+// Take implements RateLimitStore; the in-memory store never fails
+func (rl *RateLimiter) Take(ctx context.Context, key string) (bool, error) {
+	return rl.Allow(key), nil
+}
+
 // AllowN checks if n requests are allowed for the given key
 func (rl *RateLimiter) AllowN(key string, n int) bool {
 	if !rl.config.Enabled {
@@ -143,6 +179,9 @@ func (rl *RateLimiter) AllowN(key string, n int) bool {
 	
 	rl.cleanup()
 	
+	if rl.config.Strategy == StrategySlidingWindow {
+		return rl.getWindow(key).Allow(n)
+	}
 	bucket := rl.getBucket(key)
 	return bucket.Allow(float64(n))
 }
@@ -181,6 +220,30 @@ func (rl *RateLimiter) getBucket(key string) *TokenBucket {
 	return bucket
 }
 
+// getWindow gets or creates a sliding window for the given key
+func (rl *RateLimiter) getWindow(key string) *SlidingWindow {
+	rl.mutex.RLock()
+	window, exists := rl.windows[key]
+	rl.mutex.RUnlock()
+	
+	if exists {
+		return window
+	}
+	
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	
+	// Double-check after acquiring write lock
+	window, exists = rl.windows[key]
+	if exists {
+		return window
+	}
+	
+	window = NewSlidingWindow(rl.config.Rate, rl.config.GetPeriodDuration())
+	rl.windows[key] = window
+	return window
+}
+
 // cleanup removes old buckets to prevent memory leak
 func (rl *RateLimiter) cleanup() {
 	now := time.Now()
@@ -200,6 +263,11 @@ func (rl *RateLimiter) cleanup() {
 		}
 		bucket.mutex.Unlock()
 	}
+	for key, window := range rl.windows {
+		if window.idleSince(cutoff) {
+			delete(rl.windows, key)
+		}
+	}
 	
 	rl.cleanupAt = now.Add(1 * time.Hour)
 }
@@ -229,6 +297,172 @@ func (tb *TokenBucket) fill(now time.Time) {
 	tb.lastFill = now
 }
 
+// SlidingWindow allows at most limit requests in any trailing period.
+// It keeps a log of the last limit request times, so unlike a token bucket it
+// never lets more than limit requests through in one period, even right after start.
+type SlidingWindow struct {
+	limit  int
+	period time.Duration
+	log    []time.Time // ring of accepted request times, oldest at next once full
+	next   int
+	mutex  sync.Mutex
+}
+
+// NewSlidingWindow creates a sliding window allowing limit requests per period
+func NewSlidingWindow(limit int, period time.Duration) *SlidingWindow {
+	return &SlidingWindow{limit: limit, period: period}
+}
+
+// Allow checks if n more requests fit in the trailing period and records them
+func (sw *SlidingWindow) Allow(n int) bool {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	
+	if n > sw.limit {
+		return false
+	}
+	
+	now := time.Now()
+	cutoff := now.Add(-sw.period)
+	
+	// Until the log is full there is room for limit-len(log) requests; after that
+	// the n oldest entries must have left the window
+	if free := sw.limit - len(sw.log); n > free {
+		oldest := sw.log[(sw.next+n-free-1)%sw.limit]
+		if oldest.After(cutoff) {
+			return false
+		}
+	}
+	
+	for i := 0; i < n; i++ {
+		if len(sw.log) < sw.limit {
+			sw.log = append(sw.log, now)
+			continue
+		}
+		sw.log[sw.next] = now
+		sw.next = (sw.next + 1) % sw.limit
+	}
+	return true
+}
+
+// requests returns the accepted request times in the window, oldest first
+func (sw *SlidingWindow) requests() []time.Time {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	
+	times := make([]time.Time, 0, len(sw.log))
+	for i := range sw.log {
+		times = append(times, sw.log[(sw.next+i)%len(sw.log)])
+	}
+	return times
+}
+
+// record adds an accepted request at t, which must not be older than those already recorded
+func (sw *SlidingWindow) record(t time.Time) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	
+	if len(sw.log) < sw.limit {
+		sw.log = append(sw.log, t)
+		return
+	}
+	sw.log[sw.next] = t
+	sw.next = (sw.next + 1) % sw.limit
+}
+
+// idleSince reports whether no request was accepted after t
+func (sw *SlidingWindow) idleSince(t time.Time) bool {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	
+	if len(sw.log) == 0 {
+		return true
+	}
+	newest := sw.log[(sw.next+len(sw.log)-1)%len(sw.log)]
+	return newest.Before(t)
+}
+
+// RateLimitState is a snapshot of a rate limiter's buckets and windows by key,
+// so they can be carried across a restart
+type RateLimitState struct {
+	Buckets map[string]TokenBucketState   `json:"buckets,omitempty"`
+	Windows map[string]SlidingWindowState `json:"windows,omitempty"`
+}
+
+// TokenBucketState is the saved state of a token bucket
+type TokenBucketState struct {
+	Tokens   float64   `json:"tokens"`
+	LastFill time.Time `json:"last_fill"`
+}
+
+// SlidingWindowState is the saved state of a sliding window
+type SlidingWindowState struct {
+	Requests []time.Time `json:"requests"` // accepted request times, oldest first
+}
+
+// Snapshot returns the state of the limiter's buckets and windows
+func (rl *RateLimiter) Snapshot() RateLimitState {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	
+	state := RateLimitState{
+		Buckets: make(map[string]TokenBucketState, len(rl.buckets)),
+		Windows: make(map[string]SlidingWindowState, len(rl.windows)),
+	}
+	for key, bucket := range rl.buckets {
+		bucket.mutex.Lock()
+		state.Buckets[key] = TokenBucketState{Tokens: bucket.tokens, LastFill: bucket.lastFill}
+		bucket.mutex.Unlock()
+	}
+	for key, window := range rl.windows {
+		state.Windows[key] = SlidingWindowState{Requests: window.requests()}
+	}
+	return state
+}
+
+// Restore loads state saved by Snapshot, before the limiter serves requests.
+// Entries last used before notBefore are dropped. Buckets go on refilling from
+// when they were saved, so tokens accrued while the router was down are counted,
+// and a changed rate limit config caps what is restored.
+func (rl *RateLimiter) Restore(state RateLimitState, notBefore time.Time) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	
+	now := time.Now()
+	period := rl.config.GetPeriodDuration()
+	for key, saved := range state.Buckets {
+		if rl.config.Strategy == StrategySlidingWindow || saved.LastFill.Before(notBefore) {
+			continue
+		}
+		
+		lastFill := saved.LastFill
+		if lastFill.After(now) {
+			lastFill = now
+		}
+		rl.buckets[key] = &TokenBucket{
+			tokens:   min(max(saved.Tokens, 0), float64(rl.config.BurstSize)),
+			capacity: float64(rl.config.BurstSize),
+			rate:     float64(rl.config.Rate) / period.Seconds(),
+			lastFill: lastFill,
+		}
+	}
+	for key, saved := range state.Windows {
+		if rl.config.Strategy != StrategySlidingWindow {
+			continue
+		}
+		
+		window := NewSlidingWindow(rl.config.Rate, period)
+		for _, t := range saved.Requests {
+			if !t.Before(notBefore) && t.After(now.Add(-period)) {
+				window.record(t)
+			}
+		}
+		if len(window.log) > 0 {
+			rl.windows[key] = window
+		}
+	}
+}
+
 // GetStats returns statistics about the rate limiter
 func (rl *RateLimiter) GetStats() map[string]interface{} {
 	rl.mutex.RLock()
@@ -240,7 +474,8 @@ func (rl *RateLimiter) GetStats() map[string]interface{} {
 		"period":       rl.config.Period,
 		"burst_size":   rl.config.BurstSize,
 		"key_type":     rl.config.KeyType,
-		"bucket_count": len(rl.buckets),
+		"strategy":     rl.config.Strategy,
+		"bucket_count": len(rl.buckets) + len(rl.windows),
 	}
 }
 
@@ -2,26 +2,88 @@ package models
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"time"
+	
+	"golang.org/x/net/http/httpguts"
 )
 
 // RouteConfig represents a routing configuration
 type RouteConfig struct {
 	ID         string           `json:"id" yaml:"id"`
 	Path       string           `json:"path" yaml:"path"`
+	Host       string           `json:"host,omitempty" yaml:"host,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"` // exact value, or ~regex
 	Method     []string         `json:"method" yaml:"method"`
+	Version    *VersionConfig   `json:"version,omitempty" yaml:"version,omitempty"`
 	Backend    string           `json:"backend" yaml:"backend"`
 	Timeout    time.Duration    `json:"timeout" yaml:"timeout"`
+	// MaxBodyBytes caps the request body forwarded to the backend, answering larger ones
+	// with 413; 0 uses the router's max_body_bytes
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty" yaml:"max_body_bytes,omitempty" mapstructure:"max_body_bytes"`
 	RateLimit  *RateLimitConfig `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
 	Auth       *AuthConfig      `json:"auth,omitempty" yaml:"auth,omitempty"`
+	AuthPolicy string           `json:"auth_policy,omitempty" yaml:"auth_policy,omitempty" mapstructure:"auth_policy"` // ID of a shared AuthPolicy
+	Cache      *CacheConfig     `json:"cache,omitempty" yaml:"cache,omitempty"`
+	Streaming  *StreamingConfig `json:"streaming,omitempty" yaml:"streaming,omitempty"`
+	ForwardHeaders *ForwardHeadersConfig `json:"forward_headers,omitempty" yaml:"forward_headers,omitempty" mapstructure:"forward_headers"`
+	ResponseHeaders *ResponseHeadersConfig `json:"response_headers,omitempty" yaml:"response_headers,omitempty" mapstructure:"response_headers"`
+	SetResponseHeaders map[string]SetHeader `json:"set_response_headers,omitempty" yaml:"set_response_headers,omitempty" mapstructure:"set_response_headers"`
+	CORS       *CORSConfig      `json:"cors,omitempty" yaml:"cors,omitempty"` // replaces the global CORS config for this route
+	ErrorPages map[int]ErrorPage `json:"error_pages,omitempty" yaml:"error_pages,omitempty" mapstructure:"error_pages"` // by status, for 502/503/504 the router generates
+	// ContentType is html for routes serving pages to browsers; they can answer gateway
+	// errors with HTMLFallback instead of JSON when the client accepts HTML
+	ContentType  string              `json:"content_type,omitempty" yaml:"content_type,omitempty" mapstructure:"content_type"`
+	HTMLFallback *HTMLFallbackConfig `json:"html_fallback,omitempty" yaml:"html_fallback,omitempty" mapstructure:"html_fallback"`
 	Middleware []string         `json:"middleware,omitempty" yaml:"middleware,omitempty"`
 	Priority   int              `json:"priority" yaml:"priority"`
 	Enabled    bool             `json:"enabled" yaml:"enabled"`
+	Critical   bool             `json:"critical,omitempty" yaml:"critical,omitempty"` // never shed under memory pressure
+	
+	// Canary routing: CanaryWeight percent of requests go to CanaryBackend
+	CanaryBackend string `json:"canary_backend,omitempty" yaml:"canary_backend,omitempty"`
+	CanaryWeight  int    `json:"canary_weight,omitempty" yaml:"canary_weight,omitempty"`
+	
+	// Fallback routing: retry against FallbackBackend when the primary is unavailable.
+	// Idempotent methods are retried by default; FallbackMethods opts others in.
+	FallbackBackend string   `json:"fallback_backend,omitempty" yaml:"fallback_backend,omitempty"`
+	FallbackMethods []string `json:"fallback_methods,omitempty" yaml:"fallback_methods,omitempty"`
+	
+	// Path rewriting before proxying: StripPrefix is removed first, then
+	// RewritePattern (default: the whole path) is replaced with Rewrite
+	StripPrefix    string `json:"strip_prefix,omitempty" yaml:"strip_prefix,omitempty" mapstructure:"strip_prefix"`
+	RewritePattern string `json:"rewrite_pattern,omitempty" yaml:"rewrite_pattern,omitempty" mapstructure:"rewrite_pattern"`
+	Rewrite        string `json:"rewrite,omitempty" yaml:"rewrite,omitempty"`
+	
+	// RewriteRules are applied in order after Rewrite; every matching rule rewrites the path
+	RewriteRules []RewriteRule `json:"rewrite_rules,omitempty" yaml:"rewrite_rules,omitempty" mapstructure:"rewrite_rules"`
+	
+	// UpstreamPath replaces the path with a template such as /internal/avatars?user={id},
+	// filled from the {name} segments of Path. Substituted values are escaped.
+	// {version} in UpstreamPath, Rewrite and rewrite rule replacements is the request's API version.
+	UpstreamPath string `json:"upstream_path,omitempty" yaml:"upstream_path,omitempty" mapstructure:"upstream_path"`
+	
 	CreatedAt  time.Time        `json:"created_at" yaml:"created_at"`
 	UpdatedAt  time.Time        `json:"updated_at" yaml:"updated_at"`
 }
 
+// RewriteRule replaces paths matching Pattern with Replacement, e.g. ^/legacy/(.*)$ -> /v2/$1
+type RewriteRule struct {
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	Replacement string `json:"replacement" yaml:"replacement"`
+}
+
+// ArchivedRoute is a deleted route kept so it can be restored
+type ArchivedRoute struct {
+	RouteConfig `yaml:",inline" mapstructure:",squash"`
+	ArchivedAt  time.Time `json:"archived_at" yaml:"archived_at" mapstructure:"archived_at"`
+}
+
 // Validate validates the route configuration
 func (r *RouteConfig) Validate() error {
 	if r.ID == "" {
@@ -36,6 +98,40 @@ func (r *RouteConfig) Validate() error {
 		return fmt.Errorf("invalid route path: %s", r.Path)
 	}
 	
+	if malformed := pathParamPattern.ReplaceAllString(r.Path, ""); strings.ContainsAny(malformed, "{}") {
+		return fmt.Errorf("malformed path parameter in %s: names must match [A-Za-z_][A-Za-z0-9_]*", r.Path)
+	}
+	
+	params := PathParams(r.Path)
+	seen := make(map[string]bool)
+	for _, name := range params {
+		if seen[name] {
+			return fmt.Errorf("duplicate path parameter: %s", name)
+		}
+		seen[name] = true
+	}
+	
+	if r.Host != "" && !isValidHost(r.Host) {
+		return fmt.Errorf("invalid route host: %s", r.Host)
+	}
+	
+	for name, value := range r.Headers {
+		if name == "" {
+			return fmt.Errorf("header name is required")
+		}
+		if pattern, ok := strings.CutPrefix(value, "~"); ok {
+			if _, err := compiledHeaderPattern(pattern); err != nil {
+				return fmt.Errorf("invalid pattern for header %s: %w", name, err)
+			}
+		}
+	}
+	
+	if r.Version != nil {
+		if err := r.Version.Validate(); err != nil {
+			return fmt.Errorf("invalid version config: %w", err)
+		}
+	}
+	
 	if len(r.Method) == 0 {
 		return fmt.Errorf("at least one HTTP method is required")
 	}
@@ -60,6 +156,58 @@ func (r *RouteConfig) Validate() error {
 		return fmt.Errorf("priority must be between 0 and 1000")
 	}
 	
+	if r.CanaryWeight < 0 || r.CanaryWeight > 100 {
+		return fmt.Errorf("canary weight must be between 0 and 100")
+	}
+	
+	if r.CanaryWeight > 0 && r.CanaryBackend == "" {
+		return fmt.Errorf("canary backend is required when canary weight is set")
+	}
+	
+	if r.CanaryBackend != "" && r.CanaryBackend == r.Backend {
+		return fmt.Errorf("canary backend must differ from the primary backend")
+	}
+	
+	if r.FallbackBackend != "" && r.FallbackBackend == r.Backend {
+		return fmt.Errorf("fallback backend must differ from the primary backend")
+	}
+	
+	if r.StripPrefix != "" && !strings.HasPrefix(r.StripPrefix, "/") {
+		return fmt.Errorf("strip prefix must start with /")
+	}
+	
+	if r.RewritePattern != "" {
+		if r.Rewrite == "" {
+			return fmt.Errorf("rewrite is required when rewrite pattern is set")
+		}
+		if _, err := regexp.Compile(r.RewritePattern); err != nil {
+			return fmt.Errorf("invalid rewrite pattern: %w", err)
+		}
+	}
+	
+	for i, rule := range r.RewriteRules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("rewrite rule %d: pattern is required", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("rewrite rule %d: invalid pattern: %w", i, err)
+		}
+	}
+	
+	if r.UpstreamPath != "" {
+		if !strings.HasPrefix(r.UpstreamPath, "/") {
+			return fmt.Errorf("upstream path must start with /")
+		}
+		if r.StripPrefix != "" || r.Rewrite != "" || len(r.RewriteRules) > 0 {
+			return fmt.Errorf("upstream path cannot be combined with strip prefix or rewrite")
+		}
+		for _, name := range PathParams(r.UpstreamPath) {
+			if !seen[name] && !(name == "version" && r.Version != nil) {
+				return fmt.Errorf("upstream path parameter {%s} is not defined in the route path", name)
+			}
+		}
+	}
+	
 	if r.RateLimit != nil {
 		if err := r.RateLimit.Validate(); err != nil {
 			return fmt.Errorf("invalid rate limit config: %w", err)
@@ -72,15 +220,86 @@ func (r *RouteConfig) Validate() error {
 		}
 	}
 	
+	if r.Cache != nil {
+		if err := r.Cache.Validate(); err != nil {
+			return fmt.Errorf("invalid cache config: %w", err)
+		}
+	}
+	
+	if r.Streaming != nil {
+		if err := r.Streaming.Validate(); err != nil {
+			return fmt.Errorf("invalid streaming config: %w", err)
+		}
+	}
+	
+	if r.ForwardHeaders != nil {
+		if err := r.ForwardHeaders.Validate(); err != nil {
+			return fmt.Errorf("invalid forward headers config: %w", err)
+		}
+	}
+	
+	if r.ResponseHeaders != nil {
+		if err := r.ResponseHeaders.Validate(); err != nil {
+			return fmt.Errorf("invalid response headers config: %w", err)
+		}
+	}
+	
+	for name, header := range r.SetResponseHeaders {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid response header name: %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(header.Value) {
+			return fmt.Errorf("invalid value for response header %s", name)
+		}
+	}
+	
+	if r.CORS != nil {
+		if err := r.CORS.Validate(); err != nil {
+			return fmt.Errorf("invalid cors config: %w", err)
+		}
+	}
+	
+	if r.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes cannot be negative")
+	}
+	
+	if err := ValidateErrorPages(r.ErrorPages); err != nil {
+		return fmt.Errorf("invalid error pages: %w", err)
+	}
+	
+	switch r.ContentType {
+	case "", ContentTypeHTML:
+	default:
+		return fmt.Errorf("invalid content_type: %s (must be html)", r.ContentType)
+	}
+	
+	if r.HTMLFallback != nil {
+		if r.ContentType != ContentTypeHTML {
+			return fmt.Errorf("html_fallback requires content_type: html")
+		}
+		if _, err := ParseHTMLFallback(r.HTMLFallback); err != nil {
+			return fmt.Errorf("invalid html_fallback template: %w", err)
+		}
+	}
+	
 	return nil
 }
 
-// Match checks if the given path and method match this route
-func (r *RouteConfig) Match(path, method string) bool {
+// Match checks if the given host, path, method and headers match this route.
+// Routes without a Host or Headers match any host or headers.
+func (r *RouteConfig) Match(host, path, method string, header http.Header) bool {
 	if !r.Enabled {
 		return false
 	}
 	
+	if !r.MatchHost(host) || !r.MatchHeaders(header) {
+		return false
+	}
+	
+	if r.Version != nil && !r.Version.Match(path, header) {
+		return false
+	}
+	
 	// Check method
 	methodMatch := false
 	for _, m := range r.Method {
@@ -97,17 +316,198 @@ func (r *RouteConfig) Match(path, method string) bool {
 	return matchPath(r.Path, path)
 }
 
+// UsesBackend reports whether the route sends any requests to the backend
+func (r *RouteConfig) UsesBackend(backendID string) bool {
+	return r.Backend == backendID || r.CanaryBackend == backendID || r.FallbackBackend == backendID
+}
+
+// BackendIDs returns the backends the route sends requests to: primary, canary and fallback, each once
+func (r *RouteConfig) BackendIDs() []string {
+	ids := []string{}
+	for _, id := range []string{r.Backend, r.CanaryBackend, r.FallbackBackend} {
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// MatchHost checks if the request host matches this route's Host.
+// Matching is case-insensitive and ignores the port; *.example.com matches any subdomain.
+func (r *RouteConfig) MatchHost(host string) bool {
+	if r.Host == "" {
+		return true
+	}
+	
+	host = strings.ToLower(stripPort(host))
+	pattern := strings.ToLower(r.Host)
+	
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+	
+	return host == pattern
+}
+
+// MatchHeaders checks that every configured header matches.
+// Values starting with ~ are regular expressions, anything else must match exactly.
+func (r *RouteConfig) MatchHeaders(header http.Header) bool {
+	for name, expected := range r.Headers {
+		actual := header.Get(http.CanonicalHeaderKey(name))
+		if pattern, ok := strings.CutPrefix(expected, "~"); ok {
+			re, err := compiledHeaderPattern(pattern)
+			if err != nil || !re.MatchString(actual) {
+				return false
+			}
+			continue
+		}
+		if actual != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// Specificity returns the number of host, header and version conditions on the route.
+// It breaks ties between routes of equal priority in favor of the more specific one,
+// so an unversioned route only serves the versions no versioned route claims.
+func (r *RouteConfig) Specificity() int {
+	n := len(r.Headers)
+	if r.Host != "" {
+		n++
+	}
+	if r.Version != nil {
+		n++
+	}
+	return n
+}
+
+// APIVersion returns the API version of a request to the route, or "" when the route isn't versioned
+func (r *RouteConfig) APIVersion(path string, header http.Header) string {
+	if r.Version == nil {
+		return ""
+	}
+	return r.Version.Extract(path, header)
+}
+
+// LiteralPrefix returns the part of the route path before the first * or {name}.
+// It breaks ties between routes of equal priority in favor of the longer one.
+func (r *RouteConfig) LiteralPrefix() string {
+	if i := strings.IndexAny(r.Path, "*{"); i >= 0 {
+		return r.Path[:i]
+	}
+	return r.Path
+}
+
+// stripPort removes the port from a host, handling bracketed IPv6 addresses
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
+
+// compiledPaths caches PathPattern results, since every request is matched against every route
+var compiledPaths sync.Map
+
+// compiledPath returns the cached PathPattern of a route path
+func compiledPath(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := compiledPaths.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := PathPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := compiledPaths.LoadOrStore(pattern, re)
+	return cached.(*regexp.Regexp), nil
+}
+
+// compiledHeaderPatterns caches the ~regex header conditions, compiled when the route is validated
+var compiledHeaderPatterns sync.Map
+
+// compiledHeaderPattern returns the compiled regular expression of a header condition
+func compiledHeaderPattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := compiledHeaderPatterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := compiledHeaderPatterns.LoadOrStore(pattern, re)
+	return cached.(*regexp.Regexp), nil
+}
+
 // matchPath checks if a path pattern matches a given path
 func matchPath(pattern, path string) bool {
-	// Convert wildcard pattern to regex
-	// /api/* -> /api/.*
-	// /api/*/users -> /api/.*/users
-	regexPattern := regexp.QuoteMeta(pattern)
-	regexPattern = "^" + regexPattern + "$"
-	regexPattern = regexp.MustCompile(`\\\*`).ReplaceAllString(regexPattern, ".*")
+	re, err := compiledPath(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
+
+// PathParamValues returns the values of the route's {name} parameters in path,
+// or nil when the route has none or the path doesn't match
+func (r *RouteConfig) PathParamValues(path string) map[string]string {
+	if !strings.Contains(r.Path, "{") {
+		return nil
+	}
+	re, err := compiledPath(r.Path)
+	if err != nil {
+		return nil
+	}
+	match := re.FindStringSubmatch(path)
+	if match == nil {
+		return nil
+	}
+	
+	values := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			values[name] = match[i]
+		}
+	}
+	return values
+}
+
+// pathParamPattern matches {name} placeholders in route paths and upstream templates
+var pathParamPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// PathPattern converts a route path to a regular expression.
+// * matches anything and {name} matches one path segment, captured as name:
+// /api/* -> ^/api/.*$
+// /users/{id}/avatar -> ^/users/(?P<id>[^/]+)/avatar$
+func PathPattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
 	
-	matched, _ := regexp.MatchString(regexPattern, path)
-	return matched
+	last := 0
+	for _, m := range pathParamPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		b.WriteString(quoteWildcards(pattern[last:m[0]]))
+		b.WriteString("(?P<" + pattern[m[2]:m[3]] + ">[^/]+)")
+		last = m[1]
+	}
+	b.WriteString(quoteWildcards(pattern[last:]))
+	
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// PathParams returns the names of the {name} placeholders in a path or template
+func PathParams(pattern string) []string {
+	var names []string
+	for _, m := range pathParamPattern.FindAllStringSubmatch(pattern, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// quoteWildcards quotes a literal path fragment, turning * into .*
+func quoteWildcards(s string) string {
+	return strings.ReplaceAll(regexp.QuoteMeta(s), `\*`, ".*")
 }
 
 // isValidPath checks if the path is valid
@@ -119,6 +519,15 @@ func isValidPath(path string) bool {
 	return true
 }
 
+// isValidHost checks if the host is an exact name or a leading wildcard like *.example.com
+func isValidHost(host string) bool {
+	name := strings.TrimPrefix(host, "*.")
+	if name == "" || strings.ContainsAny(name, "*/: ") {
+		return false
+	}
+	return true
+}
+
 // isValidHTTPMethod checks if the method is a valid HTTP method
 func isValidHTTPMethod(method string) bool {
 	validMethods := []string{
@@ -137,19 +546,42 @@ type RouteCollection struct {
 	Routes []*RouteConfig `json:"routes" yaml:"routes"`
 }
 
-// FindRoute finds the best matching route for a given path and method
-func (rc *RouteCollection) FindRoute(path, method string) *RouteConfig {
+// FindRoute finds the best matching route for a given host, path, method and headers.
+// On equal priority the route with the longest literal path prefix wins, then the
+// one with more host and header conditions, then the one listed first.
+func (rc *RouteCollection) FindRoute(host, path, method string, header http.Header) *RouteConfig {
 	var bestMatch *RouteConfig
-	bestPriority := -1
 	
 	for _, route := range rc.Routes {
-		if route.Match(path, method) {
-			if route.Priority > bestPriority {
-				bestMatch = route
-				bestPriority = route.Priority
-			}
+		if route.Match(host, path, method, header) && (bestMatch == nil || route.outranks(bestMatch)) {
+			bestMatch = route
 		}
 	}
 	
 	return bestMatch
+}
+
+// outranks reports whether the route should be preferred over other when both match
+func (r *RouteConfig) outranks(other *RouteConfig) bool {
+	if r.Priority != other.Priority {
+		return r.Priority > other.Priority
+	}
+	if len(r.LiteralPrefix()) != len(other.LiteralPrefix()) {
+		return len(r.LiteralPrefix()) > len(other.LiteralPrefix())
+	}
+	return r.Specificity() > other.Specificity()
+}
+
+// AllowedMethods returns the methods of the routes matching the host, path and headers.
+// It is used to tell a wrong method apart from an unknown path.
+func (rc *RouteCollection) AllowedMethods(host, path string, header http.Header) []string {
+	var methods []string
+	for _, route := range rc.Routes {
+		for _, method := range route.Method {
+			if route.Match(host, path, method, header) && !slices.Contains(methods, method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods
 }
\ No newline at end of file
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"mime"
+	"time"
+)
+
+const (
+	minStreamingBufferSize = 1 << 10
+	maxStreamingBufferSize = 16 << 20
+)
+
+// StreamingConfig tunes how a route copies response bodies from the backend
+type StreamingConfig struct {
+	// BufferSize is the copy buffer size in bytes; 0 keeps the proxy default of 32KB
+	BufferSize int `json:"buffer_size,omitempty" yaml:"buffer_size,omitempty" mapstructure:"buffer_size"`
+	// FlushInterval is how often buffered response data is flushed to the client;
+	// 0 flushes only when the buffer fills and a negative value flushes after every write
+	FlushInterval time.Duration `json:"flush_interval,omitempty" yaml:"flush_interval,omitempty" mapstructure:"flush_interval"`
+	// UnbufferedContentTypes are response content types flushed after every write
+	UnbufferedContentTypes []string `json:"unbuffered_content_types,omitempty" yaml:"unbuffered_content_types,omitempty" mapstructure:"unbuffered_content_types"`
+}
+
+// Validate validates the streaming configuration
+func (s *StreamingConfig) Validate() error {
+	if s.BufferSize != 0 && (s.BufferSize < minStreamingBufferSize || s.BufferSize > maxStreamingBufferSize) {
+		return fmt.Errorf("buffer size must be between %d and %d bytes", minStreamingBufferSize, maxStreamingBufferSize)
+	}
+
+	for _, contentType := range s.UnbufferedContentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("invalid unbuffered content type %q: %w", contentType, err)
+		}
+	}
+
+	return nil
+}
+
+// Unbuffered reports whether responses with the given Content-Type are flushed after every write
+func (s *StreamingConfig) Unbuffered(contentType string) bool {
+	if len(s.UnbufferedContentTypes) == 0 || contentType == "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, unbuffered := range s.UnbufferedContentTypes {
+		if base, _, _ := mime.ParseMediaType(unbuffered); base == mediaType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// VersionConfig matches a route to the API versions it serves.
+// The version is read from a request header or from a path segment, and a
+// leading v is ignored, so /api/v2/users and X-API-Version: 2 are both version 2.
+type VersionConfig struct {
+	Versions []string `json:"versions" yaml:"versions"`
+	Header   string   `json:"header,omitempty" yaml:"header,omitempty"`   // header carrying the version
+	Segment  int      `json:"segment,omitempty" yaml:"segment,omitempty"` // 1-based path segment carrying the version
+}
+
+// Validate validates the version configuration
+func (v *VersionConfig) Validate() error {
+	if len(v.Versions) == 0 {
+		return fmt.Errorf("at least one version is required")
+	}
+	for _, version := range v.Versions {
+		if version == "" || strings.HasPrefix(version, "v") || strings.HasPrefix(version, "V") {
+			return fmt.Errorf("invalid version %q: versions are listed without the v prefix", version)
+		}
+	}
+
+	if v.Segment < 0 {
+		return fmt.Errorf("version segment cannot be negative")
+	}
+	if (v.Header == "") == (v.Segment == 0) {
+		return fmt.Errorf("exactly one of header or segment is required")
+	}
+	return nil
+}
+
+// Source describes where the version is read from.
+// Routes sharing a path prefix must read it from the same place.
+func (v *VersionConfig) Source() string {
+	if v.Header != "" {
+		return "header " + http.CanonicalHeaderKey(v.Header)
+	}
+	return fmt.Sprintf("path segment %d", v.Segment)
+}
+
+// Extract returns the version of a request, or "" when it doesn't carry one
+func (v *VersionConfig) Extract(path string, header http.Header) string {
+	var version string
+	if v.Header != "" {
+		version = header.Get(v.Header)
+	} else {
+		segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if v.Segment <= len(segments) {
+			version = segments[v.Segment-1]
+		}
+	}
+
+	if trimmed, ok := strings.CutPrefix(version, "v"); ok {
+		return trimmed
+	}
+	return strings.TrimPrefix(version, "V")
+}
+
+// Match reports whether the request carries one of the route's versions
+func (v *VersionConfig) Match(path string, header http.Header) bool {
+	version := v.Extract(path, header)
+	return version != "" && slices.Contains(v.Versions, version)
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// dispatcher picks the route for each request with RouteCollection.FindRoute,
+// so priority decides between overlapping routes rather than config order.
+// It serves the requests the endpoints router has no handler for, and answers
+// a known path with the wrong method with 405 listing the methods of both.
+// A CORS preflight is sent to the route serving the method it asks about.
+type dispatcher struct {
+	routes    models.RouteCollection
+	handlers  map[*models.RouteConfig]http.Handler
+	cors      map[*models.RouteConfig]bool
+	endpoints *mux.Router
+	notFound  http.Handler
+}
+
+// newDispatcher creates an empty dispatcher behind the endpoints router; unmatched requests go to notFound
+func newDispatcher(endpoints *mux.Router, notFound http.Handler) *dispatcher {
+	return &dispatcher{
+		handlers:  make(map[*models.RouteConfig]http.Handler),
+		cors:      make(map[*models.RouteConfig]bool),
+		endpoints: endpoints,
+		notFound:  notFound,
+	}
+}
+
+// add registers a route with the handler that serves it, behind CORS when the config
+// enables it for at least one origin
+func (d *dispatcher) add(route *models.RouteConfig, handler http.Handler, cors *models.CORSConfig) {
+	if cors != nil && cors.Enabled && len(cors.AllowedOrigins) > 0 {
+		handler = middleware.CORS(cors)(handler)
+		d.cors[route] = true
+	}
+	d.routes.Routes = append(d.routes.Routes, route)
+	d.handlers[route] = middleware.RouteMetrics(route.Path, route.ID)(handler)
+}
+
+// ServeHTTP implements http.Handler
+func (d *dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if route := d.routes.FindRoute(r.Host, r.URL.Path, r.Method, r.Header); route != nil {
+		r = middleware.WithPathParams(r, route.PathParamValues(r.URL.Path))
+		if route.Version != nil {
+			services.RecordRouteVersionRequest(route.ID, route.APIVersion(r.URL.Path, r.Header))
+		}
+		d.handlers[route].ServeHTTP(w, r)
+		return
+	}
+
+	if middleware.IsPreflight(r) {
+		method := r.Header.Get("Access-Control-Request-Method")
+		if route := d.routes.FindRoute(r.Host, r.URL.Path, method, r.Header); route != nil && d.cors[route] {
+			d.handlers[route].ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if methods := d.allowedMethods(r); len(methods) > 0 {
+		middleware.Metrics()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeMethodNotAllowed(w, r, methods)
+		})).ServeHTTP(w, r)
+		return
+	}
+
+	d.notFound.ServeHTTP(w, r)
+}
+
+// allowedMethods returns the union of the methods the endpoints and routes accept for the request's path
+func (d *dispatcher) allowedMethods(r *http.Request) []string {
+	var methods []string
+	if d.endpoints != nil {
+		d.endpoints.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			routeMethods, err := route.GetMethods()
+			if err != nil {
+				return nil
+			}
+			for _, method := range routeMethods {
+				probe := *r
+				probe.Method = method
+				if route.Match(&probe, &mux.RouteMatch{}) && !slices.Contains(methods, method) {
+					methods = append(methods, method)
+				}
+			}
+			return nil
+		})
+	}
+
+	for _, method := range d.routes.AllowedMethods(r.Host, r.URL.Path, r.Header) {
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// writeMethodNotAllowed answers with 405, the allowed methods in the Allow header and a JSON error
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, methods []string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Code:      "method_not_allowed",
+		Message:   http.StatusText(http.StatusMethodNotAllowed),
+		RequestID: r.Header.Get("X-Request-ID"),
+		Details:   map[string]interface{}{"allowed_methods": methods},
+	})
+}
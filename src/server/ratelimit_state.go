@@ -0,0 +1,56 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/ratelimit"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// memoryRateLimiter creates the in-memory limiter for a route, restoring the state saved
+// by the previous process the first time the route is built
+func (s *Server) memoryRateLimiter(route models.RouteConfig) *models.RateLimiter {
+	limiter := models.NewRateLimiter(route.RateLimit)
+
+	s.limitersMutex.Lock()
+	defer s.limitersMutex.Unlock()
+
+	if state, ok := s.restoredLimits[route.ID]; ok {
+		limiter.Restore(state, time.Now().Add(-s.currentConfig().Middleware.RateLimit.StateMaxAge))
+		delete(s.restoredLimits, route.ID)
+	}
+	s.rateLimiters[route.ID] = limiter
+	return limiter
+}
+
+// restoreRateLimitState loads the state file saved on the last graceful shutdown.
+// The file is removed once read, so a later crash can't restore it again; a corrupt
+// or stale file is ignored and the limits start empty.
+func (s *Server) restoreRateLimitState() {
+	path := s.currentConfig().Middleware.RateLimit.StateFile
+	state, err := ratelimit.LoadState(path, s.currentConfig().Middleware.RateLimit.StateMaxAge, time.Now())
+	if err != nil {
+		s.logger.Warn("Ignoring rate limit state", "path", path, "error", err)
+	} else if state != nil {
+		s.restoredLimits = state
+		s.logger.Info("Rate limit state restored", "path", path, "routes", len(state))
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Failed to remove rate limit state", "path", path, "error", err)
+	}
+}
+
+// saveRateLimitState writes the in-memory limiters to the state file
+func (s *Server) saveRateLimitState() {
+	s.limitersMutex.Lock()
+	defer s.limitersMutex.Unlock()
+
+	path := s.currentConfig().Middleware.RateLimit.StateFile
+	if err := ratelimit.SaveState(path, s.rateLimiters, time.Now()); err != nil {
+		s.logger.Error("Failed to save rate limit state", "path", path, "error", err)
+		return
+	}
+	s.logger.Info("Rate limit state saved", "path", path, "routes", len(s.rateLimiters))
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/jwks"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// swapHandler serves requests with the latest main router.
+// Requests in flight finish on the router they started with.
+type swapHandler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+// store replaces the handler for new requests
+func (h *swapHandler) store(handler http.Handler) {
+	h.current.Store(&handler)
+}
+
+// ServeHTTP implements http.Handler
+func (h *swapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.current.Load()).ServeHTTP(w, r)
+}
+
+// currentConfig returns the running configuration. Reloads swap in a new configuration
+// rather than change this one, so callers may keep using what they were given.
+func (s *Server) currentConfig() *config.Config {
+	return s.config.Load()
+}
+
+// Reload applies a new configuration to the running server. Invalid configurations
+// are rejected and the current one kept. Backends are reloaded and the route handlers,
+// middleware included, are rebuilt without closing the listeners. Port and timeout
+// changes only take effect on restart.
+func (s *Server) Reload(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	if err := s.router.Reload(cfg); err != nil {
+		return fmt.Errorf("failed to reload router: %w", err)
+	}
+
+	current := s.currentConfig()
+	if restartRequired(cfg.Router, current.Router) || cfg.Admin.Port != current.Admin.Port || cfg.Metrics.Port != current.Metrics.Port {
+		s.logger.Warn("Listener settings changed, restart to apply them")
+	}
+
+	for _, policy := range cfg.AuthPolicies {
+		if _, exists := s.keySets[policy.JWKSURL]; !exists {
+			s.keySets[policy.JWKSURL] = jwks.NewKeySet(policy.JWKSURL, policy.CacheTTL, nil)
+		}
+	}
+
+	// Runtime flag overrides survive the reload
+	s.flags.SetRollouts(cfg.FlagRollouts)
+
+	// Admin handlers read the config through currentConfig, so swap it rather than change it
+	s.config.Store(cfg)
+	s.healthChecker.Update(cfg)
+	if s.drift != nil {
+		s.drift.SetRunning(cfg)
+	}
+	s.mainHandler.store(s.setupMainRouter())
+
+	s.events.Publish(events.TopicConfigReload, map[string]interface{}{
+		"backends": len(cfg.Backends),
+		"routes":   len(cfg.Routes),
+	})
+	s.logger.Info("Configuration reloaded", "backends", len(cfg.Backends), "routes", len(cfg.Routes))
+	s.warnOrphans(cfg)
+	return nil
+}
+
+// updateBackendEndpoints replaces a backend's endpoints on the running router. The running
+// configuration follows, so a reload doesn't rebuild the backend, and the health checker
+// starts checking the new endpoint set.
+func (s *Server) updateBackendEndpoints(backendID string, endpoints []models.EndpointConfig) (router.EndpointChanges, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	changes, err := s.router.UpdateBackendEndpoints(backendID, endpoints)
+	if err != nil {
+		return changes, err
+	}
+
+	current := s.currentConfig()
+	next := *current
+	next.Backends = slices.Clone(current.Backends)
+	for i := range next.Backends {
+		if next.Backends[i].ID == backendID {
+			next.Backends[i].Endpoints = endpoints
+			break
+		}
+	}
+
+	s.config.Store(&next)
+	s.healthChecker.Update(&next)
+	if s.drift != nil {
+		s.drift.SetRunning(&next)
+	}
+	return changes, nil
+}
+
+// reloadFile reloads the server from its configuration file
+func (s *Server) reloadFile() error {
+	path := s.currentConfig().Path()
+	if path == "" {
+		return fmt.Errorf("configuration was not loaded from a file")
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	return s.Reload(cfg)
+}
+
+// WatchConfig reloads the server whenever its configuration file changes, until ctx is cancelled.
+// Changes that fail to load or validate are logged and the running configuration is kept.
+func (s *Server) WatchConfig(ctx context.Context) error {
+	path := s.currentConfig().Path()
+	if path == "" {
+		return fmt.Errorf("configuration was not loaded from a file")
+	}
+
+	return config.Watch(ctx, path, func(cfg *config.Config, err error) {
+		if err == nil {
+			err = s.Reload(cfg)
+		}
+		if err != nil {
+			s.logger.Error("Failed to reload configuration, keeping the current one", "path", path, "error", err)
+		}
+	})
+}
+
+// restartRequired reports whether router settings that only apply on restart differ
+func restartRequired(a, b config.RouterConfig) bool {
+	return a.Port != b.Port || a.ReadTimeout != b.ReadTimeout || a.WriteTimeout != b.WriteTimeout ||
+		a.IdleTimeout != b.IdleTimeout || a.MaxHeaderBytes != b.MaxHeaderBytes || a.WatchConfig != b.WatchConfig
+}
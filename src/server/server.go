@@ -2,38 +2,81 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 	"github.com/your-org/ryohi-router/src/api"
+	"github.com/your-org/ryohi-router/src/lib/bodycapture"
 	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/jwks"
+	"github.com/your-org/ryohi-router/src/lib/logging"
 	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/lib/tracing"
+	"github.com/your-org/ryohi-router/src/lib/ratelimit"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/adminlock"
+	"github.com/your-org/ryohi-router/src/services/drift"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/flags"
 	"github.com/your-org/ryohi-router/src/services/health"
+	"github.com/your-org/ryohi-router/src/services/memory"
+	"github.com/your-org/ryohi-router/src/services/profiling"
 	"github.com/your-org/ryohi-router/src/services/router"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Server represents the main router server
 type Server struct {
-	config       *config.Config
+	config       atomic.Pointer[config.Config] // swapped, never changed in place, on reload
 	logger       *slog.Logger
 	mainServer   *http.Server
 	adminServer  *http.Server
 	metricsServer *http.Server
 	router       *router.Router
 	healthChecker *health.Checker
+	events       *events.Bus
+	flags        *flags.Store
+	drift        *drift.Detector
+	adminLock    *adminlock.Lock
+	watchdog     *memory.Watchdog // nil when no memory limit is known
+	profiler     *profiling.Profiler // nil when profiling has no directory or push URL
+	redis        *redis.Client // shared rate limit store, nil when limits are kept in memory
+	rateLimiters map[string]*models.RateLimiter // in-memory route limiters, saved on shutdown
+	restoredLimits map[string]models.RateLimitState // saved state not yet handed to a limiter
+	limitersMutex sync.Mutex
+	keySets      map[string]*jwks.KeySet // auth policy signing keys by JWKS URL
+	tracer       *tracing.Provider // nil when tracing is disabled
+	mainHandler  *swapHandler // rebuilt when the configuration is reloaded
+	reloadMutex  sync.Mutex
 	wg           sync.WaitGroup
+	draining     atomic.Bool // set once shutdown begins, failing the health endpoint
+	startedAt    time.Time
+	logs         *recentLogs // kept for support bundles
 }
 
 // New creates a new server instance
 func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
+	// Recent errors, warnings and requests are kept for support bundles
+	logs := newRecentLogs()
+	logger = slog.New(logging.Tee(logger.Handler(), logs.errors, logs.warnings, logs.requests))
+
+	// Lines logged with a request's context carry its request ID
+	logger = middleware.NewContextLogger(logger)
 	s := &Server{
-		config: cfg,
-		logger: logger,
+		logger:    logger,
+		startedAt: time.Now(),
+		logs:      logs,
 	}
+	s.config.Store(cfg)
 
 	// Initialize router
 	routerService, err := router.New(cfg, logger)
@@ -42,14 +85,83 @@ func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
 	}
 	s.router = routerService
 
+	// Initialize event bus
+	s.events = events.NewBus(cfg.Admin.EventBacklogSize, cfg.Admin.MaxEventStreams)
+	s.router.SetEventBus(s.events)
+
+	// Admin mutations start locked when the admin API is read-only
+	s.adminLock = adminlock.New(cfg.Admin.ReadOnly, cfg.Admin.UnlockWindow, logger)
+	s.adminLock.SetEventBus(s.events)
+
+	// Initialize feature flags
+	s.flags = flags.NewStore(cfg.FeatureFlags)
+	s.flags.SetRollouts(cfg.FlagRollouts)
+	s.router.SetFlags(s.flags)
+
+	// Connect to the shared rate limit store
+	if cfg.Middleware.RateLimit.Store == "redis" {
+		s.redis = ratelimit.NewRedisClient(cfg.Middleware.RateLimit.Redis)
+	}
+	s.rateLimiters = make(map[string]*models.RateLimiter)
+	if cfg.Middleware.RateLimit.StateFile != "" {
+		s.restoreRateLimitState()
+	}
+
+	// Share one key cache per issuer key set between the auth policies using it
+	s.keySets = make(map[string]*jwks.KeySet)
+	for _, policy := range cfg.AuthPolicies {
+		if _, exists := s.keySets[policy.JWKSURL]; !exists {
+			s.keySets[policy.JWKSURL] = jwks.NewKeySet(policy.JWKSURL, policy.CacheTTL, nil)
+		}
+	}
+
+	// Export request and backend spans when tracing is enabled
+	if cfg.Tracing.Enabled {
+		s.tracer = tracing.NewProvider(tracing.NewOTLPExporter(cfg.Tracing.OTLPEndpoint, cfg.Tracing.ServiceName, logger))
+		s.router.SetTracer(s.tracer)
+	}
+
+	// Attach trace exemplars to latency histograms when enabled
+	services.SetExemplarsEnabled(cfg.Metrics.Exemplars)
+
 	// Initialize health checker
 	s.healthChecker = health.NewChecker(cfg, logger)
+	s.healthChecker.SetEventBus(s.events)
+	s.healthChecker.SetEndpointHealthHandler(s.router.SetEndpointHealth)
+
+	// Initialize config drift detection when the configuration came from a file
+	if cfg.Path() != "" {
+		s.drift = drift.NewDetector(cfg.Path(), cfg, cfg.Admin.DriftCheckInterval, logger)
+		s.drift.SetEventBus(s.events)
+	}
+
+	// Initialize the memory watchdog when there is a limit to stay under
+	if cfg.Memory.Enabled {
+		limit := uint64(cfg.Memory.Limit)
+		if limit == 0 {
+			limit, _ = memory.CgroupLimit(memory.DefaultCgroupRoot)
+		}
+		if limit > 0 {
+			s.watchdog = memory.NewWatchdog(limit, cfg.Memory.HighWater, cfg.Memory.LowWater, memory.ReadProcess, logger)
+			s.watchdog.SetEventBus(s.events)
+			s.router.SetWatchdog(s.watchdog)
+		} else {
+			logger.Info("Memory watchdog disabled, no memory limit configured or detected")
+		}
+	}
+
+	// Initialize continuous profiling when it has somewhere to send snapshots; it
+	// runs from startup only when enabled, otherwise when started through the admin API
+	if cfg.Profiling.Configured() {
+		s.profiler = profiling.New(cfg.Profiling, logger)
+	}
 
 	// Setup main server
-	mainRouter := s.setupMainRouter()
+	s.mainHandler = &swapHandler{}
+	s.mainHandler.store(s.setupMainRouter())
 	s.mainServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Router.Port),
-		Handler:      mainRouter,
+		Handler:      s.mainHandler,
 		ReadTimeout:  cfg.Router.ReadTimeout,
 		WriteTimeout: cfg.Router.WriteTimeout,
 		IdleTimeout:  cfg.Router.IdleTimeout,
@@ -75,27 +187,70 @@ func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
 		}
 	}
 
+	s.warnOrphans(cfg)
 	return s, nil
 }
 
+// warnOrphans logs the configuration's orphans; strict configurations are rejected with them instead
+func (s *Server) warnOrphans(cfg *config.Config) {
+	for _, orphan := range cfg.Orphans() {
+		s.logger.Warn("Configuration orphan",
+			"kind", orphan.Kind,
+			"id", orphan.ID,
+			"section", orphan.Section,
+			"file", orphan.File,
+			"message", orphan.Message,
+		)
+	}
+}
+
+// requestIDOptions returns how request IDs are accepted and generated
+func (s *Server) requestIDOptions() middleware.RequestIDOptions {
+	return middleware.RequestIDOptions{
+		Prefix:             s.currentConfig().Router.RequestIDPrefix,
+		Format:             s.currentConfig().Router.RequestIDFormat,
+		TrustInbound:       !s.currentConfig().Router.IgnoreInboundRequestID,
+		CorrelationHeaders: s.currentConfig().Router.CorrelationIDHeaders,
+	}
+}
+
 // setupMainRouter sets up the main router with all routes and middleware
 func (s *Server) setupMainRouter() http.Handler {
 	r := mux.NewRouter()
 
-	// Apply global middleware
-	handler := middleware.Chain(
-		r,
-		middleware.RequestID(),
+	// Apply global middleware; the request span starts after RequestID so it carries the ID
+	global := []func(http.Handler) http.Handler{middleware.RequestID(s.requestIDOptions())}
+	if s.tracer != nil {
+		global = append(global, middleware.Tracing(s.tracer))
+	}
+	global = append(global,
 		middleware.Logger(s.logger),
 		middleware.Recovery(s.logger),
-		middleware.Metrics(),
+		// Routes match the cleaned path, and backends get it
+		middleware.NormalizePath(),
 	)
+	if len(s.currentConfig().Router.AllowedMethods) > 0 {
+		// Disallowed methods are rejected before their bodies are buffered
+		global = append(global, middleware.AllowMethods(s.currentConfig().Router.AllowedMethods))
+	}
+	global = append(global,
+		bodycapture.Middleware(bodycapture.Options{MaxBytes: s.currentConfig().Router.MaxBufferedBodyBytes}),
+	)
+	handler := middleware.Chain(r, global...)
+
+	// Metrics are collected after route matching so they are labelled by route pattern
+	r.Use(middleware.Metrics())
+	notFound := middleware.Metrics()(http.NotFoundHandler())
 
 	// Health endpoint (no auth required)
-	r.HandleFunc("/health", api.HealthHandler(s.healthChecker)).Methods("GET")
+	r.Handle("/health", s.readiness(api.HealthHandler(s.healthChecker))).Methods("GET")
 
-	// Setup route handlers
-	for _, route := range s.config.Routes {
+	// Everything else is dispatched by route priority
+	routes := newDispatcher(r, notFound)
+	r.NotFoundHandler = routes
+	r.MethodNotAllowedHandler = routes
+
+	for _, route := range s.currentConfig().Routes {
 		if !route.Enabled {
 			continue
 		}
@@ -105,20 +260,96 @@ func (s *Server) setupMainRouter() http.Handler {
 
 		// Apply route-specific middleware
 		if route.RateLimit != nil && route.RateLimit.Enabled {
-			routeHandler = middleware.RateLimit(route.RateLimit)(routeHandler)
+			routeHandler = middleware.RateLimitWithStore(route.RateLimit, s.rateLimitStore(route),
+				s.currentConfig().Middleware.RateLimit.FailClosed, s.logger)(routeHandler)
 		}
 
 		if route.Auth != nil && route.Auth.Enabled {
 			routeHandler = middleware.Auth(route.Auth)(routeHandler)
 		}
 
-		// Register route
-		r.PathPrefix(route.Path).Handler(routeHandler).Methods(route.Method...)
+		if route.AuthPolicy != "" {
+			if policy := s.authPolicy(route.AuthPolicy); policy != nil {
+				routeHandler = middleware.JWTAuth(policy, s.keySets[policy.JWKSURL])(routeHandler)
+			} else {
+				// Never serve a protected route unprotected
+				s.logger.Error("Route references unknown auth policy", "route", route.ID, "policy", route.AuthPolicy)
+				routeHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+				})
+			}
+		}
+
+		// Shed before any other work is done for the request
+		if s.watchdog != nil && !route.Critical {
+			routeHandler = middleware.Shed(s.watchdog, route.ID)(routeHandler)
+		}
+
+		routeHandler = middleware.FeatureFlags(s.flags, route.ID, route.Backend)(routeHandler)
+
+		// A route's own CORS config replaces the global one
+		cors := route.CORS
+		if cors == nil {
+			cors = &s.currentConfig().Middleware.CORS
+		}
+		routes.add(&route, routeHandler, cors)
+	}
+
+	// gRPC clients talk to h2c backends over cleartext HTTP/2, so accept it on the main port too
+	if s.hasH2CBackends() {
+		handler = h2c.NewHandler(handler, &http2.Server{})
 	}
 
 	return handler
 }
 
+// readiness answers health checks with 503 once shutdown has begun,
+// so load balancers stop sending new requests while the listeners drain
+func (s *Server) readiness(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.draining.Load() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(models.HealthResponse{
+			Status:    "draining",
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	})
+}
+
+// authPolicy returns the auth policy with the given ID, or nil
+func (s *Server) authPolicy(id string) *models.AuthPolicy {
+	for i := range s.currentConfig().AuthPolicies {
+		if s.currentConfig().AuthPolicies[i].ID == id {
+			return &s.currentConfig().AuthPolicies[i]
+		}
+	}
+	return nil
+}
+
+// rateLimitStore returns the store for a route's rate limit buckets
+func (s *Server) rateLimitStore(route models.RouteConfig) models.RateLimitStore {
+	if s.redis == nil {
+		return s.memoryRateLimiter(route)
+	}
+	prefix := s.currentConfig().Middleware.RateLimit.Redis.KeyPrefix + route.ID + ":"
+	return ratelimit.NewRedisStore(s.redis, prefix, route.RateLimit)
+}
+
+// hasH2CBackends reports whether any enabled backend is reached over h2c
+func (s *Server) hasH2CBackends() bool {
+	for _, backend := range s.currentConfig().Backends {
+		if backend.Enabled && backend.IsH2C() {
+			return true
+		}
+	}
+	return false
+}
+
 // setupAdminRouter sets up the admin API router
 func (s *Server) setupAdminRouter() http.Handler {
 	r := mux.NewRouter()
@@ -126,23 +357,57 @@ func (s *Server) setupAdminRouter() http.Handler {
 	// Apply admin middleware
 	handler := middleware.Chain(
 		r,
-		middleware.RequestID(),
+		middleware.RequestID(s.requestIDOptions()),
 		middleware.Logger(s.logger),
-		middleware.APIKeyAuth(s.config.Admin.APIKey),
+		middleware.APIKeyAuth(s.currentConfig().Admin.APIKey),
+		adminlock.Middleware(s.adminLock, "/admin/unlock", "/admin/lock", "/admin/support-bundle"),
 	)
 
+	r.HandleFunc("/admin/lock", api.AdminLockStatusHandler(s.adminLock)).Methods("GET")
+	r.HandleFunc("/admin/lock", api.RelockAdminHandler(s.adminLock)).Methods("POST")
+	r.HandleFunc("/admin/unlock", api.UnlockAdminHandler(s.adminLock, s.currentConfig().Admin.UnlockKey, s.logger)).Methods("POST")
+
 	// Admin API endpoints
-	r.HandleFunc("/admin/routes", api.GetRoutesHandler(s.config)).Methods("GET")
-	r.HandleFunc("/admin/routes", api.CreateRouteHandler(s.config)).Methods("POST")
-	r.HandleFunc("/admin/routes/{id}", api.GetRouteHandler(s.config)).Methods("GET")
-	r.HandleFunc("/admin/routes/{id}", api.UpdateRouteHandler(s.config)).Methods("PUT")
-	r.HandleFunc("/admin/routes/{id}", api.DeleteRouteHandler(s.config)).Methods("DELETE")
-
-	r.HandleFunc("/admin/backends", api.GetBackendsHandler(s.config)).Methods("GET")
-	r.HandleFunc("/admin/backends", api.CreateBackendHandler(s.config)).Methods("POST")
+	r.HandleFunc("/admin/routes", api.GetRoutesHandler(s.currentConfig)).Methods("GET")
+	r.HandleFunc("/admin/routes", api.CreateRouteHandler(s.currentConfig, s.Reload)).Methods("POST")
+	r.HandleFunc("/admin/routes/archived", api.GetArchivedRoutesHandler(s.currentConfig)).Methods("GET")
+	r.HandleFunc("/admin/routes/timeout-report", api.TimeoutReportHandler(s.currentConfig)).Methods("GET")
+	r.HandleFunc("/admin/routes/archived/{id}/restore", api.RestoreRouteHandler(s.currentConfig, s.Reload)).Methods("POST")
+	r.HandleFunc("/admin/routes/{id}", api.GetRouteHandler(s.currentConfig)).Methods("GET")
+	r.HandleFunc("/admin/routes/{id}", api.UpdateRouteHandler(s.currentConfig, s.Reload)).Methods("PUT")
+	r.HandleFunc("/admin/routes/{id}", api.DeleteRouteHandler(s.currentConfig, s.Reload)).Methods("DELETE")
+	r.HandleFunc("/admin/routes/{id}/enable", api.SetRouteEnabledHandler(s.currentConfig, s.Reload, true)).Methods("POST")
+	r.HandleFunc("/admin/routes/{id}/disable", api.SetRouteEnabledHandler(s.currentConfig, s.Reload, false)).Methods("POST")
+	r.HandleFunc("/admin/routes/{id}/cache", api.PurgeRouteCacheHandler(s.router)).Methods("DELETE")
+
+	r.HandleFunc("/admin/backends", api.GetBackendsHandler(s.currentConfig, s.router, s.healthChecker)).Methods("GET")
+	r.HandleFunc("/admin/backends", api.CreateBackendHandler(s.currentConfig, s.Reload)).Methods("POST")
+	r.HandleFunc("/admin/backends/{id}", api.GetBackendHandler(s.currentConfig, s.router, s.healthChecker)).Methods("GET")
+	r.HandleFunc("/admin/backends/{id}", api.UpdateBackendHandler(s.currentConfig, s.Reload)).Methods("PUT")
+	r.HandleFunc("/admin/backends/{id}", api.DeleteBackendHandler(s.currentConfig, s.Reload)).Methods("DELETE")
 	r.HandleFunc("/admin/backends/{id}/health", api.GetBackendHealthHandler(s.healthChecker)).Methods("GET")
+	r.HandleFunc("/admin/backends/{id}/circuit-breaker", api.GetCircuitBreakerHandler(s.router)).Methods("GET")
+	r.HandleFunc("/admin/backends/{id}/circuit-breaker/reset", api.ResetCircuitBreakerHandler(s.router, s.logger)).Methods("POST")
+	r.HandleFunc("/admin/backends/{id}/endpoints/drain", api.DrainEndpointHandler(s.router, s.logger)).Methods("POST")
+	r.HandleFunc("/admin/backends/{id}/endpoints", api.UpdateBackendEndpointsHandler(s.updateBackendEndpoints, s.logger)).Methods("PUT")
 
-	r.HandleFunc("/admin/reload", api.ReloadConfigHandler(s.config, s.router)).Methods("POST")
+	r.HandleFunc("/admin/reload", api.ReloadConfigHandler(s.reloadFile)).Methods("POST")
+	r.HandleFunc("/admin/config", api.GetConfigHandler(s.currentConfig)).Methods("GET")
+	r.HandleFunc("/admin/config/orphans", api.ConfigOrphansHandler(s.currentConfig)).Methods("GET")
+	r.HandleFunc("/admin/config/drift", api.ConfigDriftHandler(s.drift)).Methods("GET")
+
+	r.HandleFunc("/admin/events", api.EventsHandler(s.events)).Methods("GET")
+
+	r.HandleFunc("/admin/profiling", api.ProfilingStatusHandler(s.profiler)).Methods("GET")
+	r.HandleFunc("/admin/profiling/start", api.StartProfilingHandler(s.profiler, s.logger)).Methods("POST")
+	r.HandleFunc("/admin/profiling/stop", api.StopProfilingHandler(s.profiler, s.logger)).Methods("POST")
+
+	r.HandleFunc("/admin/support-bundle", api.SupportBundleHandler(s.currentConfig, s.supportBundleFiles, s.logger)).Methods("POST")
+
+	r.HandleFunc("/admin/flags", api.GetFlagsHandler(s.flags)).Methods("GET")
+	r.HandleFunc("/admin/flags", api.SetFlagHandler(s.flags, s.logger, s.events)).Methods("POST")
+	r.HandleFunc("/admin/flags/{name}", api.UpdateFlagHandler(s.flags, s.logger, s.events)).Methods("PUT")
+	r.HandleFunc("/admin/flags/{name}", api.ResetFlagHandler(s.flags, s.logger, s.events)).Methods("DELETE")
 
 	return handler
 }
@@ -150,20 +415,50 @@ func (s *Server) setupAdminRouter() http.Handler {
 // setupMetricsRouter sets up the metrics endpoint router
 func (s *Server) setupMetricsRouter() http.Handler {
 	r := mux.NewRouter()
-	r.HandleFunc("/metrics", api.MetricsHandler()).Methods("GET")
+	r.HandleFunc("/metrics", api.MetricsHandler(s.currentConfig().Metrics.Exemplars)).Methods("GET")
+	r.MethodNotAllowedHandler = newDispatcher(r, http.NotFoundHandler())
 	return r
 }
 
-// Start starts all servers
+// Start starts all servers and blocks until ctx is cancelled. Call Shutdown afterwards;
+// health checks keep running past ctx until Shutdown has drained the listeners.
 func (s *Server) Start(ctx context.Context) error {
 	// Start health checker
-	s.healthChecker.Start(ctx)
+	s.healthChecker.Start(context.WithoutCancel(ctx))
+
+	// Start config drift detection
+	if s.drift != nil {
+		s.drift.Start(ctx)
+	}
+
+	// Start the memory watchdog
+	if s.watchdog != nil {
+		s.watchdog.Start(ctx, s.currentConfig().Memory.Interval)
+	}
+
+	// Start continuous profiling; it costs CPU, so it is called out in the startup log
+	if s.profiler != nil && s.currentConfig().Profiling.Enabled {
+		s.profiler.Start(0)
+		s.logger.Warn("Continuous profiling enabled",
+			"directory", s.currentConfig().Profiling.Directory,
+			"push_url", s.currentConfig().Profiling.PushURL,
+			"interval", s.currentConfig().Profiling.Interval,
+			"cpu_duration", s.currentConfig().Profiling.CPUDuration,
+		)
+	}
+
+	// Apply config file changes as they are made
+	if s.currentConfig().Router.WatchConfig {
+		if err := s.WatchConfig(ctx); err != nil {
+			s.logger.Error("Failed to watch configuration file", "error", err)
+		}
+	}
 
 	// Start main server
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
-		s.logger.Info("Starting main server", "port", s.config.Router.Port)
+		s.logger.Info("Starting main server", "port", s.currentConfig().Router.Port)
 		if err := s.mainServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			s.logger.Error("Main server error", "error", err)
 		}
@@ -174,7 +469,7 @@ func (s *Server) Start(ctx context.Context) error {
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
-			s.logger.Info("Starting admin server", "port", s.config.Admin.Port)
+			s.logger.Info("Starting admin server", "port", s.currentConfig().Admin.Port)
 			if err := s.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				s.logger.Error("Admin server error", "error", err)
 			}
@@ -186,7 +481,7 @@ func (s *Server) Start(ctx context.Context) error {
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
-			s.logger.Info("Starting metrics server", "port", s.config.Metrics.Port)
+			s.logger.Info("Starting metrics server", "port", s.currentConfig().Metrics.Port)
 			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				s.logger.Error("Metrics server error", "error", err)
 			}
@@ -198,14 +493,19 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
-// Shutdown gracefully shuts down all servers
+// Shutdown gracefully shuts down all servers. The health endpoint fails first,
+// then the listeners drain, and background work such as health checks stops last
+// so backend status stays current while requests in flight finish.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down servers...")
 
-	// Stop health checker
-	s.healthChecker.Stop()
+	// Fail readiness so load balancers stop sending new requests
+	s.draining.Store(true)
 
-	// Shutdown servers
+	// Close event streams so long-lived admin connections don't block shutdown
+	s.events.Close()
+
+	// Shutdown servers, draining requests in flight
 	var shutdownErr error
 
 	if err := s.mainServer.Shutdown(ctx); err != nil {
@@ -227,6 +527,44 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Stop health checker
+	s.healthChecker.Stop()
+
+	// Stop config drift detection
+	if s.drift != nil {
+		s.drift.Stop()
+	}
+
+	// Stop the memory watchdog
+	if s.watchdog != nil {
+		s.watchdog.Stop()
+	}
+
+	// Stop continuous profiling
+	if s.profiler != nil {
+		s.profiler.Stop()
+	}
+
+	// Stop the unlock window timer
+	s.adminLock.Stop()
+
+	// Save the in-memory rate limits once no more requests are served
+	if s.currentConfig().Middleware.RateLimit.StateFile != "" {
+		s.saveRateLimitState()
+	}
+
+	// Close the shared rate limit store once no more requests are served
+	if s.redis != nil {
+		s.redis.Close()
+	}
+
+	// Export the spans of the last requests
+	if s.tracer != nil {
+		if err := s.tracer.Shutdown(ctx); err != nil {
+			s.logger.Error("Failed to export remaining trace spans", "error", err)
+		}
+	}
+
 	// Wait for all goroutines to finish
 	done := make(chan struct{})
 	go func() {
@@ -250,6 +588,11 @@ func (s *Server) GetRouter() http.Handler {
 	return s.setupMainRouter()
 }
 
+// GetMainHandler returns the handler the main server serves, which follows config reloads
+func (s *Server) GetMainHandler() http.Handler {
+	return s.mainHandler
+}
+
 // GetAdminRouter returns the admin router for testing
 func (s *Server) GetAdminRouter() http.Handler {
 	return s.setupAdminRouter()
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/your-org/ryohi-router/src/lib/logging"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/router"
+	"github.com/your-org/ryohi-router/src/services/supportbundle"
+)
+
+// Log records kept for support bundles
+const (
+	recentErrorsSize   = 100
+	recentWarningsSize = 100
+	recentRequestsSize = 500
+)
+
+// recentLogs keeps the latest log records of interest for support bundles
+type recentLogs struct {
+	errors   *logging.Ring
+	warnings *logging.Ring
+	requests *logging.Ring // access log lines
+}
+
+// newRecentLogs creates empty rings of recent log records
+func newRecentLogs() *recentLogs {
+	return &recentLogs{
+		errors: logging.NewRing(recentErrorsSize, slog.LevelError, nil),
+		warnings: logging.NewRing(recentWarningsSize, slog.LevelWarn, func(record slog.Record) bool {
+			return record.Level < slog.LevelError
+		}),
+		requests: logging.NewRing(recentRequestsSize, slog.LevelInfo, func(record slog.Record) bool {
+			return record.Message == middleware.AccessLogMessage
+		}),
+	}
+}
+
+// startupReport describes how the server was started and what it runs on
+type startupReport struct {
+	StartedAt  time.Time       `json:"started_at"`
+	Uptime     string          `json:"uptime"`
+	ConfigFile string          `json:"config_file,omitempty"`
+	Listeners  map[string]int  `json:"listeners"`
+	Backends   int             `json:"backends"`
+	Routes     int             `json:"routes"`
+	Features   map[string]bool `json:"features"`
+	Runtime    runtimeReport   `json:"runtime"`
+}
+
+// runtimeReport describes the process and the machine it runs on
+type runtimeReport struct {
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	Goroutines int    `json:"goroutines"`
+	PID        int    `json:"pid"`
+	Hostname   string `json:"hostname"`
+}
+
+// versionReport identifies the build
+type versionReport struct {
+	Module       string `json:"module"`
+	Version      string `json:"version"`
+	GoVersion    string `json:"go_version"`
+	Revision     string `json:"revision,omitempty"`
+	RevisionTime string `json:"revision_time,omitempty"`
+	Modified     bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+}
+
+// routeReport is a configured route with its usage since startup
+type routeReport struct {
+	ID      string               `json:"id"`
+	Path    string               `json:"path"`
+	Backend string               `json:"backend"`
+	Enabled bool                 `json:"enabled"`
+	Usage   *services.RouteUsage `json:"usage,omitempty"`
+}
+
+// supportBundleFiles lists the files of a support bundle; profiles are only taken when asked for
+func (s *Server) supportBundleFiles(includeProfiles bool) []supportbundle.File {
+	profileSkip := ""
+	if !includeProfiles {
+		profileSkip = "not requested; set include_profiles"
+	}
+
+	return []supportbundle.File{
+		{
+			Name:        "config.json",
+			Description: "Effective configuration with secrets redacted",
+			Content: supportbundle.JSON(func() (interface{}, error) {
+				return s.currentConfig().Redacted(), nil
+			}),
+		},
+		{
+			Name:        "startup.json",
+			Description: "Start time, listeners, enabled features and runtime environment",
+			Content:     supportbundle.JSON(func() (interface{}, error) { return s.startupReport(), nil }),
+		},
+		{
+			Name:        "version.json",
+			Description: "Module version, Go version and VCS revision of the build",
+			Content:     supportbundle.JSON(func() (interface{}, error) { return buildVersion(), nil }),
+		},
+		{
+			Name:        "health.json",
+			Description: "Active health check status of each backend and recent health transitions",
+			Content: supportbundle.JSON(func() (interface{}, error) {
+				return map[string]interface{}{
+					"statuses": s.healthChecker.GetAllStatuses(),
+					"history":  s.events.Backlog([]string{events.TopicHealth}),
+				}, nil
+			}),
+		},
+		{
+			Name:        "circuit_breakers.json",
+			Description: "Circuit breaker state and endpoint runtime of each backend, and recent breaker transitions",
+			Content: supportbundle.JSON(func() (interface{}, error) {
+				return map[string]interface{}{
+					"backends": s.backendRuntimes(),
+					"history":  s.events.Backlog([]string{events.TopicCircuitBreaker}),
+				}, nil
+			}),
+		},
+		{
+			Name:        "routes.json",
+			Description: "Configured routes with request counts by status and latency since startup",
+			Content:     supportbundle.JSON(func() (interface{}, error) { return s.routeReports() }),
+		},
+		{
+			Name:        "logs/errors.json",
+			Description: "Most recent error log lines",
+			Content:     supportbundle.JSON(func() (interface{}, error) { return s.logs.errors.Entries(), nil }),
+		},
+		{
+			Name:        "logs/warnings.json",
+			Description: "Most recent warning log lines",
+			Content:     supportbundle.JSON(func() (interface{}, error) { return s.logs.warnings.Entries(), nil }),
+		},
+		{
+			Name:        "logs/access.json",
+			Description: "Most recent access log lines of the main and admin servers",
+			Content:     supportbundle.JSON(func() (interface{}, error) { return s.logs.requests.Entries(), nil }),
+		},
+		{
+			Name:        "profiles/goroutine.pprof",
+			Description: "Goroutine profile",
+			Binary:      true,
+			Skip:        profileSkip,
+			Content:     func() ([]byte, error) { return lookupProfile("goroutine") },
+		},
+		{
+			Name:        "profiles/heap.pprof",
+			Description: "Heap profile",
+			Binary:      true,
+			Skip:        profileSkip,
+			Content:     func() ([]byte, error) { return lookupProfile("heap") },
+		},
+	}
+}
+
+// startupReport describes the running server
+func (s *Server) startupReport() startupReport {
+	listeners := map[string]int{"router": s.currentConfig().Router.Port}
+	if s.currentConfig().Admin.Enabled {
+		listeners["admin"] = s.currentConfig().Admin.Port
+	}
+	if s.currentConfig().Metrics.Enabled {
+		listeners["metrics"] = s.currentConfig().Metrics.Port
+	}
+	hostname, _ := os.Hostname()
+
+	return startupReport{
+		StartedAt:  s.startedAt,
+		Uptime:     time.Since(s.startedAt).Round(time.Second).String(),
+		ConfigFile: s.currentConfig().Path(),
+		Listeners:  listeners,
+		Backends:   len(s.currentConfig().Backends),
+		Routes:     len(s.currentConfig().Routes),
+		Features: map[string]bool{
+			"tracing":          s.tracer != nil,
+			"profiling":        s.profiler != nil && s.profiler.Status().Running,
+			"memory_watchdog":  s.watchdog != nil,
+			"admin_read_only":  s.currentConfig().Admin.ReadOnly,
+			"watch_config":     s.currentConfig().Router.WatchConfig,
+			"redis_rate_limit": s.redis != nil,
+		},
+		Runtime: runtimeReport{
+			GoVersion:  runtime.Version(),
+			OS:         runtime.GOOS,
+			Arch:       runtime.GOARCH,
+			NumCPU:     runtime.NumCPU(),
+			GOMAXPROCS: runtime.GOMAXPROCS(0),
+			Goroutines: runtime.NumGoroutine(),
+			PID:        os.Getpid(),
+			Hostname:   hostname,
+		},
+	}
+}
+
+// buildVersion reads the build's version from its build info
+func buildVersion() versionReport {
+	report := versionReport{GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return report
+	}
+
+	report.Module, report.Version = info.Main.Path, info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			report.Revision = setting.Value
+		case "vcs.time":
+			report.RevisionTime = setting.Value
+		case "vcs.modified":
+			report.Modified = setting.Value == "true"
+		}
+	}
+	return report
+}
+
+// backendRuntimes returns the runtime state of the backends the router serves, by ID
+func (s *Server) backendRuntimes() map[string]router.BackendRuntime {
+	runtimes := make(map[string]router.BackendRuntime)
+	for _, backendConfig := range s.currentConfig().Backends {
+		if backend, exists := s.router.GetBackend(backendConfig.ID); exists {
+			runtimes[backendConfig.ID] = backend.Runtime()
+		}
+	}
+	return runtimes
+}
+
+// routeReports returns the configured routes with their usage, and the requests no route matched
+func (s *Server) routeReports() (interface{}, error) {
+	usage, err := services.RouteUsageStats(prometheus.DefaultGatherer)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]routeReport, 0, len(s.currentConfig().Routes))
+	for _, route := range s.currentConfig().Routes {
+		routes = append(routes, routeReport{
+			ID:      route.ID,
+			Path:    route.Path,
+			Backend: route.Backend,
+			Enabled: route.Enabled,
+			Usage:   usage[route.ID],
+		})
+	}
+	return map[string]interface{}{
+		"routes":    routes,
+		"unmatched": usage[""],
+	}, nil
+}
+
+// lookupProfile writes a runtime profile in the pprof format
+func lookupProfile(name string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
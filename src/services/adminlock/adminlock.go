@@ -0,0 +1,225 @@
+// Package adminlock keeps a read-only admin API locked against mutations,
+// except during time-boxed unlock windows. The lock is held in memory only,
+// so a restart always starts locked.
+package adminlock
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/events"
+)
+
+// Status describes the lock for admin clients
+type Status struct {
+	ReadOnly      bool       `json:"read_only"`
+	Locked        bool       `json:"locked"`
+	UnlockedUntil *time.Time `json:"unlocked_until,omitempty"`
+}
+
+// Actor identifies who changed the lock, for the audit log
+type Actor struct {
+	RemoteAddr string
+	RequestID  string
+}
+
+// ActorFrom returns the actor making an admin request
+func ActorFrom(r *http.Request) Actor {
+	return Actor{RemoteAddr: r.RemoteAddr, RequestID: r.Header.Get("X-Request-ID")}
+}
+
+// Lock decides whether admin mutations are allowed
+type Lock struct {
+	readOnly      bool
+	window        time.Duration
+	logger        *slog.Logger
+	events        *events.Bus
+	clock         clock.Clock
+	unlockedUntil time.Time
+	timer         *time.Timer
+	mutex         sync.Mutex
+}
+
+// New creates a lock. When readOnly is false mutations are always allowed;
+// otherwise they are allowed for window after each unlock.
+func New(readOnly bool, window time.Duration, logger *slog.Logger) *Lock {
+	return &Lock{
+		readOnly: readOnly,
+		window:   window,
+		logger:   logger,
+		clock:    clock.Real,
+	}
+}
+
+// SetEventBus sets the bus that unlock and relock events are published to
+func (l *Lock) SetEventBus(bus *events.Bus) {
+	l.events = bus
+}
+
+// SetClock sets the clock that unlock windows are measured on
+func (l *Lock) SetClock(c clock.Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.clock = c
+}
+
+// ReadOnly reports whether the admin API is read-only outside unlock windows
+func (l *Lock) ReadOnly() bool {
+	return l.readOnly
+}
+
+// Locked reports whether mutations are currently rejected
+func (l *Lock) Locked() bool {
+	return l.Status().Locked
+}
+
+// Status returns the current lock status
+func (l *Lock) Status() Status {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.expire()
+	status := Status{ReadOnly: l.readOnly, Locked: l.readOnly && l.unlockedUntil.IsZero()}
+	if !l.unlockedUntil.IsZero() {
+		until := l.unlockedUntil
+		status.UnlockedUntil = &until
+	}
+	return status
+}
+
+// Unlock allows mutations for the lock's window, extending a window that is already open
+func (l *Lock) Unlock(actor Actor) Status {
+	l.mutex.Lock()
+	l.unlockedUntil = l.clock.Now().Add(l.window)
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	// The timer only makes the expiry visible without a request; Status checks the clock itself
+	l.timer = time.AfterFunc(l.window, func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.expire()
+	})
+	l.audit("unlock", actor)
+	l.mutex.Unlock()
+
+	return l.Status()
+}
+
+// Relock ends the unlock window early
+func (l *Lock) Relock(actor Actor) Status {
+	l.mutex.Lock()
+	if !l.unlockedUntil.IsZero() {
+		l.relock("relock", actor)
+	}
+	l.mutex.Unlock()
+
+	return l.Status()
+}
+
+// Stop cancels the pending expiry timer
+func (l *Lock) Stop() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// expire relocks when the unlock window has passed; the caller must hold the mutex
+func (l *Lock) expire() {
+	if !l.unlockedUntil.IsZero() && !l.clock.Now().Before(l.unlockedUntil) {
+		l.relock("expire", Actor{})
+	}
+}
+
+// relock closes the unlock window; the caller must hold the mutex
+func (l *Lock) relock(action string, actor Actor) {
+	l.unlockedUntil = time.Time{}
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.audit(action, actor)
+}
+
+// audit logs a lock change and publishes it on the bus; the caller must hold the mutex
+func (l *Lock) audit(action string, actor Actor) {
+	data := map[string]interface{}{
+		"action":      action,
+		"remote_addr": actor.RemoteAddr,
+		"request_id":  actor.RequestID,
+	}
+	attrs := []any{"action", action, "remote_addr", actor.RemoteAddr, "request_id", actor.RequestID}
+	if !l.unlockedUntil.IsZero() {
+		data["unlocked_until"] = l.unlockedUntil
+		attrs = append(attrs, "unlocked_until", l.unlockedUntil)
+	}
+
+	if action == "unlock" {
+		l.logger.Warn("Admin API unlocked", attrs...)
+	} else {
+		l.logger.Info("Admin API locked", attrs...)
+	}
+	l.events.Publish(events.TopicAdminLock, data)
+}
+
+// Middleware rejects mutating admin requests with 403 and the lock status while locked.
+// Reads and the lock's own endpoints pass through.
+func Middleware(lock *Lock, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !lock.ReadOnly() || !isMutation(r.Method) || isExempt(r.URL.Path, exempt) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			status := lock.Status()
+			if !status.Locked {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			lock.logger.Warn("Admin mutation rejected while locked",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"request_id", r.Header.Get("X-Request-ID"),
+			)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Code:      "admin_locked",
+				Message:   "The admin API is read-only; unlock it to make changes",
+				RequestID: r.Header.Get("X-Request-ID"),
+				Details:   map[string]interface{}{"lock": status},
+			})
+		})
+	}
+}
+
+// isMutation reports whether a request method changes state
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// isExempt reports whether the path is one of the exempt paths
+func isExempt(path string, exempt []string) bool {
+	for _, p := range exempt {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
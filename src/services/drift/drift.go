@@ -0,0 +1,148 @@
+package drift
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
+)
+
+// Status is the result of a drift check.
+// Changes describe how the running configuration differs from the file, so a
+// route created through the admin API but never persisted shows as added.
+type Status struct {
+	Drifted   bool            `json:"drifted"`
+	Changes   []config.Change `json:"changes"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Detector periodically compares the configuration file with the running configuration
+type Detector struct {
+	path     string
+	running  *config.Config
+	interval time.Duration
+	logger   *slog.Logger
+	events   *events.Bus
+	status   Status
+	mutex    sync.Mutex
+	cancel   context.CancelFunc
+}
+
+// NewDetector creates a detector comparing the file at path with the running configuration
+func NewDetector(path string, running *config.Config, interval time.Duration, logger *slog.Logger) *Detector {
+	return &Detector{
+		path:     path,
+		running:  running,
+		interval: interval,
+		logger:   logger,
+		status:   Status{Changes: []config.Change{}},
+	}
+}
+
+// SetEventBus sets the bus that drift transitions are published to
+func (d *Detector) SetEventBus(bus *events.Bus) {
+	d.events = bus
+}
+
+// SetRunning replaces the running configuration the file is compared with, after a reload
+func (d *Detector) SetRunning(running *config.Config) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.running = running
+}
+
+// Start checks for drift every interval until the context is cancelled or Stop is called
+func (d *Detector) Start(ctx context.Context) {
+	if d.interval <= 0 {
+		return
+	}
+
+	ctx, d.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		d.Check()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Check()
+			}
+		}
+	}()
+}
+
+// Stop stops periodic checks
+func (d *Detector) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// Status returns the result of the last check
+func (d *Detector) Status() Status {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.status
+}
+
+// Check loads the configuration file and compares it with the running configuration.
+// A file that can't be loaded is reported as an error and leaves the drift state unchanged.
+func (d *Detector) Check() Status {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.status.CheckedAt = time.Now()
+
+	file, err := config.Load(d.path)
+	if err == nil {
+		// Validation fills in the same defaults the running configuration received
+		err = file.Validate()
+	}
+	if err != nil {
+		d.logger.Error("Failed to check config drift", "path", d.path, "error", err)
+		d.status.Error = err.Error()
+		return d.status
+	}
+
+	changes := config.Diff(file, d.running)
+	if changes == nil {
+		changes = []config.Change{}
+	}
+	drifted := len(changes) > 0
+
+	if drifted != d.status.Drifted {
+		d.logTransition(drifted, changes)
+	}
+
+	d.status.Drifted = drifted
+	d.status.Changes = changes
+	d.status.Error = ""
+	services.SetConfigDrift(drifted)
+	return d.status
+}
+
+// logTransition logs and publishes drift appearing or resolving
+func (d *Detector) logTransition(drifted bool, changes []config.Change) {
+	if drifted {
+		summary := make([]string, len(changes))
+		for i, change := range changes {
+			summary[i] = change.String()
+		}
+		d.logger.Warn("Config drift detected", "path", d.path, "changes", summary)
+	} else {
+		d.logger.Warn("Config drift resolved", "path", d.path)
+	}
+
+	d.events.Publish(events.TopicConfigDrift, map[string]interface{}{
+		"drifted": drifted,
+		"changes": len(changes),
+	})
+}
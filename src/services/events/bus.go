@@ -0,0 +1,226 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Event topics published on the bus
+const (
+	TopicHealth          = "health"
+	TopicCircuitBreaker  = "circuit_breaker"
+	TopicConfigReload    = "config_reload"
+	TopicConfigDrift     = "config_drift"
+	TopicMemory          = "memory"
+	TopicFeatureFlag     = "feature_flag"
+	TopicAdminLock       = "admin_lock"
+	TopicBackendOverride = "backend_override"
+)
+
+const (
+	defaultBacklogSize    = 256
+	defaultMaxSubscribers = 32
+	subscriberBufferSize  = 64
+)
+
+var (
+	// ErrTooManySubscribers is returned when the subscriber limit is reached
+	ErrTooManySubscribers = errors.New("too many event subscribers")
+	// ErrBusClosed is returned when subscribing to a closed bus
+	ErrBusClosed = errors.New("event bus is closed")
+)
+
+// Event represents a single event published on the bus
+type Event struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// Bus is an in-memory publish/subscribe bus for internal events.
+// It keeps a small backlog so reconnecting subscribers can replay missed events.
+type Bus struct {
+	backlog        []Event
+	backlogSize    int
+	nextID         uint64
+	subscribers    map[*Subscription]struct{}
+	maxSubscribers int
+	closed         bool
+	mutex          sync.Mutex
+}
+
+// Subscription receives events for a set of topics
+type Subscription struct {
+	bus    *Bus
+	topics map[string]bool
+	events chan Event
+	once   sync.Once
+}
+
+// NewBus creates a new event bus
+func NewBus(backlogSize, maxSubscribers int) *Bus {
+	if backlogSize <= 0 {
+		backlogSize = defaultBacklogSize
+	}
+	if maxSubscribers <= 0 {
+		maxSubscribers = defaultMaxSubscribers
+	}
+
+	return &Bus{
+		backlog:        make([]Event, 0, backlogSize),
+		backlogSize:    backlogSize,
+		subscribers:    make(map[*Subscription]struct{}),
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+// Publish publishes an event to all subscribers of the topic.
+// Publishing on a nil bus is a no-op so callers don't need to check for one.
+func (b *Bus) Publish(topic string, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.nextID++
+	event := Event{
+		ID:        b.nextID,
+		Type:      topic,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+
+	if len(b.backlog) == b.backlogSize {
+		b.backlog = append(b.backlog[:0], b.backlog[1:]...)
+	}
+	b.backlog = append(b.backlog, event)
+
+	for sub := range b.subscribers {
+		if !sub.matches(topic) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Slow consumer: drop it so it can reconnect and replay from the backlog
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the given topics (all topics when empty).
+// Backlogged events newer than lastEventID are returned for replay.
+func (b *Bus) Subscribe(topics []string, lastEventID uint64) (*Subscription, []Event, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, nil, ErrBusClosed
+	}
+
+	if len(b.subscribers) >= b.maxSubscribers {
+		return nil, nil, ErrTooManySubscribers
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		topics: make(map[string]bool),
+		events: make(chan Event, subscriberBufferSize),
+	}
+	for _, topic := range topics {
+		if topic != "" {
+			sub.topics[topic] = true
+		}
+	}
+
+	var replay []Event
+	if lastEventID > 0 {
+		for _, event := range b.backlog {
+			if event.ID > lastEventID && sub.matches(event.Type) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	b.subscribers[sub] = struct{}{}
+	return sub, replay, nil
+}
+
+// Backlog returns the backlogged events for the given topics (all topics when empty), oldest first
+func (b *Bus) Backlog(topics []string) []Event {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	filter := &Subscription{topics: make(map[string]bool)}
+	for _, topic := range topics {
+		if topic != "" {
+			filter.topics[topic] = true
+		}
+	}
+
+	events := []Event{}
+	for _, event := range b.backlog {
+		if filter.matches(event.Type) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// SubscriberCount returns the number of active subscribers
+func (b *Bus) SubscriberCount() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.subscribers)
+}
+
+// Close closes the bus and all active subscriptions
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.removeLocked(sub)
+	}
+}
+
+// removeLocked removes a subscriber; the caller must hold the bus mutex
+func (b *Bus) removeLocked(sub *Subscription) {
+	if _, exists := b.subscribers[sub]; !exists {
+		return
+	}
+	delete(b.subscribers, sub)
+	sub.once.Do(func() {
+		close(sub.events)
+	})
+}
+
+// Events returns the channel of events; it is closed when the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unsubscribes from the bus
+func (s *Subscription) Close() {
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+	s.bus.removeLocked(s)
+}
+
+// matches checks if the subscription is interested in the topic
+func (s *Subscription) matches(topic string) bool {
+	return len(s.topics) == 0 || s.topics[topic]
+}
@@ -0,0 +1,269 @@
+package flags
+
+import (
+	"hash/fnv"
+	"net/netip"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// Flags consulted by the router
+const (
+	CanaryRouting = "canary_routing"
+	ResponseCache = "response_cache"
+)
+
+// builtinDefaults are used for flags that are neither configured nor overridden
+var builtinDefaults = map[string]bool{
+	CanaryRouting: true,
+	ResponseCache: true,
+}
+
+// Flag describes the current state of a feature flag
+type Flag struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Overridden bool   `json:"overridden"`
+	Percent    *int   `json:"percent,omitempty"` // share of clients the flag is on for, when rolled out gradually
+}
+
+// Target is what a flag is evaluated against for a request
+type Target struct {
+	Route    string
+	Backend  string
+	APIKey   string
+	ClientIP string
+}
+
+// override is a runtime change to a flag; percent replaces the rollout percent when set
+type override struct {
+	enabled bool
+	percent *int
+}
+
+// rollout is a configured rollout with its allowed networks parsed
+type rollout struct {
+	models.FlagRollout
+	networks []netip.Prefix
+}
+
+// Store holds feature flags from configuration plus runtime overrides
+type Store struct {
+	configured map[string]bool
+	rollouts   map[string]rollout
+	overrides  map[string]override
+	mutex      sync.RWMutex
+}
+
+// NewStore creates a flag store backed by the configured flags
+func NewStore(configured map[string]bool) *Store {
+	s := &Store{
+		configured: make(map[string]bool),
+		rollouts:   make(map[string]rollout),
+		overrides:  make(map[string]override),
+	}
+	for name, enabled := range configured {
+		s.configured[name] = enabled
+	}
+	return s
+}
+
+// SetRollouts sets the flags rolled out to part of the traffic.
+// A flag with a rollout is enabled unless configured or overridden off.
+// Allowed IPs that don't parse are skipped; config validation rejects them.
+func (s *Store) SetRollouts(rollouts map[string]models.FlagRollout) {
+	parsed := make(map[string]rollout, len(rollouts))
+	for name, config := range rollouts {
+		r := rollout{FlagRollout: config}
+		for _, ip := range config.AllowIPs {
+			if prefix, err := models.ParseIPPrefix(ip); err == nil {
+				r.networks = append(r.networks, prefix)
+			}
+		}
+		parsed[name] = r
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rollouts = parsed
+}
+
+// Enabled reports whether a flag is on. Overrides take precedence over configuration.
+// A flag rolled out to part of the traffic is on here; EnabledFor decides per request.
+// A nil store reports built-in defaults so callers don't need to check for one.
+func (s *Store) Enabled(name string) bool {
+	if s == nil {
+		return builtinDefaults[name]
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.enabled(name)
+}
+
+// enabled reports the flag's on/off state; the caller holds the mutex
+func (s *Store) enabled(name string) bool {
+	if override, ok := s.overrides[name]; ok {
+		return override.enabled
+	}
+	if enabled, ok := s.configured[name]; ok {
+		return enabled
+	}
+	if _, ok := s.rollouts[name]; ok {
+		return true
+	}
+	return builtinDefaults[name]
+}
+
+// EnabledFor reports whether a flag is on for a request to the target.
+// A rolled out flag applies only to its routes and backends, is always on for
+// allowed API keys and IPs, and is on for its percent of the other clients.
+func (s *Store) EnabledFor(name string, target Target) bool {
+	if s == nil {
+		return builtinDefaults[name]
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.enabled(name) {
+		return false
+	}
+
+	percent := 100
+	rollout, rolledOut := s.rollouts[name]
+	if rolledOut {
+		if len(rollout.Routes) > 0 && !slices.Contains(rollout.Routes, target.Route) {
+			return false
+		}
+		if len(rollout.Backends) > 0 && !slices.Contains(rollout.Backends, target.Backend) {
+			return false
+		}
+		if rollout.allows(target) {
+			return true
+		}
+		percent = rollout.Percent
+	}
+	if override, ok := s.overrides[name]; ok && override.percent != nil {
+		percent = *override.percent
+	}
+
+	if percent >= 100 {
+		return true
+	}
+	return Bucket(name, target) < percent
+}
+
+// allows reports whether the target's API key or client IP is on the allow-list
+func (r rollout) allows(target Target) bool {
+	if target.APIKey != "" && slices.Contains(r.AllowAPIKeys, target.APIKey) {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(target.ClientIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range r.networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bucket maps a client to a bucket in [0, 100) for a flag. Clients are identified
+// by API key, or by IP without one. The flag name is hashed in so each flag's
+// rollout picks a different set of clients.
+func Bucket(name string, target Target) int {
+	key := target.APIKey
+	if key == "" {
+		key = target.ClientIP
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
+
+// Set overrides a flag at runtime
+func (s *Store) Set(name string, enabled bool) {
+	s.Override(name, enabled, nil)
+}
+
+// Override overrides a flag at runtime, along with its rollout percent when percent is set
+func (s *Store) Override(name string, enabled bool, percent *int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.overrides[name] = override{enabled: enabled, percent: percent}
+}
+
+// Reset removes a runtime override and returns whether one existed
+func (s *Store) Reset(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.overrides[name]
+	delete(s.overrides, name)
+	return exists
+}
+
+// Get returns the current state of a flag
+func (s *Store) Get(name string) Flag {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.flag(name)
+}
+
+// flag describes a flag's current state; the caller holds the mutex
+func (s *Store) flag(name string) Flag {
+	flag := Flag{Name: name, Enabled: s.enabled(name)}
+	if rollout, ok := s.rollouts[name]; ok {
+		percent := rollout.Percent
+		flag.Percent = &percent
+	}
+	if override, ok := s.overrides[name]; ok {
+		flag.Overridden = true
+		if override.percent != nil {
+			percent := *override.percent
+			flag.Percent = &percent
+		}
+	}
+	return flag
+}
+
+// All returns every known flag sorted by name
+func (s *Store) All() []Flag {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names := make(map[string]struct{})
+	for name := range builtinDefaults {
+		names[name] = struct{}{}
+	}
+	for name := range s.configured {
+		names[name] = struct{}{}
+	}
+	for name := range s.rollouts {
+		names[name] = struct{}{}
+	}
+	for name := range s.overrides {
+		names[name] = struct{}{}
+	}
+
+	result := make([]Flag, 0, len(names))
+	for name := range names {
+		result = append(result, s.flag(name))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
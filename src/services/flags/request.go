@@ -0,0 +1,59 @@
+package flags
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// evaluationKey is the request context key of a request's flag evaluation
+type evaluationKey struct{}
+
+// Evaluation evaluates flags for one request. Each flag is evaluated once,
+// so every middleware that checks it during the request sees the same answer.
+type Evaluation struct {
+	store   *Store
+	target  Target
+	results map[string]bool
+	mutex   sync.Mutex
+}
+
+// WithEvaluation attaches a flag evaluation for the target to the request
+func WithEvaluation(r *http.Request, store *Store, target Target) (*http.Request, *Evaluation) {
+	e := &Evaluation{store: store, target: target, results: make(map[string]bool)}
+	return r.WithContext(context.WithValue(r.Context(), evaluationKey{}, e)), e
+}
+
+// On reports whether the flag is on for this request
+func (e *Evaluation) On(name string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	on, ok := e.results[name]
+	if !ok {
+		on = e.store.EnabledFor(name, e.target)
+		e.results[name] = on
+	}
+	return on
+}
+
+// Results returns the flags evaluated so far and whether each was on
+func (e *Evaluation) Results() map[string]bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	results := make(map[string]bool, len(e.results))
+	for name, on := range e.results {
+		results[name] = on
+	}
+	return results
+}
+
+// On reports whether the flag is on for the request. It uses the evaluation attached
+// with WithEvaluation, and evaluates against an empty target for requests without one.
+func (s *Store) On(r *http.Request, name string) bool {
+	if e, ok := r.Context().Value(evaluationKey{}).(*Evaluation); ok {
+		return e.On(name)
+	}
+	return s.EnabledFor(name, Target{})
+}
@@ -2,24 +2,43 @@ package health
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/transport"
 	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
 )
 
 // Checker performs health checks on backend services
 type Checker struct {
-	config    *config.Config
-	logger    *slog.Logger
-	statuses  map[string]*models.HealthStatus
-	mutex     sync.RWMutex
-	ctx       context.Context
-	cancel    context.CancelFunc
-	client    *http.Client
+	config           *config.Config
+	logger           *slog.Logger
+	statuses         map[string]*models.HealthStatus
+	mutex            sync.RWMutex
+	ctx              context.Context
+	cancel           context.CancelFunc
+	client           *http.Client
+	clients          map[string]*backendClient
+	checks           map[string]*backendCheck // by backend ID, for every enabled backend
+	events           *events.Bus
+	onEndpointHealth func(backendID, endpointURL string, healthy bool)
+}
+
+// backendCheck is a backend's settings as checked and the cancel function of its check
+// loop, nil when the backend's health checks are disabled
+type backendCheck struct {
+	backend models.BackendService
+	cancel  context.CancelFunc
 }
 
 // NewChecker creates a new health checker
@@ -31,29 +50,97 @@ func NewChecker(cfg *config.Config, logger *slog.Logger) *Checker {
 		client: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		clients: make(map[string]*backendClient),
+		checks:  make(map[string]*backendCheck),
 	}
 }
 
+// SetEventBus sets the bus that health transitions are published to
+func (c *Checker) SetEventBus(bus *events.Bus) {
+	c.events = bus
+}
+
+// SetEndpointHealthHandler sets the function told of each endpoint's first check result and
+// of every later health transition, e.g. to update the backend's load balancer. It is called
+// with the checker's lock held and must not call back into the checker.
+func (c *Checker) SetEndpointHealthHandler(handler func(backendID, endpointURL string, healthy bool)) {
+	c.onEndpointHealth = handler
+}
+
 // Start starts the health checker
 func (c *Checker) Start(ctx context.Context) {
+	c.mutex.Lock()
 	c.ctx, c.cancel = context.WithCancel(ctx)
+	cfg := c.config
+	c.mutex.Unlock()
 	
-	// Initialize health status for each backend
-	for _, backend := range c.config.Backends {
-		if !backend.Enabled {
+	c.Update(cfg)
+}
+
+// Update applies a reloaded configuration. Checks of removed and disabled backends stop and
+// their statuses are dropped; added backends start checking, and changed ones start over so
+// their first results reach the endpoint health handler. Unchanged backends keep their
+// schedule, and their last results are passed to the handler again, as the router may
+// have rebuilt their balancers. Before Start, only the configuration is replaced.
+func (c *Checker) Update(cfg *config.Config) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.config = cfg
+	if c.ctx == nil {
+		return
+	}
+
+	enabled := make(map[string]models.BackendService)
+	for _, backend := range cfg.Backends {
+		if backend.Enabled {
+			enabled[backend.ID] = backend
+		}
+	}
+
+	for id, check := range c.checks {
+		if backend, exists := enabled[id]; exists && reflect.DeepEqual(check.backend, backend) {
+			c.repeatEndpointHealth(id)
 			continue
 		}
-		
+		if check.cancel != nil {
+			check.cancel()
+		}
+		delete(c.checks, id)
+		delete(c.statuses, id)
+		delete(c.clients, id)
+	}
+
+	for _, backend := range cfg.Backends {
+		if _, running := c.checks[backend.ID]; running || !backend.Enabled {
+			continue
+		}
+
 		c.statuses[backend.ID] = &models.HealthStatus{
 			ServiceID: backend.ID,
 			Status:    "unknown",
 			LastCheck: time.Now(),
 		}
-		
-		// Start health check goroutine for this backend
+
+		check := &backendCheck{backend: backend}
 		if backend.HealthCheck.Enabled {
-			go c.checkBackendHealth(backend)
+			var ctx context.Context
+			ctx, check.cancel = context.WithCancel(c.ctx)
+			go c.checkBackendHealth(ctx, backend)
 		}
+		c.checks[backend.ID] = check
+	}
+}
+
+// repeatEndpointHealth passes a backend's last endpoint results to the endpoint health
+// handler; the caller must hold the mutex
+func (c *Checker) repeatEndpointHealth(backendID string) {
+	status, exists := c.statuses[backendID]
+	if !exists || c.onEndpointHealth == nil {
+		return
+	}
+	for url, health := range status.EndpointStatuses {
+		c.onEndpointHealth(backendID, url, health.Healthy)
 	}
 }
 
@@ -81,6 +168,37 @@ func (c *Checker) GetStatus(serviceID string) *models.HealthStatus {
 	return status
 }
 
+// GetEndpointHealth returns a copy of the last health check result for an endpoint
+func (c *Checker) GetEndpointHealth(backendID, url string) (models.EndpointHealth, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	
+	status, exists := c.statuses[backendID]
+	if !exists {
+		return models.EndpointHealth{}, false
+	}
+	
+	health, exists := status.EndpointStatuses[url]
+	if !exists {
+		return models.EndpointHealth{}, false
+	}
+	
+	return *health, true
+}
+
+// DominantFailureReason returns the most common reason among a service's recent
+// failed checks, or "" when it has none
+func (c *Checker) DominantFailureReason(serviceID string) models.FailureReason {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	
+	status, exists := c.statuses[serviceID]
+	if !exists {
+		return ""
+	}
+	return status.DominantReason()
+}
+
 // GetAllStatuses returns all health statuses
 func (c *Checker) GetAllStatuses() map[string]*models.HealthStatus {
 	c.mutex.RLock()
@@ -95,29 +213,38 @@ func (c *Checker) GetAllStatuses() map[string]*models.HealthStatus {
 	return result
 }
 
-// checkBackendHealth performs health checks for a backend
-func (c *Checker) checkBackendHealth(backend models.BackendService) {
-	ticker := time.NewTicker(backend.HealthCheck.Interval)
-	defer ticker.Stop()
-	
+// checkBackendHealth performs health checks for a backend.
+// Each endpoint keeps its own schedule so failing endpoints can back off
+// without slowing checks of the healthy ones.
+func (c *Checker) checkBackendHealth(ctx context.Context, backend models.BackendService) {
 	// Perform initial check
-	c.performHealthCheck(&backend)
+	next := c.performHealthCheck(ctx, &backend)
+	
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
 	
 	for {
 		select {
-		case <-c.ctx.Done():
+		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			c.performHealthCheck(&backend)
+		case <-timer.C:
+			next = c.performHealthCheck(ctx, &backend)
+			timer.Reset(time.Until(next))
 		}
 	}
 }
 
-// performHealthCheck performs a single health check
-func (c *Checker) performHealthCheck(backend *models.BackendService) {
+// performHealthCheck checks the backend's endpoints that are due and returns
+// when the next endpoint is due. Nothing is checked once ctx is cancelled, as
+// the backend may have been removed while waiting for the lock.
+func (c *Checker) performHealthCheck(ctx context.Context, backend *models.BackendService) time.Time {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
+	if ctx.Err() != nil {
+		return time.Now()
+	}
+	
 	status, exists := c.statuses[backend.ID]
 	if !exists {
 		status = &models.HealthStatus{
@@ -127,12 +254,23 @@ func (c *Checker) performHealthCheck(backend *models.BackendService) {
 		c.statuses[backend.ID] = status
 	}
 	
-	// Check each endpoint
+	// Check each endpoint that is due
 	allHealthy := true
 	var lastError string
+	var next time.Time
 	
 	for _, endpoint := range backend.Endpoints {
-		healthy, responseTime, err := c.checkEndpoint(endpoint.URL, backend.HealthCheck)
+		previous, checked := status.EndpointStatuses[endpoint.URL]
+		if checked && time.Now().Before(previous.NextCheck) {
+			if !previous.Healthy {
+				allHealthy = false
+				lastError = previous.Error
+			}
+			next = earliest(next, previous.NextCheck)
+			continue
+		}
+		
+		healthy, responseTime, err := c.checkEndpoint(endpoint.URL, backend)
 		
 		endpointHealth := &models.EndpointHealth{
 			URL:          endpoint.URL,
@@ -143,12 +281,55 @@ func (c *Checker) performHealthCheck(backend *models.BackendService) {
 		
 		if err != nil {
 			endpointHealth.Error = err.Error()
+			endpointHealth.Reason = models.ClassifyFailure(err)
 			lastError = err.Error()
 			allHealthy = false
+			
+			status.RecordError(models.HealthError{
+				Time:     endpointHealth.LastCheck,
+				Endpoint: endpoint.URL,
+				Reason:   endpointHealth.Reason,
+				Error:    endpointHealth.Error,
+			})
+			services.RecordHealthCheckFailure(backend.ID, string(endpointHealth.Reason))
+		}
+		
+		// Track streaks; a success snaps the interval back to the configured one
+		if checked {
+			endpointHealth.ConsecutiveOK = previous.ConsecutiveOK
+			endpointHealth.ConsecutiveFail = previous.ConsecutiveFail
+		}
+		if healthy {
+			endpointHealth.ConsecutiveOK++
+			endpointHealth.ConsecutiveFail = 0
+		} else {
+			endpointHealth.ConsecutiveFail++
+			endpointHealth.ConsecutiveOK = 0
+		}
+		endpointHealth.EffectiveInterval = backend.HealthCheck.EffectiveInterval(endpointHealth.ConsecutiveFail)
+		endpointHealth.NextCheck = endpointHealth.LastCheck.Add(endpointHealth.EffectiveInterval)
+		next = earliest(next, endpointHealth.NextCheck)
+		
+		if checked && endpointHealth.EffectiveInterval != previous.EffectiveInterval {
+			c.logger.Debug("Health check interval changed",
+				"backend", backend.ID,
+				"endpoint", endpoint.URL,
+				"interval", endpointHealth.EffectiveInterval,
+				"consecutive_fail", endpointHealth.ConsecutiveFail,
+			)
+		}
+		
+		// Publish transitions, including the first check after startup
+		if !checked || previous.Healthy != healthy {
+			c.publishTransition(backend.ID, endpointHealth, checked && previous.Healthy, checked)
+			if c.onEndpointHealth != nil {
+				c.onEndpointHealth(backend.ID, endpoint.URL, healthy)
+			}
 		}
 		
 		// Update endpoint status
 		status.UpdateEndpoint(endpoint.URL, endpointHealth)
+		services.SetBackendHealth(backend.ID, endpoint.URL, healthy)
 	}
 	
 	// Update overall status
@@ -157,10 +338,114 @@ func (c *Checker) performHealthCheck(backend *models.BackendService) {
 	} else {
 		status.Update(false, 0, lastError)
 	}
+	
+	if next.IsZero() {
+		next = time.Now().Add(backend.HealthCheck.Interval)
+	}
+	return next
+}
+
+// earliest returns the earlier of two times, treating zero as unset
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// publishTransition publishes an endpoint health transition event
+func (c *Checker) publishTransition(backendID string, health *models.EndpointHealth, wasHealthy, checked bool) {
+	previous := "unknown"
+	if checked {
+		previous = healthState(wasHealthy)
+	}
+	
+	c.events.Publish(events.TopicHealth, map[string]interface{}{
+		"backend":  backendID,
+		"endpoint": health.URL,
+		"previous": previous,
+		"status":   healthState(health.Healthy),
+		"error":    health.Error,
+		"reason":   string(health.Reason),
+	})
+}
+
+// healthState converts a health flag to its status string
+func healthState(healthy bool) string {
+	if healthy {
+		return "healthy"
+	}
+	return "unhealthy"
+}
+
+// checkEndpoint checks a single endpoint using the backend's configured check type
+func (c *Checker) checkEndpoint(endpointURL string, backend *models.BackendService) (bool, time.Duration, error) {
+	config := backend.HealthCheck
+	if config.Type == "tcp" {
+		return c.checkEndpointTCP(endpointURL, config)
+	}
+
+	client, err := c.clientFor(backend)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if config.Type == "grpc" {
+		return c.checkEndpointGRPC(client, endpointURL, config, backend.IsH2C())
+	}
+	return c.checkEndpointHTTP(client, endpointURL, config)
+}
+
+// backendClient is an HTTP client built for a backend's protocol, TLS and transport settings
+type backendClient struct {
+	protocol  string
+	tls       *models.TLSConfig
+	transport *models.TransportConfig
+	client    *http.Client
 }
 
-// checkEndpoint checks a single endpoint
-func (c *Checker) checkEndpoint(url string, config models.HealthCheckConfig) (bool, time.Duration, error) {
+// clientFor returns the client for a backend, using the same transport as the proxy
+// so health status doesn't diverge from proxy behavior; the caller must hold the mutex
+func (c *Checker) clientFor(backend *models.BackendService) (*http.Client, error) {
+	if cached, ok := c.clients[backend.ID]; ok &&
+		cached.protocol == backend.Protocol && sameSettings(cached.tls, backend.TLS) &&
+		sameSettings(cached.transport, backend.Transport) {
+		return cached.client, nil
+	}
+
+	rt, err := transport.ForBackend(*backend)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.client
+	if rt != nil {
+		client = &http.Client{Timeout: c.client.Timeout, Transport: rt}
+	}
+
+	cached := &backendClient{protocol: backend.Protocol, client: client}
+	if backend.TLS != nil {
+		tlsCopy := *backend.TLS
+		cached.tls = &tlsCopy
+	}
+	if backend.Transport != nil {
+		transportCopy := *backend.Transport
+		cached.transport = &transportCopy
+	}
+	c.clients[backend.ID] = cached
+	return client, nil
+}
+
+// sameSettings reports whether two optional settings blocks are equal
+func sameSettings[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// checkEndpointHTTP checks an endpoint with an HTTP GET on the health path
+func (c *Checker) checkEndpointHTTP(client *http.Client, url string, config models.HealthCheckConfig) (bool, time.Duration, error) {
 	healthURL := url + config.Path
 	
 	start := time.Now()
@@ -173,7 +458,7 @@ func (c *Checker) checkEndpoint(url string, config models.HealthCheckConfig) (bo
 	defer cancel()
 	req = req.WithContext(ctx)
 	
-	resp, err := c.client.Do(req)
+	resp, err := client.Do(req)
 	duration := time.Since(start)
 	
 	if err != nil {
@@ -183,8 +468,52 @@ func (c *Checker) checkEndpoint(url string, config models.HealthCheckConfig) (bo
 	
 	// Check if status code is expected
 	if !config.IsExpectedStatus(resp.StatusCode) {
-		return false, duration, nil
+		return false, duration, fmt.Errorf("%w %d", models.ErrUnexpectedStatus, resp.StatusCode)
 	}
 	
 	return true, duration, nil
-}
\ No newline at end of file
+}
+
+// checkEndpointTCP checks that a TCP connection to the endpoint can be opened
+func (c *Checker) checkEndpointTCP(endpointURL string, config models.HealthCheckConfig) (bool, time.Duration, error) {
+	address, err := endpointAddress(endpointURL)
+	if err != nil {
+		return false, 0, err
+	}
+	
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(c.ctx, "tcp", address)
+	duration := time.Since(start)
+	
+	if err != nil {
+		return false, duration, err
+	}
+	conn.Close()
+	
+	return true, duration, nil
+}
+
+// endpointAddress returns the host:port to dial for an endpoint URL
+func endpointAddress(endpointURL string) (string, error) {
+	// Bare host:port endpoints are dialed as-is
+	if !strings.Contains(endpointURL, "://") {
+		return endpointURL, nil
+	}
+	
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return "", err
+	}
+	
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	
+	switch u.Scheme {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	default:
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	}
+}
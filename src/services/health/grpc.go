@@ -0,0 +1,134 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// grpcHealthCheckMethod is the method path of the standard gRPC health checking protocol
+const grpcHealthCheckMethod = "/grpc.health.v1.Health/Check"
+
+// grpcServing is the SERVING value of HealthCheckResponse.ServingStatus
+const grpcServing = 1
+
+// checkEndpointGRPC checks an endpoint with the grpc.health.v1 Health/Check RPC.
+// h2c backends are checked over cleartext HTTP/2; otherwise the standard library
+// HTTP/2 client is used, so the endpoint must serve TLS.
+func (c *Checker) checkEndpointGRPC(client *http.Client, endpointURL string, config models.HealthCheckConfig, h2c bool) (bool, time.Duration, error) {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if !h2c && u.Scheme != "https" {
+		return false, 0, fmt.Errorf("gRPC health check requires an https or h2c endpoint: %s", endpointURL)
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, config.Timeout)
+	defer cancel()
+
+	// An empty HealthCheckRequest asks for the overall server status
+	body := []byte{0, 0, 0, 0, 0}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(endpointURL, "/")+grpcHealthCheckMethod, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, time.Since(start), err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	duration := time.Since(start)
+	if err != nil {
+		return false, duration, err
+	}
+
+	if resp.ProtoMajor != 2 {
+		return false, duration, fmt.Errorf("gRPC health check requires HTTP/2, got %s", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, duration, fmt.Errorf("gRPC health check returned HTTP status %d: %w", resp.StatusCode, models.ErrUnexpectedStatus)
+	}
+
+	if code, message := grpcStatus(resp); code != "0" {
+		return false, duration, fmt.Errorf("gRPC health check failed with status %q: %s: %w", code, message, models.ErrUnexpectedStatus)
+	}
+
+	status, err := decodeHealthCheckResponse(payload)
+	if err != nil {
+		return false, duration, err
+	}
+	if status != grpcServing {
+		return false, duration, fmt.Errorf("gRPC server is not serving (status %d): %w", status, models.ErrUnexpectedStatus)
+	}
+
+	return true, duration, nil
+}
+
+// grpcStatus returns the gRPC status of a response. It is normally sent as a trailer,
+// but trailers-only error responses carry it in the headers.
+func grpcStatus(resp *http.Response) (string, string) {
+	if code := resp.Trailer.Get("Grpc-Status"); code != "" {
+		return code, resp.Trailer.Get("Grpc-Message")
+	}
+	return resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+}
+
+// decodeHealthCheckResponse decodes the serving status from a length-prefixed HealthCheckResponse
+func decodeHealthCheckResponse(payload []byte) (int, error) {
+	if len(payload) < 5 {
+		return 0, fmt.Errorf("gRPC health check response is truncated")
+	}
+	if payload[0] != 0 {
+		return 0, fmt.Errorf("compressed gRPC health check responses are not supported")
+	}
+
+	length := binary.BigEndian.Uint32(payload[1:5])
+	if uint32(len(payload)-5) < length {
+		return 0, fmt.Errorf("gRPC health check response is truncated")
+	}
+	message := payload[5 : 5+length]
+
+	// status is field 1; proto3 omits it when it is UNKNOWN (0)
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		message = message[n:]
+
+		if num == 1 && typ == protowire.VarintType {
+			value, n := protowire.ConsumeVarint(message)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			return int(value), nil
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, message)
+		if n < 0 {
+			return 0, protowire.ParseError(n)
+		}
+		message = message[n:]
+	}
+
+	return 0, nil
+}
@@ -1,10 +1,19 @@
 package loadbalancer
 
 import (
+	"cmp"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/your-org/ryohi-router/src/lib/clock"
 	"github.com/your-org/ryohi-router/src/models"
 )
 
@@ -13,6 +22,61 @@ type LoadBalancer interface {
 	Next() *models.EndpointConfig
 	MarkHealthy(endpoint *models.EndpointConfig)
 	MarkUnhealthy(endpoint *models.EndpointConfig)
+	// MarkDraining starts or stops draining an endpoint: a draining endpoint gets no new
+	// requests but keeps its health state, so requests in flight can finish
+	MarkDraining(endpoint *models.EndpointConfig, draining bool)
+	// AddEndpoint adds an endpoint, or updates the weight and metadata of the endpoint with
+	// its URL, keeping that endpoint's health, drain state and statistics
+	AddEndpoint(endpoint models.EndpointConfig)
+	// RemoveEndpoint removes the endpoint with the URL and its statistics, reporting whether it existed
+	RemoveEndpoint(endpointURL string) bool
+	Endpoints() []models.EndpointConfig
+	// Observe reports how long a request to an endpoint took and the error it failed with, if any;
+	// algorithms that don't use it ignore it
+	Observe(endpointURL string, d time.Duration, err error)
+}
+
+// Seeder is implemented by load balancers that make random choices,
+// so simulations can reproduce their picks
+type Seeder interface {
+	Seed(seed int64)
+}
+
+// Clocked is implemented by load balancers that measure time,
+// so simulations can run them on a virtual clock
+type Clocked interface {
+	SetClock(c clock.Clock)
+}
+
+// Keyed is implemented by load balancers that pick endpoints by a request attribute;
+// the router calls NextFor instead of Next for them
+type Keyed interface {
+	NextFor(req *http.Request) *models.EndpointConfig
+}
+
+// withEndpoint returns a copy of endpoints with the endpoint added, or with the weight and
+// metadata of the endpoint with its URL updated. Balancers hand out pointers into their
+// endpoint slice, so it is replaced rather than modified in place.
+func withEndpoint(endpoints []models.EndpointConfig, endpoint models.EndpointConfig) []models.EndpointConfig {
+	updated := slices.Clone(endpoints)
+	for i := range updated {
+		if updated[i].URL == endpoint.URL {
+			updated[i].Weight = endpoint.Weight
+			updated[i].Metadata = endpoint.Metadata
+			return updated
+		}
+	}
+	return append(updated, endpoint)
+}
+
+// withoutEndpoint returns a copy of endpoints without the endpoint with the URL and the
+// index it had, or endpoints itself and -1 if there is none
+func withoutEndpoint(endpoints []models.EndpointConfig, endpointURL string) ([]models.EndpointConfig, int) {
+	index := slices.IndexFunc(endpoints, func(ep models.EndpointConfig) bool { return ep.URL == endpointURL })
+	if index < 0 {
+		return endpoints, -1
+	}
+	return slices.Concat(endpoints[:index], endpoints[index+1:]), index
 }
 
 // New creates a new load balancer based on the algorithm
@@ -26,6 +90,12 @@ func New(config *models.LoadBalancerConfig, endpoints []models.EndpointConfig) (
 		return NewLeastConnections(endpoints), nil
 	case "random":
 		return NewRandom(endpoints), nil
+	case "least-response-time":
+		return NewLeastResponseTime(endpoints), nil
+	case "least-latency":
+		return NewLeastLatency(endpoints, config.LatencyDecay), nil
+	case "consistent-hash":
+		return NewConsistentHash(endpoints, config.HashKey, config.VirtualNodes)
 	default:
 		return nil, fmt.Errorf("unsupported algorithm: %s", config.Algorithm)
 	}
@@ -34,7 +104,7 @@ func New(config *models.LoadBalancerConfig, endpoints []models.EndpointConfig) (
 // RoundRobin implements round-robin load balancing
 type RoundRobin struct {
 	endpoints []models.EndpointConfig
-	current   uint32
+	current   int // index in endpoints where the next scan for a healthy endpoint starts
 	mutex     sync.RWMutex
 }
 
@@ -46,30 +116,33 @@ func NewRoundRobin(endpoints []models.EndpointConfig) *RoundRobin {
 	}
 }
 
-// Next returns the next endpoint in round-robin fashion
+// Next returns the next endpoint in round-robin fashion.
+// The cursor walks the endpoint list itself and skips unhealthy entries, so
+// health changes mid-rotation don't skew the share of the remaining endpoints.
+// The returned pointer refers to the balancer's own entry for the endpoint.
 func (rr *RoundRobin) Next() *models.EndpointConfig {
-	rr.mutex.RLock()
-	defer rr.mutex.RUnlock()
-
-	if len(rr.endpoints) == 0 {
-		return nil
-	}
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
 
-	// Find healthy endpoints
-	healthyEndpoints := make([]models.EndpointConfig, 0)
-	for _, ep := range rr.endpoints {
-		if ep.Healthy {
-			healthyEndpoints = append(healthyEndpoints, ep)
+	for i := range rr.endpoints {
+		index := (rr.current + i) % len(rr.endpoints)
+		if rr.endpoints[index].Available() {
+			rr.current = (index + 1) % len(rr.endpoints)
+			return &rr.endpoints[index]
 		}
 	}
 
-	if len(healthyEndpoints) == 0 {
-		return nil
-	}
+	return nil
+}
+
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (rr *RoundRobin) Endpoints() []models.EndpointConfig {
+	rr.mutex.RLock()
+	defer rr.mutex.RUnlock()
 
-	// Get next endpoint
-	index := atomic.AddUint32(&rr.current, 1) % uint32(len(healthyEndpoints))
-	return &healthyEndpoints[index]
+	endpoints := make([]models.EndpointConfig, len(rr.endpoints))
+	copy(endpoints, rr.endpoints)
+	return endpoints
 }
 
 // MarkHealthy marks an endpoint as healthy
@@ -98,6 +171,49 @@ func (rr *RoundRobin) MarkUnhealthy(endpoint *models.EndpointConfig) {
 	}
 }
 
+// MarkDraining starts or stops draining an endpoint
+func (rr *RoundRobin) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	for i := range rr.endpoints {
+		if rr.endpoints[i].URL == endpoint.URL {
+			rr.endpoints[i].Draining = draining
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint at the end of the rotation, or updates an existing one
+func (rr *RoundRobin) AddEndpoint(endpoint models.EndpointConfig) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	rr.endpoints = withEndpoint(rr.endpoints, endpoint)
+}
+
+// RemoveEndpoint removes an endpoint, keeping the rotation's place among the others
+func (rr *RoundRobin) RemoveEndpoint(endpointURL string) bool {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	var index int
+	rr.endpoints, index = withoutEndpoint(rr.endpoints, endpointURL)
+	if index < 0 {
+		return false
+	}
+	if index < rr.current {
+		rr.current--
+	}
+	if rr.current >= len(rr.endpoints) {
+		rr.current = 0
+	}
+	return true
+}
+
+// Observe is a no-op; round-robin ignores latency
+func (rr *RoundRobin) Observe(endpointURL string, d time.Duration, err error) {}
+
 // Weighted implements weighted round-robin load balancing
 type Weighted struct {
 	endpoints      []models.EndpointConfig
@@ -119,7 +235,7 @@ func NewWeighted(endpoints []models.EndpointConfig) *Weighted {
 func (w *Weighted) buildWeightedList() {
 	w.weightedList = make([]int, 0)
 	for i, ep := range w.endpoints {
-		if ep.Healthy {
+		if ep.Available() {
 			for j := 0; j < ep.Weight; j++ {
 				w.weightedList = append(w.weightedList, i)
 			}
@@ -141,6 +257,16 @@ func (w *Weighted) Next() *models.EndpointConfig {
 	return &w.endpoints[endpointIndex]
 }
 
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (w *Weighted) Endpoints() []models.EndpointConfig {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	endpoints := make([]models.EndpointConfig, len(w.endpoints))
+	copy(endpoints, w.endpoints)
+	return endpoints
+}
+
 // MarkHealthy marks an endpoint as healthy
 func (w *Weighted) MarkHealthy(endpoint *models.EndpointConfig) {
 	w.mutex.Lock()
@@ -169,6 +295,46 @@ func (w *Weighted) MarkUnhealthy(endpoint *models.EndpointConfig) {
 	}
 }
 
+// MarkDraining starts or stops draining an endpoint
+func (w *Weighted) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for i := range w.endpoints {
+		if w.endpoints[i].URL == endpoint.URL {
+			w.endpoints[i].Draining = draining
+			w.buildWeightedList()
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint, or updates an existing one, and rebuilds the weighted list
+func (w *Weighted) AddEndpoint(endpoint models.EndpointConfig) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.endpoints = withEndpoint(w.endpoints, endpoint)
+	w.buildWeightedList()
+}
+
+// RemoveEndpoint removes an endpoint and rebuilds the weighted list
+func (w *Weighted) RemoveEndpoint(endpointURL string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var index int
+	w.endpoints, index = withoutEndpoint(w.endpoints, endpointURL)
+	if index < 0 {
+		return false
+	}
+	w.buildWeightedList()
+	return true
+}
+
+// Observe is a no-op; weighted round-robin ignores latency
+func (w *Weighted) Observe(endpointURL string, d time.Duration, err error) {}
+
 // LeastConnections implements least connections load balancing
 type LeastConnections struct {
 	endpoints   []models.EndpointConfig
@@ -200,7 +366,7 @@ func (lc *LeastConnections) Next() *models.EndpointConfig {
 
 	for i := range lc.endpoints {
 		ep := &lc.endpoints[i]
-		if !ep.Healthy {
+		if !ep.Available() {
 			continue
 		}
 
@@ -218,6 +384,16 @@ func (lc *LeastConnections) Next() *models.EndpointConfig {
 	return selected
 }
 
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (lc *LeastConnections) Endpoints() []models.EndpointConfig {
+	lc.mutex.RLock()
+	defer lc.mutex.RUnlock()
+
+	endpoints := make([]models.EndpointConfig, len(lc.endpoints))
+	copy(endpoints, lc.endpoints)
+	return endpoints
+}
+
 // MarkHealthy marks an endpoint as healthy
 func (lc *LeastConnections) MarkHealthy(endpoint *models.EndpointConfig) {
 	lc.mutex.Lock()
@@ -244,39 +420,112 @@ func (lc *LeastConnections) MarkUnhealthy(endpoint *models.EndpointConfig) {
 	}
 }
 
+// MarkDraining starts or stops draining an endpoint
+func (lc *LeastConnections) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	for i := range lc.endpoints {
+		if lc.endpoints[i].URL == endpoint.URL {
+			lc.endpoints[i].Draining = draining
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint with no connections, or updates an existing one
+func (lc *LeastConnections) AddEndpoint(endpoint models.EndpointConfig) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.endpoints = withEndpoint(lc.endpoints, endpoint)
+	if _, exists := lc.connections[endpoint.URL]; !exists {
+		lc.connections[endpoint.URL] = 0
+	}
+}
+
+// RemoveEndpoint removes an endpoint and its connection count
+func (lc *LeastConnections) RemoveEndpoint(endpointURL string) bool {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	var index int
+	lc.endpoints, index = withoutEndpoint(lc.endpoints, endpointURL)
+	if index < 0 {
+		return false
+	}
+	delete(lc.connections, endpointURL)
+	return true
+}
+
+// Observe is a no-op; least connections balances on in-flight requests
+func (lc *LeastConnections) Observe(endpointURL string, d time.Duration, err error) {}
+
 // Random implements random load balancing
 type Random struct {
 	endpoints []models.EndpointConfig
 	mutex     sync.RWMutex
+	rng       *rand.Rand
+	rngMutex  sync.Mutex // rand.Rand is not safe for concurrent use
 }
 
-// NewRandom creates a new random load balancer
+// NewRandom creates a new random load balancer with its own seeded random source.
+// The seed comes from the randomly seeded global source rather than the clock, so
+// balancers created within the same clock tick still pick independently.
 func NewRandom(endpoints []models.EndpointConfig) *Random {
 	return &Random{
 		endpoints: endpoints,
+		rng:       rand.New(rand.NewSource(rand.Int63())),
 	}
 }
 
-// Next returns a random healthy endpoint
+// Seed replaces the random source with one seeded with seed
+func (r *Random) Seed(seed int64) {
+	r.rngMutex.Lock()
+	defer r.rngMutex.Unlock()
+	r.rng = rand.New(rand.NewSource(seed))
+}
+
+// Next returns a random healthy endpoint, each with equal probability
 func (r *Random) Next() *models.EndpointConfig {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	// Find healthy endpoints
-	healthyEndpoints := make([]models.EndpointConfig, 0)
-	for _, ep := range r.endpoints {
-		if ep.Healthy {
-			healthyEndpoints = append(healthyEndpoints, ep)
+	healthy := 0
+	for i := range r.endpoints {
+		if r.endpoints[i].Available() {
+			healthy++
 		}
 	}
 
-	if len(healthyEndpoints) == 0 {
+	if healthy == 0 {
 		return nil
 	}
 
-	// Random selection (simplified - should use proper random)
-	index := int(atomic.AddUint32(&randomSeed, 1)) % len(healthyEndpoints)
-	return &healthyEndpoints[index]
+	r.rngMutex.Lock()
+	pick := r.rng.Intn(healthy)
+	r.rngMutex.Unlock()
+
+	for i := range r.endpoints {
+		if !r.endpoints[i].Available() {
+			continue
+		}
+		if pick == 0 {
+			return &r.endpoints[i]
+		}
+		pick--
+	}
+	return nil
+}
+
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (r *Random) Endpoints() []models.EndpointConfig {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	endpoints := make([]models.EndpointConfig, len(r.endpoints))
+	copy(endpoints, r.endpoints)
+	return endpoints
 }
 
 // MarkHealthy marks an endpoint as healthy
@@ -305,4 +554,534 @@ func (r *Random) MarkUnhealthy(endpoint *models.EndpointConfig) {
 	}
 }
 
-var randomSeed uint32
\ No newline at end of file
+// MarkDraining starts or stops draining an endpoint
+func (r *Random) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range r.endpoints {
+		if r.endpoints[i].URL == endpoint.URL {
+			r.endpoints[i].Draining = draining
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint, or updates an existing one
+func (r *Random) AddEndpoint(endpoint models.EndpointConfig) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.endpoints = withEndpoint(r.endpoints, endpoint)
+}
+
+// RemoveEndpoint removes an endpoint
+func (r *Random) RemoveEndpoint(endpointURL string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var index int
+	r.endpoints, index = withoutEndpoint(r.endpoints, endpointURL)
+	return index >= 0
+}
+
+// Observe is a no-op; random selection ignores latency
+func (r *Random) Observe(endpointURL string, d time.Duration, err error) {}
+
+// ewmaAlpha is the weight of the newest latency sample in the moving average
+const ewmaAlpha = 0.3
+
+// LeastResponseTime picks the healthy endpoint with the lowest moving average latency.
+// Endpoints without samples yet are tried first.
+type LeastResponseTime struct {
+	endpoints []models.EndpointConfig
+	latencies map[string]float64 // EWMA in seconds
+	mutex     sync.RWMutex
+}
+
+// NewLeastResponseTime creates a new least response time load balancer
+func NewLeastResponseTime(endpoints []models.EndpointConfig) *LeastResponseTime {
+	return &LeastResponseTime{
+		endpoints: endpoints,
+		latencies: make(map[string]float64),
+	}
+}
+
+// Next returns the healthy endpoint with the lowest average latency
+func (l *LeastResponseTime) Next() *models.EndpointConfig {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	var selected *models.EndpointConfig
+	var lowest float64
+	for i := range l.endpoints {
+		ep := &l.endpoints[i]
+		if !ep.Available() {
+			continue
+		}
+
+		latency := l.latencies[ep.URL]
+		if selected == nil || latency < lowest {
+			selected = ep
+			lowest = latency
+		}
+	}
+
+	if selected == nil {
+		return nil
+	}
+	endpoint := *selected
+	return &endpoint
+}
+
+// Observe folds an observed latency into the endpoint's moving average.
+// Failures that never reached the backend say nothing about how fast it is and are ignored.
+func (l *LeastResponseTime) Observe(endpointURL string, d time.Duration, err error) {
+	if reason := models.ClassifyFailure(err); reason != "" && reason != models.FailureTimeout && reason != models.FailureBadStatus {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	sample := d.Seconds()
+	if current, ok := l.latencies[endpointURL]; ok {
+		l.latencies[endpointURL] = ewmaAlpha*sample + (1-ewmaAlpha)*current
+		return
+	}
+	l.latencies[endpointURL] = sample
+}
+
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (l *LeastResponseTime) Endpoints() []models.EndpointConfig {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	endpoints := make([]models.EndpointConfig, len(l.endpoints))
+	copy(endpoints, l.endpoints)
+	return endpoints
+}
+
+// MarkHealthy marks an endpoint as healthy
+func (l *LeastResponseTime) MarkHealthy(endpoint *models.EndpointConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range l.endpoints {
+		if l.endpoints[i].URL == endpoint.URL {
+			l.endpoints[i].Healthy = true
+			break
+		}
+	}
+}
+
+// MarkUnhealthy marks an endpoint as unhealthy
+func (l *LeastResponseTime) MarkUnhealthy(endpoint *models.EndpointConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range l.endpoints {
+		if l.endpoints[i].URL == endpoint.URL {
+			l.endpoints[i].Healthy = false
+			break
+		}
+	}
+}
+
+// MarkDraining starts or stops draining an endpoint
+func (l *LeastResponseTime) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range l.endpoints {
+		if l.endpoints[i].URL == endpoint.URL {
+			l.endpoints[i].Draining = draining
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint without samples, so it is tried first, or updates an existing one
+func (l *LeastResponseTime) AddEndpoint(endpoint models.EndpointConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.endpoints = withEndpoint(l.endpoints, endpoint)
+}
+
+// RemoveEndpoint removes an endpoint and its latency average
+func (l *LeastResponseTime) RemoveEndpoint(endpointURL string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var index int
+	l.endpoints, index = withoutEndpoint(l.endpoints, endpointURL)
+	if index < 0 {
+		return false
+	}
+	delete(l.latencies, endpointURL)
+	return true
+}
+
+// defaultVirtualNodes is the number of ring points per endpoint when none is configured
+const defaultVirtualNodes = 160
+
+// ringPoint is one virtual node of an endpoint on the hash ring
+type ringPoint struct {
+	hash     uint64
+	endpoint int // index in endpoints
+}
+
+// ConsistentHash sends requests with the same key to the same endpoint.
+// Every endpoint stays on the ring whatever its health; a key whose endpoint is
+// unhealthy moves on to the next healthy point, so only that endpoint's keys move.
+type ConsistentHash struct {
+	endpoints    []models.EndpointConfig
+	ring         []ringPoint // sorted by hash
+	key          func(req *http.Request) string
+	virtualNodes int // ring points per endpoint
+	mutex        sync.RWMutex
+}
+
+// NewConsistentHash creates a consistent-hash load balancer keyed on hashKey
+// (see models.ParseHashKey), with virtualNodes ring points per endpoint
+func NewConsistentHash(endpoints []models.EndpointConfig, hashKey string, virtualNodes int) (*ConsistentHash, error) {
+	source, name, err := models.ParseHashKey(hashKey)
+	if err != nil {
+		return nil, err
+	}
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+
+	ch := &ConsistentHash{endpoints: endpoints, virtualNodes: virtualNodes}
+	switch source {
+	case models.HashKeyHeader:
+		ch.key = func(req *http.Request) string { return req.Header.Get(name) }
+	case models.HashKeyQuery:
+		ch.key = func(req *http.Request) string { return req.URL.Query().Get(name) }
+	default:
+		ch.key = func(req *http.Request) string { return req.URL.Path }
+	}
+
+	ch.buildRing()
+	return ch, nil
+}
+
+// buildRing places every endpoint's virtual nodes on the ring. A point's position depends
+// only on its endpoint's URL, so adding or removing an endpoint leaves the others in place.
+// The caller must hold the mutex or own the balancer.
+func (ch *ConsistentHash) buildRing() {
+	ch.ring = make([]ringPoint, 0, len(ch.endpoints)*ch.virtualNodes)
+	for i, ep := range ch.endpoints {
+		for v := 0; v < ch.virtualNodes; v++ {
+			ch.ring = append(ch.ring, ringPoint{hash: hashKey64(ep.URL + "#" + strconv.Itoa(v)), endpoint: i})
+		}
+	}
+	slices.SortFunc(ch.ring, func(a, b ringPoint) int { return cmp.Compare(a.hash, b.hash) })
+}
+
+// hashKey64 places a key on the ring
+func hashKey64(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// FNV alone leaves keys that differ only in their last bytes close together; mix the bits
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// NextFor returns the endpoint that owns the request's key
+func (ch *ConsistentHash) NextFor(req *http.Request) *models.EndpointConfig {
+	return ch.NextForKey(ch.key(req))
+}
+
+// NextForKey returns the first available endpoint clockwise from the key's point on the ring
+func (ch *ConsistentHash) NextForKey(key string) *models.EndpointConfig {
+	ch.mutex.RLock()
+	defer ch.mutex.RUnlock()
+
+	if len(ch.ring) == 0 {
+		return nil
+	}
+
+	hash := hashKey64(key)
+	start, _ := slices.BinarySearchFunc(ch.ring, hash, func(p ringPoint, h uint64) int { return cmp.Compare(p.hash, h) })
+
+	for i := range ch.ring {
+		point := ch.ring[(start+i)%len(ch.ring)]
+		if ch.endpoints[point.endpoint].Available() {
+			return &ch.endpoints[point.endpoint]
+		}
+	}
+	return nil
+}
+
+// Next returns the endpoint for an empty key; requests are routed with NextFor
+func (ch *ConsistentHash) Next() *models.EndpointConfig {
+	return ch.NextForKey("")
+}
+
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (ch *ConsistentHash) Endpoints() []models.EndpointConfig {
+	ch.mutex.RLock()
+	defer ch.mutex.RUnlock()
+
+	endpoints := make([]models.EndpointConfig, len(ch.endpoints))
+	copy(endpoints, ch.endpoints)
+	return endpoints
+}
+
+// MarkHealthy marks an endpoint as healthy
+func (ch *ConsistentHash) MarkHealthy(endpoint *models.EndpointConfig) {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	for i := range ch.endpoints {
+		if ch.endpoints[i].URL == endpoint.URL {
+			ch.endpoints[i].Healthy = true
+			break
+		}
+	}
+}
+
+// MarkUnhealthy marks an endpoint as unhealthy
+func (ch *ConsistentHash) MarkUnhealthy(endpoint *models.EndpointConfig) {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	for i := range ch.endpoints {
+		if ch.endpoints[i].URL == endpoint.URL {
+			ch.endpoints[i].Healthy = false
+			break
+		}
+	}
+}
+
+// MarkDraining starts or stops draining an endpoint
+func (ch *ConsistentHash) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	for i := range ch.endpoints {
+		if ch.endpoints[i].URL == endpoint.URL {
+			ch.endpoints[i].Draining = draining
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint to the ring, or updates an existing one. Only the keys
+// that land on the new endpoint's points move to it.
+func (ch *ConsistentHash) AddEndpoint(endpoint models.EndpointConfig) {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	ch.endpoints = withEndpoint(ch.endpoints, endpoint)
+	ch.buildRing()
+}
+
+// RemoveEndpoint removes an endpoint from the ring; only its keys move
+func (ch *ConsistentHash) RemoveEndpoint(endpointURL string) bool {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	var index int
+	ch.endpoints, index = withoutEndpoint(ch.endpoints, endpointURL)
+	if index < 0 {
+		return false
+	}
+	ch.buildRing()
+	return true
+}
+
+// Observe is a no-op; consistent hashing ignores latency
+func (ch *ConsistentHash) Observe(endpointURL string, d time.Duration, err error) {}
+
+// defaultLatencyDecay is the half-life of a least-latency score when none is configured
+const defaultLatencyDecay = 10 * time.Second
+
+// latencyErrorPenalty is added to the score of an endpoint whose recent requests all failed,
+// in seconds; it scales with the moving average error rate
+const latencyErrorPenalty = 1.0
+
+// latencyScore is the state least-latency balancing keeps per endpoint
+type latencyScore struct {
+	latency  float64 // EWMA in seconds
+	errors   float64 // EWMA of the error rate, 0 to 1
+	observed time.Time
+}
+
+// LeastLatency picks the healthy endpoint with the lowest score: the moving average of
+// its response times plus a penalty for recent errors. Scores halve every decay while an
+// endpoint isn't observed, so an endpoint that was slow is tried again and can recover.
+// Endpoints without observations yet are tried first.
+type LeastLatency struct {
+	endpoints []models.EndpointConfig
+	scores    map[string]*latencyScore
+	decay     time.Duration
+	clock     clock.Clock
+	mutex     sync.Mutex
+}
+
+// NewLeastLatency creates a least latency load balancer; decay 0 uses the default
+func NewLeastLatency(endpoints []models.EndpointConfig, decay time.Duration) *LeastLatency {
+	if decay <= 0 {
+		decay = defaultLatencyDecay
+	}
+	return &LeastLatency{
+		endpoints: endpoints,
+		scores:    make(map[string]*latencyScore),
+		decay:     decay,
+		clock:     clock.Real,
+	}
+}
+
+// SetClock sets the clock scores decay on
+func (l *LeastLatency) SetClock(c clock.Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.clock = c
+}
+
+// decayFactor returns how much of a score observed at t is left now; the caller must hold the mutex
+func (l *LeastLatency) decayFactor(t time.Time) float64 {
+	elapsed := l.clock.Now().Sub(t)
+	if elapsed <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(elapsed) / float64(l.decay))
+}
+
+// score returns the endpoint's current score; the caller must hold the mutex
+func (l *LeastLatency) score(endpointURL string) float64 {
+	s, ok := l.scores[endpointURL]
+	if !ok {
+		return 0
+	}
+	return (s.latency + latencyErrorPenalty*s.errors) * l.decayFactor(s.observed)
+}
+
+// Next returns the healthy endpoint with the lowest score
+func (l *LeastLatency) Next() *models.EndpointConfig {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var selected *models.EndpointConfig
+	var lowest float64
+	for i := range l.endpoints {
+		ep := &l.endpoints[i]
+		if !ep.Available() {
+			continue
+		}
+
+		score := l.score(ep.URL)
+		if selected == nil || score < lowest {
+			selected = ep
+			lowest = score
+		}
+	}
+
+	if selected == nil {
+		return nil
+	}
+	endpoint := *selected
+	return &endpoint
+}
+
+// Observe folds a request's latency and outcome into the endpoint's decayed moving averages
+func (l *LeastLatency) Observe(endpointURL string, d time.Duration, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+
+	s, ok := l.scores[endpointURL]
+	if !ok {
+		l.scores[endpointURL] = &latencyScore{latency: d.Seconds(), errors: failed, observed: l.clock.Now()}
+		return
+	}
+
+	decay := l.decayFactor(s.observed)
+	s.latency = ewmaAlpha*d.Seconds() + (1-ewmaAlpha)*s.latency*decay
+	s.errors = ewmaAlpha*failed + (1-ewmaAlpha)*s.errors*decay
+	s.observed = l.clock.Now()
+}
+
+// Endpoints returns a copy of the balancer's view of its endpoints
+func (l *LeastLatency) Endpoints() []models.EndpointConfig {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	endpoints := make([]models.EndpointConfig, len(l.endpoints))
+	copy(endpoints, l.endpoints)
+	return endpoints
+}
+
+// MarkHealthy marks an endpoint as healthy
+func (l *LeastLatency) MarkHealthy(endpoint *models.EndpointConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range l.endpoints {
+		if l.endpoints[i].URL == endpoint.URL {
+			l.endpoints[i].Healthy = true
+			break
+		}
+	}
+}
+
+// MarkUnhealthy marks an endpoint as unhealthy
+func (l *LeastLatency) MarkUnhealthy(endpoint *models.EndpointConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range l.endpoints {
+		if l.endpoints[i].URL == endpoint.URL {
+			l.endpoints[i].Healthy = false
+			break
+		}
+	}
+}
+
+// MarkDraining starts or stops draining an endpoint
+func (l *LeastLatency) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i := range l.endpoints {
+		if l.endpoints[i].URL == endpoint.URL {
+			l.endpoints[i].Draining = draining
+			break
+		}
+	}
+}
+
+// AddEndpoint adds an endpoint without observations, so it is tried first, or updates an existing one
+func (l *LeastLatency) AddEndpoint(endpoint models.EndpointConfig) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.endpoints = withEndpoint(l.endpoints, endpoint)
+}
+
+// RemoveEndpoint removes an endpoint and its score
+func (l *LeastLatency) RemoveEndpoint(endpointURL string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var index int
+	l.endpoints, index = withoutEndpoint(l.endpoints, endpointURL)
+	if index < 0 {
+		return false
+	}
+	delete(l.scores, endpointURL)
+	return true
+}
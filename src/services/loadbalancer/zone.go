@@ -0,0 +1,200 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// DefaultZoneMinHealthy is how many local endpoints must be available to keep traffic in
+// the zone when no threshold is configured
+const DefaultZoneMinHealthy = 1
+
+// ZoneAware prefers the endpoints in the router's own zone. It filters them into a balancer
+// of the configured algorithm and uses it while at least minHealthy of them are available;
+// otherwise it spills over to a balancer of every endpoint, in any zone. An endpoint's zone
+// is its "zone" metadata.
+type ZoneAware struct {
+	zone       string
+	minHealthy int
+	local      LoadBalancer // endpoints in the zone only
+	all        LoadBalancer // every endpoint, used when the local ones are too few
+
+	mutex  sync.RWMutex
+	locals map[string]*models.EndpointConfig // health and drain state of the local endpoints, by URL
+}
+
+// NewZoneAware creates a zone-aware balancer delegating to the algorithm in config;
+// minHealthy 0 uses the default
+func NewZoneAware(config *models.LoadBalancerConfig, endpoints []models.EndpointConfig, zone string, minHealthy int) (*ZoneAware, error) {
+	if minHealthy <= 0 {
+		minHealthy = DefaultZoneMinHealthy
+	}
+
+	z := &ZoneAware{zone: zone, minHealthy: minHealthy, locals: make(map[string]*models.EndpointConfig)}
+	var local []models.EndpointConfig
+	for _, endpoint := range endpoints {
+		if endpoint.Zone() == zone {
+			local = append(local, endpoint)
+			z.locals[endpoint.URL] = &models.EndpointConfig{URL: endpoint.URL, Healthy: endpoint.Healthy, Draining: endpoint.Draining}
+		}
+	}
+
+	var err error
+	if z.local, err = New(config, local); err != nil {
+		return nil, err
+	}
+	if z.all, err = New(config, slices.Clone(endpoints)); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// balancer returns the balancer to pick from: the local one while enough local endpoints are available
+func (z *ZoneAware) balancer() LoadBalancer {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+
+	available := 0
+	for _, endpoint := range z.locals {
+		if endpoint.Available() {
+			available++
+		}
+	}
+	if available >= z.minHealthy {
+		return z.local
+	}
+	return z.all
+}
+
+// Next returns an endpoint from the zone, or from any zone if the zone has too few available
+func (z *ZoneAware) Next() *models.EndpointConfig {
+	return z.balancer().Next()
+}
+
+// NextFor picks like Next, by the request's key when the algorithm is keyed
+func (z *ZoneAware) NextFor(req *http.Request) *models.EndpointConfig {
+	lb := z.balancer()
+	if keyed, ok := lb.(Keyed); ok {
+		return keyed.NextFor(req)
+	}
+	return lb.Next()
+}
+
+// isLocal reports whether the endpoint with the URL is in the zone
+func (z *ZoneAware) isLocal(endpointURL string) bool {
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+
+	_, local := z.locals[endpointURL]
+	return local
+}
+
+// update changes the state kept for a local endpoint
+func (z *ZoneAware) update(endpointURL string, change func(endpoint *models.EndpointConfig)) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	if endpoint, local := z.locals[endpointURL]; local {
+		change(endpoint)
+	}
+}
+
+// MarkHealthy marks an endpoint as healthy
+func (z *ZoneAware) MarkHealthy(endpoint *models.EndpointConfig) {
+	z.all.MarkHealthy(endpoint)
+	z.local.MarkHealthy(endpoint)
+	z.update(endpoint.URL, func(ep *models.EndpointConfig) { ep.Healthy = true })
+}
+
+// MarkUnhealthy marks an endpoint as unhealthy
+func (z *ZoneAware) MarkUnhealthy(endpoint *models.EndpointConfig) {
+	z.all.MarkUnhealthy(endpoint)
+	z.local.MarkUnhealthy(endpoint)
+	z.update(endpoint.URL, func(ep *models.EndpointConfig) { ep.Healthy = false })
+}
+
+// MarkDraining starts or stops draining an endpoint
+func (z *ZoneAware) MarkDraining(endpoint *models.EndpointConfig, draining bool) {
+	z.all.MarkDraining(endpoint, draining)
+	z.local.MarkDraining(endpoint, draining)
+	z.update(endpoint.URL, func(ep *models.EndpointConfig) { ep.Draining = draining })
+}
+
+// AddEndpoint adds an endpoint, or updates an existing one. An endpoint whose zone metadata
+// changes moves in or out of the zone, keeping its health and drain state.
+func (z *ZoneAware) AddEndpoint(endpoint models.EndpointConfig) {
+	z.all.AddEndpoint(endpoint)
+
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	_, wasLocal := z.locals[endpoint.URL]
+	switch {
+	case endpoint.Zone() != z.zone:
+		if wasLocal {
+			z.local.RemoveEndpoint(endpoint.URL)
+			delete(z.locals, endpoint.URL)
+		}
+	case wasLocal:
+		z.local.AddEndpoint(endpoint)
+	default:
+		// The endpoint may have been in another zone; it brings its state along
+		for _, current := range z.all.Endpoints() {
+			if current.URL == endpoint.URL {
+				endpoint.Healthy, endpoint.Draining = current.Healthy, current.Draining
+			}
+		}
+		z.local.AddEndpoint(endpoint)
+		z.locals[endpoint.URL] = &models.EndpointConfig{URL: endpoint.URL, Healthy: endpoint.Healthy, Draining: endpoint.Draining}
+	}
+}
+
+// RemoveEndpoint removes an endpoint from the zone and the spill-over balancer
+func (z *ZoneAware) RemoveEndpoint(endpointURL string) bool {
+	z.mutex.Lock()
+	if _, local := z.locals[endpointURL]; local {
+		z.local.RemoveEndpoint(endpointURL)
+		delete(z.locals, endpointURL)
+	}
+	z.mutex.Unlock()
+
+	return z.all.RemoveEndpoint(endpointURL)
+}
+
+// Endpoints returns a copy of every endpoint, in any zone
+func (z *ZoneAware) Endpoints() []models.EndpointConfig {
+	return z.all.Endpoints()
+}
+
+// Observe passes the observation on to the balancers that have the endpoint
+func (z *ZoneAware) Observe(endpointURL string, d time.Duration, err error) {
+	z.all.Observe(endpointURL, d, err)
+	if z.isLocal(endpointURL) {
+		z.local.Observe(endpointURL, d, err)
+	}
+}
+
+// Seed seeds the delegate balancers when their algorithm makes random choices
+func (z *ZoneAware) Seed(seed int64) {
+	if seeder, ok := z.local.(Seeder); ok {
+		seeder.Seed(seed)
+	}
+	if seeder, ok := z.all.(Seeder); ok {
+		seeder.Seed(seed + 1)
+	}
+}
+
+// SetClock sets the clock of the delegate balancers when their algorithm measures time
+func (z *ZoneAware) SetClock(c clock.Clock) {
+	if clocked, ok := z.local.(Clocked); ok {
+		clocked.SetClock(c)
+	}
+	if clocked, ok := z.all.(Clocked); ok {
+		clocked.SetClock(c)
+	}
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultCgroupRoot is where the container's cgroup filesystem is mounted
+const DefaultCgroupRoot = "/sys/fs/cgroup"
+
+// unlimitedCgroupV1 is the smallest memory.limit_in_bytes treated as no limit;
+// cgroup v1 reports an unset limit as a page-rounded maximum int64
+const unlimitedCgroupV1 = 1 << 62
+
+// CgroupLimit returns the memory limit of the cgroup mounted at root.
+// It reads cgroup v2's memory.max, then cgroup v1's memory.limit_in_bytes,
+// and reports false when neither sets a limit.
+func CgroupLimit(root string) (uint64, bool) {
+	if data, err := os.ReadFile(filepath.Join(root, "memory.max")); err == nil {
+		value := string(bytes.TrimSpace(data))
+		if value == "max" {
+			return 0, false
+		}
+		limit, err := strconv.ParseUint(value, 10, 64)
+		return limit, err == nil && limit > 0
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes")); err == nil {
+		limit, err := strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64)
+		return limit, err == nil && limit > 0 && limit < unlimitedCgroupV1
+	}
+
+	return 0, false
+}
+
+// ReadProcess samples the heap from the Go runtime and RSS from /proc.
+// RSS is left at 0 where /proc is not available.
+func ReadProcess() (Sample, error) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	sample := Sample{Heap: stats.HeapInuse}
+
+	if rss, err := readRSS(); err == nil {
+		sample.RSS = rss
+	}
+	return sample, nil
+}
+
+// readRSS reads the resident set size from /proc/self/statm
+func readRSS() (uint64, error) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected statm format: %q", data)
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
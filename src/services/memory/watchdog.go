@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
+)
+
+// Sample is a reading of the process's memory use
+type Sample struct {
+	Heap uint64 // bytes of heap in use
+	RSS  uint64 // resident set size, 0 when unknown
+}
+
+// Usage returns the memory the watchdog compares against the limit.
+// The heap is part of RSS, so RSS is used when it is known.
+func (s Sample) Usage() uint64 {
+	return max(s.Heap, s.RSS)
+}
+
+// Reader reads the process's current memory use
+type Reader func() (Sample, error)
+
+// Status is the result of the last memory check
+type Status struct {
+	Usage        uint64    `json:"usage_bytes"`
+	Limit        uint64    `json:"limit_bytes"`
+	Shedding     bool      `json:"shedding"`
+	ShedFraction float64   `json:"shed_fraction"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Watchdog samples memory use against a limit and sheds load when it runs high.
+// Shedding starts above the high-water mark and stops below the low-water mark;
+// in between, the fraction of requests shed grows from 0 at the low-water mark
+// to 1 at the limit.
+type Watchdog struct {
+	limit     uint64
+	highWater float64
+	lowWater  float64
+	read      Reader
+	logger    *slog.Logger
+	events    *events.Bus
+	status    Status
+	mutex     sync.Mutex
+	cancel    context.CancelFunc
+
+	// Read on every request, so kept outside the mutex
+	shedding     atomic.Bool
+	shedFraction atomic.Uint64 // math.Float64bits of the fraction
+}
+
+// NewWatchdog creates a watchdog for the given limit in bytes and watermarks as fractions of it
+func NewWatchdog(limit uint64, highWater, lowWater float64, read Reader, logger *slog.Logger) *Watchdog {
+	return &Watchdog{
+		limit:     limit,
+		highWater: highWater,
+		lowWater:  lowWater,
+		read:      read,
+		logger:    logger,
+		status:    Status{Limit: limit},
+	}
+}
+
+// SetEventBus sets the bus that shedding transitions are published to
+func (w *Watchdog) SetEventBus(bus *events.Bus) {
+	w.events = bus
+}
+
+// Start checks memory every interval (a second if unset) until the context is cancelled or Stop is called
+func (w *Watchdog) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		w.Check()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.Check()
+			}
+		}
+	}()
+}
+
+// Stop stops periodic checks
+func (w *Watchdog) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// Status returns the result of the last check
+func (w *Watchdog) Status() Status {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.status
+}
+
+// Check samples memory use and updates the shedding state.
+// A failed read is logged and leaves the state unchanged.
+func (w *Watchdog) Check() Status {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	sample, err := w.read()
+	if err != nil {
+		w.logger.Warn("Failed to read memory usage", "error", err)
+		return w.status
+	}
+
+	usage := sample.Usage()
+	ratio := float64(usage) / float64(w.limit)
+
+	shedding := w.status.Shedding
+	if !shedding && ratio >= w.highWater {
+		shedding = true
+	} else if shedding && ratio < w.lowWater {
+		shedding = false
+	}
+
+	fraction := 0.0
+	if shedding {
+		fraction = min(max((ratio-w.lowWater)/(1-w.lowWater), 0), 1)
+	}
+
+	if shedding != w.status.Shedding {
+		w.logTransition(shedding, sample, fraction)
+	}
+
+	w.status = Status{
+		Usage:        usage,
+		Limit:        w.limit,
+		Shedding:     shedding,
+		ShedFraction: fraction,
+		CheckedAt:    time.Now(),
+	}
+	w.shedding.Store(shedding)
+	w.shedFraction.Store(math.Float64bits(fraction))
+	services.SetMemoryStatus(usage, w.limit, fraction)
+	return w.status
+}
+
+// logTransition logs and publishes shedding starting or stopping
+func (w *Watchdog) logTransition(shedding bool, sample Sample, fraction float64) {
+	if shedding {
+		w.logger.Error("Memory high, shedding load",
+			"usage", sample.Usage(), "heap", sample.Heap, "rss", sample.RSS, "limit", w.limit, "shed_fraction", fraction)
+	} else {
+		w.logger.Warn("Memory recovered, load shedding stopped",
+			"usage", sample.Usage(), "heap", sample.Heap, "rss", sample.RSS, "limit", w.limit)
+	}
+
+	w.events.Publish(events.TopicMemory, map[string]interface{}{
+		"shedding":      shedding,
+		"usage_bytes":   sample.Usage(),
+		"limit_bytes":   w.limit,
+		"shed_fraction": fraction,
+	})
+}
+
+// Shedding reports whether emergency load shedding is active.
+// A nil watchdog never sheds so callers don't need to check for one.
+func (w *Watchdog) Shedding() bool {
+	return w != nil && w.shedding.Load()
+}
+
+// ShouldShed decides whether to reject a non-critical request.
+// While shedding, it rejects the current shed fraction of requests at random.
+func (w *Watchdog) ShouldShed() bool {
+	if !w.Shedding() {
+		return false
+	}
+	return rand.Float64() < math.Float64frombits(w.shedFraction.Load())
+}
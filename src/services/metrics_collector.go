@@ -1,6 +1,8 @@
 package services
 
 import (
+	"sync/atomic"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -12,7 +14,7 @@ var (
 			Name: "http_requests_total",
 			Help: "Total number of HTTP requests",
 		},
-		[]string{"method", "path", "status"},
+		[]string{"method", "path", "route", "status"},
 	)
 	
 	HTTPRequestDuration = promauto.NewHistogramVec(
@@ -21,7 +23,16 @@ var (
 			Help:    "HTTP request latency",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"method", "path", "status"},
+		[]string{"method", "path", "route", "status"},
+	)
+	
+	RouteRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "route_request_duration_seconds",
+			Help:    "Latency of requests served by a route, with buckets fine enough for tail percentiles",
+			Buckets: RouteLatencyBuckets,
+		},
+		[]string{"route"},
 	)
 	
 	HTTPRequestsInFlight = promauto.NewGauge(
@@ -40,12 +51,124 @@ var (
 		[]string{"backend", "endpoint"},
 	)
 	
+	BackendHealthCheckFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_health_check_failures_total",
+			Help: "Total failed health checks by failure reason",
+		},
+		[]string{"backend", "reason"},
+	)
+	
+	EndpointEjectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_endpoint_ejections_total",
+			Help: "Total endpoints ejected by passive health checks",
+		},
+		[]string{"backend", "endpoint"},
+	)
+	
+	BackendErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_errors_total",
+			Help: "Total proxied requests that got no response from the backend, by failure reason",
+		},
+		[]string{"backend", "reason"},
+	)
+	
 	BackendRequestsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "backend_requests_total",
-			Help: "Total requests to backend services",
+			Help: "Total requests to backend services; error_class tells timeouts from refusals",
 		},
-		[]string{"backend", "endpoint", "status"},
+		[]string{"backend", "endpoint", "status", "error_class"},
+	)
+	
+	RouteBackendRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_backend_requests_total",
+			Help: "Total requests per route and serving backend (primary or canary)",
+		},
+		[]string{"route", "backend", "status"},
+	)
+	
+	RouteFallbacksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_fallbacks_total",
+			Help: "Total requests served by a route's fallback backend",
+		},
+		[]string{"route", "backend", "reason"},
+	)
+	
+	ClientDisconnectsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "client_disconnects_total",
+			Help: "Total requests abandoned by the client before the response completed",
+		},
+		[]string{"route", "phase"},
+	)
+	
+	CacheHitsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_cache_hits_total",
+			Help: "Total responses served from a route's response cache",
+		},
+		[]string{"route"},
+	)
+	
+	CacheMissesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_cache_misses_total",
+			Help: "Total cacheable requests not found in a route's response cache",
+		},
+		[]string{"route"},
+	)
+	
+	CacheStaleTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_cache_stale_total",
+			Help: "Total expired responses served from a route's response cache, by reason (revalidate, error or circuit_open)",
+		},
+		[]string{"route", "reason"},
+	)
+	
+	BackendOverridesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_overrides_total",
+			Help: "Total X-Backend-Override attempts by outcome (applied, rejected or invalid_token)",
+		},
+		[]string{"route", "outcome"},
+	)
+	
+	BodyTooLargeTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_body_too_large_total",
+			Help: "Total requests answered with 413 because their body exceeded the route's max_body_bytes",
+		},
+		[]string{"route"},
+	)
+	
+	BackendRequestsInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "backend_requests_in_flight",
+			Help: "Current number of requests in flight to each backend",
+		},
+		[]string{"backend"},
+	)
+	
+	BackendQueueRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_queue_rejections_total",
+			Help: "Total requests rejected because a backend's concurrency limit stayed full for the queue timeout",
+		},
+		[]string{"backend"},
+	)
+	
+	BackendHeaderLimitViolationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_header_limit_violations_total",
+			Help: "Total requests whose headers broke a backend's header limits, by limit and whether the request was rejected or the header stripped",
+		},
+		[]string{"backend", "limit", "action"},
 	)
 	
 	BackendRequestDuration = promauto.NewHistogramVec(
@@ -92,6 +215,67 @@ var (
 		},
 		[]string{"backend"},
 	)
+	
+	ConfigDrift = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_drift",
+			Help: "Whether the running configuration differs from the configuration file (0=in sync, 1=drifted)",
+		},
+	)
+	
+	MemoryUsage = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "memory_usage_bytes",
+			Help: "Process memory use sampled by the memory watchdog (the larger of RSS and heap)",
+		},
+	)
+	
+	MemoryLimit = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "memory_limit_bytes",
+			Help: "Memory ceiling the watchdog sheds load against",
+		},
+	)
+	
+	MemoryShedFraction = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "memory_shed_fraction",
+			Help: "Fraction of non-critical requests rejected by emergency load shedding (0 when not shedding)",
+		},
+	)
+	
+	RequestsShedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "requests_shed_total",
+			Help: "Total requests rejected by emergency load shedding",
+		},
+		[]string{"route"},
+	)
+	
+	FeatureFlagRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feature_flag_requests_total",
+			Help: "Total route requests that evaluated a feature flag, by whether it was on",
+		},
+		[]string{"flag", "state", "route", "status"},
+	)
+	
+	FeatureFlagRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "feature_flag_request_duration_seconds",
+			Help:    "Latency of route requests that evaluated a feature flag, by whether it was on",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"flag", "state"},
+	)
+	
+	RouteVersionRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "route_version_requests_total",
+			Help: "Total requests per versioned route and API version",
+		},
+		[]string{"route", "version"},
+	)
 )
 
 // MetricsCollector manages metrics collection
@@ -106,16 +290,90 @@ func NewMetricsCollector() *MetricsCollector {
 	}
 }
 
-// RecordHTTPRequest records an HTTP request metric
-func RecordHTTPRequest(method, path, status string, duration float64) {
-	HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
-	HTTPRequestDuration.WithLabelValues(method, path, status).Observe(duration)
+// RouteLatencyBuckets span 1ms to 5m in about 24% steps, so tail percentiles
+// of route latency can be estimated closely enough to suggest timeouts
+var RouteLatencyBuckets = prometheus.ExponentialBucketsRange(0.001, 300, 60)
+
+// NoRoute is the route label of requests not served by a configured route,
+// such as health checks, admin calls and unmatched paths
+const NoRoute = "none"
+
+// exemplarsEnabled controls whether latency observations carry trace ID exemplars
+var exemplarsEnabled atomic.Bool
+
+// SetExemplarsEnabled turns trace ID exemplars on latency histograms on or off.
+// Exemplars are only exposed to scrapers that negotiate OpenMetrics.
+func SetExemplarsEnabled(enabled bool) {
+	exemplarsEnabled.Store(enabled)
+}
+
+// observe records a latency, attaching the trace ID as an exemplar when enabled
+func observe(observer prometheus.Observer, duration float64, traceID string) {
+	if traceID != "" && exemplarsEnabled.Load() {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(duration, prometheus.Labels{"trace_id": traceID})
+			return
+		}
+	}
+	observer.Observe(duration)
+}
+
+// RecordHTTPRequest records an HTTP request metric.
+// route is the ID of the route that served it, or NoRoute;
+// traceID is the request's sampled trace ID, or "" when it isn't traced.
+func RecordHTTPRequest(method, path, route, status string, duration float64, traceID string) {
+	HTTPRequestsTotal.WithLabelValues(method, path, route, status).Inc()
+	observe(HTTPRequestDuration.WithLabelValues(method, path, route, status), duration, traceID)
+	if route != NoRoute {
+		RouteRequestDuration.WithLabelValues(route).Observe(duration)
+	}
+}
+
+// RecordBackendRequest records a backend request metric.
+// errorClass is "none" when the backend responded; traceID is as for RecordHTTPRequest.
+func RecordBackendRequest(backend, endpoint, status, errorClass string, duration float64, traceID string) {
+	BackendRequestsTotal.WithLabelValues(backend, endpoint, status, errorClass).Inc()
+	observe(BackendRequestDuration.WithLabelValues(backend, endpoint), duration, traceID)
+}
+
+// RecordRouteBackendRequest records which backend served a request for a route
+func RecordRouteBackendRequest(route, backend, status string) {
+	RouteBackendRequestsTotal.WithLabelValues(route, backend, status).Inc()
 }
 
-// RecordBackendRequest records a backend request metric
-func RecordBackendRequest(backend, endpoint, status string, duration float64) {
-	BackendRequestsTotal.WithLabelValues(backend, endpoint, status).Inc()
-	BackendRequestDuration.WithLabelValues(backend, endpoint).Observe(duration)
+// RecordRouteFallback records a request that was served by a fallback backend
+func RecordRouteFallback(route, backend, reason string) {
+	RouteFallbacksTotal.WithLabelValues(route, backend, reason).Inc()
+}
+
+// RecordClientDisconnect records a request abandoned by the client
+func RecordClientDisconnect(route, phase string) {
+	ClientDisconnectsTotal.WithLabelValues(route, phase).Inc()
+}
+
+// RecordCacheHit records a response served from the cache
+func RecordCacheHit(route string) {
+	CacheHitsTotal.WithLabelValues(route).Inc()
+}
+
+// RecordCacheMiss records a cacheable request that was not in the cache
+func RecordCacheMiss(route string) {
+	CacheMissesTotal.WithLabelValues(route).Inc()
+}
+
+// RecordCacheStale records an expired response served from the cache
+func RecordCacheStale(route, reason string) {
+	CacheStaleTotal.WithLabelValues(route, reason).Inc()
+}
+
+// RecordBackendOverride records an X-Backend-Override attempt
+func RecordBackendOverride(route, outcome string) {
+	BackendOverridesTotal.WithLabelValues(route, outcome).Inc()
+}
+
+// RecordBodyTooLarge records a request rejected for its body size
+func RecordBodyTooLarge(route string) {
+	BodyTooLargeTotal.WithLabelValues(route).Inc()
 }
 
 // SetBackendHealth sets the health status of a backend
@@ -127,6 +385,21 @@ func SetBackendHealth(backend, endpoint string, healthy bool) {
 	BackendHealthStatus.WithLabelValues(backend, endpoint).Set(value)
 }
 
+// RecordHealthCheckFailure records a failed health check
+func RecordHealthCheckFailure(backend, reason string) {
+	BackendHealthCheckFailuresTotal.WithLabelValues(backend, reason).Inc()
+}
+
+// RecordEndpointEjection records an endpoint ejected by a passive health check
+func RecordEndpointEjection(backend, endpoint string) {
+	EndpointEjectionsTotal.WithLabelValues(backend, endpoint).Inc()
+}
+
+// RecordBackendError records a proxied request the backend did not answer
+func RecordBackendError(backend, reason string) {
+	BackendErrorsTotal.WithLabelValues(backend, reason).Inc()
+}
+
 // SetCircuitBreakerState sets the circuit breaker state
 func SetCircuitBreakerState(backend string, state int) {
 	CircuitBreakerState.WithLabelValues(backend).Set(float64(state))
@@ -137,7 +410,55 @@ func RecordCircuitBreakerTrip(backend string) {
 	CircuitBreakerTrips.WithLabelValues(backend).Inc()
 }
 
+// SetConfigDrift sets whether the running configuration has drifted from the file
+func SetConfigDrift(drifted bool) {
+	value := 0.0
+	if drifted {
+		value = 1.0
+	}
+	ConfigDrift.Set(value)
+}
+
 // RecordRateLimitExceeded records a rate limit exceeded event
 func RecordRateLimitExceeded(route, client string) {
 	RateLimitExceeded.WithLabelValues(route, client).Inc()
-}
\ No newline at end of file
+}
+
+// SetMemoryStatus records the memory watchdog's latest sample
+func SetMemoryStatus(usage, limit uint64, shedFraction float64) {
+	MemoryUsage.Set(float64(usage))
+	MemoryLimit.Set(float64(limit))
+	MemoryShedFraction.Set(shedFraction)
+}
+
+// RecordRequestShed records a request rejected by emergency load shedding
+func RecordRequestShed(route string) {
+	RequestsShedTotal.WithLabelValues(route).Inc()
+}
+
+// RecordRouteVersionRequest records the API version of a request to a versioned route
+func RecordRouteVersionRequest(route, version string) {
+	RouteVersionRequestsTotal.WithLabelValues(route, version).Inc()
+}
+
+// AddBackendInFlight adjusts the number of requests in flight to a backend by delta
+func AddBackendInFlight(backend string, delta float64) {
+	BackendRequestsInFlight.WithLabelValues(backend).Add(delta)
+}
+
+// RecordBackendQueueRejection records a request rejected by a backend's concurrency limit
+func RecordBackendQueueRejection(backend string) {
+	BackendQueueRejectionsTotal.WithLabelValues(backend).Inc()
+}
+
+// RecordHeaderLimitViolation records a request whose headers broke a backend's limit;
+// action is "rejected" or "stripped"
+func RecordHeaderLimitViolation(backend, limit, action string) {
+	BackendHeaderLimitViolationsTotal.WithLabelValues(backend, limit, action).Inc()
+}
+
+// RecordFeatureFlagRequest records a request that evaluated a flag; state is "on" or "off"
+func RecordFeatureFlagRequest(flag, state, route, status string, duration float64) {
+	FeatureFlagRequestsTotal.WithLabelValues(flag, state, route, status).Inc()
+	FeatureFlagRequestDuration.WithLabelValues(flag, state).Observe(duration)
+}
@@ -0,0 +1,250 @@
+// Package profiling takes periodic CPU and heap profiles of the running router, so
+// performance regressions can be investigated from production. Snapshots are written
+// to a directory, pushed to a Pyroscope-compatible server, or both.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/config"
+)
+
+// Profile types, used in snapshot file names
+const (
+	profileCPU  = "cpu"
+	profileHeap = "heap"
+)
+
+// Status describes the profiler for admin clients
+type Status struct {
+	Running      bool       `json:"running"`
+	Until        *time.Time `json:"until,omitempty"` // absent while running until stopped
+	Directory    string     `json:"directory,omitempty"`
+	PushURL      string     `json:"push_url,omitempty"`
+	Interval     string     `json:"interval"`
+	CPUDuration  string     `json:"cpu_duration"`
+	Snapshots    int        `json:"snapshots"` // taken since startup
+	LastSnapshot *time.Time `json:"last_snapshot,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// Profiler takes a CPU profile of CPUDuration and a heap profile every interval while running
+type Profiler struct {
+	config config.ProfilingConfig
+	logger *slog.Logger
+	client *http.Client
+
+	mutex        sync.Mutex
+	cancel       context.CancelFunc // nil while stopped
+	done         chan struct{}      // closed when the snapshot loop returns
+	until        time.Time          // zero while running until stopped
+	timer        *time.Timer
+	snapshots    int
+	lastSnapshot time.Time
+	lastError    string
+}
+
+// New creates a stopped profiler for a configuration with a directory or push URL
+func New(cfg config.ProfilingConfig, logger *slog.Logger) *Profiler {
+	return &Profiler{
+		config: cfg,
+		logger: logger,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// DefaultDuration is how long profiling started through the admin API runs by default
+func (p *Profiler) DefaultDuration() time.Duration {
+	return p.config.DefaultDuration
+}
+
+// Start profiles for duration, or until stopped when it is 0. Starting while running
+// replaces the expiry without interrupting the snapshot in progress.
+func (p *Profiler) Start(duration time.Duration) Status {
+	p.mutex.Lock()
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.until = time.Time{}
+	if duration > 0 {
+		p.until = time.Now().Add(duration)
+		p.timer = time.AfterFunc(duration, p.expire)
+	}
+	if p.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.cancel, p.done = cancel, make(chan struct{})
+		go p.run(ctx, p.done)
+	}
+	p.mutex.Unlock()
+	return p.Status()
+}
+
+// Stop stops profiling, discarding a CPU profile in progress, and reports whether it was running
+func (p *Profiler) Stop() bool {
+	p.mutex.Lock()
+	cancel, done := p.release()
+	p.mutex.Unlock()
+	return wait(cancel, done)
+}
+
+// expire stops profiling once the window set by Start has passed
+func (p *Profiler) expire() {
+	p.mutex.Lock()
+	if p.cancel == nil || p.until.IsZero() || time.Now().Before(p.until) {
+		p.mutex.Unlock()
+		return
+	}
+	cancel, done := p.release()
+	p.mutex.Unlock()
+
+	p.logger.Info("Profiling window expired, profiling stopped")
+	wait(cancel, done)
+}
+
+// release clears the running state and returns what stops the loop; the caller must hold the mutex
+func (p *Profiler) release() (context.CancelFunc, chan struct{}) {
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done, p.until = nil, nil, time.Time{}
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	return cancel, done
+}
+
+// wait stops a snapshot loop released by release and waits for it to return
+func wait(cancel context.CancelFunc, done chan struct{}) bool {
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	<-done
+	return true
+}
+
+// Status returns whether profiling is running and the outcome of the last snapshot
+func (p *Profiler) Status() Status {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	status := Status{
+		Running:     p.cancel != nil,
+		Directory:   p.config.Directory,
+		PushURL:     p.config.PushURL,
+		Interval:    p.config.Interval.String(),
+		CPUDuration: p.config.CPUDuration.String(),
+		Snapshots:   p.snapshots,
+		LastError:   p.lastError,
+	}
+	if !p.until.IsZero() {
+		until := p.until
+		status.Until = &until
+	}
+	if !p.lastSnapshot.IsZero() {
+		last := p.lastSnapshot
+		status.LastSnapshot = &last
+	}
+	return status
+}
+
+// run takes a snapshot at once and then every interval until ctx is cancelled
+func (p *Profiler) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.snapshot(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// snapshot takes a CPU profile followed by a heap profile and stores both. A CPU profile
+// cut short by Stop is discarded; one that can't start, e.g. because another CPU profile
+// is running, is reported and the heap profile is still taken.
+func (p *Profiler) snapshot(ctx context.Context) {
+	from := time.Now()
+	var cpu bytes.Buffer
+	cpuErr := profileCPUFor(ctx, &cpu, p.config.CPUDuration)
+	if ctx.Err() != nil {
+		return
+	}
+	until := time.Now()
+
+	var errs []error
+	if cpuErr != nil {
+		errs = append(errs, fmt.Errorf("cpu profile: %w", cpuErr))
+	} else {
+		errs = append(errs, p.store(ctx, profileCPU, from, until, cpu.Bytes()))
+	}
+
+	var heap bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		errs = append(errs, fmt.Errorf("heap profile: %w", err))
+	} else {
+		errs = append(errs, p.store(ctx, profileHeap, until, until, heap.Bytes()))
+	}
+
+	p.record(until, errors.Join(errs...))
+}
+
+// profileCPUFor writes a CPU profile of duration to w, ending early if ctx is cancelled
+func profileCPUFor(ctx context.Context, w io.Writer, duration time.Duration) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	pprof.StopCPUProfile()
+	return nil
+}
+
+// store writes a profile to the directory and pushes it, where configured
+func (p *Profiler) store(ctx context.Context, kind string, from, until time.Time, data []byte) error {
+	var errs []error
+	if p.config.Directory != "" {
+		errs = append(errs, p.write(kind, until, data))
+	}
+	if p.config.PushURL != "" {
+		errs = append(errs, p.push(ctx, kind, from, until, data))
+	}
+	return errors.Join(errs...)
+}
+
+// record keeps the outcome of a snapshot for Status
+func (p *Profiler) record(at time.Time, err error) {
+	p.mutex.Lock()
+	p.snapshots++
+	p.lastSnapshot = at
+	p.lastError = ""
+	if err != nil {
+		p.lastError = err.Error()
+	}
+	p.mutex.Unlock()
+
+	if err != nil {
+		p.logger.Warn("Failed to store profile snapshot", "error", err)
+		return
+	}
+	p.logger.Debug("Profile snapshot stored", "directory", p.config.Directory, "push_url", p.config.PushURL)
+}
@@ -0,0 +1,136 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotTimeFormat names snapshot files; it sorts in time order
+const snapshotTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// snapshotName returns the file name of a profile of the given type taken at t,
+// e.g. cpu-2026-10-16T09-30-00.000000000.pprof
+func snapshotName(kind string, t time.Time) string {
+	return kind + "-" + t.UTC().Format(snapshotTimeFormat) + ".pprof"
+}
+
+// takenAt returns when the snapshot file with the given name was taken, or false if it
+// isn't a snapshot of the given type
+func takenAt(kind, name string) (time.Time, bool) {
+	prefix := kind + "-"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".pprof") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(snapshotTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".pprof"))
+	return t, err == nil
+}
+
+// write saves a profile to the directory, creating it if needed, and prunes old snapshots.
+// The profile is renamed into place so readers never see a partial file.
+func (p *Profiler) write(kind string, t time.Time, data []byte) error {
+	if err := os.MkdirAll(p.config.Directory, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.config.Directory, snapshotName(kind, t))
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	p.prune(kind)
+	return nil
+}
+
+// snapshotFiles returns the snapshot files of the given type, oldest first
+func (p *Profiler) snapshotFiles(kind string) ([]string, error) {
+	entries, err := os.ReadDir(p.config.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if _, ok := takenAt(kind, entry.Name()); ok && !entry.IsDir() {
+			files = append(files, filepath.Join(p.config.Directory, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// prune removes snapshots of the given type beyond the count limit and older than the
+// age limit. Failures only leave extra files behind, so they are ignored.
+func (p *Profiler) prune(kind string) {
+	if p.config.MaxFiles == 0 && p.config.MaxAge == 0 {
+		return
+	}
+	files, err := p.snapshotFiles(kind)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-p.config.MaxAge)
+	for i, file := range files {
+		excess := p.config.MaxFiles > 0 && i < len(files)-p.config.MaxFiles
+		t, _ := takenAt(kind, filepath.Base(file))
+		expired := p.config.MaxAge > 0 && t.Before(cutoff)
+		if excess || expired {
+			os.Remove(file)
+		}
+	}
+}
+
+// push sends a profile to the Pyroscope-compatible server's ingest endpoint as a
+// multipart pprof upload. The server reads the profile type from the pprof itself.
+func (p *Profiler) push(ctx context.Context, kind string, from, until time.Time, data []byte) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("profile", kind+".pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"name":    {p.config.AppName},
+		"from":    {strconv.FormatInt(from.Unix(), 10)},
+		"until":   {strconv.FormatInt(until.Unix(), 10)},
+		"format":  {"pprof"},
+		"spyName": {"gospy"},
+	}
+	target := strings.TrimSuffix(p.config.PushURL, "/") + "/ingest?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push %s profile: %w", kind, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("push %s profile: %s", kind, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RouteUsage is how much a route has been used since startup
+type RouteUsage struct {
+	Requests uint64            `json:"requests"`
+	ByStatus map[string]uint64 `json:"by_status"`
+	P50      string            `json:"p50,omitempty"` // estimated from the latency histogram
+	P99      string            `json:"p99,omitempty"`
+}
+
+// RouteUsageStats reads the per-route request counts and latencies from the gatherer, keyed
+// by route ID. Requests no route matched are under the empty ID.
+func RouteUsageStats(gatherer prometheus.Gatherer) (map[string]*RouteUsage, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]*RouteUsage)
+	for _, family := range families {
+		if family.GetName() != "http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var route, status string
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "route":
+					route = label.GetValue()
+				case "status":
+					status = label.GetValue()
+				}
+			}
+
+			stats, exists := usage[route]
+			if !exists {
+				stats = &RouteUsage{ByStatus: make(map[string]uint64)}
+				usage[route] = stats
+			}
+			count := uint64(metric.GetCounter().GetValue())
+			stats.Requests += count
+			stats.ByStatus[status] += count
+		}
+	}
+
+	histograms, err := RouteLatencyHistograms(gatherer)
+	if err != nil {
+		return nil, err
+	}
+	for route, histogram := range histograms {
+		if stats, exists := usage[route]; exists && histogram.Count > 0 {
+			stats.P50 = histogram.Quantile(0.5).String()
+			stats.P99 = histogram.Quantile(0.99).String()
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/lib/bodycapture"
+)
+
+// bufferBody captures the request body so it can be sent more than once, and sets
+// req.GetBody to replay it. It reports false when the body is over the buffer cap;
+// the request then still carries the whole body but can only be sent once.
+// The capture middleware removes any spill file when the request completes.
+func bufferBody(req *http.Request) (bool, error) {
+	body, err := bodycapture.Request(req)
+	if err != nil {
+		return false, err
+	}
+	if body == nil {
+		return true, nil
+	}
+
+	replay, err := body.Replay()
+	if errors.Is(err, bodycapture.ErrTooLarge) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	req.GetBody = body.Replay
+	req.Body = replay
+	return true, nil
+}
+
+// replayBody rewinds a buffered request body
+func replayBody(req *http.Request) {
+	if req.GetBody != nil {
+		req.Body, _ = req.GetBody()
+	}
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// errorClassBodyTooLarge is the error class of requests whose body breaks the route's limit
+const errorClassBodyTooLarge = "body_too_large"
+
+// bodyLimitKey is the request context key of the route's body limit
+type bodyLimitKey struct{}
+
+// bodyLimit returns the route's request body limit, or 0 for none
+func (r *Router) bodyLimit(route *models.RouteConfig) int64 {
+	if route.MaxBodyBytes > 0 {
+		return route.MaxBodyBytes
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.config.Router.MaxBodyBytes
+}
+
+// withBodyLimit attaches the body limit to the request for the proxy Director
+func withBodyLimit(req *http.Request, limit int64) *http.Request {
+	if limit <= 0 {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), bodyLimitKey{}, limit))
+}
+
+// applyBodyLimit stops the outgoing request's body at the limit attached to it. The limit
+// applies to each attempt, so a body buffered for a fallback is limited on replay too.
+func applyBodyLimit(req *http.Request) {
+	limit, ok := req.Context().Value(bodyLimitKey{}).(int64)
+	if !ok || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	req.Body = http.MaxBytesReader(nil, req.Body, limit)
+}
+
+// rejectLargeBody answers a request whose declared length is over the limit before
+// anything is forwarded, and reports whether it did. Bodies without a declared
+// length are cut off by applyBodyLimit as they are forwarded.
+func (r *Router) rejectLargeBody(w http.ResponseWriter, req *http.Request, route *models.RouteConfig, limit int64) bool {
+	if limit <= 0 || req.ContentLength <= limit {
+		return false
+	}
+	r.recordBodyTooLarge(req, route, limit)
+	writeBodyTooLarge(w, req, limit)
+	return true
+}
+
+// recordBodyTooLarge logs and counts a request rejected by the route's body limit
+func (r *Router) recordBodyTooLarge(req *http.Request, route *models.RouteConfig, limit int64) {
+	r.logger.WarnContext(req.Context(), "Request body exceeds route limit",
+		"route", route.ID,
+		"content_length", req.ContentLength,
+		"max", limit,
+	)
+	services.RecordBodyTooLarge(route.ID)
+	middleware.SetErrorClass(req, errorClassBodyTooLarge)
+}
+
+// bodyTooLarge returns the limit a request body broke, if err is from applyBodyLimit
+func bodyTooLarge(err error) (int64, bool) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return tooLarge.Limit, true
+	}
+	return 0, false
+}
+
+// writeBodyTooLarge answers with 413 and the limit
+func writeBodyTooLarge(w http.ResponseWriter, req *http.Request, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Code:      "request_entity_too_large",
+		Message:   "Request body exceeds the route's limit",
+		RequestID: req.Header.Get("X-Request-ID"),
+		Details: map[string]interface{}{
+			"error_class": errorClassBodyTooLarge,
+			"max_bytes":   limit,
+		},
+	})
+}
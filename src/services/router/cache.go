@@ -0,0 +1,415 @@
+package router
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/flags"
+)
+
+// maxCachedBodyBytes limits the size of a response body that will be cached
+const maxCachedBodyBytes = 1 << 20
+
+// cacheEntry is a stored response
+type cacheEntry struct {
+	key        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// fresh reports whether the entry is within its TTL
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.expires)
+}
+
+// responseCache is an LRU cache of successful GET responses for one route
+type responseCache struct {
+	config       *models.CacheConfig
+	entries      map[string]*list.Element
+	lru          *list.List
+	revalidating map[string]bool // keys being refreshed in the background
+	perRequest   []string        // headers set for each request before the cache, never stored
+	mutex        sync.Mutex
+}
+
+// newResponseCache creates a cache that drops the request ID headers, X-Request-ID and the
+// configured correlation headers, from the responses it stores
+func newResponseCache(config *models.CacheConfig, correlationHeaders []string) *responseCache {
+	return &responseCache{
+		config:       config,
+		perRequest:   append([]string{"X-Cache", "X-Request-ID"}, correlationHeaders...),
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+		revalidating: make(map[string]bool),
+	}
+}
+
+// get returns the entry for the key, fresh or not. Expired entries stay until they are
+// replaced or evicted, as the last known good response while the backend's circuit is open.
+func (c *responseCache) get(key string) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return element.Value.(*cacheEntry), true
+}
+
+// set stores an entry, evicting the least recently used entry when full
+func (c *responseCache) set(entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[entry.key]; exists {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// store caches a response under the key. The per-request headers are dropped and an
+// ETag is added when the backend sent none.
+func (c *responseCache) store(key string, statusCode int, header http.Header, body []byte) {
+	header = header.Clone()
+	for _, name := range c.perRequest {
+		header.Del(name)
+	}
+	if header.Get("ETag") == "" {
+		header.Set("ETag", generateETag(body))
+	}
+	c.set(&cacheEntry{
+		key:        key,
+		statusCode: statusCode,
+		header:     header,
+		body:       body,
+		expires:    time.Now().Add(c.config.TTL),
+	})
+}
+
+// startRevalidation reports whether the caller should refresh the key, which is
+// false while another refresh of it is running
+func (c *responseCache) startRevalidation(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.revalidating[key] {
+		return false
+	}
+	c.revalidating[key] = true
+	return true
+}
+
+// finishRevalidation allows the key to be refreshed again
+func (c *responseCache) finishRevalidation(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.revalidating, key)
+}
+
+// purge removes all entries and returns how many were removed
+func (c *responseCache) purge() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := c.lru.Len()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	return count
+}
+
+// key builds the cache key from the method, path, query and configured vary headers
+func (c *responseCache) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(req.URL.Path)
+	b.WriteString("?")
+	b.WriteString(req.URL.Query().Encode())
+
+	for _, name := range c.config.VaryHeaders {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteString(": ")
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+
+	return b.String()
+}
+
+// cacheHandler serves GET requests from the route's cache and stores successful responses.
+// An expired entry is served while the route's backend circuit is open. Otherwise one within
+// the route's stale_while_revalidate window is served while it is refreshed in the background,
+// and one within its stale_if_error window is served when the backend fails.
+func (r *Router) cacheHandler(route *models.RouteConfig, cache *responseCache, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Overridden requests may go to another backend, whose responses must not be shared
+		if req.Method != http.MethodGet || r.overridden(req, route) || !shareable(route, req) || !r.flags.On(req, flags.ResponseCache) || r.watchdog.Shedding() {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		key := cache.key(req)
+		entry, ok := cache.get(key)
+		if ok {
+			now := time.Now()
+			if entry.fresh(now) {
+				services.RecordCacheHit(route.ID)
+				writeCacheEntry(w, req, entry, "HIT")
+				return
+			}
+			if r.circuitOpen(route) {
+				services.RecordCacheStale(route.ID, "circuit_open")
+				writeCacheEntry(w, req, entry, "STALE")
+				return
+			}
+			if now.Before(entry.expires.Add(cache.config.StaleWhileRevalidate)) {
+				services.RecordCacheStale(route.ID, "revalidate")
+				if cache.startRevalidation(key) {
+					go r.revalidate(cache, key, middleware.Detach(req), next)
+				}
+				writeCacheEntry(w, req, entry, "STALE")
+				return
+			}
+			if now.Before(entry.expires.Add(cache.config.StaleIfError)) {
+				r.serveOrStale(w, req, route, cache, key, entry, next)
+				return
+			}
+		}
+
+		services.RecordCacheMiss(route.ID)
+		w.Header().Set("X-Cache", "MISS")
+
+		recorder := &cacheRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+
+		if cacheable(recorder.statusCode, w.Header(), recorder.tooLarge) {
+			cache.store(key, recorder.statusCode, w.Header(), recorder.body.Bytes())
+		}
+	})
+}
+
+// shareable reports whether a response to the request may be shared with other callers.
+// The key has no credentials in it, so requests carrying them and routes with auth bypass
+// the cache unless the route opts in.
+func shareable(route *models.RouteConfig, req *http.Request) bool {
+	if route.Cache.Authenticated {
+		return true
+	}
+	if req.Header.Get("Authorization") != "" || req.Header.Get("X-API-Key") != "" {
+		return false
+	}
+	return (route.Auth == nil || !route.Auth.Enabled) && route.AuthPolicy == ""
+}
+
+// circuitOpen reports whether the route's backend is rejecting requests with an open circuit
+func (r *Router) circuitOpen(route *models.RouteConfig) bool {
+	backend, exists := r.GetBackend(route.Backend)
+	return exists && backend.CircuitBreaker.Rejecting()
+}
+
+// writeCacheEntry writes a cached response, or 304 when the client's copy is current
+func writeCacheEntry(w http.ResponseWriter, req *http.Request, entry *cacheEntry, xCache string) {
+	copyHeader(w.Header(), entry.header)
+	w.Header().Set("X-Cache", xCache)
+	if etagMatches(req.Header.Get("If-None-Match"), entry.header.Get("ETag")) {
+		// The client's copy is current, so only the headers are sent
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(entry.statusCode)
+	w.Write(entry.body)
+}
+
+// serveOrStale sends the request to the backend and serves the stale entry instead
+// if the backend fails with a 5xx, which includes the router's own gateway errors
+func (r *Router) serveOrStale(w http.ResponseWriter, req *http.Request, route *models.RouteConfig, cache *responseCache, key string, stale *cacheEntry, next http.Handler) {
+	buffered := newBufferedResponse()
+	next.ServeHTTP(buffered, req)
+
+	if buffered.statusCode >= http.StatusInternalServerError {
+		services.RecordCacheStale(route.ID, "error")
+		r.logger.WarnContext(req.Context(), "Serving stale cached response",
+			"route", route.ID,
+			"status", buffered.statusCode)
+		writeCacheEntry(w, req, stale, "STALE")
+		return
+	}
+
+	services.RecordCacheMiss(route.ID)
+	copyHeader(w.Header(), buffered.header)
+	w.Header().Set("X-Cache", "MISS")
+	w.WriteHeader(buffered.statusCode)
+	w.Write(buffered.body.Bytes())
+
+	if cacheable(buffered.statusCode, buffered.header, buffered.tooLarge()) {
+		cache.store(key, buffered.statusCode, buffered.header, buffered.body.Bytes())
+	}
+}
+
+// revalidate refreshes a stale entry in the background with a detached copy of the
+// request. A failed refresh keeps the stale entry.
+func (r *Router) revalidate(cache *responseCache, key string, req *http.Request, next http.Handler) {
+	defer cache.finishRevalidation(key)
+
+	buffered := newBufferedResponse()
+	next.ServeHTTP(buffered, req)
+
+	if cacheable(buffered.statusCode, buffered.header, buffered.tooLarge()) {
+		cache.store(key, buffered.statusCode, buffered.header, buffered.body.Bytes())
+		return
+	}
+	r.logger.DebugContext(req.Context(), "Cache revalidation not stored",
+		"key", key,
+		"status", buffered.statusCode)
+}
+
+// generateETag returns a strong ETag for a cached body that has none from the backend
+func generateETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag.
+// If-None-Match uses the weak comparison, so W/ prefixes are ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// PurgeCache removes all cached responses for a route.
+// It returns the number of removed entries and whether the route has a cache.
+func (r *Router) PurgeCache(routeID string) (int, bool) {
+	r.mutex.RLock()
+	cache, exists := r.caches[routeID]
+	r.mutex.RUnlock()
+
+	if !exists {
+		return 0, false
+	}
+	return cache.purge(), true
+}
+
+// cacheRecorder passes a response through while keeping a copy of the body
+type cacheRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	body        bytes.Buffer
+	tooLarge    bool
+	wroteHeader bool
+}
+
+func (cr *cacheRecorder) WriteHeader(code int) {
+	if !cr.wroteHeader {
+		cr.wroteHeader = true
+		cr.statusCode = code
+	}
+	cr.ResponseWriter.WriteHeader(code)
+}
+
+func (cr *cacheRecorder) Write(b []byte) (int, error) {
+	if !cr.wroteHeader {
+		cr.WriteHeader(http.StatusOK)
+	}
+	if !cr.tooLarge {
+		if cr.body.Len()+len(b) > maxCachedBodyBytes {
+			cr.tooLarge = true
+			cr.body.Reset()
+		} else {
+			cr.body.Write(b)
+		}
+	}
+	return cr.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streaming responses
+func (cr *cacheRecorder) Flush() {
+	_ = http.NewResponseController(cr.ResponseWriter).Flush()
+}
+
+// bufferedResponse holds a whole response so the cache can decide what to send
+type bufferedResponse struct {
+	header      http.Header
+	statusCode  int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), statusCode: http.StatusOK}
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(code int) {
+	if !br.wroteHeader {
+		br.wroteHeader = true
+		br.statusCode = code
+	}
+}
+
+func (br *bufferedResponse) Write(b []byte) (int, error) {
+	if !br.wroteHeader {
+		br.WriteHeader(http.StatusOK)
+	}
+	return br.body.Write(b)
+}
+
+// tooLarge reports whether the body is over the cacheable size
+func (br *bufferedResponse) tooLarge() bool {
+	return br.body.Len() > maxCachedBodyBytes
+}
+
+// cacheable reports whether a response may be stored. The cache is shared between callers,
+// so responses the backend marked no-store or private are not.
+func cacheable(statusCode int, header http.Header, tooLarge bool) bool {
+	if statusCode != http.StatusOK || tooLarge {
+		return false
+	}
+
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	for _, directive := range strings.Split(strings.ToLower(header.Get("Cache-Control")), ",") {
+		switch strings.TrimSpace(directive) {
+		case "no-store", "private":
+			return false
+		}
+	}
+	return true
+}
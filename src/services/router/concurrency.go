@@ -0,0 +1,75 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// newSlots returns the semaphore for the backend's concurrency limit, or nil when it has none
+func newSlots(config models.BackendService) chan struct{} {
+	if config.MaxConcurrentRequests <= 0 {
+		return nil
+	}
+	return make(chan struct{}, config.MaxConcurrentRequests)
+}
+
+// acquire takes a concurrency slot, waiting up to the backend's queue timeout for one.
+// It returns how long it waited and whether it got a slot; a slot must be given back with release.
+func (b *Backend) acquire(ctx context.Context) (time.Duration, bool) {
+	if b.slots == nil {
+		return 0, true
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return 0, true
+	default:
+	}
+	if b.Config.QueueTimeout <= 0 {
+		return 0, false
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(b.Config.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return time.Since(start), true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return time.Since(start), false
+}
+
+// release gives back a slot taken by acquire
+func (b *Backend) release() {
+	if b.slots != nil {
+		<-b.slots
+	}
+}
+
+// rejectConcurrency answers a request that found the backend's concurrency limit full
+// with 503 and Retry-After
+func (r *Router) rejectConcurrency(w http.ResponseWriter, req *http.Request, route *models.RouteConfig, backend *Backend, waited time.Duration) {
+	r.logger.WarnContext(req.Context(), "Backend concurrency limit reached",
+		"route", route.ID,
+		"backend", backend.Config.ID,
+		"max_concurrent_requests", backend.Config.MaxConcurrentRequests,
+		"queue_wait", waited.String(),
+	)
+
+	status := strconv.Itoa(http.StatusServiceUnavailable)
+	services.RecordBackendQueueRejection(backend.Config.ID)
+	services.RecordRouteBackendRequest(route.ID, backend.Config.ID, status)
+	middleware.SetErrorClass(req, errorClassConcurrencyLimit)
+
+	w.Header().Set("Retry-After", "1")
+	writeProxyError(w, req, http.StatusServiceUnavailable, errorClassConcurrencyLimit)
+}
@@ -0,0 +1,171 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// defaultDebugBodyBytes is how much of each body is logged when no limit is configured
+const defaultDebugBodyBytes = 4096
+
+// redactedHeaders carry credentials and are never logged
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// debugTransport logs each request to a backend and its response in detail.
+// It wraps the backend's transport when the backend's logging config sets Debug.
+type debugTransport struct {
+	next      http.RoundTripper
+	backendID string
+	config    models.BackendLoggingConfig
+	logger    *slog.Logger
+	clock     clock.Clock
+}
+
+// newDebugTransport wraps next, or the default transport when next is nil
+func newDebugTransport(next http.RoundTripper, backendID string, config models.BackendLoggingConfig, logger *slog.Logger, c clock.Clock) *debugTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = defaultDebugBodyBytes
+	}
+	return &debugTransport{next: next, backendID: backendID, config: config, logger: logger, clock: c}
+}
+
+// RoundTrip implements http.RoundTripper. The request is logged once the backend
+// answers; the response is logged when its body is closed, so the body and the
+// full duration can be included.
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.clock.Now()
+
+	var requestBody *bodySample
+	if t.config.LogBody && req.Body != nil && req.Body != http.NoBody {
+		requestBody = newBodySample(req.Body, t.config.MaxBodyBytes)
+		// A RoundTripper must not modify the request, so send a copy with the sampled body
+		sampled := *req
+		sampled.Body = requestBody
+		req = &sampled
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	attrs := []any{
+		"backend", t.backendID,
+		"method", req.Method,
+		"url", req.URL.String(),
+		"request_id", req.Header.Get("X-Request-ID"),
+		"headers", redactHeaders(req.Header),
+	}
+	if requestBody != nil {
+		attrs = append(attrs, "body", requestBody.String())
+	}
+	if err != nil {
+		attrs = append(attrs, "duration", clock.Since(t.clock, start).String(), "error", err)
+		t.logger.Info("Backend debug request failed", attrs...)
+		return nil, err
+	}
+	t.logger.Info("Backend debug request", attrs...)
+
+	responseBody := newBodySample(resp.Body, 0)
+	if t.config.LogBody {
+		responseBody.max = t.config.MaxBodyBytes
+	}
+	responseBody.onClose = func() {
+		attrs := []any{
+			"backend", t.backendID,
+			"status", resp.StatusCode,
+			"request_id", req.Header.Get("X-Request-ID"),
+			"headers", redactHeaders(resp.Header),
+			"bytes", responseBody.Len(),
+			"duration", clock.Since(t.clock, start).String(),
+		}
+		if t.config.LogBody {
+			attrs = append(attrs, "body", responseBody.String())
+		}
+		t.logger.Info("Backend debug response", attrs...)
+	}
+	resp.Body = responseBody
+	return resp, nil
+}
+
+// redactHeaders returns the headers for logging, with credentials replaced
+func redactHeaders(h http.Header) map[string][]string {
+	logged := make(map[string][]string, len(h))
+	for name, values := range h {
+		if redactedHeaders[name] {
+			logged[name] = []string{"[REDACTED]"}
+			continue
+		}
+		logged[name] = values
+	}
+	return logged
+}
+
+// bodySample passes a body through, keeping its first max bytes for logging.
+// The transport may still be sending a request body after the response arrives,
+// so reads and String are synchronized.
+type bodySample struct {
+	body    io.ReadCloser
+	max     int
+	sample  []byte
+	total   int64
+	onClose func()
+	once    sync.Once
+	mutex   sync.Mutex
+}
+
+// newBodySample wraps body, keeping up to max bytes of it
+func newBodySample(body io.ReadCloser, max int) *bodySample {
+	return &bodySample{body: body, max: max}
+}
+
+// Read implements io.Reader
+func (b *bodySample) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.total += int64(n)
+	if keep := min(n, b.max-len(b.sample)); keep > 0 {
+		b.sample = append(b.sample, p[:keep]...)
+	}
+	return n, err
+}
+
+// Close implements io.Closer; the close callback runs once
+func (b *bodySample) Close() error {
+	err := b.body.Close()
+	if b.onClose != nil {
+		b.once.Do(b.onClose)
+	}
+	return err
+}
+
+// Len returns the number of bytes read so far
+func (b *bodySample) Len() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.total
+}
+
+// String returns the kept bytes, marking a body that was cut short
+func (b *bodySample) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.total > int64(len(b.sample)) {
+		return string(b.sample) + "...(truncated)"
+	}
+	return string(b.sample)
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// defaultDrainTimeout is how long a replaced backend's requests may run when no drain timeout is configured
+const defaultDrainTimeout = 30 * time.Second
+
+// drainPollInterval is how often a draining backend is checked for finished requests
+const drainPollInterval = 10 * time.Millisecond
+
+// errorClassDrainTimeout is the error class of requests cancelled because their backend
+// was replaced and they outlived the drain timeout
+const errorClassDrainTimeout = "drain_timeout"
+
+// errBackendDiscarded is the cause of cancelling requests still running on a discarded backend
+var errBackendDiscarded = errors.New("backend discarded after drain timeout")
+
+// track counts a request against the endpoint until the returned function is called
+func (b *Backend) track(endpointURL string) func() {
+	b.endpointMutex.RLock()
+	counter, exists := b.active[endpointURL]
+	b.endpointMutex.RUnlock()
+	if !exists {
+		return func() {}
+	}
+	counter.Add(1)
+	return func() { counter.Add(-1) }
+}
+
+// activeRequests returns the number of requests using any of the backend's endpoints
+func (b *Backend) activeRequests() int64 {
+	b.endpointMutex.RLock()
+	defer b.endpointMutex.RUnlock()
+
+	var total int64
+	for _, counter := range b.active {
+		total += counter.Load()
+	}
+	return total
+}
+
+// bindDiscard returns the request with a context that is cancelled if the backend is
+// discarded before the request finishes, and a function releasing that context
+func (b *Backend) bindDiscard(req *http.Request) (*http.Request, func()) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	stop := context.AfterFunc(b.discarded, func() { cancel(errBackendDiscarded) })
+	return req.WithContext(ctx), func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// discard cancels the backend's remaining requests and closes its idle connections
+func (b *Backend) discard() {
+	b.cancelDiscard()
+	if closer, ok := b.transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// drain waits up to timeout for the requests on a replaced or removed backend to finish,
+// then discards it. New requests look up the reloaded backends, so the count soon only falls.
+func (r *Router) drain(backend *Backend, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for backend.activeRequests() > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+	}
+
+	remaining := backend.activeRequests()
+	backend.discard()
+	if remaining > 0 {
+		r.logger.Warn("Backend discarded with requests in flight",
+			"backend", backend.Config.ID,
+			"in_flight", remaining,
+			"drain_timeout", timeout.String(),
+		)
+		return
+	}
+	r.logger.Info("Backend drained", "backend", backend.Config.ID)
+}
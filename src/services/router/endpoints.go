@@ -0,0 +1,122 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// ErrBackendNotFound is returned for a backend the router doesn't serve, e.g. a disabled one
+var ErrBackendNotFound = errors.New("backend not found")
+
+// EndpointChanges lists the endpoints UpdateBackendEndpoints changed, by URL
+type EndpointChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Updated []string `json:"updated"` // weight or metadata changed
+}
+
+// UpdateBackendEndpoints changes a backend's endpoints to the given list in place: new
+// endpoints are added, missing ones removed and the weight and metadata of the others
+// updated. Unlike Reload it keeps the backend's proxies, circuit breaker and the balancer's
+// health, drain state and statistics for endpoints that stay, so their traffic is undisturbed.
+// Requests already on a removed endpoint finish normally.
+//
+// The change is to the running router only; a later Reload with a configuration that
+// lists other endpoints rebuilds the backend with those.
+func (r *Router) UpdateBackendEndpoints(backendID string, endpoints []models.EndpointConfig) (EndpointChanges, error) {
+	changes := EndpointChanges{Added: []string{}, Removed: []string{}, Updated: []string{}}
+
+	backend, exists := r.GetBackend(backendID)
+	if !exists {
+		return changes, ErrBackendNotFound
+	}
+
+	backend.endpointMutex.Lock()
+	defer backend.endpointMutex.Unlock()
+
+	updated := backend.Config
+	updated.Endpoints = endpoints
+	if err := updated.Validate(); err != nil {
+		return changes, err
+	}
+	wanted := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		if wanted[endpoint.URL] {
+			return changes, fmt.Errorf("duplicate endpoint %s", endpoint.URL)
+		}
+		wanted[endpoint.URL] = true
+	}
+
+	current := backend.LoadBalancer.Endpoints()
+	for _, endpoint := range endpoints {
+		index := slices.IndexFunc(current, func(ep models.EndpointConfig) bool { return ep.URL == endpoint.URL })
+		switch {
+		case index < 0:
+			if err := r.addEndpointProxy(backend, endpoint.URL); err != nil {
+				return changes, err
+			}
+			backend.LoadBalancer.AddEndpoint(endpoint)
+			changes.Added = append(changes.Added, endpoint.URL)
+		case current[index].Weight != endpoint.Weight || !maps.Equal(current[index].Metadata, endpoint.Metadata):
+			backend.LoadBalancer.AddEndpoint(endpoint)
+			changes.Updated = append(changes.Updated, endpoint.URL)
+		}
+	}
+	for _, endpoint := range current {
+		if !wanted[endpoint.URL] {
+			backend.LoadBalancer.RemoveEndpoint(endpoint.URL)
+			if backend.outliers != nil {
+				backend.outliers.forget(endpoint.URL)
+			}
+			changes.Removed = append(changes.Removed, endpoint.URL)
+		}
+	}
+
+	backend.Config.Endpoints = slices.Clone(endpoints)
+	backend.pruneEndpoints(wanted)
+	return changes, nil
+}
+
+// pruneEndpoints drops the proxies and counters of removed endpoints once no request is
+// using them; the caller must hold the endpoint mutex
+func (b *Backend) pruneEndpoints(wanted map[string]bool) {
+	for endpointURL, counter := range b.active {
+		if wanted[endpointURL] || counter.Load() > 0 {
+			continue
+		}
+		delete(b.proxies, endpointURL)
+		delete(b.inFlight, endpointURL)
+		delete(b.active, endpointURL)
+		b.tuned.Range(func(key, _ any) bool {
+			if strings.HasSuffix(key.(string), " "+endpointURL) {
+				b.tuned.Delete(key)
+			}
+			return true
+		})
+	}
+}
+
+// inFlightCounter returns the endpoint's in-flight counter, or a detached one if the
+// endpoint was removed after being picked
+func (b *Backend) inFlightCounter(endpointURL string) *atomic.Int64 {
+	b.endpointMutex.RLock()
+	defer b.endpointMutex.RUnlock()
+
+	if counter, exists := b.inFlight[endpointURL]; exists {
+		return counter
+	}
+	return &atomic.Int64{}
+}
+
+// currentConfig returns the backend's configuration, including endpoint updates
+func (b *Backend) currentConfig() models.BackendService {
+	b.endpointMutex.RLock()
+	defer b.endpointMutex.RUnlock()
+	return b.Config
+}
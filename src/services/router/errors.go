@@ -0,0 +1,183 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// Classes of proxy errors, reported in the request log and backend metrics.
+// Upstream failures use the shared failure reasons.
+const (
+	errorClassNone             = "none"
+	errorClassCanceled         = string(models.FailureCanceled)
+	errorClassTimeout          = string(models.FailureTimeout)
+	errorClassConcurrencyLimit = "concurrency_limit"
+)
+
+// classifyProxyError returns the error class of a failed upstream request and the status to answer with.
+// Timeouts are answered with 504, everything else that stopped the backend responding with 502.
+func classifyProxyError(err error) (string, int) {
+	reason := models.ClassifyFailure(err)
+	if reason == models.FailureTimeout {
+		return errorClassTimeout, http.StatusGatewayTimeout
+	}
+	return string(reason), http.StatusBadGateway
+}
+
+// errorPagesKey is the request context key of a route's error pages
+type errorPagesKey struct{}
+
+// routeErrorPages are what a route answers the gateway errors the router generates with
+type routeErrorPages struct {
+	pages    map[int]models.ErrorPage
+	fallback *template.Template // parsed once per route; nil unless the route serves html
+}
+
+// newRouteErrorPages prepares the route's error pages, or returns nil when it has none
+func newRouteErrorPages(route *models.RouteConfig, logger *slog.Logger) *routeErrorPages {
+	pages := &routeErrorPages{pages: route.ErrorPages}
+	if route.ContentType == models.ContentTypeHTML && route.HTMLFallback != nil {
+		fallback, err := models.ParseHTMLFallback(route.HTMLFallback)
+		if err != nil {
+			// Validation rejects these, so this only happens for unvalidated configs
+			logger.Error("Invalid HTML fallback template", "route", route.ID, "error", err)
+		}
+		pages.fallback = fallback
+	}
+
+	if len(pages.pages) == 0 && pages.fallback == nil {
+		return nil
+	}
+	return pages
+}
+
+// withErrorPages attaches the route's error pages to the request for the proxy's ErrorHandler
+func withErrorPages(req *http.Request, pages *routeErrorPages) *http.Request {
+	if pages == nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), errorPagesKey{}, pages))
+}
+
+// writeErrorPage answers with the route's error page for the status and reports whether it had one.
+// A page configured for the status wins; html routes otherwise render their fallback for browsers.
+func writeErrorPage(w http.ResponseWriter, req *http.Request, status int) bool {
+	pages, _ := req.Context().Value(errorPagesKey{}).(*routeErrorPages)
+	if pages == nil {
+		return false
+	}
+
+	page, ok := pages.pages[status]
+	if !ok {
+		return pages.fallback != nil && acceptsHTML(req) && writeHTMLFallback(w, req, pages.fallback, status)
+	}
+
+	contentType := page.ContentType
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	io.WriteString(w, page.Body)
+	return true
+}
+
+// htmlFallbackData is what an HTML fallback template is rendered with
+type htmlFallbackData struct {
+	Status     int
+	StatusText string
+	RequestID  string
+	RetryURL   string
+}
+
+// writeHTMLFallback renders the fallback page with the status preserved. The page must
+// not be cached, or the browser would keep showing it after the backend recovers.
+func writeHTMLFallback(w http.ResponseWriter, req *http.Request, fallback *template.Template, status int) bool {
+	var body bytes.Buffer
+	err := fallback.Execute(&body, htmlFallbackData{
+		Status:     status,
+		StatusText: http.StatusText(status),
+		RequestID:  req.Header.Get("X-Request-ID"),
+		RetryURL:   req.URL.RequestURI(),
+	})
+	if err != nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+	return true
+}
+
+// acceptsHTML reports whether the client prefers HTML to JSON, as browsers navigating to a page do.
+// Wildcards don't count, so API clients sending */* still get JSON.
+func acceptsHTML(req *http.Request) bool {
+	var html, json float64
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case "text/html":
+			html = max(html, q)
+		case "application/json":
+			json = max(json, q)
+		}
+	}
+	return html > 0 && html >= json
+}
+
+// writeGatewayError answers with the route's error page for the status, or a plain-text error
+func writeGatewayError(w http.ResponseWriter, req *http.Request, status int) {
+	if !writeErrorPage(w, req, status) {
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// writeProxyError answers a failed upstream request with the route's error page,
+// or a JSON error carrying the request ID
+func writeProxyError(w http.ResponseWriter, req *http.Request, status int, class string) {
+	if writeErrorPage(w, req, status) {
+		return
+	}
+
+	code := "bad_gateway"
+	switch status {
+	case http.StatusGatewayTimeout:
+		code = "gateway_timeout"
+	case http.StatusServiceUnavailable:
+		code = "service_unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Code:      code,
+		Message:   http.StatusText(status),
+		RequestID: req.Header.Get("X-Request-ID"),
+		Details:   map[string]interface{}{"error_class": class},
+	})
+}
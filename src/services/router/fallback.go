@@ -0,0 +1,141 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// selectFallback returns the fallback backend if the route has one and the request may be replayed
+func (r *Router) selectFallback(route *models.RouteConfig, req *http.Request) *Backend {
+	if route.FallbackBackend == "" || !fallbackEligible(route, req.Method) {
+		return nil
+	}
+
+	fallback, exists := r.GetBackend(route.FallbackBackend)
+	if !exists {
+		return nil
+	}
+	return fallback
+}
+
+// fallbackEligible reports whether a request method may be retried against the fallback backend
+func fallbackEligible(route *models.RouteConfig, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+
+	for _, m := range route.FallbackMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveFallback proxies a request to the fallback backend.
+// If the fallback cannot serve it either, the primary's held-back response is sent instead.
+func (r *Router) serveFallback(w http.ResponseWriter, req *http.Request, route *models.RouteConfig, fallback *Backend, primary *fallbackWriter, reason string) {
+	endpoint := r.nextEndpoint(route, req, fallback)
+	if endpoint == nil {
+		if primary != nil {
+			primary.flush()
+			return
+		}
+		writeGatewayError(w, req, http.StatusServiceUnavailable)
+		return
+	}
+
+	r.logger.WarnContext(req.Context(), "Serving request from fallback backend",
+		"route", route.ID,
+		"backend", route.Backend,
+		"fallback", fallback.Config.ID,
+		"reason", reason,
+	)
+	services.RecordRouteFallback(route.ID, fallback.Config.ID, reason)
+
+	r.serveEndpoint(w, req, route, fallback, endpoint)
+}
+
+// isFallbackStatus reports whether a primary response should be retried against the fallback
+func isFallbackStatus(code int) bool {
+	return code == http.StatusBadGateway ||
+		code == http.StatusServiceUnavailable ||
+		code == http.StatusGatewayTimeout
+}
+
+// fallbackWriter holds back retryable error responses so the request can be replayed.
+// Other responses are passed straight through to the client.
+type fallbackWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+	failed      bool
+}
+
+func newFallbackWriter(w http.ResponseWriter) *fallbackWriter {
+	return &fallbackWriter{w: w, header: make(http.Header)}
+}
+
+func (fw *fallbackWriter) Header() http.Header {
+	return fw.header
+}
+
+func (fw *fallbackWriter) WriteHeader(code int) {
+	if fw.wroteHeader {
+		return
+	}
+	fw.wroteHeader = true
+	fw.statusCode = code
+
+	if isFallbackStatus(code) {
+		fw.failed = true
+		return
+	}
+
+	copyHeader(fw.w.Header(), fw.header)
+	fw.w.WriteHeader(code)
+}
+
+func (fw *fallbackWriter) Write(b []byte) (int, error) {
+	if !fw.wroteHeader {
+		fw.WriteHeader(http.StatusOK)
+	}
+	if fw.failed {
+		return fw.body.Write(b)
+	}
+	return fw.w.Write(b)
+}
+
+// Flush implements http.Flusher for streaming responses
+func (fw *fallbackWriter) Flush() {
+	if !fw.wroteHeader || fw.failed {
+		return
+	}
+	_ = http.NewResponseController(fw.w).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (fw *fallbackWriter) Unwrap() http.ResponseWriter {
+	return fw.w
+}
+
+// flush writes the held-back response to the client
+func (fw *fallbackWriter) flush() {
+	copyHeader(fw.w.Header(), fw.header)
+	fw.w.WriteHeader(fw.statusCode)
+	fw.w.Write(fw.body.Bytes())
+}
+
+// copyHeader copies all header values from src to dst
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = values
+	}
+}
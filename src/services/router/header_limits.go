@@ -0,0 +1,157 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// Header limits a request can break, reported in errors and metrics
+const (
+	headerLimitValueBytes = "max_value_bytes"
+	headerLimitCount      = "max_count"
+	headerLimitTotalBytes = "max_total_bytes"
+)
+
+// errorClassHeaderLimit is the error class of requests rejected by a backend's header limits
+const errorClassHeaderLimit = "header_limit"
+
+// headerLineOverhead is the ": " and CRLF around each header line on the wire
+const headerLineOverhead = 4
+
+// headerViolation is a header limit the forwarded headers break
+type headerViolation struct {
+	header string // the header most responsible
+	limit  string
+	max    int
+}
+
+// headerStats is the size of one header's lines
+type headerStats struct {
+	name  string
+	count int
+	bytes int
+}
+
+// checkHeaderLimits returns the first limit the headers break, or nil.
+// A single oversized value is reported first, then too many lines, then too many bytes.
+func checkHeaderLimits(h http.Header, limits *models.HeaderLimitsConfig) *headerViolation {
+	stats := make([]headerStats, 0, len(h))
+	count, total := 0, 0
+	for name, values := range h {
+		s := headerStats{name: name, count: len(values)}
+		for _, value := range values {
+			s.bytes += len(name) + len(value) + headerLineOverhead
+		}
+		stats = append(stats, s)
+		count += s.count
+		total += s.bytes
+	}
+	// Report the same header for the same request
+	sort.Slice(stats, func(i, j int) bool { return stats[i].name < stats[j].name })
+
+	if limits.MaxValueBytes > 0 {
+		for _, s := range stats {
+			for _, value := range h[s.name] {
+				if len(value) > limits.MaxValueBytes {
+					return &headerViolation{header: s.name, limit: headerLimitValueBytes, max: limits.MaxValueBytes}
+				}
+			}
+		}
+	}
+
+	if limits.MaxCount > 0 && count > limits.MaxCount {
+		worst := stats[0]
+		for _, s := range stats[1:] {
+			if s.count > worst.count || (s.count == worst.count && s.bytes > worst.bytes) {
+				worst = s
+			}
+		}
+		return &headerViolation{header: worst.name, limit: headerLimitCount, max: limits.MaxCount}
+	}
+
+	if limits.MaxTotalBytes > 0 && total > limits.MaxTotalBytes {
+		worst := stats[0]
+		for _, s := range stats[1:] {
+			if s.bytes > worst.bytes {
+				worst = s
+			}
+		}
+		return &headerViolation{header: worst.name, limit: headerLimitTotalBytes, max: limits.MaxTotalBytes}
+	}
+
+	return nil
+}
+
+// forwardedHeaders returns the headers the proxy forwards for the request
+func forwardedHeaders(req *http.Request) http.Header {
+	outbound := req.Clone(req.Context())
+	stripHopByHopHeaders(outbound.Header)
+	applyHeaderPolicy(outbound)
+	stripOverrideHeaders(outbound.Header)
+	return outbound.Header
+}
+
+// enforceHeaderLimits checks the request's forwarded headers against the backend's limits.
+// Headers the route strips over the limit are dropped and the check repeated; any other
+// violation is answered with 431 and reports false.
+func (r *Router) enforceHeaderLimits(w http.ResponseWriter, req *http.Request, route *models.RouteConfig, backend *Backend) bool {
+	limits := backend.Config.HeaderLimits
+	if limits == nil {
+		return true
+	}
+
+	for {
+		violation := checkHeaderLimits(forwardedHeaders(req), limits)
+		if violation == nil {
+			return true
+		}
+
+		if _, sent := req.Header[violation.header]; sent && route.ForwardHeaders.StripsOverLimit(violation.header) {
+			r.logger.DebugContext(req.Context(), "Header stripped over backend limit",
+				"route", route.ID,
+				"backend", backend.Config.ID,
+				"header", violation.header,
+				"limit", violation.limit,
+			)
+			services.RecordHeaderLimitViolation(backend.Config.ID, violation.limit, "stripped")
+			req.Header.Del(violation.header)
+			continue
+		}
+
+		r.logger.WarnContext(req.Context(), "Request headers exceed backend limit",
+			"route", route.ID,
+			"backend", backend.Config.ID,
+			"header", violation.header,
+			"limit", violation.limit,
+			"max", violation.max,
+		)
+		services.RecordHeaderLimitViolation(backend.Config.ID, violation.limit, "rejected")
+		services.RecordRouteBackendRequest(route.ID, backend.Config.ID, "431")
+		middleware.SetErrorClass(req, errorClassHeaderLimit)
+		writeHeaderLimitError(w, req, violation)
+		return false
+	}
+}
+
+// writeHeaderLimitError answers with 431, naming the offending header but never its value
+func writeHeaderLimitError(w http.ResponseWriter, req *http.Request, violation *headerViolation) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusRequestHeaderFieldsTooLarge)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Code:      "request_header_fields_too_large",
+		Message:   "Request headers exceed the limits of the backend",
+		RequestID: req.Header.Get("X-Request-ID"),
+		Details: map[string]interface{}{
+			"error_class": errorClassHeaderLimit,
+			"header":      violation.header,
+			"limit":       violation.limit,
+			"max":         violation.max,
+		},
+	})
+}
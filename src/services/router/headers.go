@@ -0,0 +1,226 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// hopByHopHeaders are the connection-level headers of RFC 7230 section 6.1, plus the
+// non-standard Proxy-Connection, which apply to one hop and must not be forwarded
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// stripHopByHopHeaders removes hop-by-hop headers and the headers named in Connection.
+// A WebSocket handshake keeps Connection: Upgrade and Upgrade: websocket so the
+// backend can switch protocols; other upgrades are dropped.
+func stripHopByHopHeaders(h http.Header) {
+	websocket := httpguts.HeaderValuesContainsToken(h["Connection"], "Upgrade") &&
+		strings.EqualFold(h.Get("Upgrade"), "websocket")
+
+	for _, value := range h["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+
+	if websocket {
+		h.Set("Connection", "Upgrade")
+		h.Set("Upgrade", "websocket")
+	}
+}
+
+// headerPolicy filters the client headers a route forwards to its backend.
+// It runs after stripHopByHopHeaders, so only a WebSocket upgrade is left to filter.
+type headerPolicy struct {
+	remove []string
+	allow  map[string]bool // nil forwards every header that isn't removed
+}
+
+// headerPolicyKey is the request context key of a route's header policy
+type headerPolicyKey struct{}
+
+// newHeaderPolicy builds the route's header policy, or nil when it forwards everything.
+// The request ID and its correlation headers always reach the backend.
+func newHeaderPolicy(route *models.RouteConfig, correlationHeaders []string) *headerPolicy {
+	config := route.ForwardHeaders
+	if config == nil || (len(config.Remove) == 0 && len(config.Allow) == 0) {
+		return nil
+	}
+
+	policy := &headerPolicy{}
+	for _, name := range config.Remove {
+		policy.remove = append(policy.remove, http.CanonicalHeaderKey(name))
+	}
+	if len(config.Allow) > 0 {
+		// A WebSocket handshake must survive the allowlist to reach the backend
+		policy.allow = map[string]bool{"X-Request-Id": true, "Connection": true, "Upgrade": true}
+		for _, name := range correlationHeaders {
+			policy.allow[http.CanonicalHeaderKey(name)] = true
+		}
+		for _, name := range config.Allow {
+			policy.allow[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	return policy
+}
+
+// withHeaderPolicy attaches the policy to the request for the proxy Director
+func withHeaderPolicy(req *http.Request, policy *headerPolicy) *http.Request {
+	if policy == nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), headerPolicyKey{}, policy))
+}
+
+// applyHeaderPolicy filters the outgoing request's headers by the policy attached to it.
+// The proxy clones headers before the Director runs, so the client request is untouched.
+func applyHeaderPolicy(req *http.Request) {
+	policy, ok := req.Context().Value(headerPolicyKey{}).(*headerPolicy)
+	if !ok {
+		return
+	}
+
+	if policy.allow != nil {
+		for name := range req.Header {
+			if !policy.allow[name] {
+				req.Header.Del(name)
+			}
+		}
+	}
+	for _, name := range policy.remove {
+		req.Header.Del(name)
+	}
+}
+
+// responseHeaderPolicy filters the backend response headers a route returns to clients
+// and adds the headers the route sets
+type responseHeaderPolicy struct {
+	remove headerMatcher
+	allow  *headerMatcher // nil returns every header that isn't removed
+	set    map[string]models.SetHeader
+}
+
+// headerMatcher matches canonical header names exactly or, for names ending in *, by prefix
+type headerMatcher struct {
+	names    map[string]bool
+	prefixes []string
+}
+
+// newHeaderMatcher builds a matcher for the configured names
+func newHeaderMatcher(names []string) headerMatcher {
+	m := headerMatcher{names: make(map[string]bool)}
+	for _, name := range names {
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			m.prefixes = append(m.prefixes, http.CanonicalHeaderKey(prefix))
+			continue
+		}
+		m.names[http.CanonicalHeaderKey(name)] = true
+	}
+	return m
+}
+
+// match reports whether the canonical header name is matched
+func (m headerMatcher) match(name string) bool {
+	if m.names[name] {
+		return true
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseHeaderPolicyKey is the request context key of a route's response header policy
+type responseHeaderPolicyKey struct{}
+
+// defaultResponseHeaderPolicy applies to routes without a response_headers config
+var defaultResponseHeaderPolicy = &responseHeaderPolicy{
+	remove: newHeaderMatcher(models.DefaultResponseHeaderDenylist),
+}
+
+// newResponseHeaderPolicy builds the route's response header policy, or nil when
+// the default policy applies
+func newResponseHeaderPolicy(route *models.RouteConfig) *responseHeaderPolicy {
+	config := route.ResponseHeaders
+	if config == nil && len(route.SetResponseHeaders) == 0 {
+		return nil
+	}
+	if config == nil {
+		config = &models.ResponseHeadersConfig{}
+	}
+
+	remove := config.Remove
+	if !config.DisableDefaults {
+		remove = append(append([]string{}, models.DefaultResponseHeaderDenylist...), remove...)
+	}
+
+	policy := &responseHeaderPolicy{remove: newHeaderMatcher(remove)}
+	if len(config.Allow) > 0 {
+		// Without these the client can't decode the body
+		allow := append([]string{"Content-Type", "Content-Length", "Content-Encoding"}, config.Allow...)
+		matcher := newHeaderMatcher(allow)
+		policy.allow = &matcher
+	}
+	if len(route.SetResponseHeaders) > 0 {
+		policy.set = make(map[string]models.SetHeader, len(route.SetResponseHeaders))
+		for name, header := range route.SetResponseHeaders {
+			policy.set[http.CanonicalHeaderKey(name)] = header
+		}
+	}
+	return policy
+}
+
+// withResponseHeaderPolicy attaches the policy to the request for the proxy's ModifyResponse
+func withResponseHeaderPolicy(req *http.Request, policy *responseHeaderPolicy) *http.Request {
+	if policy == nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), responseHeaderPolicyKey{}, policy))
+}
+
+// applyResponseHeaderPolicy filters the backend response's headers by the policy
+// attached to its request, or by the default denylist, then sets the route's headers.
+// A set header replaces the backend's only when it is marked override.
+func applyResponseHeaderPolicy(resp *http.Response) error {
+	policy := defaultResponseHeaderPolicy
+	if resp.Request != nil {
+		if attached, ok := resp.Request.Context().Value(responseHeaderPolicyKey{}).(*responseHeaderPolicy); ok {
+			policy = attached
+		}
+	}
+
+	for name := range resp.Header {
+		if policy.remove.match(name) || (policy.allow != nil && !policy.allow.match(name)) {
+			resp.Header.Del(name)
+		}
+	}
+	for name, header := range policy.set {
+		if _, sent := resp.Header[name]; sent && !header.Override {
+			continue
+		}
+		resp.Header.Set(name, header.Value)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package router
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/loadbalancer"
+)
+
+// outlierDetector is a backend's passive health check. It counts the consecutive failures
+// of proxied requests per endpoint and ejects an endpoint from the load balancer once they
+// reach the threshold. After the ejection time the endpoint is re-admitted on probation:
+// a success clears it, a failure ejects it again. Re-admission happens on the first request
+// or runtime lookup after the cool-down, as the clock has no timers.
+type outlierDetector struct {
+	config   models.PassiveHealthCheckConfig
+	balancer loadbalancer.LoadBalancer
+	onChange func(endpointURL string, ejected bool)
+
+	mutex     sync.Mutex
+	clock     clock.Clock
+	failures  map[string]int
+	ejected   map[string]time.Time // endpoint URL -> end of its ejection
+	probation map[string]bool
+	down      map[string]bool // failing the active health check; kept out of the balancer after an ejection ends
+	pending   atomic.Int32    // len(ejected), so requests skip the lock while nothing is ejected
+}
+
+// newOutlierDetector creates a detector for a backend, or returns nil if passive health
+// checking is disabled
+func newOutlierDetector(cfg *models.PassiveHealthCheckConfig, balancer loadbalancer.LoadBalancer, c clock.Clock, onChange func(endpointURL string, ejected bool)) *outlierDetector {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	return &outlierDetector{
+		config:    *cfg,
+		balancer:  balancer,
+		onChange:  onChange,
+		clock:     c,
+		failures:  make(map[string]int),
+		ejected:   make(map[string]time.Time),
+		probation: make(map[string]bool),
+		down:      make(map[string]bool),
+	}
+}
+
+// setClock replaces the clock ejection times are measured on
+func (d *outlierDetector) setClock(c clock.Clock) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.clock = c
+}
+
+// observe records the outcome of a request to an endpoint, ejecting it if it failed too often
+func (d *outlierDetector) observe(endpointURL string, failed bool) {
+	d.mutex.Lock()
+	if _, ejected := d.ejected[endpointURL]; ejected {
+		// Requests that were in flight when it was ejected don't count
+		d.mutex.Unlock()
+		return
+	}
+	if !failed {
+		delete(d.failures, endpointURL)
+		delete(d.probation, endpointURL)
+		d.mutex.Unlock()
+		return
+	}
+
+	d.failures[endpointURL]++
+	if d.failures[endpointURL] < d.config.ConsecutiveErrors && !d.probation[endpointURL] {
+		d.mutex.Unlock()
+		return
+	}
+	if !d.hasOtherAvailable(endpointURL) {
+		// Ejecting the last endpoint would turn its errors into 503s for every request
+		d.mutex.Unlock()
+		return
+	}
+	delete(d.failures, endpointURL)
+	delete(d.probation, endpointURL)
+	d.ejected[endpointURL] = d.clock.Now().Add(d.config.EjectionTime)
+	d.pending.Add(1)
+	d.balancer.MarkUnhealthy(&models.EndpointConfig{URL: endpointURL})
+	d.mutex.Unlock()
+
+	d.onChange(endpointURL, true)
+}
+
+// hasOtherAvailable reports whether the balancer has an available endpoint besides the given one
+func (d *outlierDetector) hasOtherAvailable(endpointURL string) bool {
+	for _, endpoint := range d.balancer.Endpoints() {
+		if endpoint.URL != endpointURL && endpoint.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// readmit returns endpoints whose ejection has ended to the load balancer, on probation
+func (d *outlierDetector) readmit() {
+	if d.pending.Load() == 0 {
+		return
+	}
+
+	d.mutex.Lock()
+	now := d.clock.Now()
+	var readmitted []string
+	for endpointURL, until := range d.ejected {
+		if now.Before(until) {
+			continue
+		}
+		delete(d.ejected, endpointURL)
+		d.pending.Add(-1)
+		if d.down[endpointURL] {
+			continue
+		}
+		d.probation[endpointURL] = true
+		d.balancer.MarkHealthy(&models.EndpointConfig{URL: endpointURL})
+		readmitted = append(readmitted, endpointURL)
+	}
+	d.mutex.Unlock()
+
+	for _, endpointURL := range readmitted {
+		d.onChange(endpointURL, false)
+	}
+}
+
+// setActiveHealth records the active health check's view of an endpoint and reports whether
+// the balancer should follow it; an ejected endpoint stays out until its ejection ends
+func (d *outlierDetector) setActiveHealth(endpointURL string, healthy bool) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if healthy {
+		delete(d.down, endpointURL)
+	} else {
+		d.down[endpointURL] = true
+	}
+	_, ejected := d.ejected[endpointURL]
+	return !ejected
+}
+
+// isEjected reports whether the endpoint is currently ejected
+func (d *outlierDetector) isEjected(endpointURL string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	_, ejected := d.ejected[endpointURL]
+	return ejected
+}
+
+// forget drops the state of a removed endpoint
+func (d *outlierDetector) forget(endpointURL string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, ejected := d.ejected[endpointURL]; ejected {
+		delete(d.ejected, endpointURL)
+		d.pending.Add(-1)
+	}
+	delete(d.failures, endpointURL)
+	delete(d.probation, endpointURL)
+	delete(d.down, endpointURL)
+}
+
+// onEndpointEjection records a passive health check ejection or re-admission in the log,
+// metrics and on the event bus
+func (r *Router) onEndpointEjection(backendID string, cfg models.PassiveHealthCheckConfig, endpointURL string, ejected bool) {
+	previous, status := "healthy", "unhealthy"
+	if ejected {
+		r.logger.Warn("Endpoint ejected after consecutive errors", "backend", backendID, "endpoint", endpointURL,
+			"consecutive_errors", cfg.ConsecutiveErrors, "ejection_time", cfg.EjectionTime)
+		services.RecordEndpointEjection(backendID, endpointURL)
+	} else {
+		r.logger.Info("Ejected endpoint readmitted on probation", "backend", backendID, "endpoint", endpointURL)
+		previous, status = status, previous
+	}
+
+	r.events.Publish(events.TopicHealth, map[string]interface{}{
+		"backend":  backendID,
+		"endpoint": endpointURL,
+		"previous": previous,
+		"status":   status,
+		"source":   "passive",
+	})
+}
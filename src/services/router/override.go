@@ -0,0 +1,134 @@
+package router
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
+)
+
+// Headers of a backend override, which sends one request to another backend for testing
+const (
+	BackendOverrideHeader        = "X-Backend-Override"         // ID of the backend to use
+	BackendOverrideTokenHeader   = "X-Backend-Override-Token"   // one of the router's override tokens
+	BackendOverrideAppliedHeader = "X-Backend-Override-Applied" // response: the backend that served the override
+)
+
+// Outcomes of a backend override attempt, for metrics and the audit log
+const (
+	overrideApplied      = "applied"
+	overrideRejected     = "rejected"
+	overrideInvalidToken = "invalid_token"
+)
+
+// requestsOverride reports whether the request asks for a backend override. Without
+// the token header the override header is ignored entirely.
+func requestsOverride(req *http.Request) bool {
+	return req.Header.Get(BackendOverrideHeader) != "" && req.Header.Get(BackendOverrideTokenHeader) != ""
+}
+
+// overridden reports whether the request asks for a backend override with a valid token,
+// so it may be served by another backend than the route's. An override with an invalid
+// token is audited and removed, and the request is served, and may be cached, as if it
+// had none.
+func (r *Router) overridden(req *http.Request, route *models.RouteConfig) bool {
+	if !requestsOverride(req) {
+		return false
+	}
+	if r.validOverrideToken(req.Header.Get(BackendOverrideTokenHeader)) {
+		return true
+	}
+
+	r.auditOverride(req, route, req.Header.Get(BackendOverrideHeader), overrideInvalidToken)
+	stripOverrideHeaders(req.Header)
+	return false
+}
+
+// stripOverrideHeaders removes the override headers from a request to a backend. The
+// token is a credential, and neither is meant for the backend.
+func stripOverrideHeaders(header http.Header) {
+	header.Del(BackendOverrideHeader)
+	header.Del(BackendOverrideTokenHeader)
+}
+
+// validOverrideToken reports whether the token is one of the configured override tokens.
+// Every token is compared, so the time taken doesn't tell which one came close.
+func (r *Router) validOverrideToken(token string) bool {
+	r.mutex.RLock()
+	tokens := r.config.Router.OverrideTokens
+	r.mutex.RUnlock()
+
+	valid := 0
+	for _, candidate := range tokens {
+		valid |= subtle.ConstantTimeCompare([]byte(token), []byte(candidate))
+	}
+	return valid == 1
+}
+
+// serveOverride handles a request carrying an override header and token. It reports false
+// when the token is invalid, so the request is served as if it had no override; that and
+// every other outcome is audited. A valid override of a backend that isn't overridable is
+// answered with 403.
+func (r *Router) serveOverride(w http.ResponseWriter, req *http.Request, route *models.RouteConfig) bool {
+	backendID := req.Header.Get(BackendOverrideHeader)
+
+	if !r.validOverrideToken(req.Header.Get(BackendOverrideTokenHeader)) {
+		r.auditOverride(req, route, backendID, overrideInvalidToken)
+		return false
+	}
+
+	backend, exists := r.GetBackend(backendID)
+	if !exists || !backend.Config.Overridable {
+		r.auditOverride(req, route, backendID, overrideRejected)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Code:      "override_not_allowed",
+			Message:   "backend " + backendID + " is not overridable",
+			RequestID: req.Header.Get("X-Request-ID"),
+		})
+		return true
+	}
+
+	r.auditOverride(req, route, backendID, overrideApplied)
+	middleware.SetBackendOverride(req)
+	w.Header().Set(BackendOverrideAppliedHeader, backendID)
+
+	endpoint := r.nextEndpoint(route, req, backend)
+	if endpoint == nil {
+		writeGatewayError(w, req, http.StatusServiceUnavailable)
+		return true
+	}
+	r.serveEndpoint(w, req, route, backend, endpoint)
+	return true
+}
+
+// auditOverride logs an override attempt and publishes it on the bus
+func (r *Router) auditOverride(req *http.Request, route *models.RouteConfig, backendID, outcome string) {
+	services.RecordBackendOverride(route.ID, outcome)
+
+	attrs := []any{
+		"route", route.ID,
+		"backend", backendID,
+		"outcome", outcome,
+		"remote_addr", req.RemoteAddr,
+		"request_id", req.Header.Get("X-Request-ID"),
+	}
+	if outcome == overrideApplied {
+		r.logger.InfoContext(req.Context(), "Backend override", attrs...)
+	} else {
+		r.logger.WarnContext(req.Context(), "Backend override refused", attrs...)
+	}
+
+	r.events.Publish(events.TopicBackendOverride, map[string]interface{}{
+		"route":       route.ID,
+		"backend":     backendID,
+		"outcome":     outcome,
+		"remote_addr": req.RemoteAddr,
+		"request_id":  req.Header.Get("X-Request-ID"),
+	})
+}
@@ -0,0 +1,249 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// defaultRewritePattern matches the whole path when no rewrite pattern is configured
+var defaultRewritePattern = regexp.MustCompile(`^(.*)$`)
+
+var (
+	// errPathMismatch is returned when the path doesn't fit the route's parameter pattern
+	errPathMismatch = errors.New("path does not match route pattern")
+	// errUnsafeParam is returned when a path parameter can't be substituted safely
+	errUnsafeParam = errors.New("unsafe path parameter")
+	// errInvalidRewrite is returned when a rewrite rule produces a path that can't be sent upstream
+	errInvalidRewrite = errors.New("rewrite produced an invalid path")
+)
+
+// rewriteRule is a compiled models.RewriteRule
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// pathRewriter rewrites request paths before they are proxied
+type pathRewriter struct {
+	stripPrefix string
+	pattern     *regexp.Regexp
+	replacement string
+	rules       []rewriteRule
+	version     *models.VersionConfig // fills {version} in replacements and the upstream template
+
+	// Upstream template: params is matched against the escaped request path
+	params        *regexp.Regexp
+	pathTemplate  string
+	queryTemplate url.Values
+}
+
+// newPathRewriter creates a rewriter for the route, or nil when the route has no rewrite
+func newPathRewriter(route *models.RouteConfig) *pathRewriter {
+	if route.StripPrefix == "" && route.Rewrite == "" && len(route.RewriteRules) == 0 && route.UpstreamPath == "" {
+		return nil
+	}
+
+	rw := &pathRewriter{
+		stripPrefix: strings.TrimSuffix(route.StripPrefix, "/"),
+		version:     route.Version,
+	}
+
+	if route.Rewrite != "" {
+		rw.pattern = defaultRewritePattern
+		if route.RewritePattern != "" {
+			// Validated by RouteConfig.Validate
+			rw.pattern = regexp.MustCompile(route.RewritePattern)
+		}
+		rw.replacement = route.Rewrite
+	}
+
+	for _, rule := range route.RewriteRules {
+		// Validated by RouteConfig.Validate
+		rw.rules = append(rw.rules, rewriteRule{
+			pattern:     regexp.MustCompile(rule.Pattern),
+			replacement: rule.Replacement,
+		})
+	}
+
+	if route.UpstreamPath != "" {
+		params, err := models.PathPattern(route.Path)
+		if err != nil {
+			params = regexp.MustCompile(`^$`)
+		}
+		rw.params = params
+
+		rw.pathTemplate, rw.queryTemplate = route.UpstreamPath, nil
+		if i := strings.IndexByte(route.UpstreamPath, '?'); i >= 0 {
+			rw.pathTemplate = route.UpstreamPath[:i]
+			rw.queryTemplate, _ = url.ParseQuery(route.UpstreamPath[i+1:])
+		}
+	}
+
+	return rw
+}
+
+// rewrite returns the rewritten path of a request with the given API version
+func (rw *pathRewriter) rewrite(path, version string) (string, error) {
+	if rw.stripPrefix != "" {
+		// Only strip on a segment boundary so /api/v1 doesn't match /api/v10
+		if path == rw.stripPrefix {
+			path = "/"
+		} else if strings.HasPrefix(path, rw.stripPrefix+"/") {
+			path = path[len(rw.stripPrefix):]
+		}
+	}
+
+	if rw.pattern != nil {
+		path = rw.pattern.ReplaceAllString(path, fillVersion(rw.replacement, version))
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+
+	for _, rule := range rw.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		path = rule.pattern.ReplaceAllString(path, fillVersion(rule.replacement, version))
+		if !validUpstreamPath(path) {
+			return "", fmt.Errorf("%w: rule %q produced %q", errInvalidRewrite, rule.pattern, path)
+		}
+	}
+
+	return path, nil
+}
+
+// fillVersion replaces {version} in a replacement with the request's API version
+func fillVersion(replacement, version string) string {
+	return strings.ReplaceAll(replacement, "{version}", version)
+}
+
+// validUpstreamPath reports whether a rewritten path is safe to send upstream:
+// absolute, not scheme-relative, free of control characters and dot segments
+func validUpstreamPath(path string) bool {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return false
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] < 0x20 || path[i] == 0x7f {
+			return false
+		}
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// expand fills the upstream template from the path parameters of the escaped path
+// and the API version. It returns the escaped upstream path and the query parameters to inject.
+func (rw *pathRewriter) expand(escapedPath, version string) (string, url.Values, error) {
+	match := rw.params.FindStringSubmatch(escapedPath)
+	if match == nil {
+		return "", nil, errPathMismatch
+	}
+
+	values := make(map[string]string)
+	for i, name := range rw.params.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		value, err := url.PathUnescape(match[i])
+		if err != nil {
+			return "", nil, errUnsafeParam
+		}
+		// Dot segments would let a parameter walk the upstream path
+		if value == "." || value == ".." {
+			return "", nil, errUnsafeParam
+		}
+		values[name] = value
+	}
+	if _, ok := values["version"]; !ok && rw.version != nil {
+		values["version"] = version
+	}
+
+	fill := func(template string, escape func(string) string) string {
+		return replaceParams(template, func(name string) string {
+			return escape(values[name])
+		})
+	}
+
+	path := fill(rw.pathTemplate, url.PathEscape)
+
+	query := make(url.Values, len(rw.queryTemplate))
+	for key, templates := range rw.queryTemplate {
+		for _, template := range templates {
+			// Values are encoded by url.Values, so substitute them raw
+			query.Add(key, fill(template, func(s string) string { return s }))
+		}
+	}
+
+	return path, query, nil
+}
+
+// paramPlaceholder matches {name} placeholders in upstream templates
+var paramPlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// replaceParams replaces {name} placeholders using the given function
+func replaceParams(template string, value func(name string) string) string {
+	return paramPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return value(placeholder[1 : len(placeholder)-1])
+	})
+}
+
+// apply returns a copy of the request with the rewritten path.
+// The original request is left untouched so logging still sees the client path.
+func (rw *pathRewriter) apply(req *http.Request) (*http.Request, error) {
+	if rw == nil {
+		return req, nil
+	}
+
+	u := *req.URL
+
+	var version string
+	if rw.version != nil {
+		version = rw.version.Extract(req.URL.Path, req.Header)
+	}
+
+	if rw.params != nil {
+		escaped, query, err := rw.expand(req.URL.EscapedPath(), version)
+		if err != nil {
+			return nil, err
+		}
+		path, err := url.PathUnescape(escaped)
+		if err != nil {
+			return nil, errUnsafeParam
+		}
+		u.Path, u.RawPath = path, escaped
+
+		if len(query) > 0 {
+			// Injected parameters override client-supplied ones so they can't be spoofed
+			merged := req.URL.Query()
+			for key, values := range query {
+				merged[key] = values
+			}
+			u.RawQuery = merged.Encode()
+		}
+	} else {
+		path, err := rw.rewrite(req.URL.Path, version)
+		if err != nil {
+			return nil, err
+		}
+		if path == req.URL.Path {
+			return req, nil
+		}
+		u.Path, u.RawPath = path, ""
+	}
+
+	out := req.WithContext(req.Context())
+	out.URL = &u
+	return out, nil
+}
@@ -0,0 +1,851 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/lib/tracing"
+	"github.com/your-org/ryohi-router/src/lib/transport"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/flags"
+	"github.com/your-org/ryohi-router/src/services/loadbalancer"
+	"github.com/your-org/ryohi-router/src/services/memory"
+)
+
+// Router routes requests to backend services
+type Router struct {
+	config   *config.Config
+	logger   *slog.Logger
+	backends map[string]*Backend
+	caches   map[string]*responseCache
+	events   *events.Bus
+	flags    *flags.Store
+	watchdog *memory.Watchdog
+	tracer   *tracing.Provider // nil when tracing is disabled
+	mutex    sync.RWMutex
+
+	// Time and randomness are injectable so simulations are reproducible
+	clock    clock.Clock
+	rng      *rand.Rand // nil uses the global source
+	rngMutex sync.Mutex
+}
+
+// Backend holds the runtime state of a backend service
+type Backend struct {
+	Config         models.BackendService
+	LoadBalancer   loadbalancer.LoadBalancer
+	CircuitBreaker *models.CircuitBreaker
+	proxies        map[string]*httputil.ReverseProxy
+	tuned          sync.Map // route ID and endpoint URL -> proxy with the route's streaming settings
+	inFlight       map[string]*atomic.Int64
+	slots          chan struct{}    // nil when the backend has no concurrency limit
+	outliers       *outlierDetector // nil when passive health checking is disabled
+
+	// Requests from entering serveEndpoint until they finish, including any queueing,
+	// so a replaced backend is only discarded once they are done
+	active        map[string]*atomic.Int64
+	transport     http.RoundTripper // nil when the endpoints use the default transport
+	discarded     context.Context   // done once the backend is discarded
+	cancelDiscard context.CancelFunc
+
+	// UpdateBackendEndpoints changes the endpoints in place, so the maps above and
+	// Config.Endpoints are guarded
+	endpointMutex  sync.RWMutex
+	proxyTransport http.RoundTripper // transport, wrapped for debug logging when enabled
+}
+
+// EndpointRuntime is the live state of an endpoint as seen by the router
+type EndpointRuntime struct {
+	URL             string `json:"url"`
+	BalancerHealthy bool   `json:"balancer_healthy"`
+	Draining        bool   `json:"draining"`
+	EffectiveWeight int    `json:"effective_weight"`
+	InFlight        int64  `json:"in_flight"`
+	Ejected         bool   `json:"ejected"` // by the passive health check
+}
+
+// BackendRuntime is the live state of a backend as seen by the router
+type BackendRuntime struct {
+	CircuitBreaker string            `json:"circuit_breaker"`
+	Endpoints      []EndpointRuntime `json:"endpoints"`
+}
+
+// New creates a new router
+func New(cfg *config.Config, logger *slog.Logger) (*Router, error) {
+	r := &Router{
+		config: cfg,
+		logger: logger,
+		caches: make(map[string]*responseCache),
+		clock:  clock.Real,
+	}
+
+	backends, err := r.initializeBackends(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r.backends = backends
+
+	return r, nil
+}
+
+// SetEventBus sets the bus that circuit breaker state changes are published on
+func (r *Router) SetEventBus(bus *events.Bus) {
+	r.events = bus
+}
+
+// SetFlags sets the feature flag store consulted when routing requests
+func (r *Router) SetFlags(store *flags.Store) {
+	r.flags = store
+}
+
+// SetWatchdog sets the memory watchdog; while it sheds load, responses are
+// not cached and request bodies are not captured for fallback replays
+func (r *Router) SetWatchdog(watchdog *memory.Watchdog) {
+	r.watchdog = watchdog
+}
+
+// SetTracer sets the provider backend calls are traced with
+func (r *Router) SetTracer(provider *tracing.Provider) {
+	r.tracer = provider
+}
+
+// SetClock sets the clock that latencies, circuit breaker timeouts and balancer scores are measured on.
+// Like the other setters it is meant to be called before the router serves requests.
+func (r *Router) SetClock(c clock.Clock) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.clock = c
+	for _, backend := range r.backends {
+		backend.CircuitBreaker.SetClock(c)
+		if clocked, ok := backend.LoadBalancer.(loadbalancer.Clocked); ok {
+			clocked.SetClock(c)
+		}
+		if backend.outliers != nil {
+			backend.outliers.setClock(c)
+		}
+	}
+}
+
+// SetSeed makes the router's random choices reproducible: canary buckets for
+// requests without an ID, and the picks of random load balancers
+func (r *Router) SetSeed(seed int64) {
+	r.rngMutex.Lock()
+	r.rng = rand.New(rand.NewSource(seed))
+	r.rngMutex.Unlock()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Seed backends in a fixed order so the same seed gives the same picks
+	ids := make([]string, 0, len(r.backends))
+	for id := range r.backends {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		r.seedBackend(r.backends[id])
+	}
+}
+
+// seedBackend seeds a backend's load balancer from the router's source, if it has been seeded
+func (r *Router) seedBackend(backend *Backend) {
+	r.rngMutex.Lock()
+	defer r.rngMutex.Unlock()
+
+	if seeder, ok := backend.LoadBalancer.(loadbalancer.Seeder); ok && r.rng != nil {
+		seeder.Seed(r.rng.Int63())
+	}
+}
+
+// initializeBackends builds the runtime state for all enabled backends
+func (r *Router) initializeBackends(cfg *config.Config) (map[string]*Backend, error) {
+	backends := make(map[string]*Backend)
+	for _, backendConfig := range cfg.Backends {
+		if !backendConfig.Enabled {
+			continue
+		}
+
+		backend, err := r.initializeBackend(cfg.Router, backendConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize backend %s: %w", backendConfig.ID, err)
+		}
+		backends[backendConfig.ID] = backend
+	}
+	return backends, nil
+}
+
+// initializeBackend creates the load balancer, circuit breaker and proxies for a backend;
+// the balancer prefers endpoints in the router's zone when one is configured
+func (r *Router) initializeBackend(routerConfig config.RouterConfig, backendConfig models.BackendService) (*Backend, error) {
+	endpoints := make([]models.EndpointConfig, len(backendConfig.Endpoints))
+	copy(endpoints, backendConfig.Endpoints)
+
+	var lb loadbalancer.LoadBalancer
+	var err error
+	if routerConfig.Zone != "" {
+		lb, err = loadbalancer.NewZoneAware(&backendConfig.LoadBalancer, endpoints, routerConfig.Zone, routerConfig.ZoneMinHealthy)
+	} else {
+		lb, err = loadbalancer.New(&backendConfig.LoadBalancer, endpoints)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cbConfig := backendConfig.CircuitBreaker
+	backend := &Backend{
+		Config:         backendConfig,
+		LoadBalancer:   lb,
+		CircuitBreaker: models.NewCircuitBreaker(&cbConfig),
+		proxies:        make(map[string]*httputil.ReverseProxy),
+		inFlight:       make(map[string]*atomic.Int64),
+		slots:          newSlots(backendConfig),
+		active:         make(map[string]*atomic.Int64),
+	}
+	backend.discarded, backend.cancelDiscard = context.WithCancel(context.Background())
+	backend.CircuitBreaker.SetStateChangeHandler(func(from, to models.CircuitBreakerState) {
+		r.onCircuitStateChange(backendConfig.ID, from, to)
+	})
+	backend.CircuitBreaker.SetClock(r.clock)
+	if clocked, ok := lb.(loadbalancer.Clocked); ok {
+		clocked.SetClock(r.clock)
+	}
+	r.seedBackend(backend)
+	backend.outliers = newOutlierDetector(backendConfig.PassiveHealthCheck, lb, r.clock, func(endpointURL string, ejected bool) {
+		r.onEndpointEjection(backendConfig.ID, *backendConfig.PassiveHealthCheck, endpointURL, ejected)
+	})
+
+	// Endpoints share one transport so connections and TLS settings are per backend
+	rt, err := transport.ForBackend(backendConfig)
+	if err != nil {
+		return nil, err
+	}
+	backend.transport = rt
+	if backendConfig.Logging != nil && backendConfig.Logging.Debug {
+		rt = newDebugTransport(rt, backendConfig.ID, *backendConfig.Logging, r.logger, r.clock)
+	}
+
+	backend.proxyTransport = rt
+
+	for _, endpoint := range backendConfig.Endpoints {
+		if err := r.addEndpointProxy(backend, endpoint.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	return backend, nil
+}
+
+// addEndpointProxy creates the proxy and counters of one of the backend's endpoints;
+// the caller must hold the backend's endpoint mutex or own the backend
+func (r *Router) addEndpointProxy(backend *Backend, endpointURL string) error {
+	proxy, err := r.createProxy(backend.Config.ID, endpointURL)
+	if err != nil {
+		return err
+	}
+	if backend.proxyTransport != nil {
+		proxy.Transport = backend.proxyTransport
+	}
+	if backend.Config.IsH2C() {
+		// gRPC streams must reach the client as soon as each message arrives
+		proxy.FlushInterval = -1
+	}
+	backend.proxies[endpointURL] = proxy
+	backend.inFlight[endpointURL] = &atomic.Int64{}
+	backend.active[endpointURL] = &atomic.Int64{}
+	return nil
+}
+
+// createProxy creates a reverse proxy for a single endpoint
+func (r *Router) createProxy(backendID, endpointURL string) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse(endpointURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL %s: %w", endpointURL, err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		stripHopByHopHeaders(req.Header)
+		applyHeaderPolicy(req)
+		stripOverrideHeaders(req.Header)
+		applyBodyLimit(req)
+		tracing.Inject(req.Context(), req.Header)
+	}
+	proxy.ModifyResponse = applyResponseHeaderPolicy
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if limit, ok := bodyTooLarge(err); ok {
+			// The client broke the route's body limit, which says nothing about the backend
+			if recorder, ok := w.(*statusRecorder); ok {
+				recorder.errorClass = errorClassBodyTooLarge
+			}
+			writeBodyTooLarge(w, req, limit)
+			return
+		}
+
+		class, status := classifyProxyError(err)
+		// The route timeout cancels with context.DeadlineExceeded as the cause
+		if errors.Is(context.Cause(req.Context()), context.Canceled) {
+			class, status = errorClassCanceled, statusClientClosedRequest
+		}
+		if errors.Is(context.Cause(req.Context()), errBackendDiscarded) {
+			class, status = errorClassDrainTimeout, http.StatusServiceUnavailable
+		}
+
+		if recorder, ok := w.(*statusRecorder); ok {
+			recorder.errorClass = class
+			recorder.err = err
+		}
+		middleware.SetErrorClass(req, class)
+
+		if status == statusClientClosedRequest {
+			// The client went away; serveEndpoint logs the disconnect
+			w.WriteHeader(status)
+			return
+		}
+
+		services.RecordBackendError(backendID, class)
+		r.logger.ErrorContext(req.Context(), "Proxy error",
+			"backend", backendID,
+			"endpoint", endpointURL,
+			"path", req.URL.Path,
+			"error_class", class,
+			"error", err,
+		)
+		writeProxyError(w, req, status, class)
+	}
+
+	return proxy, nil
+}
+
+// onCircuitStateChange records a circuit breaker transition in metrics and on the event bus
+func (r *Router) onCircuitStateChange(backendID string, from, to models.CircuitBreakerState) {
+	r.logger.Info("Circuit breaker state changed", "backend", backendID, "from", from, "to", to)
+
+	switch to {
+	case models.StateClosed:
+		services.SetCircuitBreakerState(backendID, 0)
+	case models.StateOpen:
+		services.SetCircuitBreakerState(backendID, 1)
+		services.RecordCircuitBreakerTrip(backendID)
+	case models.StateHalfOpen:
+		services.SetCircuitBreakerState(backendID, 2)
+	}
+
+	r.events.Publish(events.TopicCircuitBreaker, map[string]interface{}{
+		"backend": backendID,
+		"from":    string(from),
+		"to":      string(to),
+	})
+}
+
+// GetBackend returns the runtime state for a backend
+func (r *Router) GetBackend(id string) (*Backend, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	backend, exists := r.backends[id]
+	return backend, exists
+}
+
+// GetCircuitBreaker returns the circuit breaker of a backend
+func (r *Router) GetCircuitBreaker(backendID string) (*models.CircuitBreaker, bool) {
+	backend, exists := r.GetBackend(backendID)
+	if !exists {
+		return nil, false
+	}
+	return backend.CircuitBreaker, true
+}
+
+// CreateHandler creates an HTTP handler that proxies requests for a route
+func (r *Router) CreateHandler(route *models.RouteConfig) http.Handler {
+	handler := r.proxyHandler(route)
+
+	if route.Cache != nil && route.Cache.Enabled {
+		cache := newResponseCache(route.Cache, r.config.Router.CorrelationIDHeaders)
+
+		r.mutex.Lock()
+		r.caches[route.ID] = cache
+		r.mutex.Unlock()
+
+		handler = r.cacheHandler(route, cache, handler)
+	}
+
+	return handler
+}
+
+// proxyHandler creates the handler that selects a backend and proxies the request
+func (r *Router) proxyHandler(route *models.RouteConfig) http.Handler {
+	rewriter := newPathRewriter(route)
+	policy := newHeaderPolicy(route, r.config.Router.CorrelationIDHeaders)
+	responsePolicy := newResponseHeaderPolicy(route)
+	errorPages := newRouteErrorPages(route, r.logger)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rewritten, err := rewriter.apply(req)
+		if err != nil {
+			if errors.Is(err, errPathMismatch) {
+				http.Error(w, "Not Found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, errInvalidRewrite) {
+				r.logger.ErrorContext(req.Context(), "Rewrite produced an invalid path", "route", route.ID, "path", req.URL.Path, "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		req = withErrorPages(withResponseHeaderPolicy(withHeaderPolicy(rewritten, policy), responsePolicy), errorPages)
+
+		limit := r.bodyLimit(route)
+		if r.rejectLargeBody(w, req, route, limit) {
+			return
+		}
+		req = withBodyLimit(req, limit)
+
+		if requestsOverride(req) && r.serveOverride(w, req, route) {
+			return
+		}
+
+		backend, exists := r.GetBackend(route.Backend)
+		if !exists {
+			r.logger.ErrorContext(req.Context(), "Backend not found", "route", route.ID, "backend", route.Backend)
+			writeGatewayError(w, req, http.StatusServiceUnavailable)
+			return
+		}
+
+		fallback := r.selectFallback(route, req)
+		if fallback != nil && r.watchdog.Shedding() && req.Body != nil && req.Body != http.NoBody {
+			// Replaying the body would mean holding it in memory
+			fallback = nil
+		}
+		if fallback != nil {
+			replayable, err := bufferBody(req)
+			if err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+			if !replayable {
+				r.logger.DebugContext(req.Context(), "Request body too large to replay, fallback disabled", "route", route.ID, "fallback", fallback.Config.ID)
+				fallback = nil
+			}
+		}
+
+		target, endpoint := r.selectTarget(route, req, backend)
+		if endpoint == nil {
+			if fallback != nil {
+				r.serveFallback(w, req, route, fallback, nil, "unavailable")
+				return
+			}
+			writeGatewayError(w, req, http.StatusServiceUnavailable)
+			return
+		}
+
+		if fallback == nil {
+			r.serveEndpoint(w, req, route, target, endpoint)
+			return
+		}
+
+		fw := newFallbackWriter(w)
+		r.serveEndpoint(fw, req, route, target, endpoint)
+		if !fw.failed {
+			return
+		}
+
+		replayBody(req)
+		r.serveFallback(w, req, route, fallback, fw, strconv.Itoa(fw.statusCode))
+	})
+}
+
+// selectTarget picks the backend and endpoint for a request, preferring the canary when selected
+func (r *Router) selectTarget(route *models.RouteConfig, req *http.Request, primary *Backend) (*Backend, *models.EndpointConfig) {
+	if canary := r.selectCanary(route, req); canary != nil {
+		if endpoint := r.nextEndpoint(route, req, canary); endpoint != nil {
+			return canary, endpoint
+		}
+		r.logger.DebugContext(req.Context(), "Canary unavailable, using primary backend", "route", route.ID, "canary", canary.Config.ID)
+	}
+
+	return primary, r.nextEndpoint(route, req, primary)
+}
+
+// selectCanary returns the canary backend if this request falls into the canary split.
+// Requests are bucketed by request ID so retries of the same request stay on one backend.
+func (r *Router) selectCanary(route *models.RouteConfig, req *http.Request) *Backend {
+	if route.CanaryBackend == "" || route.CanaryWeight <= 0 || !r.flags.On(req, flags.CanaryRouting) {
+		return nil
+	}
+
+	if r.canaryBucket(req.Header.Get("X-Request-ID")) >= route.CanaryWeight {
+		return nil
+	}
+
+	canary, exists := r.GetBackend(route.CanaryBackend)
+	if !exists {
+		return nil
+	}
+	return canary
+}
+
+// canaryBucket maps a request ID to a bucket in [0, 100)
+func (r *Router) canaryBucket(requestID string) int {
+	if requestID == "" {
+		r.rngMutex.Lock()
+		defer r.rngMutex.Unlock()
+		if r.rng != nil {
+			return r.rng.Intn(100)
+		}
+		return rand.Intn(100)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(requestID))
+	return int(h.Sum32() % 100)
+}
+
+// nextEndpoint picks an endpoint of the backend, or nil if the backend cannot serve requests
+func (r *Router) nextEndpoint(route *models.RouteConfig, req *http.Request, backend *Backend) *models.EndpointConfig {
+	if backend.Config.CircuitBreaker.Enabled && !backend.CircuitBreaker.CanExecute() {
+		return nil
+	}
+	if backend.outliers != nil {
+		backend.outliers.readmit()
+	}
+
+	var endpoint *models.EndpointConfig
+	if keyed, ok := backend.LoadBalancer.(loadbalancer.Keyed); ok {
+		endpoint = keyed.NextFor(req)
+	} else {
+		endpoint = backend.LoadBalancer.Next()
+	}
+	if endpoint == nil {
+		r.logger.WarnContext(req.Context(), "No healthy endpoints", "route", route.ID, "backend", backend.Config.ID)
+		return nil
+	}
+	return endpoint
+}
+
+// serveEndpoint proxies a request to one endpoint of the given backend
+func (r *Router) serveEndpoint(w http.ResponseWriter, req *http.Request, route *models.RouteConfig, backend *Backend, endpoint *models.EndpointConfig) {
+	proxy, exists := backend.routeProxy(route, endpoint.URL)
+	if !exists {
+		writeGatewayError(w, req, http.StatusServiceUnavailable)
+		return
+	}
+
+	if !r.enforceHeaderLimits(w, req, route, backend) {
+		return
+	}
+
+	untrack := backend.track(endpoint.URL)
+	defer untrack()
+
+	// The client's context, before the route timeout is applied, tells disconnects from timeouts
+	clientCtx := req.Context()
+
+	// Queueing for a slot doesn't count against the route timeout
+	waited, acquired := backend.acquire(clientCtx)
+	middleware.SetQueueWait(req, waited)
+	if !acquired {
+		if clientCtx.Err() != nil {
+			services.RecordClientDisconnect(route.ID, "queue")
+			w.WriteHeader(statusClientClosedRequest)
+			return
+		}
+		r.rejectConcurrency(w, req, route, backend, waited)
+		return
+	}
+	defer backend.release()
+	services.AddBackendInFlight(backend.Config.ID, 1)
+	defer services.AddBackendInFlight(backend.Config.ID, -1)
+
+	// A reload that replaces the backend cancels the request if it outlives the drain timeout
+	req, releaseDiscard := backend.bindDiscard(req)
+	defer releaseDiscard()
+
+	var deadline *routeDeadline
+	if route.Timeout > 0 {
+		req, deadline = withRouteTimeout(req, route.Timeout)
+		defer deadline.release()
+	}
+
+	if r.servedByHeader() {
+		w.Header().Set("X-Served-By", backend.Config.ID)
+	}
+
+	middleware.SetServedBy(req, backend.Config.ID)
+	middleware.SetEndpoint(req, endpoint.URL)
+
+	inFlight := backend.inFlightCounter(endpoint.URL)
+	inFlight.Add(1)
+	defer inFlight.Add(-1)
+
+	start := r.clock.Now()
+	if route.Streaming != nil && len(route.Streaming.UnbufferedContentTypes) > 0 {
+		w = &unbufferedWriter{ResponseWriter: w, streaming: route.Streaming}
+	}
+	recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK, errorClass: errorClassNone}
+	recorder.onHeader = func() { liftStreamingDeadlines(recorder.ResponseWriter, route, deadline) }
+
+	if r.tracer != nil {
+		var span *tracing.Span
+		req, span = r.startBackendSpan(req, route, backend, endpoint)
+		defer endBackendSpan(span, recorder)
+	}
+
+	// The proxy aborts with http.ErrAbortHandler when the client goes away mid-response
+	defer func() {
+		if p := recover(); p != nil {
+			if p == http.ErrAbortHandler && clientCtx.Err() != nil {
+				r.recordClientDisconnect(req, route, backend, endpoint, recorder, clock.Since(r.clock, start))
+			}
+			panic(p)
+		}
+	}()
+
+	proxy.ServeHTTP(recorder, req)
+	duration := clock.Since(r.clock, start)
+
+	if recorder.errorClass == errorClassBodyTooLarge {
+		r.recordBodyTooLarge(req, route, r.bodyLimit(route))
+	}
+
+	if clientCtx.Err() != nil {
+		r.recordClientDisconnect(req, route, backend, endpoint, recorder, duration)
+		return
+	}
+
+	if backend.Config.CircuitBreaker.Enabled {
+		backend.CircuitBreaker.RecordResult(recorder.statusCode < http.StatusInternalServerError)
+	}
+
+	failure := recorder.failure()
+	backend.LoadBalancer.Observe(endpoint.URL, duration, failure)
+	if backend.outliers != nil {
+		backend.outliers.observe(endpoint.URL, failure != nil)
+	}
+
+	status := strconv.Itoa(recorder.statusCode)
+	services.RecordBackendRequest(backend.Config.ID, endpoint.URL, status, recorder.errorClass, duration.Seconds(), middleware.SampledTraceID(req))
+	services.RecordRouteBackendRequest(route.ID, backend.Config.ID, status)
+}
+
+// servedByHeader reports whether the X-Served-By debug header is enabled
+func (r *Router) servedByHeader() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.config.Router.ServedByHeader
+}
+
+// Runtime returns the live state of the backend.
+// Endpoints the balancer considers unhealthy or that are draining have an effective weight of zero.
+func (b *Backend) Runtime() BackendRuntime {
+	runtime := BackendRuntime{CircuitBreaker: "disabled"}
+	if b.Config.CircuitBreaker.Enabled {
+		runtime.CircuitBreaker = string(b.CircuitBreaker.GetState())
+	}
+	if b.outliers != nil {
+		b.outliers.readmit()
+	}
+
+	for _, endpoint := range b.LoadBalancer.Endpoints() {
+		endpointRuntime := EndpointRuntime{
+			URL:             endpoint.URL,
+			BalancerHealthy: endpoint.Healthy,
+			Draining:        endpoint.Draining,
+		}
+		if endpoint.Available() {
+			endpointRuntime.EffectiveWeight = endpoint.Weight
+		}
+		endpointRuntime.InFlight = b.inFlightCounter(endpoint.URL).Load()
+		endpointRuntime.Ejected = b.outliers != nil && b.outliers.isEjected(endpoint.URL)
+		runtime.Endpoints = append(runtime.Endpoints, endpointRuntime)
+	}
+
+	return runtime
+}
+
+// SetDraining starts or stops draining one of the backend's endpoints.
+// It reports false if the backend has no endpoint with the URL.
+func (b *Backend) SetDraining(endpointURL string, draining bool) bool {
+	for _, endpoint := range b.LoadBalancer.Endpoints() {
+		if endpoint.URL == endpointURL {
+			b.LoadBalancer.MarkDraining(&endpoint, draining)
+			return true
+		}
+	}
+	return false
+}
+
+// SetEndpointHealth applies an active health check result to the load balancer of a backend
+// the router serves, so unhealthy endpoints get no requests and canaries fall back to their
+// primary. An endpoint ejected by the passive health check stays out until its ejection ends.
+func (r *Router) SetEndpointHealth(backendID, endpointURL string, healthy bool) {
+	backend, exists := r.GetBackend(backendID)
+	if !exists {
+		return
+	}
+	if backend.outliers != nil && !backend.outliers.setActiveHealth(endpointURL, healthy) {
+		return
+	}
+
+	endpoint := &models.EndpointConfig{URL: endpointURL}
+	if healthy {
+		backend.LoadBalancer.MarkHealthy(endpoint)
+	} else {
+		backend.LoadBalancer.MarkUnhealthy(endpoint)
+	}
+}
+
+// Reload applies a new configuration. Only added and changed backends are rebuilt;
+// unchanged ones keep their proxies, in-flight counts and circuit breaker state, and
+// rebuilt ones keep the drain state of endpoints that still exist.
+// Requests already in flight finish on the backend they started with; replaced and
+// removed backends are discarded once those requests finish or the drain timeout passes.
+func (r *Router) Reload(cfg *config.Config) error {
+	r.mutex.RLock()
+	current := r.backends
+	// Every balancer is built for the router's zone, so a new zone rebuilds all backends
+	zoneChanged := cfg.Router.Zone != r.config.Router.Zone || cfg.Router.ZoneMinHealthy != r.config.Router.ZoneMinHealthy
+	r.mutex.RUnlock()
+
+	backends := make(map[string]*Backend)
+	rebuilt := 0
+	for _, backendConfig := range cfg.Backends {
+		if !backendConfig.Enabled {
+			continue
+		}
+
+		if existing, ok := current[backendConfig.ID]; ok && !zoneChanged && reflect.DeepEqual(existing.currentConfig(), backendConfig) {
+			backends[backendConfig.ID] = existing
+			continue
+		}
+
+		backend, err := r.initializeBackend(cfg.Router, backendConfig)
+		if err != nil {
+			return fmt.Errorf("failed to initialize backend %s: %w", backendConfig.ID, err)
+		}
+		if existing, ok := current[backendConfig.ID]; ok {
+			for _, endpoint := range existing.LoadBalancer.Endpoints() {
+				if endpoint.Draining {
+					backend.SetDraining(endpoint.URL, true)
+				}
+			}
+		}
+		backends[backendConfig.ID] = backend
+		rebuilt++
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.config = cfg
+	r.backends = backends
+
+	drainTimeout := cfg.Router.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	for id, backend := range current {
+		if backends[id] != backend {
+			go r.drain(backend, drainTimeout)
+		}
+	}
+
+	r.logger.Info("Router reloaded", "backends", len(backends), "rebuilt", rebuilt)
+	return nil
+}
+
+// statusClientClosedRequest is the non-standard status recorded when the client disconnects
+const statusClientClosedRequest = 499
+
+// recordClientDisconnect logs and counts a request the client abandoned.
+// It isn't counted against the backend's circuit breaker.
+func (r *Router) recordClientDisconnect(req *http.Request, route *models.RouteConfig, backend *Backend, endpoint *models.EndpointConfig, recorder *statusRecorder, waited time.Duration) {
+	// Until the backend's response headers are written the request is still upstream
+	phase := "response"
+	if !recorder.wroteHeader || recorder.statusCode == statusClientClosedRequest {
+		phase = "upstream"
+	}
+
+	r.logger.WarnContext(req.Context(), "Client disconnected",
+		"route", route.ID,
+		"backend", backend.Config.ID,
+		"endpoint", endpoint.URL,
+		"phase", phase,
+		"waited", waited.String(),
+	)
+
+	status := strconv.Itoa(statusClientClosedRequest)
+	services.RecordClientDisconnect(route.ID, phase)
+	services.RecordBackendRequest(backend.Config.ID, endpoint.URL, status, errorClassCanceled, waited.Seconds(), "")
+	services.RecordRouteBackendRequest(route.ID, backend.Config.ID, status)
+}
+
+// statusRecorder wraps http.ResponseWriter to capture the status code
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	errorClass  string // set by the proxy error handler
+	err         error  // set by the proxy error handler
+	onHeader    func() // called before the response headers are written
+}
+
+// failure returns the error the request failed with: the proxy's error,
+// or ErrUnexpectedStatus when the backend answered with a 5xx
+func (sr *statusRecorder) failure() error {
+	if sr.err != nil {
+		return sr.err
+	}
+	if sr.statusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: %d", models.ErrUnexpectedStatus, sr.statusCode)
+	}
+	return nil
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.statusCode = code
+	sr.wroteHeader = true
+	if sr.onHeader != nil {
+		sr.onHeader()
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Write records an implicit 200 before writing the body
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	sr.wroteHeader = true
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streaming responses
+func (sr *statusRecorder) Flush() {
+	// Use a ResponseController so wrapped writers that only implement Unwrap still flush
+	_ = http.NewResponseController(sr.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
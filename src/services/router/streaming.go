@@ -0,0 +1,154 @@
+package router
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// bufferPools holds one copy buffer pool per buffer size, shared by all routes
+var bufferPools sync.Map // int -> *bufferPool
+
+// bufferPool is an httputil.BufferPool of fixed size buffers
+type bufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+// bufferPoolFor returns the shared pool for the buffer size
+func bufferPoolFor(size int) *bufferPool {
+	if pool, ok := bufferPools.Load(size); ok {
+		return pool.(*bufferPool)
+	}
+	pool, _ := bufferPools.LoadOrStore(size, &bufferPool{size: size})
+	return pool.(*bufferPool)
+}
+
+// Get implements httputil.BufferPool
+func (p *bufferPool) Get() []byte {
+	if buf, ok := p.pool.Get().(*[]byte); ok {
+		return *buf
+	}
+	return make([]byte, p.size)
+}
+
+// Put implements httputil.BufferPool
+func (p *bufferPool) Put(buf []byte) {
+	p.pool.Put(&buf)
+}
+
+// routeProxy returns the endpoint's proxy, tuned with the route's streaming settings.
+// Tuned proxies are copies of the endpoint proxy and are built once per route.
+func (b *Backend) routeProxy(route *models.RouteConfig, endpointURL string) (*httputil.ReverseProxy, bool) {
+	b.endpointMutex.RLock()
+	proxy, exists := b.proxies[endpointURL]
+	b.endpointMutex.RUnlock()
+	if !exists || route.Streaming == nil {
+		return proxy, exists
+	}
+
+	key := route.ID + " " + endpointURL
+	if tuned, ok := b.tuned.Load(key); ok {
+		return tuned.(*httputil.ReverseProxy), true
+	}
+
+	tuned := *proxy
+	if route.Streaming.BufferSize > 0 {
+		tuned.BufferPool = bufferPoolFor(route.Streaming.BufferSize)
+	}
+	if route.Streaming.FlushInterval != 0 {
+		tuned.FlushInterval = route.Streaming.FlushInterval
+	}
+
+	actual, _ := b.tuned.LoadOrStore(key, &tuned)
+	return actual.(*httputil.ReverseProxy), true
+}
+
+// unbufferedWriter flushes after every write when the response has a content type
+// the route streams unbuffered
+type unbufferedWriter struct {
+	http.ResponseWriter
+	streaming *models.StreamingConfig
+	flush     bool
+}
+
+func (w *unbufferedWriter) WriteHeader(code int) {
+	w.flush = w.streaming.Unbuffered(w.Header().Get("Content-Type"))
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the body, flushing it straight to the client for unbuffered content types
+func (w *unbufferedWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if w.flush && err == nil {
+		err = http.NewResponseController(w.ResponseWriter).Flush()
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *unbufferedWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *unbufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// isStreamingResponse reports whether a response is a long-lived stream:
+// server-sent events, or a content type the route streams unbuffered
+func isStreamingResponse(route *models.RouteConfig, contentType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "text/event-stream" {
+		return true
+	}
+	return route.Streaming != nil && route.Streaming.Unbuffered(contentType)
+}
+
+// routeDeadline bounds a request by the route timeout. Unlike a context deadline it
+// can be lifted, so streaming responses aren't cut off once the backend has answered.
+type routeDeadline struct {
+	timer  *time.Timer
+	cancel context.CancelCauseFunc
+}
+
+// withRouteTimeout returns the request bounded by the timeout.
+// The request context is cancelled with context.DeadlineExceeded as its cause,
+// which is what the transport reports, so expiry is still classified as a timeout.
+func withRouteTimeout(req *http.Request, timeout time.Duration) (*http.Request, *routeDeadline) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	deadline := &routeDeadline{
+		timer:  time.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) }),
+		cancel: cancel,
+	}
+	return req.WithContext(ctx), deadline
+}
+
+// lift stops the deadline from expiring; it has no effect once it has expired
+func (d *routeDeadline) lift() {
+	d.timer.Stop()
+}
+
+// release stops the deadline and cancels the request context once the request is done
+func (d *routeDeadline) release() {
+	d.timer.Stop()
+	d.cancel(context.Canceled)
+}
+
+// liftStreamingDeadlines lets a streaming response outlive the route timeout
+// and the server's write timeout; it is called once the response headers are known
+func liftStreamingDeadlines(w http.ResponseWriter, route *models.RouteConfig, deadline *routeDeadline) {
+	if !isStreamingResponse(route, w.Header().Get("Content-Type")) {
+		return
+	}
+	if deadline != nil {
+		deadline.lift()
+	}
+	// Writers without deadline support have no write timeout to lift
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/your-org/ryohi-router/src/lib/tracing"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// startBackendSpan starts the client span of a call to a backend endpoint as a child of
+// the request's span. The proxy Director sends it to the backend as the traceparent.
+func (r *Router) startBackendSpan(req *http.Request, route *models.RouteConfig, backend *Backend, endpoint *models.EndpointConfig) (*http.Request, *tracing.Span) {
+	ctx, span := r.tracer.Start(req.Context(), "backend "+backend.Config.ID, tracing.SpanKindClient)
+	span.SetAttribute("route.id", route.ID)
+	span.SetAttribute("backend.id", backend.Config.ID)
+	span.SetAttribute("endpoint.url", endpoint.URL)
+	span.SetAttribute("http.request.method", req.Method)
+	return req.WithContext(ctx), span
+}
+
+// endBackendSpan records how the backend call went and ends its span
+func endBackendSpan(span *tracing.Span, recorder *statusRecorder) {
+	span.SetAttribute("http.response.status_code", recorder.statusCode)
+	if recorder.errorClass != errorClassNone {
+		span.SetAttribute("error.type", recorder.errorClass)
+	}
+	span.RecordError(recorder.failure())
+	span.End()
+}
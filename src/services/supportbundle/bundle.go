@@ -0,0 +1,119 @@
+// Package supportbundle writes the zip operators attach when filing an issue: the
+// diagnostics asked for, each in its own file, listed in a manifest.
+package supportbundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// DefaultMaxBytes caps a bundle when no size cap is configured
+	DefaultMaxBytes = 32 << 20
+	// ManifestName is the manifest's name in the bundle
+	ManifestName = "manifest.json"
+	// manifestReserve is kept free under the cap for the manifest and the zip directory
+	manifestReserve = 64 << 10
+)
+
+// File is one file of a bundle
+type File struct {
+	Name        string
+	Description string
+	Binary      bool                   // written as is; other files have secrets redacted
+	Skip        string                 // why the file is left out, e.g. not requested; empty to include it
+	Content     func() ([]byte, error) // called only when the file is included
+}
+
+// JSON returns file content that is value encoded as indented JSON
+func JSON(value func() (interface{}, error)) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(v, "", "  ")
+	}
+}
+
+// ManifestEntry describes one file of a bundle
+type ManifestEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Bytes       int    `json:"bytes"`
+	Included    bool   `json:"included"`
+	Reason      string `json:"reason,omitempty"` // why a file was left out
+}
+
+// Manifest lists the files of a bundle, including those left out
+type Manifest struct {
+	CreatedAt time.Time       `json:"created_at"`
+	MaxBytes  int64           `json:"max_bytes"`
+	Redacted  bool            `json:"redacted"` // secrets in text files were replaced
+	Files     []ManifestEntry `json:"files"`
+}
+
+// Options controls how a bundle is written
+type Options struct {
+	MaxBytes int64               // 0 uses DefaultMaxBytes
+	Redact   func([]byte) []byte // applied to every file that isn't Binary; nil leaves them as is
+}
+
+// Write streams a zip of the files to w, followed by the manifest. Files whose content
+// fails or would take the bundle past the size cap are left out and the reason given in
+// the manifest, so a bundle is always complete and readable. Errors are those of w.
+func Write(w io.Writer, files []File, opts Options, now time.Time) (Manifest, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	manifest := Manifest{CreatedAt: now, MaxBytes: opts.MaxBytes, Redacted: opts.Redact != nil, Files: []ManifestEntry{}}
+
+	// Uncompressed sizes are a safe upper bound for what the files add to the zip
+	var size int64
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry := ManifestEntry{Name: file.Name, Description: file.Description, Reason: file.Skip}
+		if file.Skip == "" {
+			content, err := file.Content()
+			if err == nil && !file.Binary && opts.Redact != nil {
+				content = opts.Redact(content)
+			}
+			switch {
+			case err != nil:
+				entry.Reason = fmt.Sprintf("failed: %v", err)
+			case size+int64(len(content)) > opts.MaxBytes-manifestReserve:
+				entry.Reason = fmt.Sprintf("left out to keep the bundle under %d bytes", opts.MaxBytes)
+			default:
+				if err := add(archive, file.Name, content, now); err != nil {
+					return manifest, err
+				}
+				size += int64(len(content))
+				entry.Bytes = len(content)
+				entry.Included = true
+			}
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := add(archive, ManifestName, data, now); err != nil {
+		return manifest, err
+	}
+	return manifest, archive.Close()
+}
+
+// add compresses a file into the archive
+func add(archive *zip.Writer, name string, content []byte, modified time.Time) error {
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// Timeout suggestions are p999 latency times TimeoutHeadroom, rounded up to TimeoutGranularity
+const (
+	TimeoutHeadroom    = 1.5
+	TimeoutGranularity = 100 * time.Millisecond
+)
+
+// MinTimeoutSamples is the number of requests a route needs before a timeout is suggested;
+// with fewer the p999 is only a handful of requests
+const MinTimeoutSamples = 1000
+
+// CandidateTimeouts are the timeouts the report estimates cut-off fractions for,
+// besides the configured and suggested ones
+var CandidateTimeouts = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
+
+// LatencyBucket is one cumulative histogram bucket
+type LatencyBucket struct {
+	UpperBound float64 // seconds
+	Count      uint64  // requests at or below UpperBound
+}
+
+// LatencyHistogram is a route's latency distribution, with buckets sorted by upper bound
+type LatencyHistogram struct {
+	Count   uint64
+	Buckets []LatencyBucket
+}
+
+// RouteLatencyHistograms reads the per-route latency histograms from the gatherer, keyed by route ID
+func RouteLatencyHistograms(gatherer prometheus.Gatherer) (map[string]LatencyHistogram, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	histograms := make(map[string]LatencyHistogram)
+	for _, family := range families {
+		if family.GetName() != "route_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var route string
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "route" {
+					route = label.GetValue()
+				}
+			}
+
+			h := metric.GetHistogram()
+			histogram := LatencyHistogram{Count: h.GetSampleCount()}
+			for _, b := range h.GetBucket() {
+				histogram.Buckets = append(histogram.Buckets, LatencyBucket{UpperBound: b.GetUpperBound(), Count: b.GetCumulativeCount()})
+			}
+			sort.Slice(histogram.Buckets, func(i, j int) bool {
+				return histogram.Buckets[i].UpperBound < histogram.Buckets[j].UpperBound
+			})
+			histograms[route] = histogram
+		}
+	}
+	return histograms, nil
+}
+
+// Quantile estimates the latency below which the fraction q of requests fall,
+// interpolating linearly within a bucket like Prometheus' histogram_quantile.
+// Requests slower than the last bucket are reported at its upper bound.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 || len(h.Buckets) == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.Count)
+	lower, below := 0.0, uint64(0)
+	for _, b := range h.Buckets {
+		if float64(b.Count) >= rank && b.Count > below {
+			seconds := lower + (b.UpperBound-lower)*(rank-float64(below))/float64(b.Count-below)
+			return secondsToDuration(seconds)
+		}
+		lower, below = b.UpperBound, b.Count
+	}
+	return secondsToDuration(lower)
+}
+
+// FractionOver estimates the fraction of requests that took longer than d,
+// which is the fraction a timeout of d would have cut off
+func (h LatencyHistogram) FractionOver(d time.Duration) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	seconds := d.Seconds()
+	lower, below := 0.0, uint64(0)
+	for _, b := range h.Buckets {
+		if seconds <= b.UpperBound {
+			within := float64(b.Count-below) * (seconds - lower) / (b.UpperBound - lower)
+			return 1 - (float64(below)+within)/float64(h.Count)
+		}
+		lower, below = b.UpperBound, b.Count
+	}
+	return 1 - float64(below)/float64(h.Count)
+}
+
+// SuggestTimeout returns p999 times TimeoutHeadroom rounded up to TimeoutGranularity,
+// or 0 when the route has served fewer than MinTimeoutSamples requests
+func SuggestTimeout(h LatencyHistogram) time.Duration {
+	if h.Count < MinTimeoutSamples {
+		return 0
+	}
+
+	suggested := time.Duration(float64(h.Quantile(0.999)) * TimeoutHeadroom)
+	steps := (suggested + TimeoutGranularity - 1) / TimeoutGranularity
+	if steps < 1 {
+		steps = 1
+	}
+	return steps * TimeoutGranularity
+}
+
+// secondsToDuration converts seconds to a duration, rounded to the microsecond
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds*1e6)) * time.Microsecond
+}
+
+// TimeoutCutoff is the estimated fraction of requests a timeout would have cut off
+type TimeoutCutoff struct {
+	TimeoutSeconds float64 `json:"timeout_seconds"`
+	Fraction       float64 `json:"fraction"`
+}
+
+// RouteTimeoutReport compares a route's configured timeout with its observed latency
+type RouteTimeoutReport struct {
+	RouteID           string          `json:"route_id"`
+	Samples           uint64          `json:"samples"`
+	ConfiguredTimeout float64         `json:"configured_timeout_seconds"`
+	P99               float64         `json:"p99_seconds"`
+	P999              float64         `json:"p999_seconds"`
+	SuggestedTimeout  float64         `json:"suggested_timeout_seconds,omitempty"`
+	ConfiguredCutoff  float64         `json:"configured_cutoff_fraction"`
+	Cutoffs           []TimeoutCutoff `json:"cutoffs"`
+	InsufficientData  bool            `json:"insufficient_data,omitempty"`
+}
+
+// BuildTimeoutReport reports on each route from its latency histogram.
+// Routes that haven't served requests yet are reported with no samples.
+func BuildTimeoutReport(routes []models.RouteConfig, histograms map[string]LatencyHistogram) []RouteTimeoutReport {
+	reports := make([]RouteTimeoutReport, 0, len(routes))
+	for _, route := range routes {
+		h := histograms[route.ID]
+		report := RouteTimeoutReport{
+			RouteID:           route.ID,
+			Samples:           h.Count,
+			ConfiguredTimeout: route.Timeout.Seconds(),
+			P99:               h.Quantile(0.99).Seconds(),
+			P999:              h.Quantile(0.999).Seconds(),
+			ConfiguredCutoff:  h.FractionOver(route.Timeout),
+			InsufficientData:  h.Count < MinTimeoutSamples,
+		}
+
+		candidates := append([]time.Duration(nil), CandidateTimeouts...)
+		if suggested := SuggestTimeout(h); suggested > 0 {
+			report.SuggestedTimeout = suggested.Seconds()
+			candidates = append(candidates, suggested)
+		}
+		if route.Timeout > 0 {
+			candidates = append(candidates, route.Timeout)
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+
+		for i, candidate := range candidates {
+			if i > 0 && candidate == candidates[i-1] {
+				continue
+			}
+			report.Cutoffs = append(report.Cutoffs, TimeoutCutoff{
+				TimeoutSeconds: candidate.Seconds(),
+				Fraction:       h.FractionOver(candidate),
+			})
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
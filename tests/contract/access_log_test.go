@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/logging"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestAccessLog_WritesJSONLinesToFile(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"users":[]}`))
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Router.CorrelationIDHeaders = []string{"X-Correlation-ID"}
+	cfg.Logging.Level = "info"
+	cfg.Logging.Output = "file"
+	cfg.Logging.FilePath = filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, cfg.Validate())
+
+	logger, closer, err := logging.New(cfg.Logging)
+	require.NoError(t, err)
+	srv, err := server.New(cfg, logger)
+	require.NoError(t, err)
+
+	for _, id := range []string{"corr-1", "corr-2"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("X-Correlation-ID", id)
+		w := httptest.NewRecorder()
+		srv.GetRouter().ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	require.NoError(t, closer.Close())
+
+	file, err := os.Open(cfg.Logging.FilePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), scanner.Text())
+		if entry["msg"] == "HTTP Request" {
+			entries = append(entries, entry)
+		}
+	}
+
+	require.Len(t, entries, 2, "one access log line per request")
+	for i, id := range []string{"corr-1", "corr-2"} {
+		entry := entries[i]
+		assert.Equal(t, "GET", entry["method"])
+		assert.Equal(t, "/api/v1/users", entry["path"])
+		assert.EqualValues(t, http.StatusOK, entry["status"])
+		assert.EqualValues(t, len(`{"users":[]}`), entry["bytes"])
+		assert.True(t, strings.HasSuffix(entry["duration"].(string), "s"), entry["duration"])
+		assert.Equal(t, id, entry["request_id"])
+	}
+}
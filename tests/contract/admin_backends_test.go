@@ -0,0 +1,242 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/api"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/health"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// BackendView represents the enriched backend listing structure
+type BackendView struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Endpoints []struct {
+		URL     string `json:"url"`
+		Healthy bool   `json:"healthy"`
+	} `json:"endpoints"`
+	Runtime *struct {
+		CircuitBreaker string `json:"circuit_breaker"`
+		Endpoints      []struct {
+			URL             string `json:"url"`
+			Health          string `json:"health"`
+			BalancerHealthy bool   `json:"balancer_healthy"`
+			EffectiveWeight int    `json:"effective_weight"`
+			InFlight        int64  `json:"in_flight"`
+			LastError       string `json:"last_error"`
+		} `json:"endpoints"`
+	} `json:"runtime"`
+}
+
+func TestAdminBackendsEndpoint_LiveState(t *testing.T) {
+	release := make(chan struct{})
+	slowBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slowBackend.Close()
+	defer close(release)
+
+	// A closed port makes the second endpoint fail its health check
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	refusedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints = []models.EndpointConfig{
+		{URL: slowBackend.URL, Weight: 70, Healthy: true},
+		{URL: refusedURL, Weight: 30, Healthy: false},
+	}
+	cfg.Backends[0].HealthCheck.Interval = time.Second
+	cfg.Backends[0].HealthCheck.Timeout = 500 * time.Millisecond
+	cfg.Backends[0].HealthCheck.ExpectedStatus = []int{200}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	routerService, err := router.New(cfg, logger)
+	require.NoError(t, err)
+	checker := health.NewChecker(cfg, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+
+	current := func() *config.Config { return cfg }
+	r := mux.NewRouter()
+	r.HandleFunc("/admin/backends", api.GetBackendsHandler(current, routerService, checker)).Methods("GET")
+	r.HandleFunc("/admin/backends/{id}", api.GetBackendHandler(current, routerService, checker)).Methods("GET")
+
+	// Hold one request in flight against the slow endpoint
+	go routerService.CreateHandler(&cfg.Routes[0]).ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/api/v1/slow", nil))
+
+	backend, exists := routerService.GetBackend("test-backend")
+	require.True(t, exists)
+	require.Eventually(t, func() bool {
+		return backend.Runtime().Endpoints[0].InFlight == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, checked := checker.GetEndpointHealth("test-backend", refusedURL)
+		return checked
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Trip the circuit breaker
+	for i := 0; i < 3; i++ {
+		backend.CircuitBreaker.RecordResult(false)
+	}
+
+	t.Run("listing reflects live state", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/backends", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var backends []BackendView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &backends))
+		require.Len(t, backends, 1)
+		runtime := backends[0].Runtime
+		require.NotNil(t, runtime)
+		assert.Equal(t, "open", runtime.CircuitBreaker)
+		require.Len(t, runtime.Endpoints, 2)
+
+		healthy := runtime.Endpoints[0]
+		assert.Equal(t, slowBackend.URL, healthy.URL)
+		assert.Equal(t, int64(1), healthy.InFlight)
+		assert.Equal(t, 70, healthy.EffectiveWeight)
+		assert.True(t, healthy.BalancerHealthy)
+		assert.Equal(t, "healthy", healthy.Health)
+
+		refused := runtime.Endpoints[1]
+		assert.Equal(t, 0, refused.EffectiveWeight)
+		assert.False(t, refused.BalancerHealthy)
+		assert.Equal(t, "unhealthy", refused.Health)
+		assert.Contains(t, refused.LastError, "refused")
+	})
+
+	t.Run("get by ID reflects live state", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/backends/test-backend", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var backend BackendView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &backend))
+		require.NotNil(t, backend.Runtime)
+		assert.Equal(t, "open", backend.Runtime.CircuitBreaker)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/backends/unknown", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("raw returns configuration only", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/backends?raw=true", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var backends []BackendView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &backends))
+		require.Len(t, backends, 1)
+		assert.Nil(t, backends[0].Runtime)
+		assert.False(t, backends[0].Endpoints[1].Healthy)
+	})
+}
+
+func TestAdminBackendsEndpoint_Update(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+	get := func(t *testing.T) BackendView {
+		w := adminRequest(router, http.MethodGet, "/admin/backends/test-backend?raw=true", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var backend BackendView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &backend))
+		return backend
+	}
+	payload := `{
+		"id": "test-backend",
+		"name": "Updated Backend",
+		"endpoints": [{"url": "http://localhost:4000", "weight": 100, "healthy": true}],
+		"load_balancer": {"algorithm": "round-robin"},
+		"enabled": true
+	}`
+
+	t.Run("updates an existing backend", func(t *testing.T) {
+		w := adminRequest(router, http.MethodPut, "/admin/backends/test-backend", payload)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var backend models.BackendService
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &backend))
+		assert.Equal(t, "Updated Backend", backend.Name)
+		current := get(t)
+		assert.Equal(t, "Updated Backend", current.Name)
+		assert.Equal(t, "http://localhost:4000", current.Endpoints[0].URL)
+	})
+
+	t.Run("rejects an invalid backend", func(t *testing.T) {
+		w := adminRequest(router, http.MethodPut, "/admin/backends/test-backend", `{"id": "test-backend", "name": "No Endpoints"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "Updated Backend", get(t).Name, "an invalid update should not be applied")
+	})
+
+	t.Run("rejects a body with another ID", func(t *testing.T) {
+		renamed := strings.Replace(payload, `"id": "test-backend"`, `"id": "renamed-backend"`, 1)
+		w := adminRequest(router, http.MethodPut, "/admin/backends/test-backend", renamed)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "does not match")
+		assert.Equal(t, "test-backend", get(t).ID, "the backend should keep its ID")
+	})
+
+	t.Run("returns 404 for an unknown backend", func(t *testing.T) {
+		w := adminRequest(router, http.MethodPut, "/admin/backends/unknown", payload)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAdminBackendsEndpoint_Delete(t *testing.T) {
+	t.Run("rejects deleting a backend used by an enabled route", func(t *testing.T) {
+		router, _ := setupTestAdminServer(t)
+
+		w := adminRequest(router, http.MethodDelete, "/admin/backends/test-backend", "")
+		require.Equal(t, http.StatusConflict, w.Code)
+
+		var body models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "backend_in_use", body.Code)
+		assert.Equal(t, []interface{}{"test-route"}, body.Details["routes"])
+		w = adminRequest(router, http.MethodGet, "/admin/backends/test-backend", "")
+		assert.Equal(t, http.StatusOK, w.Code, "the backend should be kept")
+	})
+
+	t.Run("deletes a backend only disabled routes use", func(t *testing.T) {
+		router, _ := setupTestAdminServer(t)
+		w := adminRequest(router, http.MethodPost, "/admin/routes/test-route/disable", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = adminRequest(router, http.MethodDelete, "/admin/backends/test-backend", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		w = adminRequest(router, http.MethodGet, "/admin/backends/test-backend", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("returns 404 for an unknown backend", func(t *testing.T) {
+		router, _ := setupTestAdminServer(t)
+
+		w := adminRequest(router, http.MethodDelete, "/admin/backends/unknown", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestAdminRouteCache_Purge(t *testing.T) {
+	// Test DELETE /admin/routes/{routeId}/cache
+	cfg := createTestConfig()
+	cfg.Routes[0].Cache = &models.CacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10}
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetAdminRouter()
+
+	tests := []struct {
+		name           string
+		routeID        string
+		apiKey         string
+		expectedStatus int
+	}{
+		{
+			name:           "returns 200 for a cached route",
+			routeID:        "test-route",
+			apiKey:         "valid-api-key",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "returns 404 for a route without a cache",
+			routeID:        "unknown-route",
+			apiKey:         "valid-api-key",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "returns 401 without API key",
+			routeID:        "test-route",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/admin/routes/"+tt.routeID+"/cache", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.routeID, response["route"])
+				assert.Contains(t, response, "purged")
+			}
+		})
+	}
+}
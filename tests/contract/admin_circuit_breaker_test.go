@@ -0,0 +1,109 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/api"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// CircuitBreakerView represents the circuit breaker response structure
+type CircuitBreakerView struct {
+	BackendID       string    `json:"backend_id"`
+	Enabled         bool      `json:"enabled"`
+	State           string    `json:"state"`
+	Requests        uint32    `json:"requests"`
+	Failures        uint32    `json:"failures"`
+	NextAttemptTime time.Time `json:"next_attempt_time"`
+}
+
+func TestAdminCircuitBreakerEndpoint(t *testing.T) {
+	cfg := createTestConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	routerService, err := router.New(cfg, logger)
+	require.NoError(t, err)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/admin/backends/{id}/circuit-breaker", api.GetCircuitBreakerHandler(routerService)).Methods("GET")
+	r.HandleFunc("/admin/backends/{id}/circuit-breaker/reset", api.ResetCircuitBreakerHandler(routerService, logger)).Methods("POST")
+
+	get := func(t *testing.T) CircuitBreakerView {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/backends/test-backend/circuit-breaker", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var view CircuitBreakerView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+		return view
+	}
+
+	// Trip the circuit breaker
+	breaker, exists := routerService.GetCircuitBreaker("test-backend")
+	require.True(t, exists)
+	for i := 0; i < 3; i++ {
+		breaker.RecordResult(false)
+	}
+
+	t.Run("returns the breaker state", func(t *testing.T) {
+		view := get(t)
+		assert.Equal(t, "test-backend", view.BackendID)
+		assert.True(t, view.Enabled)
+		assert.Equal(t, "open", view.State)
+		assert.NotZero(t, view.Failures)
+		assert.False(t, view.NextAttemptTime.IsZero(), "an open breaker should report when it is retried")
+	})
+
+	t.Run("reset force-closes the breaker", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/backends/test-backend/circuit-breaker/reset", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var view CircuitBreakerView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+		assert.Equal(t, "closed", view.State)
+		assert.Zero(t, view.Failures)
+		assert.True(t, view.NextAttemptTime.IsZero())
+
+		assert.Equal(t, "closed", get(t).State)
+		assert.True(t, breaker.CanExecute())
+	})
+
+	t.Run("unknown backend returns 404", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/admin/backends/unknown/circuit-breaker", nil),
+			httptest.NewRequest(http.MethodPost, "/admin/backends/unknown/circuit-breaker/reset", nil),
+		} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code, req.Method)
+		}
+	})
+}
+
+func TestAdminCircuitBreakerEndpoint_Routes(t *testing.T) {
+	adminRouter, _ := setupTestAdminServer(t)
+
+	w := adminRequest(adminRouter, http.MethodGet, "/admin/backends/test-backend/circuit-breaker", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var view CircuitBreakerView
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	assert.Equal(t, "closed", view.State)
+
+	w = adminRequest(adminRouter, http.MethodPost, "/admin/backends/test-backend/circuit-breaker/reset", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = adminRequest(adminRouter, http.MethodGet, "/admin/backends/unknown/circuit-breaker", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = adminRequest(adminRouter, http.MethodPost, "/admin/backends/unknown/circuit-breaker/reset", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
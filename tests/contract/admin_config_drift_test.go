@@ -0,0 +1,158 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+const driftTestConfig = `
+version: "1.0"
+router:
+  port: 8080
+admin:
+  enabled: true
+  api_key: valid-api-key
+  port: 8081
+metrics:
+  enabled: true
+  port: 9090
+backends:
+  - id: test-backend
+    name: Test Backend
+    enabled: true
+    endpoints:
+      - url: http://localhost:3000
+        weight: 1
+    load_balancer:
+      algorithm: round-robin
+routes:
+  - id: api-route
+    path: /api/v1
+    method: [GET]
+    backend: test-backend
+    timeout: 30s
+    enabled: true
+`
+
+// ConfigDriftStatus represents the GET /admin/config/drift response
+type ConfigDriftStatus struct {
+	Drifted bool `json:"drifted"`
+	Changes []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	} `json:"changes"`
+	CheckedAt string `json:"checked_at"`
+	Error     string `json:"error"`
+}
+
+// setupDriftServer writes the config to a file and serves the admin and metrics routers for it
+func setupDriftServer(t *testing.T) (admin, metrics http.Handler, path string) {
+	path = filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(driftTestConfig), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return srv.GetAdminRouter(), srv.GetMetricsRouter(), path
+}
+
+// getDrift fetches the drift status
+func getDrift(t *testing.T, router http.Handler) ConfigDriftStatus {
+	w := adminRequest(router, http.MethodGet, "/admin/config/drift", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var status ConfigDriftStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.NotEmpty(t, status.CheckedAt)
+	return status
+}
+
+// driftGauge returns the config_drift line from the metrics endpoint
+func driftGauge(t *testing.T, router http.Handler) string {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "config_drift ") {
+			return line
+		}
+	}
+	t.Fatal("config_drift gauge not exported")
+	return ""
+}
+
+func TestAdminConfigDrift_AdminChangeWithoutPersist(t *testing.T) {
+	admin, metrics, _ := setupDriftServer(t)
+
+	status := getDrift(t, admin)
+	assert.False(t, status.Drifted, "a freshly loaded config should match its file")
+	assert.Empty(t, status.Changes)
+	assert.Equal(t, "config_drift 0", driftGauge(t, metrics))
+
+	w := adminRequest(admin, http.MethodPost, "/admin/routes",
+		`{"id":"new-route","path":"/api/v2","method":["GET"],"backend":"test-backend","enabled":true}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	status = getDrift(t, admin)
+	assert.True(t, status.Drifted)
+	require.Len(t, status.Changes, 1)
+	assert.Equal(t, "routes.new-route", status.Changes[0].Path)
+	assert.Equal(t, "added", status.Changes[0].Type)
+	assert.Equal(t, "config_drift 1", driftGauge(t, metrics))
+
+	// Undoing the change brings the running config back in line with the file
+	w = adminRequest(admin, http.MethodDelete, "/admin/routes/new-route?permanent=true", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	assert.False(t, getDrift(t, admin).Drifted)
+	assert.Equal(t, "config_drift 0", driftGauge(t, metrics))
+}
+
+func TestAdminConfigDrift_FileEditWithoutReload(t *testing.T) {
+	admin, _, path := setupDriftServer(t)
+
+	edited := strings.Replace(driftTestConfig, "timeout: 30s", "timeout: 10s", 1)
+	edited = strings.Replace(edited, "port: 8080", "port: 8090", 1)
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0o644))
+
+	status := getDrift(t, admin)
+	assert.True(t, status.Drifted)
+	paths := make([]string, len(status.Changes))
+	for i, change := range status.Changes {
+		paths[i] = change.Path + " " + change.Type
+	}
+	assert.Equal(t, []string{"router changed", "routes.api-route changed"}, paths)
+
+	// A broken file is reported without clearing the drift
+	require.NoError(t, os.WriteFile(path, []byte("routes: ["), 0o644))
+	status = getDrift(t, admin)
+	assert.NotEmpty(t, status.Error)
+	assert.True(t, status.Drifted)
+
+	require.NoError(t, os.WriteFile(path, []byte(driftTestConfig), 0o644))
+	status = getDrift(t, admin)
+	assert.False(t, status.Drifted)
+	assert.Empty(t, status.Error)
+}
+
+func TestAdminConfigDrift_NoConfigFile(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	w := adminRequest(router, http.MethodGet, "/admin/config/drift", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
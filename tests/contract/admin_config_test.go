@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestAdminConfigEndpoint(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Admin.ReadOnly = true
+	cfg.Admin.UnlockKey = "unlock-secret"
+	cfg.Middleware.RateLimit.Redis.Password = "redis-secret"
+	cfg.Router.OverrideTokens = []string{"override-secret"}
+	cfg.FlagRollouts = map[string]models.FlagRollout{
+		"new-checkout": {Percent: 10, AllowAPIKeys: []string{"partner-key"}},
+	}
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	admin := srv.GetAdminRouter()
+
+	w := adminRequest(admin, http.MethodGet, "/admin/config", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	for _, secret := range []string{"valid-api-key", "unlock-secret", "redis-secret", "override-secret", "partner-key"} {
+		assert.NotContains(t, w.Body.String(), secret, "secrets should be redacted")
+	}
+
+	var body map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	for _, section := range []string{"router", "admin", "backends", "routes", "middleware"} {
+		assert.Contains(t, body, section)
+	}
+
+	var effective config.Config
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &effective))
+	require.Len(t, effective.Routes, 1)
+	assert.Equal(t, "test-route", effective.Routes[0].ID)
+	require.Len(t, effective.Backends, 1)
+	assert.Equal(t, "test-backend", effective.Backends[0].ID)
+	assert.Equal(t, config.RedactedValue, effective.Admin.APIKey)
+	assert.Equal(t, config.RedactedValue, effective.Admin.UnlockKey)
+	assert.Equal(t, config.RedactedValue, effective.Middleware.RateLimit.Redis.Password)
+	assert.Equal(t, []string{config.RedactedValue}, effective.Router.OverrideTokens)
+	assert.Equal(t, []string{config.RedactedValue}, effective.FlagRollouts["new-checkout"].AllowAPIKeys)
+
+	assert.Equal(t, "valid-api-key", cfg.Admin.APIKey, "the running configuration should keep its secrets")
+	assert.Equal(t, []string{"partner-key"}, cfg.FlagRollouts["new-checkout"].AllowAPIKeys)
+}
+
+func TestAdminConfigEndpoint_RequiresAPIKey(t *testing.T) {
+	admin := setupTestAdminRouter()
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAdminConfigOrphansEndpoint(t *testing.T) {
+	cfg := createTestConfig()
+	unused := cfg.Backends[0]
+	unused.ID = "unused-backend"
+	cfg.Backends = append(cfg.Backends, unused)
+	cfg.AuthPolicies = []models.AuthPolicy{{ID: "unused-policy", Issuer: "https://issuer", JWKSURL: "https://issuer/jwks.json"}}
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	w := adminRequest(srv.GetAdminRouter(), http.MethodGet, "/admin/config/orphans", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var body struct {
+		Orphans []config.Orphan `json:"orphans"`
+		Count   int             `json:"count"`
+		Strict  bool            `json:"strict"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, 2, body.Count)
+	assert.Equal(t, config.OrphanUnusedBackend, body.Orphans[0].Kind)
+	assert.Equal(t, "unused-backend", body.Orphans[0].ID)
+	assert.Equal(t, "backends[1]", body.Orphans[0].Section)
+	assert.Equal(t, config.OrphanUnusedAuthPolicy, body.Orphans[1].Kind)
+	assert.Equal(t, "unused-policy", body.Orphans[1].ID)
+	assert.False(t, body.Strict)
+}
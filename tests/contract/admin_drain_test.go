@@ -0,0 +1,159 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestAdminDrainEndpoint(t *testing.T) {
+	adminRouter, _ := setupTestAdminServer(t)
+
+	t.Run("drains and undrains an endpoint", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPost, "/admin/backends/test-backend/endpoints/drain", `{"url": "http://localhost:3000"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, true, response["draining"])
+
+		endpoint := backendEndpointRuntime(t, adminRouter)
+		assert.Equal(t, true, endpoint["draining"])
+		assert.Equal(t, float64(0), endpoint["effective_weight"])
+
+		w = adminRequest(adminRouter, http.MethodPost, "/admin/backends/test-backend/endpoints/drain", `{"url": "http://localhost:3000", "draining": false}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		endpoint = backendEndpointRuntime(t, adminRouter)
+		assert.Equal(t, false, endpoint["draining"])
+	})
+
+	t.Run("rejects unknown backends, endpoints and bad bodies", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPost, "/admin/backends/unknown/endpoints/drain", `{"url": "http://localhost:3000"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = adminRequest(adminRouter, http.MethodPost, "/admin/backends/test-backend/endpoints/drain", `{"url": "http://localhost:9999"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = adminRequest(adminRouter, http.MethodPost, "/admin/backends/test-backend/endpoints/drain", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// backendEndpointRuntime returns the runtime view of test-backend's only endpoint
+func backendEndpointRuntime(t *testing.T, adminRouter http.Handler) map[string]interface{} {
+	endpoints := backendEndpointsRuntime(t, adminRouter)
+	require.Len(t, endpoints, 1)
+	return endpoints[0]
+}
+
+func TestAdminUpdateBackendEndpoints(t *testing.T) {
+	adminRouter, _ := setupTestAdminServer(t)
+
+	t.Run("adds and removes endpoints in place", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPost, "/admin/backends/test-backend/endpoints/drain", `{"url": "http://localhost:3000"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = adminRequest(adminRouter, http.MethodPut, "/admin/backends/test-backend/endpoints",
+			`{"endpoints": [{"url": "http://localhost:3000"}, {"url": "http://localhost:3001", "weight": 2}]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		var changes map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &changes))
+		assert.Equal(t, "test-backend", changes["backend"])
+		assert.Equal(t, []interface{}{"http://localhost:3001"}, changes["added"])
+		assert.Equal(t, []interface{}{}, changes["removed"])
+
+		endpoints := backendEndpointsRuntime(t, adminRouter)
+		require.Len(t, endpoints, 2)
+		assert.Equal(t, true, endpoints[0]["draining"], "an endpoint that stays keeps its drain state")
+		assert.Equal(t, float64(2), endpoints[1]["effective_weight"], "added endpoints are healthy by default")
+
+		w = adminRequest(adminRouter, http.MethodGet, "/admin/backends/test-backend?raw=true", "")
+		var backend models.BackendService
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &backend))
+		require.Len(t, backend.Endpoints, 2, "the configuration should follow")
+
+		w = adminRequest(adminRouter, http.MethodPut, "/admin/backends/test-backend/endpoints",
+			`{"endpoints": [{"url": "http://localhost:3001", "weight": 2}]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &changes))
+		assert.Equal(t, []interface{}{"http://localhost:3000"}, changes["removed"])
+		assert.Len(t, backendEndpointsRuntime(t, adminRouter), 1)
+	})
+
+	t.Run("rejects unknown backends and invalid lists", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPut, "/admin/backends/unknown/endpoints", `{"endpoints": [{"url": "http://localhost:3000"}]}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		for _, body := range []string{`{"endpoints": []}`, `{"endpoints": [{"url": "localhost"}]}`, `{`} {
+			w = adminRequest(adminRouter, http.MethodPut, "/admin/backends/test-backend/endpoints", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+}
+
+// backendEndpointsRuntime returns the runtime view of test-backend's endpoints
+func backendEndpointsRuntime(t *testing.T, adminRouter http.Handler) []map[string]interface{} {
+	w := adminRequest(adminRouter, http.MethodGet, "/admin/backends/test-backend", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var view struct {
+		Runtime struct {
+			Endpoints []map[string]interface{} `json:"endpoints"`
+		} `json:"runtime"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	return view.Runtime.Endpoints
+}
+
+func TestAdminUpdateBackendEndpoints_HealthChecksAddedEndpoints(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	cfg := createTestConfig()
+	cfg.Router.Port = freePort(t)
+	cfg.Admin.Enabled = false
+	cfg.Metrics.Enabled = false
+	cfg.Backends[0].Endpoints[0].URL = healthy.URL
+	cfg.Backends[0].HealthCheck.Type = "http"
+	cfg.Backends[0].HealthCheck.ExpectedStatus = []int{http.StatusOK}
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Start(ctx)
+	defer srv.Shutdown(context.Background())
+	adminRouter := srv.GetAdminRouter()
+
+	require.Eventually(t, func() bool {
+		return backendEndpointRuntime(t, adminRouter)["health"] == "healthy"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	w := adminRequest(adminRouter, http.MethodPut, "/admin/backends/test-backend/endpoints",
+		fmt.Sprintf(`{"endpoints": [{"url": %q}, {"url": %q}]}`, healthy.URL, failing.URL))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Eventually(t, func() bool {
+		endpoints := backendEndpointsRuntime(t, adminRouter)
+		return len(endpoints) == 2 && endpoints[1]["health"] == "unhealthy"
+	}, 5*time.Second, 10*time.Millisecond, "an added endpoint should be health checked")
+
+	// The failed check reaches the balancer, which stops picking the endpoint
+	endpoints := backendEndpointsRuntime(t, adminRouter)
+	assert.Equal(t, failing.URL, endpoints[1]["url"])
+	assert.Equal(t, false, endpoints[1]["balancer_healthy"])
+}
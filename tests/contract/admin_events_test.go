@@ -0,0 +1,194 @@
+package contract
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/api"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/health"
+)
+
+// streamEvent represents a decoded server-sent event
+type streamEvent struct {
+	ID    string
+	Event string
+	Data  events.Event
+}
+
+// openEventStream connects to the events endpoint and returns a channel of decoded events
+func openEventStream(t *testing.T, url string, lastEventID string) (<-chan streamEvent, *http.Response) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	ch := make(chan streamEvent, 16)
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(resp.Body)
+		var current streamEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				current.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.Data)
+			case line == "" && current.ID != "":
+				ch <- current
+				current = streamEvent{}
+			}
+		}
+	}()
+
+	return ch, resp
+}
+
+// nextEvent waits for the next event on the stream
+func nextEvent(t *testing.T, ch <-chan streamEvent) streamEvent {
+	select {
+	case ev, ok := <-ch:
+		require.True(t, ok, "event stream closed unexpectedly")
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return streamEvent{}
+	}
+}
+
+func TestAdminEvents_HealthTransitionsInOrder(t *testing.T) {
+	var failing atomic.Bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Backends[0].HealthCheck.Interval = 1 * time.Second
+	cfg.Backends[0].HealthCheck.Timeout = 500 * time.Millisecond
+	cfg.Backends[0].HealthCheck.ExpectedStatus = []int{200}
+
+	bus := events.NewBus(16, 4)
+	eventServer := httptest.NewServer(api.EventsHandler(bus))
+	defer eventServer.Close()
+	defer bus.Close() // ends open streams so the server can close
+
+	healthEvents, _ := openEventStream(t, eventServer.URL+"?topics="+events.TopicHealth, "")
+	reloadEvents, _ := openEventStream(t, eventServer.URL+"?topics="+events.TopicConfigReload, "")
+	require.Eventually(t, func() bool { return bus.SubscriberCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	checker := health.NewChecker(cfg, logger)
+	checker.SetEventBus(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+
+	first := nextEvent(t, healthEvents)
+	assert.Equal(t, events.TopicHealth, first.Event)
+	assert.Equal(t, events.TopicHealth, first.Data.Type)
+	data := first.Data.Data.(map[string]interface{})
+	assert.Equal(t, "unknown", data["previous"])
+	assert.Equal(t, "healthy", data["status"])
+
+	failing.Store(true)
+
+	second := nextEvent(t, healthEvents)
+	assert.Greater(t, second.Data.ID, first.Data.ID, "events should arrive in publish order")
+	data = second.Data.Data.(map[string]interface{})
+	assert.Equal(t, "healthy", data["previous"])
+	assert.Equal(t, "unhealthy", data["status"])
+
+	// The reload-only stream must not have seen any health events
+	bus.Publish(events.TopicConfigReload, map[string]interface{}{"routes": 1})
+	reload := nextEvent(t, reloadEvents)
+	assert.Equal(t, events.TopicConfigReload, reload.Event)
+	assert.Greater(t, reload.Data.ID, second.Data.ID)
+}
+
+func TestAdminEvents_ReplayFromLastEventID(t *testing.T) {
+	bus := events.NewBus(16, 4)
+	bus.Publish(events.TopicHealth, "first")
+	bus.Publish(events.TopicCircuitBreaker, "second")
+	bus.Publish(events.TopicHealth, "third")
+
+	eventServer := httptest.NewServer(api.EventsHandler(bus))
+	defer eventServer.Close()
+	defer bus.Close()
+
+	stream, _ := openEventStream(t, eventServer.URL, "1")
+
+	ev := nextEvent(t, stream)
+	assert.Equal(t, "2", ev.ID)
+	assert.Equal(t, "second", ev.Data.Data)
+
+	ev = nextEvent(t, stream)
+	assert.Equal(t, "3", ev.ID)
+	assert.Equal(t, "third", ev.Data.Data)
+
+	filtered, _ := openEventStream(t, eventServer.URL+"?topics="+events.TopicHealth, "1")
+	ev = nextEvent(t, filtered)
+	assert.Equal(t, "3", ev.ID, "replay should honor the topic filter")
+}
+
+func TestAdminEvents_ConnectionLimit(t *testing.T) {
+	bus := events.NewBus(16, 1)
+
+	eventServer := httptest.NewServer(api.EventsHandler(bus))
+	defer eventServer.Close()
+
+	stream, _ := openEventStream(t, eventServer.URL, "")
+	require.Eventually(t, func() bool { return bus.SubscriberCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	_, resp := openEventStream(t, eventServer.URL, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	// Closing the bus ends active streams
+	bus.Close()
+	select {
+	case _, ok := <-stream:
+		assert.False(t, ok, "stream should be closed on shutdown")
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not closed on shutdown")
+	}
+	assert.Equal(t, 0, bus.SubscriberCount())
+}
+
+func TestAdminEvents_RequiresAPIKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin/events", nil)
+	w := httptest.NewRecorder()
+
+	router := setupTestAdminRouter()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,116 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+// FeatureFlag represents the feature flag structure
+type FeatureFlag struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Overridden bool   `json:"overridden"`
+	Percent    *int   `json:"percent,omitempty"`
+}
+
+// findFlag returns the named flag from a GET /admin/flags response
+func findFlag(t *testing.T, body []byte, name string) *FeatureFlag {
+	var flags []FeatureFlag
+	require.NoError(t, json.Unmarshal(body, &flags), "response should be valid JSON array")
+	for i := range flags {
+		if flags[i].Name == name {
+			return &flags[i]
+		}
+	}
+	return nil
+}
+
+func TestAdminFlagsEndpoint_Override(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	w := adminRequest(router, http.MethodGet, "/admin/flags", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	flag := findFlag(t, w.Body.Bytes(), "canary_routing")
+	require.NotNil(t, flag)
+	assert.True(t, flag.Enabled)
+	assert.False(t, flag.Overridden)
+
+	w = adminRequest(router, http.MethodPost, "/admin/flags", `{"name":"canary_routing","enabled":false}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = adminRequest(router, http.MethodGet, "/admin/flags", "")
+	flag = findFlag(t, w.Body.Bytes(), "canary_routing")
+	require.NotNil(t, flag)
+	assert.False(t, flag.Enabled)
+	assert.True(t, flag.Overridden)
+
+	w = adminRequest(router, http.MethodDelete, "/admin/flags/canary_routing", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = adminRequest(router, http.MethodGet, "/admin/flags", "")
+	assert.True(t, findFlag(t, w.Body.Bytes(), "canary_routing").Enabled)
+}
+
+func TestAdminFlagsEndpoint_InvalidRequest(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	w := adminRequest(router, http.MethodPost, "/admin/flags", `{"name":"canary_routing"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = adminRequest(router, http.MethodPost, "/admin/flags", `not json`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = adminRequest(router, http.MethodDelete, "/admin/flags/not_overridden", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminFlagsEndpoint_Update(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := createTestConfig()
+	cfg.FlagRollouts = map[string]models.FlagRollout{"new_compression": {Percent: 5, Routes: []string{"test-route"}}}
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+	router := srv.GetAdminRouter()
+
+	w := adminRequest(router, http.MethodGet, "/admin/flags", "")
+	flag := findFlag(t, w.Body.Bytes(), "new_compression")
+	require.NotNil(t, flag)
+	assert.True(t, flag.Enabled, "a flag with a rollout is enabled")
+	require.NotNil(t, flag.Percent)
+	assert.Equal(t, 5, *flag.Percent)
+
+	w = adminRequest(router, http.MethodPut, "/admin/flags/new_compression", `{"enabled":true,"percent":25}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated FeatureFlag
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.True(t, updated.Overridden)
+	require.NotNil(t, updated.Percent)
+	assert.Equal(t, 25, *updated.Percent)
+
+	assert.Contains(t, logs.String(), `msg="Feature flag changed" flag=new_compression action=update`)
+	assert.Contains(t, logs.String(), "percent=25")
+
+	w = adminRequest(router, http.MethodPut, "/admin/flags/new_compression", `{"enabled":false}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = adminRequest(router, http.MethodGet, "/admin/flags", "")
+	flag = findFlag(t, w.Body.Bytes(), "new_compression")
+	assert.False(t, flag.Enabled)
+	assert.Equal(t, 5, *flag.Percent, "the configured percent applies again without an override percent")
+
+	w = adminRequest(router, http.MethodDelete, "/admin/flags/new_compression", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, logs.String(), "action=reset")
+
+	w = adminRequest(router, http.MethodPut, "/admin/flags/new_compression", `{"enabled":true,"percent":150}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = adminRequest(router, http.MethodPut, "/admin/flags/new_compression", `{"percent":10}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,113 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+// AdminLockStatus represents the admin lock response structure
+type AdminLockStatus struct {
+	ReadOnly      bool       `json:"read_only"`
+	Locked        bool       `json:"locked"`
+	UnlockedUntil *time.Time `json:"unlocked_until"`
+}
+
+// setupReadOnlyAdminServer creates an admin router for a read-only admin API
+func setupReadOnlyAdminServer(t *testing.T) http.Handler {
+	cfg := createTestConfig()
+	cfg.Admin.ReadOnly = true
+	cfg.Admin.UnlockKey = "unlock-secret"
+	cfg.Admin.UnlockWindow = time.Minute
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return srv.GetAdminRouter()
+}
+
+// unlockRequest sends POST /admin/unlock with the given unlock key
+func unlockRequest(router http.Handler, unlockKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/admin/unlock", nil)
+	req.Header.Set("X-API-Key", "valid-api-key")
+	if unlockKey != "" {
+		req.Header.Set("X-Unlock-Key", unlockKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminLockEndpoint(t *testing.T) {
+	router := setupReadOnlyAdminServer(t)
+	newFlag := `{"name": "lock-test", "enabled": true}`
+
+	t.Run("starts locked", func(t *testing.T) {
+		w := adminRequest(router, http.MethodGet, "/admin/lock", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status AdminLockStatus
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.True(t, status.ReadOnly)
+		assert.True(t, status.Locked)
+		assert.Nil(t, status.UnlockedUntil)
+	})
+
+	t.Run("reads are allowed and mutations rejected while locked", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, adminRequest(router, http.MethodGet, "/admin/routes", "").Code)
+
+		w := adminRequest(router, http.MethodPost, "/admin/flags", newFlag)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"admin_locked"`)
+		assert.Contains(t, w.Body.String(), `"locked":true`)
+	})
+
+	t.Run("unlock requires the unlock key", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, unlockRequest(router, "").Code)
+		assert.Equal(t, http.StatusForbidden, unlockRequest(router, "valid-api-key").Code)
+	})
+
+	t.Run("unlock allows mutations for the window", func(t *testing.T) {
+		w := unlockRequest(router, "unlock-secret")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status AdminLockStatus
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.False(t, status.Locked)
+		require.NotNil(t, status.UnlockedUntil)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *status.UnlockedUntil, 5*time.Second)
+
+		assert.NotEqual(t, http.StatusForbidden, adminRequest(router, http.MethodPost, "/admin/flags", newFlag).Code)
+	})
+
+	t.Run("relock ends the window", func(t *testing.T) {
+		w := adminRequest(router, http.MethodPost, "/admin/lock", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status AdminLockStatus
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.True(t, status.Locked)
+
+		assert.Equal(t, http.StatusForbidden, adminRequest(router, http.MethodPost, "/admin/flags", newFlag).Code)
+	})
+}
+
+func TestAdminLockEndpoint_NotReadOnly(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	w := adminRequest(router, http.MethodGet, "/admin/lock", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var status AdminLockStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.False(t, status.ReadOnly)
+	assert.False(t, status.Locked)
+
+	assert.Equal(t, http.StatusConflict, unlockRequest(router, "anything").Code)
+}
@@ -0,0 +1,84 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+// profilingStatus decodes a profiling status response
+func profilingStatus(t *testing.T, body []byte) map[string]interface{} {
+	var status map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &status))
+	return status
+}
+
+func TestAdminProfiling(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Profiling = config.ProfilingConfig{
+		Directory:       t.TempDir(),
+		Interval:        time.Minute,
+		CPUDuration:     10 * time.Millisecond,
+		DefaultDuration: 15 * time.Minute,
+	}
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	adminRouter := srv.GetAdminRouter()
+	t.Cleanup(func() { adminRequest(adminRouter, http.MethodPost, "/admin/profiling/stop", "") })
+
+	t.Run("is off by default", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodGet, "/admin/profiling", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, false, profilingStatus(t, w.Body.Bytes())["running"])
+	})
+
+	t.Run("starts with the default duration and stops", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPost, "/admin/profiling/start", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		status := profilingStatus(t, w.Body.Bytes())
+		assert.Equal(t, true, status["running"])
+		until, err := time.Parse(time.RFC3339Nano, status["until"].(string))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), until, time.Minute)
+
+		w = adminRequest(adminRouter, http.MethodPost, "/admin/profiling/stop", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, false, profilingStatus(t, w.Body.Bytes())["running"])
+	})
+
+	t.Run("starts for the requested duration", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPost, "/admin/profiling/start", `{"duration": "2m"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		until, err := time.Parse(time.RFC3339Nano, profilingStatus(t, w.Body.Bytes())["until"].(string))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), until, 30*time.Second)
+	})
+
+	t.Run("rejects invalid durations", func(t *testing.T) {
+		for _, body := range []string{`{"duration": "soon"}`, `{"duration": "-1m"}`, `{`} {
+			w := adminRequest(adminRouter, http.MethodPost, "/admin/profiling/start", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+}
+
+func TestAdminProfiling_NotConfigured(t *testing.T) {
+	adminRouter, _ := setupTestAdminServer(t)
+
+	for _, request := range []struct{ method, target string }{
+		{http.MethodGet, "/admin/profiling"},
+		{http.MethodPost, "/admin/profiling/start"},
+		{http.MethodPost, "/admin/profiling/stop"},
+	} {
+		w := adminRequest(adminRouter, request.method, request.target, "")
+		assert.Equal(t, http.StatusNotFound, w.Code, request.target)
+	}
+}
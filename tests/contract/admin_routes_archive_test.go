@@ -0,0 +1,116 @@
+package contract
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// routeIDs decodes a JSON list of routes and returns their IDs
+func routeIDs(t *testing.T, body []byte) []string {
+	var routes []RouteConfig
+	require.NoError(t, json.Unmarshal(body, &routes))
+
+	ids := make([]string, 0, len(routes))
+	for _, route := range routes {
+		ids = append(ids, route.ID)
+	}
+	return ids
+}
+
+func TestAdminRoutesArchive_DeleteArchivesRoute(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	w := adminRequest(router, http.MethodDelete, "/admin/routes/test-route", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = adminRequest(router, http.MethodGet, "/admin/routes", "")
+	assert.NotContains(t, routeIDs(t, w.Body.Bytes()), "test-route", "archived routes are excluded from the list")
+
+	w = adminRequest(router, http.MethodGet, "/admin/routes/archived", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"test-route"}, routeIDs(t, w.Body.Bytes()))
+
+	var archived []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &archived))
+	assert.NotEmpty(t, archived[0]["archived_at"])
+}
+
+func TestAdminRoutesArchive_Restore(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	adminRequest(router, http.MethodDelete, "/admin/routes/test-route", "")
+
+	w := adminRequest(router, http.MethodPost, "/admin/routes/archived/test-route/restore", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var route RouteConfig
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &route))
+	assert.Equal(t, "test-route", route.ID)
+
+	w = adminRequest(router, http.MethodGet, "/admin/routes", "")
+	assert.Contains(t, routeIDs(t, w.Body.Bytes()), "test-route")
+
+	w = adminRequest(router, http.MethodGet, "/admin/routes/archived", "")
+	assert.Empty(t, routeIDs(t, w.Body.Bytes()))
+
+	w = adminRequest(router, http.MethodPost, "/admin/routes/archived/unknown/restore", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminRoutesArchive_RestoreIDCollision(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	adminRequest(router, http.MethodDelete, "/admin/routes/test-route", "")
+
+	// A new route takes over the archived route's ID
+	newRoute := `{"id":"test-route","path":"/api/v2/*","method":["GET"],"backend":"test-backend","enabled":true}`
+	w := adminRequest(router, http.MethodPost, "/admin/routes", newRoute)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = adminRequest(router, http.MethodPost, "/admin/routes/archived/test-route/restore", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// The archived copy is kept so it can be restored once the ID is free
+	w = adminRequest(router, http.MethodGet, "/admin/routes/archived", "")
+	assert.Equal(t, []string{"test-route"}, routeIDs(t, w.Body.Bytes()))
+}
+
+func TestAdminRoutesArchive_PermanentDelete(t *testing.T) {
+	router, _ := setupTestAdminServer(t)
+
+	w := adminRequest(router, http.MethodDelete, "/admin/routes/test-route?permanent=true", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = adminRequest(router, http.MethodGet, "/admin/routes/archived", "")
+	assert.Empty(t, routeIDs(t, w.Body.Bytes()))
+
+	w = adminRequest(router, http.MethodDelete, "/admin/routes/test-route?permanent=true", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminRoutesArchive_RetentionPurge(t *testing.T) {
+	router, cfg := setupTestAdminServer(t)
+	cfg.Admin.ArchiveRetention = 24 * time.Hour
+
+	expired := cfg.Routes[0]
+	expired.ID = "expired-route"
+	cfg.ArchivedRoutes = append(cfg.ArchivedRoutes, models.ArchivedRoute{
+		RouteConfig: expired,
+		ArchivedAt:  time.Now().Add(-48 * time.Hour),
+	})
+
+	w := adminRequest(router, http.MethodGet, "/admin/routes/archived", "")
+	assert.Empty(t, routeIDs(t, w.Body.Bytes()), "routes past retention are not listed")
+	assert.Len(t, cfg.ArchivedRoutes, 1, "listing should not purge the running config")
+
+	adminRequest(router, http.MethodDelete, "/admin/routes/test-route", "")
+
+	w = adminRequest(router, http.MethodGet, "/admin/routes/archived", "")
+	assert.Equal(t, []string{"test-route"}, routeIDs(t, w.Body.Bytes()), "routes past retention are purged")
+}
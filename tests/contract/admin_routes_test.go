@@ -104,7 +104,7 @@ func TestAdminRoutesEndpoint_GetAll(t *testing.T) {
 func TestAdminRoutesEndpoint_Create(t *testing.T) {
 	// Test POST /admin/routes
 	newRoute := RouteConfig{
-		ID:      "test-route",
+		ID:      "new-route",
 		Path:    "/test/*",
 		Method:  []string{"GET", "POST"},
 		Backend: "test-backend",
@@ -225,7 +225,7 @@ func TestAdminRoutesEndpoint_Update(t *testing.T) {
 		ID:      "test-route",
 		Path:    "/test/v2/*",
 		Method:  []string{"GET", "POST", "PUT"},
-		Backend: "test-backend-v2",
+		Backend: "test-backend",
 		Timeout: 60000000000, // 60 seconds in nanoseconds
 		Enabled: true,
 	}
@@ -0,0 +1,79 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestAdminRouteToggleEndpoints(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "backend")
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	mainHandler := srv.GetMainHandler()
+	admin := srv.GetAdminRouter()
+
+	get := func(t *testing.T) int {
+		w := httptest.NewRecorder()
+		mainHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, get(t))
+
+	t.Run("disabling a route stops it matching", func(t *testing.T) {
+		w := adminRequest(admin, http.MethodPost, "/admin/routes/test-route/disable", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var route RouteConfig
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &route))
+		assert.Equal(t, "test-route", route.ID)
+		assert.False(t, route.Enabled)
+
+		assert.Equal(t, http.StatusNotFound, get(t))
+
+		// Reloads swap in a new config, so check the definition through the admin API
+		w = adminRequest(admin, http.MethodGet, "/admin/routes", "")
+		var routes []RouteConfig
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &routes))
+		require.Len(t, routes, 1, "the route definition is kept")
+		assert.False(t, routes[0].Enabled)
+	})
+
+	t.Run("re-enabling a route restores it", func(t *testing.T) {
+		w := adminRequest(admin, http.MethodPost, "/admin/routes/test-route/enable", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var route RouteConfig
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &route))
+		assert.True(t, route.Enabled)
+
+		assert.Equal(t, http.StatusOK, get(t))
+	})
+
+	t.Run("toggling is idempotent", func(t *testing.T) {
+		w := adminRequest(admin, http.MethodPost, "/admin/routes/test-route/enable", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, get(t))
+	})
+
+	t.Run("returns 404 for unknown routes", func(t *testing.T) {
+		for _, action := range []string{"enable", "disable"} {
+			w := adminRequest(admin, http.MethodPost, "/admin/routes/missing/"+action, "")
+			assert.Equal(t, http.StatusNotFound, w.Code, action)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package contract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+	"github.com/your-org/ryohi-router/src/services/supportbundle"
+)
+
+// bundleSecrets are configured as secrets and must not appear anywhere in a bundle
+var bundleSecrets = []string{"valid-api-key", "unlock-secret-123", "redis-secret-456", "rollout-secret-789"}
+
+// supportBundleServer creates a read-only server with every kind of secret configured
+func supportBundleServer(t *testing.T, maxBytes int64) *server.Server {
+	cfg := createTestConfig()
+	cfg.Admin.ReadOnly = true
+	cfg.Admin.UnlockKey = "unlock-secret-123"
+	cfg.Admin.SupportBundleMaxBytes = maxBytes
+	cfg.Middleware.RateLimit.Redis.Password = "redis-secret-456"
+	cfg.FlagRollouts = map[string]models.FlagRollout{"beta": {Percent: 10, AllowAPIKeys: []string{"rollout-secret-789"}}}
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return srv
+}
+
+// readBundle unzips a support bundle response into its files and manifest
+func readBundle(t *testing.T, w *httptest.ResponseRecorder) (map[string][]byte, supportbundle.Manifest) {
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "support-bundle-")
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(reader)
+		require.NoError(t, err)
+		reader.Close()
+	}
+
+	var manifest supportbundle.Manifest
+	require.Contains(t, files, supportbundle.ManifestName)
+	require.NoError(t, json.Unmarshal(files[supportbundle.ManifestName], &manifest))
+	return files, manifest
+}
+
+func TestAdminSupportBundle(t *testing.T) {
+	srv := supportBundleServer(t, 0)
+	adminRouter := srv.GetAdminRouter()
+
+	// A request quoting a secret fails on the unreachable backend, leaving an access and an error line
+	w := httptest.NewRecorder()
+	srv.GetMainHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/valid-api-key", nil))
+	require.Equal(t, http.StatusBadGateway, w.Code)
+
+	t.Run("lists every file in the manifest and redacts secrets", func(t *testing.T) {
+		files, manifest := readBundle(t, adminRequest(adminRouter, http.MethodPost, "/admin/support-bundle", ""))
+
+		assert.True(t, manifest.Redacted)
+		assert.Equal(t, int64(supportbundle.DefaultMaxBytes), manifest.MaxBytes)
+		listed := map[string]supportbundle.ManifestEntry{}
+		for _, entry := range manifest.Files {
+			assert.NotEmpty(t, entry.Description, entry.Name)
+			listed[entry.Name] = entry
+		}
+		for _, name := range []string{
+			"config.json", "startup.json", "version.json", "health.json", "circuit_breakers.json",
+			"routes.json", "logs/errors.json", "logs/warnings.json", "logs/access.json",
+		} {
+			require.Contains(t, listed, name)
+			assert.True(t, listed[name].Included, name)
+			require.Contains(t, files, name)
+			assert.Len(t, files[name], listed[name].Bytes, name)
+			assert.True(t, json.Valid(files[name]), name)
+		}
+		for _, name := range []string{"profiles/goroutine.pprof", "profiles/heap.pprof"} {
+			require.Contains(t, listed, name)
+			assert.False(t, listed[name].Included, "profiles are only taken when asked for")
+			assert.NotEmpty(t, listed[name].Reason)
+			assert.NotContains(t, files, name)
+		}
+		assert.Len(t, files, len(manifest.Files)-2+1, "the zip should hold the included files and the manifest")
+
+		for name, content := range files {
+			for _, secret := range bundleSecrets {
+				assert.NotContains(t, string(content), secret, "%s leaks a secret", name)
+			}
+		}
+
+		var cfg config.Config
+		require.NoError(t, json.Unmarshal(files["config.json"], &cfg))
+		assert.Equal(t, config.RedactedValue, cfg.Admin.APIKey)
+		assert.Equal(t, config.RedactedValue, cfg.Admin.UnlockKey)
+		assert.Equal(t, config.RedactedValue, cfg.Middleware.RateLimit.Redis.Password)
+
+		assert.Contains(t, string(files["logs/access.json"]), "/api/v1/"+config.RedactedValue)
+		assert.Contains(t, string(files["logs/errors.json"]), "Proxy error")
+		assert.Contains(t, string(files["routes.json"]), `"id": "test-route"`)
+		assert.Contains(t, string(files["circuit_breakers.json"]), `"test-backend"`)
+	})
+
+	t.Run("includes profiles when asked for", func(t *testing.T) {
+		files, manifest := readBundle(t, adminRequest(adminRouter, http.MethodPost, "/admin/support-bundle", `{"include_profiles": true}`))
+
+		for _, entry := range manifest.Files {
+			assert.True(t, entry.Included, entry.Name)
+		}
+		assert.NotEmpty(t, files["profiles/goroutine.pprof"])
+		assert.NotEmpty(t, files["profiles/heap.pprof"])
+	})
+
+	t.Run("rejects an invalid body", func(t *testing.T) {
+		w := adminRequest(adminRouter, http.MethodPost, "/admin/support-bundle", `{`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAdminSupportBundle_SizeCap(t *testing.T) {
+	maxBytes := int64(66 << 10)
+	srv := supportBundleServer(t, maxBytes)
+
+	w := adminRequest(srv.GetAdminRouter(), http.MethodPost, "/admin/support-bundle", `{"include_profiles": true}`)
+	files, manifest := readBundle(t, w)
+
+	assert.LessOrEqual(t, int64(w.Body.Len()), maxBytes)
+	assert.Equal(t, maxBytes, manifest.MaxBytes)
+	var leftOut int
+	for _, entry := range manifest.Files {
+		if !entry.Included {
+			leftOut++
+			assert.Contains(t, entry.Reason, "under", entry.Name)
+			_, exists := files[entry.Name]
+			assert.False(t, exists, entry.Name)
+		}
+	}
+	assert.NotZero(t, leftOut, "files past the cap should be left out")
+	_, kept := files["version.json"]
+	assert.True(t, kept, "files that still fit should be kept")
+}
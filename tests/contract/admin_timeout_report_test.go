@@ -0,0 +1,66 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// TimeoutReport represents the timeout report response structure
+type TimeoutReport struct {
+	Routes []struct {
+		RouteID           string  `json:"route_id"`
+		Samples           uint64  `json:"samples"`
+		ConfiguredTimeout float64 `json:"configured_timeout_seconds"`
+		P99               float64 `json:"p99_seconds"`
+		P999              float64 `json:"p999_seconds"`
+		SuggestedTimeout  float64 `json:"suggested_timeout_seconds"`
+		ConfiguredCutoff  float64 `json:"configured_cutoff_fraction"`
+		Cutoffs           []struct {
+			TimeoutSeconds float64 `json:"timeout_seconds"`
+			Fraction       float64 `json:"fraction"`
+		} `json:"cutoffs"`
+	} `json:"routes"`
+}
+
+func TestAdminTimeoutReportEndpoint(t *testing.T) {
+	cfg := createTestConfig()
+	// The latency histograms are process-wide, so use a route no other test serves
+	cfg.Routes[0].ID = "timeout-report-route"
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetAdminRouter()
+
+	for i := 0; i < 2000; i++ {
+		services.RecordHTTPRequest(http.MethodGet, "/api/v1/*", "timeout-report-route", "200", 0.04, "")
+	}
+
+	w := adminRequest(router, http.MethodGet, "/admin/routes/timeout-report", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var report TimeoutReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(t, report.Routes, 1)
+
+	route := report.Routes[0]
+	assert.Equal(t, "timeout-report-route", route.RouteID)
+	assert.Equal(t, uint64(2000), route.Samples)
+	assert.Equal(t, cfg.Routes[0].Timeout.Seconds(), route.ConfiguredTimeout)
+	assert.InDelta(t, 0.04, route.P999, 0.01)
+	assert.Equal(t, 0.1, route.SuggestedTimeout)
+	assert.Zero(t, route.ConfiguredCutoff)
+	assert.NotEmpty(t, route.Cutoffs)
+
+	t.Run("is read-only", func(t *testing.T) {
+		w := adminRequest(router, http.MethodPost, "/admin/routes/timeout-report", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
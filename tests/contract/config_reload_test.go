@@ -0,0 +1,200 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+// reloadTestConfig is a config with one route to the backend at %[1]s; %[2]s adds routes
+const reloadTestConfig = `
+router:
+  port: 8080
+metrics:
+  enabled: false
+backends:
+  - id: test-backend
+    name: Test Backend
+    enabled: true
+    endpoints:
+      - url: %[1]s
+        weight: 1
+        healthy: true
+    load_balancer:
+      algorithm: round-robin
+routes:
+  - id: users
+    path: /users
+    method: [GET]
+    backend: test-backend
+    timeout: 5s
+    enabled: true
+%[2]s`
+
+// ordersRoute is the route added by the reload
+const ordersRoute = `
+  - id: orders
+    path: /orders
+    method: [GET]
+    backend: test-backend
+    timeout: 5s
+    enabled: true
+`
+
+func TestConfigReload_WatchedFile(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer backend.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(routes string) {
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadTestConfig, backend.URL, routes)), 0o644))
+	}
+	write("")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, srv.WatchConfig(ctx))
+	handler := srv.GetMainHandler()
+
+	status := func(path string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, status("/users"))
+	require.Equal(t, http.StatusNotFound, status("/orders"))
+
+	// A new route becomes reachable once the file is written
+	write(ordersRoute)
+	assert.Eventually(t, func() bool { return status("/orders") == http.StatusOK }, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, http.StatusOK, status("/users"))
+
+	// An invalid change is rejected and the running config kept
+	write(ordersRoute + "  - id: broken\n    path: /broken\n    method: [GET]\n    backend: missing-backend\n    enabled: true\n")
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, status("/orders"))
+	assert.Equal(t, http.StatusNotFound, status("/broken"))
+}
+
+// reloadAdmin enables the admin API with the key adminRequest sends
+const reloadAdmin = `
+admin:
+  enabled: true
+  api_key: valid-api-key
+`
+
+func TestConfigReload_AdminEndpoint(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer backend.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(routes string) {
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadTestConfig, backend.URL, routes+reloadAdmin)), 0o644))
+	}
+	write("")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := srv.GetMainHandler()
+	admin := srv.GetAdminRouter()
+
+	status := func(path string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	// The file is reloaded the same way as when it is watched
+	write(ordersRoute)
+	before := time.Now().Truncate(time.Second)
+	w := adminRequest(admin, http.MethodPost, "/admin/reload", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, status("/orders"))
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	reloadedAt, err := time.Parse(time.RFC3339, response["timestamp"])
+	require.NoError(t, err)
+	assert.False(t, reloadedAt.Before(before), "the timestamp should be the time of the reload")
+
+	w = adminRequest(admin, http.MethodGet, "/admin/routes/orders", "")
+	assert.Equal(t, http.StatusOK, w.Code, "admin handlers should see the reloaded config")
+
+	// An invalid file is rejected and the running config kept
+	write(ordersRoute + "  - id: broken\n    path: /broken\n    method: [GET]\n    backend: missing-backend\n    enabled: true\n")
+	w = adminRequest(admin, http.MethodPost, "/admin/reload", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid configuration")
+	assert.Equal(t, http.StatusOK, status("/orders"))
+	assert.Equal(t, http.StatusNotFound, status("/broken"))
+}
+
+func TestConfigReload_AdminRouteChanges(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer backend.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadTestConfig, backend.URL, reloadAdmin)), 0o644))
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := srv.GetMainHandler()
+	admin := srv.GetAdminRouter()
+
+	status := func(path string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	// A route created through the admin API is served without a reload
+	w := adminRequest(admin, http.MethodPost, "/admin/routes",
+		`{"id": "orders", "path": "/orders", "method": ["GET"], "backend": "test-backend", "timeout": 5000000000, "enabled": true}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, status("/orders"))
+
+	// A route that would make the config invalid is rejected
+	w = adminRequest(admin, http.MethodPost, "/admin/routes",
+		`{"id": "broken", "path": "/broken", "method": ["GET"], "backend": "missing-backend", "timeout": 5000000000, "enabled": true}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, status("/broken"))
+
+	// Deleting it stops serving it
+	w = adminRequest(admin, http.MethodDelete, "/admin/routes/orders?permanent=true", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusNotFound, status("/orders"))
+	assert.Equal(t, http.StatusOK, status("/users"))
+	assert.Len(t, cfg.Routes, 1, "the loaded config should not be changed in place")
+}
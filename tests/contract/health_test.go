@@ -132,6 +132,9 @@ func TestHealthEndpoint_Methods(t *testing.T) {
 			
 			assert.Equal(t, http.StatusMethodNotAllowed, w.Code,
 				"only GET method should be allowed for /health")
+			assert.Equal(t, "GET", w.Header().Get("Allow"))
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), `"code":"method_not_allowed"`)
 		})
 	}
 }
\ No newline at end of file
@@ -1,12 +1,16 @@
 package contract
 
 import (
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
 )
 
 func TestMetricsEndpoint_Contract(t *testing.T) {
@@ -114,6 +118,9 @@ func TestMetricsEndpoint_Methods(t *testing.T) {
 			
 			assert.Equal(t, http.StatusMethodNotAllowed, w.Code,
 				"only GET method should be allowed for /metrics")
+			assert.Equal(t, "GET", w.Header().Get("Allow"))
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), `"code":"method_not_allowed"`)
 		})
 	}
 }
@@ -132,4 +139,33 @@ func TestMetricsEndpoint_MetricLabels(t *testing.T) {
 	assert.Contains(t, body, `method="`, "metrics should include method label")
 	assert.Contains(t, body, `status="`, "metrics should include status label")
 	assert.Contains(t, body, `path="`, "metrics should include path label")
+	assert.Contains(t, body, `route="`, "metrics should include route label")
+}
+
+func TestMetricsEndpoint_RouteLabel(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	// Both routes match /api/v1/orders/1; the more specific one wins
+	orders := cfg.Routes[0]
+	orders.ID = "orders-route"
+	orders.Path = "/api/v1/orders/*"
+	cfg.Routes = append(cfg.Routes, orders)
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	for _, path := range []string{"/api/v1/orders/1", "/api/v1/users/1", "/unknown"} {
+		srv.GetRouter().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	srv.GetMetricsRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Contains(t, body, `http_requests_total{method="GET",path="/api/v1/orders/*",route="orders-route",status="200"}`)
+	assert.Contains(t, body, `http_requests_total{method="GET",path="/api/v1/*",route="test-route",status="200"}`)
+	assert.Contains(t, body, `http_requests_total{method="GET",path="unmatched",route="none",status="404"}`)
+	assert.NotContains(t, body, `path="/api/v1/orders/*",route="test-route"`)
 }
\ No newline at end of file
@@ -0,0 +1,108 @@
+package contract
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_CORS(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The router's CORS headers replace the backend's
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		io.WriteString(w, r.Method)
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Middleware.CORS = models.CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		ExposedHeaders: []string{"X-Request-Id"},
+		MaxAge:         600,
+	}
+
+	// The partner route allows another origin with credentials
+	partner := cfg.Routes[0]
+	partner.ID = "partner-route"
+	partner.Path = "/api/v1/partner/*"
+	partner.Method = []string{"GET"}
+	partner.CORS = &models.CORSConfig{
+		Enabled:          true,
+		AllowedOrigins:   []string{"https://partner.example.com"},
+		AllowCredentials: true,
+	}
+	// The internal route turns CORS off
+	internal := cfg.Routes[0]
+	internal.ID = "internal-route"
+	internal.Path = "/api/v1/internal/*"
+	internal.CORS = &models.CORSConfig{Enabled: false}
+	cfg.Routes = append(cfg.Routes, partner, internal)
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetRouter()
+
+	serve := func(method, path, origin, requestMethod string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", requestMethod)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("preflight for an allowed origin", func(t *testing.T) {
+		w := serve(http.MethodOptions, "/api/v1/users", "https://app.example.com", "POST")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight for another origin is forbidden", func(t *testing.T) {
+		w := serve(http.MethodOptions, "/api/v1/users", "https://evil.example.com", "POST")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("response headers replace the backend's", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/v1/users", "https://app.example.com", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("route config overrides the global one", func(t *testing.T) {
+		w := serve(http.MethodOptions, "/api/v1/partner/orders", "https://partner.example.com", "GET")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://partner.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+		w = serve(http.MethodOptions, "/api/v1/partner/orders", "https://app.example.com", "GET")
+		assert.Equal(t, http.StatusForbidden, w.Code, "the global origins don't apply to the route")
+	})
+
+	t.Run("route can turn CORS off", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/v1/internal/jobs", "https://app.example.com", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"), "the backend's header passes through")
+
+		w = serve(http.MethodOptions, "/api/v1/internal/jobs", "https://app.example.com", "GET")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
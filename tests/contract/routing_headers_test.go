@@ -0,0 +1,69 @@
+package contract
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_HeaderBased(t *testing.T) {
+	newBackend := func(name string) *httptest.Server {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name)
+		}))
+		t.Cleanup(backend.Close)
+		return backend
+	}
+	stableBackend := newBackend("stable")
+	canaryBackend := newBackend("canary")
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = stableBackend.URL
+	canaryService := cfg.Backends[0]
+	canaryService.ID = "canary-backend"
+	canaryService.Endpoints = []models.EndpointConfig{{URL: canaryBackend.URL, Weight: 100, Healthy: true}}
+	cfg.Backends = append(cfg.Backends, canaryService)
+
+	// The generic route comes first to check it doesn't shadow the header route
+	canaryRoute := cfg.Routes[0]
+	canaryRoute.ID = "canary-route"
+	canaryRoute.Headers = map[string]string{"X-Canary": "true"}
+	canaryRoute.Backend = "canary-backend"
+	cfg.Routes = append(cfg.Routes, canaryRoute)
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetRouter()
+
+	tests := []struct {
+		name     string
+		canary   string
+		expected string
+	}{
+		{name: "request with canary header goes to canary backend", canary: "true", expected: "canary"},
+		{name: "request without canary header goes to stable backend", expected: "stable"},
+		{name: "non-matching header value goes to stable backend", canary: "false", expected: "stable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if tt.canary != "" {
+				req.Header.Set("X-Canary", tt.canary)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
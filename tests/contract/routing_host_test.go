@@ -0,0 +1,67 @@
+package contract
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_HostBased(t *testing.T) {
+	newBackend := func(name string) *httptest.Server {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name)
+		}))
+		t.Cleanup(backend.Close)
+		return backend
+	}
+	apiBackend := newBackend("api")
+	adminBackend := newBackend("admin")
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = apiBackend.URL
+	adminService := cfg.Backends[0]
+	adminService.ID = "admin-backend"
+	adminService.Endpoints = []models.EndpointConfig{{URL: adminBackend.URL, Weight: 100, Healthy: true}}
+	cfg.Backends = append(cfg.Backends, adminService)
+
+	// The match-any route comes first to check it doesn't shadow the host route
+	adminRoute := cfg.Routes[0]
+	adminRoute.ID = "admin-route"
+	adminRoute.Host = "*.admin.example.com"
+	adminRoute.Backend = "admin-backend"
+	cfg.Routes = append(cfg.Routes, adminRoute)
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetRouter()
+
+	tests := []struct {
+		name     string
+		host     string
+		expected string
+	}{
+		{name: "wildcard host goes to admin backend", host: "eu.admin.example.com", expected: "admin"},
+		{name: "host match ignores case and port", host: "EU.Admin.Example.com:8080", expected: "admin"},
+		{name: "other hosts go to the match-any route", host: "api.example.com", expected: "api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
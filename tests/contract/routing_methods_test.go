@@ -0,0 +1,59 @@
+package contract
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_AllowedMethods(t *testing.T) {
+	var proxied atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		io.WriteString(w, r.Method)
+	}))
+	defer backend.Close()
+
+	newHandler := func(t *testing.T, allowed []string) http.Handler {
+		cfg := createTestConfig()
+		cfg.Backends[0].Endpoints[0].URL = backend.URL
+		cfg.Routes[0].Method = append(cfg.Routes[0].Method, "TRACE")
+		cfg.Router.AllowedMethods = allowed
+		require.NoError(t, cfg.Validate())
+		srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+		return srv.GetMainHandler()
+	}
+	send := func(handler http.Handler, method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/users", nil))
+		return w
+	}
+
+	t.Run("TRACE is rejected globally even on a route allowing it", func(t *testing.T) {
+		handler := newHandler(t, []string{"GET", "HEAD", "POST"})
+		before := proxied.Load()
+
+		w := send(handler, http.MethodTrace)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD, POST", w.Header().Get("Allow"))
+		assert.Equal(t, before, proxied.Load(), "the request should not reach the backend")
+
+		w = send(handler, http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "GET", w.Body.String())
+	})
+
+	t.Run("all methods are allowed by default", func(t *testing.T) {
+		w := send(newHandler(t, nil), http.MethodTrace)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "TRACE", w.Body.String())
+	})
+}
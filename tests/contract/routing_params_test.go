@@ -0,0 +1,45 @@
+package contract
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_PathParams(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Routes[0].Path = "/api/v1/users/{id}/orders/{order_id}"
+
+	var logs bytes.Buffer
+	srv, err := server.New(cfg, slog.New(slog.NewJSONHandler(&logs, nil)))
+	require.NoError(t, err)
+	router := srv.GetRouter()
+
+	t.Run("parameters are extracted for middleware", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/42/orders/7", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/api/v1/users/42/orders/7", w.Body.String())
+		assert.Contains(t, logs.String(), `"path_params":{"id":"42","order_id":"7"}`)
+	})
+
+	t.Run("each parameter matches one segment", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/42/extra/orders/7", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,60 @@
+package contract
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_PathNormalization(t *testing.T) {
+	var proxied atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		io.WriteString(w, r.URL.EscapedPath())
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	require.NoError(t, cfg.Validate())
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := srv.GetMainHandler()
+
+	send := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("double slashes and dot segments are resolved before matching", func(t *testing.T) {
+		for _, target := range []string{"/api//v1/../v1/users", "/api/./v1//users", "//api/v1/%2e/users"} {
+			w := send(target)
+			require.Equal(t, http.StatusOK, w.Code, target)
+			assert.Equal(t, "/api/v1/users", w.Body.String(), "the backend should get the cleaned path for %s", target)
+		}
+	})
+
+	t.Run("a path leaving the route's prefix no longer matches it", func(t *testing.T) {
+		proxied.Store(0)
+		w := send("/api/v1/../../admin/config")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Zero(t, proxied.Load())
+	})
+
+	t.Run("traversal above the root is rejected", func(t *testing.T) {
+		proxied.Store(0)
+		for _, target := range []string{"/api/v1/../../../etc/passwd", "/api/v1/%2e%2e/%2e%2e/%2e%2e/etc/passwd", "/api/v1/..%2F..%2F..%2Fetc/passwd"} {
+			w := send(target)
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		}
+		assert.Zero(t, proxied.Load())
+	})
+}
@@ -0,0 +1,105 @@
+package contract
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_Priority(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Backends = nil
+	cfg.Routes = nil
+
+	// addRoute adds a route to its own backend, which answers with the route ID
+	addRoute := func(id, path string, priority int) {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, id)
+		}))
+		t.Cleanup(backend.Close)
+
+		service := createTestConfig().Backends[0]
+		service.ID = id + "-backend"
+		service.Endpoints = []models.EndpointConfig{{URL: backend.URL, Weight: 100, Healthy: true}}
+		cfg.Backends = append(cfg.Backends, service)
+
+		route := createTestConfig().Routes[0]
+		route.ID = id
+		route.Path = path
+		route.Method = []string{"GET"}
+		route.Backend = service.ID
+		route.Priority = priority
+		cfg.Routes = append(cfg.Routes, route)
+	}
+
+	// Broad routes come first to check config order doesn't decide
+	addRoute("catch-all", "/*", 10)
+	addRoute("api", "/api/*", 100)
+	addRoute("admin", "/api/v1/admin/*", 200)
+	addRoute("v1", "/api/v1/*", 100)
+	addRoute("user", "/api/v1/users/{id}", 100)
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetRouter()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "higher priority wins over a broader route", path: "/api/v1/admin/users", expected: "admin"},
+		{name: "equal priority prefers the longer literal prefix", path: "/api/v1/orders", expected: "v1"},
+		{name: "path parameters count only their literal prefix", path: "/api/v1/users/42", expected: "user"},
+		{name: "broad route still serves what nothing else matches", path: "/api/v2/orders", expected: "api"},
+		{name: "lowest priority catches the rest", path: "/static/app.js", expected: "catch-all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+
+	t.Run("health endpoint is not shadowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Contains(t, w.Body.String(), `"status"`)
+	})
+}
+
+func TestRouting_UnmatchedRequests(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("unknown path returns 404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("wrong method returns 405 with Allow", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/v1/users", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, POST, PUT, DELETE", w.Header().Get("Allow"))
+
+		var body models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "method_not_allowed", body.Code)
+		assert.Equal(t, []interface{}{"GET", "POST", "PUT", "DELETE"}, body.Details["allowed_methods"])
+	})
+}
@@ -0,0 +1,157 @@
+package contract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_CorrelationID(t *testing.T) {
+	var mutex sync.Mutex
+	var proxied http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		proxied = r.Header.Clone()
+		mutex.Unlock()
+	}))
+	defer backend.Close()
+
+	// serve proxies a request and returns the response and the headers the backend saw
+	serve := func(t *testing.T, configure func(cfg *config.Config), header http.Header) (*httptest.ResponseRecorder, http.Header) {
+		cfg := createTestConfig()
+		cfg.Backends[0].Endpoints[0].URL = backend.URL
+		cfg.Router.CorrelationIDHeaders = []string{"X-Correlation-ID"}
+		if configure != nil {
+			configure(cfg)
+		}
+
+		srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		srv.GetRouter().ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		return w, proxied
+	}
+
+	t.Run("a generated ID is shared by every header", func(t *testing.T) {
+		w, upstream := serve(t, func(cfg *config.Config) { cfg.Router.RequestIDPrefix = "tyo1-" }, nil)
+
+		id := w.Header().Get("X-Request-ID")
+		assert.True(t, strings.HasPrefix(id, "tyo1-"), id)
+		assert.Equal(t, id, w.Header().Get("X-Correlation-ID"))
+		assert.Equal(t, id, upstream.Get("X-Request-ID"))
+		assert.Equal(t, id, upstream.Get("X-Correlation-ID"))
+	})
+
+	t.Run("an inbound correlation ID is used for the request ID", func(t *testing.T) {
+		w, upstream := serve(t, nil, http.Header{"X-Correlation-Id": {"corr-123"}})
+
+		assert.Equal(t, "corr-123", w.Header().Get("X-Request-ID"))
+		assert.Equal(t, "corr-123", w.Header().Get("X-Correlation-ID"))
+		assert.Equal(t, "corr-123", upstream.Get("X-Request-ID"))
+		assert.Equal(t, "corr-123", upstream.Get("X-Correlation-ID"))
+	})
+
+	t.Run("inbound IDs are replaced when not trusted", func(t *testing.T) {
+		w, upstream := serve(t, func(cfg *config.Config) { cfg.Router.IgnoreInboundRequestID = true },
+			http.Header{"X-Request-Id": {"client-chosen"}})
+
+		id := w.Header().Get("X-Request-ID")
+		assert.NotEqual(t, "client-chosen", id)
+		assert.Equal(t, id, upstream.Get("X-Request-ID"))
+		assert.Equal(t, id, upstream.Get("X-Correlation-ID"))
+	})
+
+	t.Run("correlation headers survive a forward allowlist", func(t *testing.T) {
+		w, upstream := serve(t, func(cfg *config.Config) {
+			cfg.Routes[0].ForwardHeaders = &models.ForwardHeadersConfig{Allow: []string{"Accept"}}
+		}, nil)
+
+		id := w.Header().Get("X-Request-ID")
+		assert.Equal(t, id, upstream.Get("X-Request-ID"))
+		assert.Equal(t, id, upstream.Get("X-Correlation-ID"))
+	})
+
+	t.Run("the ID is on the request's log lines", func(t *testing.T) {
+		cfg := createTestConfig()
+		cfg.Backends[0].Endpoints[0].URL = backend.URL
+		cfg.Router.CorrelationIDHeaders = []string{"X-Correlation-ID"}
+
+		var logs bytes.Buffer
+		srv, err := server.New(cfg, slog.New(slog.NewJSONHandler(&logs, nil)))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("X-Correlation-ID", "corr-logged")
+		w := httptest.NewRecorder()
+		srv.GetRouter().ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		mutex.Lock()
+		assert.Equal(t, "corr-logged", proxied.Get("X-Correlation-ID"))
+		mutex.Unlock()
+		assert.Equal(t, "corr-logged", w.Header().Get("X-Correlation-ID"))
+
+		var logged bool
+		scanner := bufio.NewScanner(&logs)
+		for scanner.Scan() {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			if line["msg"] == "HTTP Request" {
+				logged = true
+				assert.Equal(t, "corr-logged", line["request_id"])
+			}
+		}
+		assert.True(t, logged, "request log line missing")
+	})
+}
+
+func TestRouting_CorrelationIDOnCachedResponse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "users")
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Router.CorrelationIDHeaders = []string{"X-Correlation-ID"}
+	cfg.Routes[0].Cache = &models.CacheConfig{Enabled: true}
+	require.NoError(t, cfg.Validate())
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := srv.GetMainHandler()
+
+	for _, cached := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, cached, w.Header().Get("X-Cache"))
+
+		// A hit carries the caller's own ID, not the one of the request that filled the cache
+		id := w.Header().Get("X-Request-ID")
+		assert.NotEmpty(t, id)
+		assert.Equal(t, id, w.Header().Get("X-Correlation-ID"), cached)
+	}
+}
@@ -0,0 +1,95 @@
+package contract
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+func TestRouting_APIVersion(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Backends = nil
+	cfg.Routes = nil
+
+	// addRoute adds a route to its own backend, which answers with the route ID and upstream path
+	addRoute := func(route models.RouteConfig) {
+		id := route.ID
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, id+" "+r.URL.Path)
+		}))
+		t.Cleanup(backend.Close)
+
+		service := createTestConfig().Backends[0]
+		service.ID = id + "-backend"
+		service.Endpoints = []models.EndpointConfig{{URL: backend.URL, Weight: 100, Healthy: true}}
+		cfg.Backends = append(cfg.Backends, service)
+
+		base := createTestConfig().Routes[0]
+		route.Method = []string{"GET"}
+		route.Backend = service.ID
+		route.Timeout = base.Timeout
+		route.Enabled = true
+		cfg.Routes = append(cfg.Routes, route)
+	}
+
+	// One route serves v1 and v2 by path and normalizes the upstream path
+	addRoute(models.RouteConfig{
+		ID:             "users-versioned",
+		Path:           "/api/*/users",
+		Version:        &models.VersionConfig{Versions: []string{"1", "2"}, Segment: 2},
+		RewritePattern: `^/api/[^/]+/users$`,
+		Rewrite:        "/users/v{version}",
+	})
+	addRoute(models.RouteConfig{ID: "users-default", Path: "/api/*/users"})
+
+	// Another is picked by header and fills {version} in its upstream template
+	addRoute(models.RouteConfig{
+		ID:           "orders-versioned",
+		Path:         "/orders/{id}",
+		Version:      &models.VersionConfig{Versions: []string{"2"}, Header: "X-API-Version"},
+		UpstreamPath: "/v{version}/orders/{id}",
+	})
+	addRoute(models.RouteConfig{ID: "orders-default", Path: "/orders/{id}"})
+	require.NoError(t, cfg.Validate())
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	router := srv.GetRouter()
+
+	tests := []struct {
+		name     string
+		path     string
+		version  string
+		expected string
+	}{
+		{name: "path version", path: "/api/v1/users", expected: "users-versioned /users/v1"},
+		{name: "another path version", path: "/api/v2/users", expected: "users-versioned /users/v2"},
+		{name: "unknown path version falls through", path: "/api/v3/users", expected: "users-default /api/v3/users"},
+		{name: "header version", path: "/orders/7", version: "2", expected: "orders-versioned /v2/orders/7"},
+		{name: "header version with v prefix", path: "/orders/7", version: "v2", expected: "orders-versioned /v2/orders/7"},
+		{name: "unknown header version falls through", path: "/orders/7", version: "1", expected: "orders-default /orders/7"},
+		{name: "missing header falls through", path: "/orders/7", expected: "orders-default /orders/7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.version != "" {
+				req.Header.Set("X-API-Version", tt.version)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+// freePort returns a port that was free a moment ago
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestShutdown_HealthChecksOutlastDraining(t *testing.T) {
+	var healthChecks atomic.Int64
+	slowStarted := make(chan struct{})
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			healthChecks.Add(1)
+			return
+		}
+		close(slowStarted)
+		<-release
+		io.WriteString(w, "done")
+	}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Router.Port = freePort(t)
+	cfg.Admin.Enabled = false
+	cfg.Metrics.Enabled = false
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Backends[0].HealthCheck.Type = "http"
+	cfg.Backends[0].HealthCheck.ExpectedStatus = []int{http.StatusOK}
+	cfg.Backends[0].HealthCheck.Interval = 20 * time.Millisecond
+	cfg.Backends[0].HealthCheck.Timeout = 10 * time.Millisecond
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Cancelling the start context, as a signal handler would, doesn't stop health checks
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		srv.Start(ctx)
+		close(started)
+	}()
+
+	base := fmt.Sprintf("http://127.0.0.1:%d", cfg.Router.Port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(base + "/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond, "main server should start and report healthy")
+
+	// A request that is still in flight when shutdown begins
+	inFlight := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/api/v1/slow")
+		if err != nil {
+			inFlight <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		inFlight <- string(body)
+	}()
+	<-slowStarted
+
+	cancel()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- srv.Shutdown(context.Background())
+	}()
+
+	t.Run("the health endpoint fails once draining", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			w := httptest.NewRecorder()
+			srv.GetMainHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+			return w.Code == http.StatusServiceUnavailable
+		}, time.Second, 5*time.Millisecond)
+
+		w := httptest.NewRecorder()
+		srv.GetMainHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var health map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+		assert.Equal(t, "draining", health["status"])
+	})
+
+	t.Run("health checks continue while requests drain", func(t *testing.T) {
+		before := healthChecks.Load()
+		assert.Eventually(t, func() bool {
+			return healthChecks.Load() >= before+3
+		}, 2*time.Second, 10*time.Millisecond)
+
+		select {
+		case err := <-shutdownDone:
+			t.Fatalf("shutdown finished before the request drained: %v", err)
+		default:
+		}
+	})
+
+	close(release)
+	assert.Equal(t, "done", <-inFlight, "the in-flight request should complete")
+	require.NoError(t, <-shutdownDone)
+
+	t.Run("health checks stop after draining", func(t *testing.T) {
+		// Allow a check that was already under way to finish
+		time.Sleep(50 * time.Millisecond)
+		after := healthChecks.Load()
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, after, healthChecks.Load())
+	})
+}
+
+func TestShutdown_RateLimitStateSurvivesRestart(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	cfg := createTestConfig()
+	cfg.Admin.Enabled = false
+	cfg.Metrics.Enabled = false
+	cfg.Backends[0].Endpoints[0].URL = backend.URL
+	cfg.Backends[0].HealthCheck.Enabled = false
+	cfg.Routes[0].RateLimit = &models.RateLimitConfig{Enabled: true, Rate: 2, Period: "minute", KeyType: "IP"}
+	require.NoError(t, cfg.Routes[0].RateLimit.Validate())
+	cfg.Middleware.RateLimit.StateFile = filepath.Join(t.TempDir(), "ratelimit.json")
+	cfg.Middleware.RateLimit.StateMaxAge = time.Hour
+
+	statuses := func(srv *server.Server, n int) []int {
+		var codes []int
+		for i := 0; i < n; i++ {
+			w := httptest.NewRecorder()
+			srv.GetMainHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+			codes = append(codes, w.Code)
+		}
+		return codes
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	first, err := server.New(cfg, logger)
+	require.NoError(t, err)
+	require.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, statuses(first, 3))
+	require.NoError(t, first.Shutdown(context.Background()))
+	require.FileExists(t, cfg.Middleware.RateLimit.StateFile)
+
+	// The restarted router keeps the client's empty bucket instead of granting a new burst
+	second, err := server.New(cfg, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []int{http.StatusTooManyRequests}, statuses(second, 1))
+	assert.NoFileExists(t, cfg.Middleware.RateLimit.StateFile, "the state should only be restored once")
+}
+
+func TestShutdown_CorruptRateLimitStateIsIgnored(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Admin.Enabled = false
+	cfg.Metrics.Enabled = false
+	cfg.Middleware.RateLimit.StateFile = filepath.Join(t.TempDir(), "ratelimit.json")
+	cfg.Middleware.RateLimit.StateMaxAge = time.Hour
+	require.NoError(t, os.WriteFile(cfg.Middleware.RateLimit.StateFile, []byte("not json"), 0o600))
+
+	var logs bytes.Buffer
+	_, err := server.New(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "Ignoring rate limit state")
+}
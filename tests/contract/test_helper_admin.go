@@ -1,11 +1,18 @@
 package contract
 
 import (
+	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"testing"
 
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
 	"github.com/your-org/ryohi-router/src/server"
+	"github.com/your-org/ryohi-router/src/services"
 )
 
 // setupTestAdminRouter creates a test admin router for contract tests
@@ -44,6 +51,32 @@ func setupTestMetricsRouter() http.Handler {
 		panic(err)
 	}
 	
+	// Labeled series are only exported once observed, so record some live values
+	services.RecordHTTPRequest("GET", "/api/v1/*", "test-route", "200", 0.05, "")
+	services.SetBackendHealth("test-backend", "http://localhost:3000", true)
+	
 	// Return the metrics router handler
 	return srv.GetMetricsRouter()
-}
\ No newline at end of file
+}
+
+// setupTestAdminServer creates a test admin router and returns the config it serves
+func setupTestAdminServer(t *testing.T) (http.Handler, *config.Config) {
+	cfg := createTestConfig()
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return srv.GetAdminRouter(), cfg
+}
+
+// adminRequest sends an authenticated admin request
+func adminRequest(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("X-API-Key", "valid-api-key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
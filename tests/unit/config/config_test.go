@@ -0,0 +1,407 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+func TestConfig_InsecureTLSRequiresAllowFlag(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			{
+				ID:   "backend",
+				Name: "backend",
+				Endpoints: []models.EndpointConfig{
+					{URL: "https://localhost:8443", Weight: 1},
+				},
+				TLS: &models.TLSConfig{InsecureSkipVerify: true},
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "allow_insecure_tls")
+
+	cfg.Router.AllowInsecureTLS = true
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_RateLimitStore(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}}
+	assert.NoError(t, cfg.Validate(), "the in-memory store is the default")
+
+	cfg.Middleware.RateLimit.Store = "redis"
+	assert.ErrorContains(t, cfg.Validate(), "redis address")
+
+	cfg.Middleware.RateLimit.Redis.Address = "localhost:6379"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Middleware.RateLimit.Store = "memcached"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestConfig_RateLimitStateFile(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}}
+	cfg.Middleware.RateLimit.StateFile = "/var/lib/ryohi/ratelimit.json"
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, time.Hour, cfg.Middleware.RateLimit.StateMaxAge, "the default cutoff should be filled")
+
+	cfg.Middleware.RateLimit.StateMaxAge = -time.Minute
+	assert.ErrorContains(t, cfg.Validate(), "state_max_age")
+
+	cfg.Middleware.RateLimit.StateMaxAge = time.Hour
+	cfg.Middleware.RateLimit.Store = "redis"
+	cfg.Middleware.RateLimit.Redis.Address = "localhost:6379"
+	assert.ErrorContains(t, cfg.Validate(), "memory store")
+}
+
+func TestConfig_RedisStoreRejectsSlidingWindow(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			{
+				ID:        "backend",
+				Name:      "backend",
+				Endpoints: []models.EndpointConfig{{URL: "http://localhost:9000", Weight: 1}},
+			},
+		},
+		Routes: []models.RouteConfig{
+			{
+				ID:        "route",
+				Path:      "/api/*",
+				Method:    []string{"GET"},
+				Backend:   "backend",
+				RateLimit: &models.RateLimitConfig{Enabled: true, Rate: 10, Period: "minute", Strategy: models.StrategySlidingWindow},
+			},
+		},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Middleware.RateLimit.Store = "redis"
+	cfg.Middleware.RateLimit.Redis.Address = "localhost:6379"
+	assert.ErrorContains(t, cfg.Validate(), "token_bucket")
+}
+
+func TestConfig_VersionSourcesMustAgree(t *testing.T) {
+	route := func(id, path string, version *models.VersionConfig) models.RouteConfig {
+		return models.RouteConfig{ID: id, Path: path, Method: []string{"GET"}, Backend: "backend", Version: version}
+	}
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			{
+				ID:        "backend",
+				Name:      "backend",
+				Endpoints: []models.EndpointConfig{{URL: "http://localhost:9000", Weight: 1}},
+			},
+		},
+		Routes: []models.RouteConfig{
+			route("v1", "/api/*", &models.VersionConfig{Versions: []string{"1"}, Segment: 2}),
+			route("v2", "/api/*", &models.VersionConfig{Versions: []string{"2"}, Segment: 2}),
+			route("default", "/api/*", nil),
+			route("other", "/other/*", &models.VersionConfig{Versions: []string{"2"}, Header: "X-API-Version"}),
+		},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Routes[1].Version = &models.VersionConfig{Versions: []string{"2"}, Header: "X-API-Version"}
+	assert.ErrorContains(t, cfg.Validate(), "same prefix")
+}
+
+func TestConfig_AuthPolicies(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			{
+				ID:        "backend",
+				Name:      "backend",
+				Endpoints: []models.EndpointConfig{{URL: "http://localhost:9000", Weight: 1}},
+			},
+		},
+		Routes: []models.RouteConfig{
+			{ID: "route", Path: "/api/*", Method: []string{"GET"}, Backend: "backend", AuthPolicy: "tenant-a"},
+		},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "non-existent auth policy")
+
+	cfg.AuthPolicies = []models.AuthPolicy{
+		{ID: "tenant-a", Issuer: "https://tenant-a", JWKSURL: "https://tenant-a/jwks.json"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "roles", cfg.AuthPolicies[0].RolesClaim, "defaults should be filled")
+
+	cfg.AuthPolicies = append(cfg.AuthPolicies, cfg.AuthPolicies[0])
+	assert.ErrorContains(t, cfg.Validate(), "duplicate")
+
+	cfg.AuthPolicies = []models.AuthPolicy{{ID: "tenant-a", Issuer: "https://tenant-a", JWKSURL: "ftp://tenant-a/jwks"}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestConfig_MemoryWatchdog(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}}
+	cfg.Memory.Enabled = true
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, 0.9, cfg.Memory.HighWater, "defaults should be filled")
+	assert.Equal(t, 0.8, cfg.Memory.LowWater)
+
+	cfg.Memory.LowWater = 0.95
+	assert.ErrorContains(t, cfg.Validate(), "low_water < high_water")
+
+	cfg.Memory.LowWater = 0.5
+	cfg.Memory.HighWater = 1.2
+	assert.Error(t, cfg.Validate())
+}
+
+func TestConfig_RequestIDFormat(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080, RequestIDPrefix: "tyo1-", RequestIDFormat: "hex"}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Router.RequestIDFormat = "ulid"
+	assert.ErrorContains(t, cfg.Validate(), "request_id_format")
+
+	cfg.Router.RequestIDFormat = "uuid"
+	cfg.Router.RequestIDPrefix = "tyo 1"
+	assert.ErrorContains(t, cfg.Validate(), "request_id_prefix")
+
+	cfg.Router.RequestIDPrefix = ""
+	cfg.Router.CorrelationIDHeaders = []string{"X-Correlation-ID"}
+	assert.NoError(t, cfg.Validate())
+	cfg.Router.CorrelationIDHeaders = []string{"X Correlation"}
+	assert.ErrorContains(t, cfg.Validate(), "correlation_id_headers")
+}
+
+func TestConfig_AllowedMethods(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080, AllowedMethods: []string{"GET", "HEAD", "POST"}}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Router.AllowedMethods = []string{"GET", "BREW COFFEE"}
+	assert.ErrorContains(t, cfg.Validate(), "allowed_methods")
+}
+
+func TestConfig_ZoneMinHealthy(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080, Zone: "tokyo-a", ZoneMinHealthy: 2}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Router.ZoneMinHealthy = -1
+	assert.ErrorContains(t, cfg.Validate(), "zone_min_healthy")
+}
+
+func TestConfig_OverrideTokens(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080, OverrideTokens: []string{"override-token"}},
+		Admin:  config.AdminConfig{Enabled: true, APIKey: "admin-key", Port: 8081},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Router.OverrideTokens = []string{"override-token", "admin-key"}
+	assert.ErrorContains(t, cfg.Validate(), "override_tokens", "the admin key must not be accepted on the data plane")
+
+	cfg.Router.OverrideTokens = []string{""}
+	assert.ErrorContains(t, cfg.Validate(), "override_tokens")
+}
+
+func TestConfig_DrainTimeout(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080, DrainTimeout: 5 * time.Second}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Router.DrainTimeout = -time.Second
+	assert.ErrorContains(t, cfg.Validate(), "drain_timeout")
+}
+
+func TestConfig_MaxBodyBytesCeiling(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080, MaxBodyBytes: 1 << 20, MaxBodyBytesCeiling: 8 << 20},
+		Backends: []models.BackendService{
+			{
+				ID:        "backend",
+				Name:      "backend",
+				Endpoints: []models.EndpointConfig{{URL: "http://localhost:9000", Weight: 1}},
+			},
+		},
+		Routes: []models.RouteConfig{
+			{ID: "uploads", Path: "/uploads/*", Method: []string{"POST"}, Backend: "backend", MaxBodyBytes: 8 << 20},
+		},
+	}
+	assert.NoError(t, cfg.Validate(), "a route may allow up to the ceiling")
+
+	cfg.Routes[0].MaxBodyBytes = 8<<20 + 1
+	assert.ErrorContains(t, cfg.Validate(), "route uploads max_body_bytes")
+
+	cfg.Routes[0].MaxBodyBytes = -1
+	assert.ErrorContains(t, cfg.Validate(), "max_body_bytes cannot be negative")
+
+	cfg.Routes[0].MaxBodyBytes = 0
+	cfg.Router.MaxBodyBytes = 16 << 20
+	assert.ErrorContains(t, cfg.Validate(), "router max_body_bytes")
+}
+
+func TestConfig_Profiling(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}}
+	assert.NoError(t, cfg.Validate(), "profiling is off without a destination")
+	assert.Zero(t, cfg.Profiling.Interval, "defaults only apply when profiling is configured")
+
+	cfg.Profiling.Enabled = true
+	assert.ErrorContains(t, cfg.Validate(), "requires a directory or push_url")
+
+	cfg.Profiling.Directory = "/var/lib/ryohi/profiles"
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, time.Minute, cfg.Profiling.Interval)
+	assert.Equal(t, 10*time.Second, cfg.Profiling.CPUDuration)
+	assert.Equal(t, 15*time.Minute, cfg.Profiling.DefaultDuration)
+	assert.Equal(t, "ryohi-router", cfg.Profiling.AppName)
+
+	cfg.Profiling.CPUDuration = time.Minute
+	assert.ErrorContains(t, cfg.Validate(), "must be shorter than interval")
+
+	cfg.Profiling.CPUDuration = 10 * time.Second
+	cfg.Profiling.PushURL = "pyroscope:4040"
+	assert.ErrorContains(t, cfg.Validate(), "invalid profiling push_url")
+
+	cfg.Profiling.PushURL = "http://pyroscope:4040"
+	cfg.Profiling.MaxFiles = -1
+	assert.ErrorContains(t, cfg.Validate(), "cannot be negative")
+}
+
+func TestConfig_AdminReadOnly(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Admin:  config.AdminConfig{Enabled: true, APIKey: "admin-key", Port: 8081, ReadOnly: true},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "unlock_key is required")
+
+	cfg.Admin.UnlockKey = "admin-key"
+	assert.ErrorContains(t, cfg.Validate(), "must differ")
+
+	cfg.Admin.UnlockKey = "unlock-key"
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, 15*time.Minute, cfg.Admin.UnlockWindow, "the window should default")
+
+	cfg.Admin.UnlockWindow = 48 * time.Hour
+	assert.ErrorContains(t, cfg.Validate(), "unlock_window")
+}
+
+func TestConfig_FlagRollouts(t *testing.T) {
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			{
+				ID:        "backend",
+				Name:      "backend",
+				Endpoints: []models.EndpointConfig{{URL: "http://localhost:9000", Weight: 1}},
+			},
+		},
+		Routes: []models.RouteConfig{
+			{ID: "route", Path: "/api/*", Method: []string{"GET"}, Backend: "backend"},
+		},
+	}
+
+	cfg.FlagRollouts = map[string]models.FlagRollout{
+		"new_compression": {Percent: 5, Routes: []string{"route"}, Backends: []string{"backend"}, AllowIPs: []string{"10.0.0.0/8", "192.0.2.1"}},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.FlagRollouts = map[string]models.FlagRollout{"new_compression": {Percent: 101}}
+	assert.ErrorContains(t, cfg.Validate(), "between 0 and 100")
+
+	cfg.FlagRollouts = map[string]models.FlagRollout{"new_compression": {Percent: 5, Routes: []string{"missing"}}}
+	assert.ErrorContains(t, cfg.Validate(), "non-existent route")
+
+	cfg.FlagRollouts = map[string]models.FlagRollout{"new_compression": {Percent: 5, Backends: []string{"missing"}}}
+	assert.ErrorContains(t, cfg.Validate(), "non-existent backend")
+
+	cfg.FlagRollouts = map[string]models.FlagRollout{"new_compression": {Percent: 5, AllowIPs: []string{"10.0.0.0/33"}}}
+	assert.ErrorContains(t, cfg.Validate(), "invalid allowed IP")
+}
+
+func TestConfig_Tracing(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}}
+	cfg.Tracing = config.TracingConfig{Enabled: true, OTLPEndpoint: "http://otel-collector:4318"}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "ryohi-router", cfg.Tracing.ServiceName, "the service name should default")
+
+	cfg.Tracing.OTLPEndpoint = ""
+	assert.ErrorContains(t, cfg.Validate(), "otlp_endpoint")
+	cfg.Tracing.OTLPEndpoint = "otel-collector:4317"
+	assert.ErrorContains(t, cfg.Validate(), "otlp_endpoint")
+
+	cfg.Tracing.Enabled = false
+	assert.NoError(t, cfg.Validate(), "the endpoint only matters when tracing is enabled")
+}
+
+func TestConfig_Orphans(t *testing.T) {
+	backend := func(id string, enabled bool) models.BackendService {
+		return models.BackendService{
+			ID:        id,
+			Name:      id,
+			Enabled:   enabled,
+			Endpoints: []models.EndpointConfig{{URL: "http://localhost:9000", Weight: 1}},
+		}
+	}
+	policy := func(id string) models.AuthPolicy {
+		return models.AuthPolicy{ID: id, Issuer: "https://" + id, JWKSURL: "https://" + id + "/jwks.json"}
+	}
+
+	// Overridable backends are reached without a route
+	staging := backend("orders-staging", true)
+	staging.Overridable = true
+
+	cfg := &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			backend("orders", true),
+			backend("legacy", true),
+			backend("orders-next", false),
+			staging,
+		},
+		AuthPolicies: []models.AuthPolicy{policy("tenant-a"), policy("tenant-b")},
+		Routes: []models.RouteConfig{
+			{ID: "orders", Path: "/orders/*", Method: []string{"GET"}, Backend: "orders", CanaryBackend: "orders-next", AuthPolicy: "tenant-a"},
+		},
+	}
+	assert.NoError(t, cfg.Validate(), "orphans are only warnings by default")
+
+	orphans := cfg.Orphans()
+	assert.Equal(t, []config.Orphan{
+		{Kind: config.OrphanUnusedBackend, ID: "legacy", Section: "backends[1]", Message: "backend legacy is not used by any route"},
+		{Kind: config.OrphanUnusedAuthPolicy, ID: "tenant-b", Section: "auth_policies[1]", Message: "auth policy tenant-b is not used by any route"},
+		{Kind: config.OrphanRouteDisabledBackend, ID: "orders", Section: "routes[0]", Message: "route orders uses disabled backend orders-next"},
+	}, orphans)
+
+	refs := cfg.References()
+	require.Len(t, refs.BackendRoutes("orders-next"), 1, "canaries count as references")
+	assert.Empty(t, refs.BackendRoutes("legacy"))
+
+	cfg.Strict = true
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, orphan := range orphans {
+		assert.ErrorContains(t, err, orphan.Message)
+	}
+
+	cfg.Backends = cfg.Backends[:1]
+	cfg.AuthPolicies = cfg.AuthPolicies[:1]
+	cfg.Routes[0].CanaryBackend = ""
+	assert.NoError(t, cfg.Validate(), "a configuration without orphans passes strict mode")
+	assert.Empty(t, cfg.Orphans())
+}
+
+func TestConfig_LoggingOutput(t *testing.T) {
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}}
+	cfg.Logging = config.LoggingConfig{Output: "file", FilePath: "logs/router.log", MaxSizeMB: 100, MaxBackups: 7}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Logging.FilePath = ""
+	assert.ErrorContains(t, cfg.Validate(), "file_path")
+
+	cfg.Logging = config.LoggingConfig{Output: "syslog"}
+	assert.ErrorContains(t, cfg.Validate(), "logging output")
+
+	cfg.Logging = config.LoggingConfig{Output: "stdout", MaxBackups: -1}
+	assert.Error(t, cfg.Validate())
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+func diffTestConfig() *config.Config {
+	return &config.Config{
+		Router: config.RouterConfig{Port: 8080},
+		Backends: []models.BackendService{
+			{ID: "users", Name: "users"},
+			{ID: "orders", Name: "orders"},
+		},
+		Routes: []models.RouteConfig{
+			{ID: "users-route", Path: "/users", Backend: "users", Timeout: 30 * time.Second},
+			{ID: "orders-route", Path: "/orders", Backend: "orders", Timeout: 30 * time.Second},
+		},
+		FeatureFlags: map[string]bool{"canary_routing": true},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := diffTestConfig()
+	assert.Empty(t, config.Diff(from, diffTestConfig()), "identical configs should have no changes")
+
+	to := diffTestConfig()
+	to.Routes[0], to.Routes[1] = to.Routes[1], to.Routes[0]
+	assert.Empty(t, config.Diff(from, to), "reordering routes is not a change")
+
+	to.Router.Port = 9000
+	to.Routes[0].Timeout = 10 * time.Second
+	to.Routes = append(to.Routes, models.RouteConfig{ID: "admin-route", Path: "/admin", Backend: "users"})
+	to.Backends = to.Backends[:1]
+	to.FeatureFlags["response_cache"] = false
+	to.AuthPolicies = []models.AuthPolicy{{ID: "tenant-a", Issuer: "https://tenant-a"}}
+
+	assert.Equal(t, []config.Change{
+		{Path: "auth_policies.tenant-a", Type: config.ChangeAdded},
+		{Path: "backends.orders", Type: config.ChangeRemoved},
+		{Path: "feature_flags.response_cache", Type: config.ChangeAdded},
+		{Path: "router", Type: config.ChangeChanged},
+		{Path: "routes.admin-route", Type: config.ChangeAdded},
+		{Path: "routes.orders-route", Type: config.ChangeChanged},
+	}, config.Diff(from, to))
+}
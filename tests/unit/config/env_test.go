@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+)
+
+// loadYAML writes the YAML to a temp file and loads it
+func loadYAML(t *testing.T, yaml string) (*config.Config, error) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o644))
+	return config.Load(path)
+}
+
+const envConfig = `
+router:
+  port: ${TEST_ROUTER_PORT:-8080}
+  read_timeout: ${TEST_READ_TIMEOUT:-15s}
+admin:
+  api_key: ${TEST_ADMIN_KEY}
+backends:
+  - id: users
+    endpoints:
+      - url: "http://${TEST_BACKEND_HOST:-localhost}:3000/api"
+auth_policies:
+  - id: tenant
+    issuer: "https://login.example.com"
+    jwks_url: "https://login.example.com/$$keys/${TEST_TENANT:-default}"
+`
+
+func TestLoad_ExpandsEnvVars(t *testing.T) {
+	t.Run("set variables are substituted in nested values", func(t *testing.T) {
+		t.Setenv("TEST_ADMIN_KEY", "s3cret")
+		t.Setenv("TEST_BACKEND_HOST", "users.internal")
+		t.Setenv("TEST_ROUTER_PORT", "9000")
+		t.Setenv("TEST_TENANT", "acme")
+
+		cfg, err := loadYAML(t, envConfig)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.Admin.APIKey)
+		assert.Equal(t, "http://users.internal:3000/api", cfg.Backends[0].Endpoints[0].URL)
+		assert.Equal(t, 9000, cfg.Router.Port)
+		assert.Equal(t, "https://login.example.com/$keys/acme", cfg.AuthPolicies[0].JWKSURL, "$$ is a literal dollar")
+	})
+
+	t.Run("unset variables take their default", func(t *testing.T) {
+		t.Setenv("TEST_ADMIN_KEY", "s3cret")
+		t.Setenv("TEST_BACKEND_HOST", "")
+
+		cfg, err := loadYAML(t, envConfig)
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:3000/api", cfg.Backends[0].Endpoints[0].URL, "empty variables take the default too")
+		assert.Equal(t, 8080, cfg.Router.Port)
+		assert.Equal(t, 15*time.Second, cfg.Router.ReadTimeout)
+		assert.Equal(t, "https://login.example.com/$keys/default", cfg.AuthPolicies[0].JWKSURL)
+	})
+
+	t.Run("unset variables without a default fail the load", func(t *testing.T) {
+		_, err := loadYAML(t, envConfig)
+		assert.ErrorContains(t, err, "TEST_ADMIN_KEY is not set")
+	})
+
+	t.Run("set but empty variables without a default are empty", func(t *testing.T) {
+		t.Setenv("TEST_ADMIN_KEY", "")
+
+		cfg, err := loadYAML(t, envConfig)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Admin.APIKey)
+	})
+
+	t.Run("malformed references fail the load", func(t *testing.T) {
+		_, err := loadYAML(t, "admin:\n  api_key: ${TEST_ADMIN_KEY\n")
+		assert.ErrorContains(t, err, "unterminated")
+	})
+}
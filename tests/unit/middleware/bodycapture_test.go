@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/bodycapture"
+)
+
+// serveCapture runs the handler behind the capture middleware with a POST body
+func serveCapture(opts bodycapture.Options, body string, handler http.HandlerFunc) {
+	req := httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader(body))
+	bodycapture.Middleware(opts)(handler).ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// readReplay reads a fresh replay of the captured body
+func readReplay(t *testing.T, body *bodycapture.Body) string {
+	replay, err := body.Replay()
+	require.NoError(t, err)
+	data, err := io.ReadAll(replay)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBodyCapture_MultipleConsumers(t *testing.T) {
+	payload := strings.Repeat("payload-", 1000)
+
+	serveCapture(bodycapture.Options{}, payload, func(w http.ResponseWriter, r *http.Request) {
+		// A logging consumer and a fallback consumer both request capture
+		logging, err := bodycapture.Request(r)
+		require.NoError(t, err)
+		fallback, err := bodycapture.Request(r.WithContext(r.Context()))
+		require.NoError(t, err)
+		assert.Same(t, logging, fallback, "consumers should share one capture")
+
+		// The proxied request reads part of the body before the fallback replays it
+		prefix := make([]byte, 100)
+		_, err = io.ReadFull(r.Body, prefix)
+		require.NoError(t, err)
+
+		assert.Equal(t, payload, readReplay(t, fallback))
+		assert.Equal(t, payload, readReplay(t, fallback), "each replay should start from the beginning")
+
+		rest, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(prefix)+string(rest), "the forwarded body should be intact")
+
+		captured, err := logging.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(captured))
+	})
+}
+
+func TestBodyCapture_SpillsToTempFile(t *testing.T) {
+	tempDir := t.TempDir()
+	opts := bodycapture.Options{MemoryLimit: 16, MaxBytes: 64, TempDir: tempDir}
+	payload := strings.Repeat("x", 48)
+
+	serveCapture(opts, payload, func(w http.ResponseWriter, r *http.Request) {
+		body, err := bodycapture.Request(r)
+		require.NoError(t, err)
+
+		assert.Equal(t, payload, readReplay(t, body))
+
+		files, _ := os.ReadDir(tempDir)
+		assert.Len(t, files, 1, "bytes over the memory limit should spill to a temp file")
+	})
+
+	files, _ := os.ReadDir(tempDir)
+	assert.Empty(t, files, "spill file should be removed when the request completes")
+}
+
+func TestBodyCapture_OverCap(t *testing.T) {
+	opts := bodycapture.Options{MemoryLimit: 16, MaxBytes: 64, TempDir: t.TempDir()}
+	payload := strings.Repeat("0123456789", 10)
+
+	serveCapture(opts, payload, func(w http.ResponseWriter, r *http.Request) {
+		body, err := bodycapture.Request(r)
+		require.NoError(t, err)
+
+		_, err = body.Replay()
+		assert.ErrorIs(t, err, bodycapture.ErrTooLarge)
+		assert.True(t, body.Truncated())
+
+		captured, err := body.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, payload[:64], string(captured), "the capture should keep the first MaxBytes")
+
+		forwarded, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(forwarded), "bodies over the cap should still pass through in full")
+	})
+}
+
+func TestBodyCapture_NoSpillAfterRequestCompletes(t *testing.T) {
+	tempDir := t.TempDir()
+	opts := bodycapture.Options{MemoryLimit: 16, MaxBytes: 64, TempDir: tempDir}
+	payload := strings.Repeat("x", 48)
+
+	// The handler returns before the body is read, as when the client disconnects
+	// while the transport is still sending the request upstream
+	var body *bodycapture.Body
+	serveCapture(opts, payload, func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		body, err = bodycapture.Request(r)
+		require.NoError(t, err)
+	})
+
+	forwarded, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, payload, string(forwarded))
+
+	files, _ := os.ReadDir(tempDir)
+	assert.Empty(t, files, "no spill file should be created once the request has completed")
+}
+
+func TestBodyCapture_RequestAfterRead(t *testing.T) {
+	serveCapture(bodycapture.Options{}, "payload", func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 3)
+		_, err := r.Body.Read(buf)
+		require.NoError(t, err)
+
+		_, err = bodycapture.Request(r)
+		assert.ErrorIs(t, err, bodycapture.ErrAlreadyRead)
+	})
+}
+
+func TestBodyCapture_NoBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	body, err := bodycapture.Request(req)
+	assert.NoError(t, err)
+	assert.Nil(t, body)
+}
+
+func TestBodyCapture_ZeroCostPassthrough(t *testing.T) {
+	handler := bodycapture.Middleware(bodycapture.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	w := httptest.NewRecorder()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		handler.ServeHTTP(w, req)
+	})
+	assert.Zero(t, allocs, "requests without a body should not allocate")
+
+	// Reading an uncaptured body should not allocate either
+	var body *bodycapture.Body
+	serveCapture(bodycapture.Options{}, "", func(w http.ResponseWriter, r *http.Request) {
+		body = r.Body.(*bodycapture.Body)
+	})
+	buf := make([]byte, 8)
+	allocs = testing.AllocsPerRun(100, func() {
+		body.Read(buf)
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkBodyCapture_PassthroughNoBody(b *testing.B) {
+	handler := bodycapture.Middleware(bodycapture.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkBodyCapture_PassthroughRead(b *testing.B) {
+	payload := strings.NewReader(strings.Repeat("x", 32<<10))
+	handler := bodycapture.Middleware(bodycapture.Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/test", nil)
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		payload.Seek(0, io.SeekStart)
+		req.Body = io.NopCloser(payload)
+		handler.ServeHTTP(w, req)
+	}
+}
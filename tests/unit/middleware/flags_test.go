@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/flags"
+)
+
+func TestFeatureFlags_GatesMiddlewarePerRequest(t *testing.T) {
+	store := flags.NewStore(nil)
+	store.SetRollouts(map[string]models.FlagRollout{
+		"beta_header": {Percent: 0, Routes: []string{"users-route"}, AllowAPIKeys: []string{"beta-key"}},
+	})
+
+	beta := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Beta", "true")
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := middleware.FeatureFlags(store, "users-route", "users-backend")(
+		middleware.Flagged(store, "beta_header", beta)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Later checks during the request see the same answer
+			if store.On(r, "beta_header") {
+				w.WriteHeader(http.StatusAccepted)
+			}
+		})))
+
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	onLabels := map[string]string{"flag": "beta_header", "state": "on", "route": "users-route", "status": "202"}
+	offLabels := map[string]string{"flag": "beta_header", "state": "off", "route": "users-route", "status": "200"}
+	onBefore := gatherCounter(t, "feature_flag_requests_total", onLabels)
+	offBefore := gatherCounter(t, "feature_flag_requests_total", offLabels)
+
+	w := send("beta-key")
+	assert.Equal(t, "true", w.Header().Get("X-Beta"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w = send("other-key")
+	assert.Empty(t, w.Header().Get("X-Beta"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, onBefore+1, gatherCounter(t, "feature_flag_requests_total", onLabels))
+	assert.Equal(t, offBefore+1, gatherCounter(t, "feature_flag_requests_total", offLabels),
+		"each request is counted once per flag however often it is checked")
+
+	// Toggling at runtime takes effect on the next request
+	store.Set("beta_header", false)
+	assert.Empty(t, send("beta-key").Header().Get("X-Beta"))
+}
+
+func TestFeatureFlags_IgnoresForwardingHeaders(t *testing.T) {
+	store := flags.NewStore(nil)
+	store.SetRollouts(map[string]models.FlagRollout{
+		"beta_header": {Percent: 0, AllowIPs: []string{"10.20.0.0/16"}},
+	})
+	handler := middleware.FeatureFlags(store, "users-route", "users-backend")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if store.On(r, "beta_header") {
+				w.WriteHeader(http.StatusAccepted)
+			}
+		}))
+
+	send := func(remoteAddr string, header http.Header) int {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = remoteAddr
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, send("10.20.1.5:41000", nil))
+	assert.Equal(t, http.StatusOK, send("192.0.2.7:41000", http.Header{
+		"X-Forwarded-For": {"10.20.1.5"},
+		"X-Real-Ip":       {"10.20.1.5"},
+	}), "a client must not get into allow_ips by claiming an address")
+}
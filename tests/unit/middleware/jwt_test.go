@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/jwks"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// fakeIssuer publishes a JWKS with one RSA key and signs tokens with it
+type fakeIssuer struct {
+	name    string
+	key     *rsa.PrivateKey
+	server  *httptest.Server
+	fetches atomic.Int32
+}
+
+func newFakeIssuer(t *testing.T, name string) *fakeIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &fakeIssuer{name: name, key: key}
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": name + "-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// policy returns an auth policy trusting the issuer
+func (i *fakeIssuer) policy(t *testing.T) *models.AuthPolicy {
+	policy := &models.AuthPolicy{ID: i.name, Issuer: "https://" + i.name, JWKSURL: i.server.URL, Audience: "router"}
+	require.NoError(t, policy.Validate())
+	return policy
+}
+
+// token signs a token from the issuer with extra claims
+func (i *fakeIssuer) token(t *testing.T, claims jwt.MapClaims) string {
+	all := jwt.MapClaims{
+		"iss": "https://" + i.name,
+		"aud": "router",
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		all[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	token.Header["kid"] = i.name + "-key"
+	signed, err := token.SignedString(i.key)
+	require.NoError(t, err)
+	return signed
+}
+
+// protected returns a handler behind the policy that reports the caller's roles
+func protected(policy *models.AuthPolicy, keys *jwks.KeySet) http.Handler {
+	return middleware.JWTAuth(policy, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(middleware.AuthContextFrom(r))
+	}))
+}
+
+// call sends a request with the bearer token and returns the response
+func call(handler http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestJWTAuth_PolicyPerGroup(t *testing.T) {
+	tenantA := newFakeIssuer(t, "tenant-a")
+	tenantB := newFakeIssuer(t, "tenant-b")
+
+	groupA := protected(tenantA.policy(t), jwks.NewKeySet(tenantA.server.URL, 0, nil))
+	groupB := protected(tenantB.policy(t), jwks.NewKeySet(tenantB.server.URL, 0, nil))
+
+	tokenA := tenantA.token(t, nil)
+	tokenB := tenantB.token(t, nil)
+
+	assert.Equal(t, http.StatusOK, call(groupA, tokenA).Code)
+	assert.Equal(t, http.StatusOK, call(groupB, tokenB).Code)
+	assert.Equal(t, http.StatusUnauthorized, call(groupB, tokenA).Code, "issuer A's token should be rejected on group B")
+	assert.Equal(t, http.StatusUnauthorized, call(groupA, tokenB).Code, "issuer B's token should be rejected on group A")
+
+	// Keys are cached per issuer rather than fetched per request
+	for i := 0; i < 5; i++ {
+		call(groupA, tokenA)
+	}
+	assert.Equal(t, int32(1), tenantA.fetches.Load())
+}
+
+func TestJWTAuth_RejectsInvalidTokens(t *testing.T) {
+	issuer := newFakeIssuer(t, "tenant-a")
+	handler := protected(issuer.policy(t), jwks.NewKeySet(issuer.server.URL, 0, nil))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "missing", token: ""},
+		{name: "malformed", token: "not-a-token"},
+		{name: "expired", token: issuer.token(t, jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})},
+		{name: "no expiry", token: issuer.token(t, jwt.MapClaims{"exp": nil})},
+		{name: "wrong issuer", token: issuer.token(t, jwt.MapClaims{"iss": "https://elsewhere"})},
+		{name: "wrong audience", token: issuer.token(t, jwt.MapClaims{"aud": "other"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := call(handler, tt.token)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+		})
+	}
+}
+
+func TestJWTAuth_RoleMappings(t *testing.T) {
+	issuer := newFakeIssuer(t, "tenant-a")
+	policy := issuer.policy(t)
+	policy.RoleMappings = map[string]string{"tenant-admins": "admin"}
+	policy.Roles = []string{"admin"}
+	handler := protected(policy, jwks.NewKeySet(issuer.server.URL, 0, nil))
+
+	w := call(handler, issuer.token(t, jwt.MapClaims{"roles": []string{"tenant-admins", "viewer"}}))
+	require.Equal(t, http.StatusOK, w.Code)
+	var auth models.AuthContext
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &auth))
+	assert.Equal(t, []string{"admin", "viewer"}, auth.Roles)
+	assert.Equal(t, "user-1", auth.UserID)
+
+	w = call(handler, issuer.token(t, jwt.MapClaims{"roles": []string{"viewer"}}))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestJWTAuth_IssuerUnreachable(t *testing.T) {
+	issuer := newFakeIssuer(t, "tenant-a")
+	policy := issuer.policy(t)
+	token := issuer.token(t, nil)
+	issuer.server.Close()
+
+	w := call(protected(policy, jwks.NewKeySet(policy.JWKSURL, 0, nil)), token)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/logging"
+)
+
+func TestRotatingFile_RotatesAtMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "router.log")
+	file, err := logging.OpenRotatingFile(path, 1, 2, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	// Each line is a quarter of the 1MB limit, so every fourth line starts a new file
+	line := strings.Repeat("x", 256<<10-1) + "\n"
+	for i := 0; i < 16; i++ {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	backups, err := file.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 2, "only max_backups rotated files should be kept")
+	for _, backup := range append(backups, path) {
+		info, err := os.Stat(backup)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(line)*4), info.Size(), "lines should never be split across files")
+	}
+}
+
+func TestRotatingFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "router.log")
+	require.NoError(t, os.WriteFile(path, []byte("earlier\n"), 0o644))
+
+	file, err := logging.OpenRotatingFile(path, 0, 0, 0)
+	require.NoError(t, err)
+	file.Write([]byte("later\n"))
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "earlier\nlater\n", string(data))
+}
+
+func TestLogging_New(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "router.log")
+	logger, closer, err := logging.New(config.LoggingConfig{Level: "warn", Format: "json", Output: "file", FilePath: path})
+	require.NoError(t, err)
+
+	logger.Info("Dropped below the level")
+	logger.Warn("Kept", "key", "value")
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Dropped")
+	assert.Contains(t, string(data), `"msg":"Kept","key":"value"`)
+
+	_, _, err = logging.New(config.LoggingConfig{Level: "verbose"})
+	assert.Error(t, err)
+	_, _, err = logging.New(config.LoggingConfig{Output: "file"})
+	assert.Error(t, err, "file output needs a path")
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+)
+
+func TestLogger_IncludesServingBackend(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := middleware.Logger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.SetServedBy(r, "fallback-backend")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+	assert.Contains(t, buf.String(), "backend=fallback-backend")
+}
+
+func TestLogger_IncludesUpstreamError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := middleware.Logger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.SetServedBy(r, "users")
+		middleware.SetEndpoint(r, "http://users-1:3000")
+		middleware.SetErrorClass(r, "timeout")
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+	assert.Contains(t, buf.String(), "status=504")
+	assert.Contains(t, buf.String(), "endpoint=http://users-1:3000")
+	assert.Contains(t, buf.String(), "error_class=timeout")
+}
+
+func TestLogger_OmitsBackendWhenNotProxied(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := middleware.Logger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.NotContains(t, buf.String(), "backend=")
+	assert.NotContains(t, buf.String(), "error_class=")
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/api"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// gatherCounter returns the value of a counter in the default registry with the given labels
+func gatherCounter(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// hasLabels checks that a metric carries all of the given label values
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, pair := range metric.GetLabel() {
+		if value, ok := labels[pair.GetName()]; ok && value == pair.GetValue() {
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
+
+func TestMetrics_RecordsRequestsByRoutePattern(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(middleware.Metrics())
+	r.NotFoundHandler = middleware.Metrics()(http.NotFoundHandler())
+	r.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+
+	okLabels := map[string]string{"method": "GET", "path": "/items/{id}", "status": "200"}
+	notFoundLabels := map[string]string{"method": "GET", "path": "/items/{id}", "status": "404"}
+	unmatchedLabels := map[string]string{"method": "GET", "path": "unmatched", "status": "404"}
+
+	okBefore := gatherCounter(t, "http_requests_total", okLabels)
+	notFoundBefore := gatherCounter(t, "http_requests_total", notFoundLabels)
+	unmatchedBefore := gatherCounter(t, "http_requests_total", unmatchedLabels)
+
+	for _, path := range []string{"/items/1", "/items/2", "/items/3", "/items/missing", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, okBefore+3, gatherCounter(t, "http_requests_total", okLabels))
+	assert.Equal(t, notFoundBefore+1, gatherCounter(t, "http_requests_total", notFoundLabels))
+	assert.Equal(t, unmatchedBefore+1, gatherCounter(t, "http_requests_total", unmatchedLabels))
+	assert.Zero(t, gatherCounter(t, "http_requests_total", map[string]string{"path": "/items/1"}),
+		"raw paths must not be used as labels")
+}
+
+func TestMetrics_TracksInFlightRequests(t *testing.T) {
+	var during float64
+	handler := middleware.Metrics()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		during = gatherGauge(t, "http_requests_in_flight")
+	}))
+
+	before := gatherGauge(t, "http_requests_in_flight")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, before+1, during)
+	assert.Equal(t, before, gatherGauge(t, "http_requests_in_flight"))
+}
+
+// gatherGauge returns the value of an unlabelled gauge in the default registry
+func gatherGauge(t *testing.T, name string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) > 0 {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+// scrapeOpenMetrics scrapes the metrics handler the way an OpenMetrics-aware Prometheus does
+func scrapeOpenMetrics(t *testing.T) string {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	w := httptest.NewRecorder()
+	api.MetricsHandler(true).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "application/openmetrics-text")
+	return w.Body.String()
+}
+
+// exemplarLines returns the histogram bucket lines for the path that carry an exemplar
+func exemplarLines(body, path string) []string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "http_request_duration_seconds_bucket{") &&
+			strings.Contains(line, `path="`+path+`"`) && strings.Contains(line, " # {") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestMetrics_TraceExemplars(t *testing.T) {
+	const sampledTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const unsampledTrace = "0af7651916cd43dd8448eb211c80319c"
+
+	r := mux.NewRouter()
+	r.Use(middleware.Metrics())
+	r.HandleFunc("/traced/{status}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["status"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods("GET")
+
+	send := func(path, traceparent string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("traceparent", traceparent)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		send("/traced/ok", "00-"+sampledTrace+"-00f067aa0ba902b7-01")
+		assert.Empty(t, exemplarLines(scrapeOpenMetrics(t), "/traced/{status}"))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		services.SetExemplarsEnabled(true)
+		t.Cleanup(func() { services.SetExemplarsEnabled(false) })
+
+		send("/traced/ok", "00-"+sampledTrace+"-00f067aa0ba902b7-01")
+		send("/traced/missing", "00-"+unsampledTrace+"-00f067aa0ba902b7-00")
+
+		lines := exemplarLines(scrapeOpenMetrics(t), "/traced/{status}")
+		require.Len(t, lines, 1, "only the sampled request should carry an exemplar")
+		assert.Contains(t, lines[0], `status="200"`)
+		assert.Contains(t, lines[0], `# {trace_id="`+sampledTrace+`"}`)
+	})
+}
+
+func TestSampledTraceID(t *testing.T) {
+	tests := []struct {
+		traceparent string
+		expected    string
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", ""},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", ""},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"00-4bf92f35-00f067aa0ba902b7-01", ""},
+		{"garbage", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", tt.traceparent)
+		assert.Equal(t, tt.expected, middleware.SampledTraceID(req), tt.traceparent)
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+)
+
+// normalizedPath runs a request for target through the middleware and returns the status and
+// the escaped path the handler saw
+func normalizedPath(t *testing.T, target string) (int, string) {
+	seen := ""
+	handler := middleware.NormalizePath()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.EscapedPath()
+		decoded, err := url.PathUnescape(seen)
+		assert.NoError(t, err)
+		assert.Equal(t, decoded, r.URL.Path, "the decoded path should match the escaped one")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w.Code, seen
+}
+
+func TestNormalizePath_Cleans(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"clean path is untouched", "/api/v1/users", "/api/v1/users"},
+		{"double slashes", "/api//v1///users", "/api/v1/users"},
+		{"leading double slash", "//api/v1/users", "/api/v1/users"},
+		{"dot segments", "/api/./v1/./users", "/api/v1/users"},
+		{"parent segments within the path", "/api//v1/../v1/users", "/api/v1/users"},
+		{"parent segment back to the root", "/api/..", "/"},
+		{"trailing slash is kept", "/api//v1/users/", "/api/v1/users/"},
+		{"trailing dot segment", "/api/v1/users/.", "/api/v1/users"},
+		{"encoded dot segments", "/api/v1/%2e%2E/v2/users", "/api/v2/users"},
+		{"encoded characters are kept", "/api/v1/a%2Fb//c", "/api/v1/a%2Fb/c"},
+		{"dotted names are not segments", "/api/.well-known/..data", "/api/.well-known/..data"},
+		{"query is untouched", "/api//v1?next=//x/../y", "/api/v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, path := normalizedPath(t, tt.target)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.want, path)
+		})
+	}
+}
+
+func TestNormalizePath_RejectsTraversal(t *testing.T) {
+	for _, target := range []string{
+		"/..",
+		"/../etc/passwd",
+		"/api/../../etc/passwd",
+		"/api/v1/../../../etc/passwd",
+		"/api/%2e%2e/%2E%2E/etc/passwd",
+		"/api/v1/..%2f..%2f..%2fetc/passwd",
+		"/api/v1/..%5c..%5cetc",
+	} {
+		t.Run(target, func(t *testing.T) {
+			code, path := normalizedPath(t, target)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Empty(t, path, "the request should not reach the handler")
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/lib/ratelimit"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// rateLimitConfig allows two requests per second per client IP
+func rateLimitConfig(t *testing.T) *models.RateLimitConfig {
+	limit := &models.RateLimitConfig{Enabled: true, Rate: 2, Period: "second"}
+	require.NoError(t, limit.Validate())
+	return limit
+}
+
+// redisLimited returns a handler rate limited through the Redis at address
+func redisLimited(t *testing.T, address string, failClosed bool, logs *bytes.Buffer) http.Handler {
+	client := ratelimit.NewRedisClient(config.RedisConfig{Address: address, Timeout: 100 * time.Millisecond})
+	t.Cleanup(func() { client.Close() })
+
+	limit := rateLimitConfig(t)
+	store := ratelimit.NewRedisStore(client, "ratelimit:route:", limit)
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	return middleware.RateLimitWithStore(limit, store, failClosed, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+// statuses sends n requests and returns their status codes
+func statuses(handler http.Handler, n int) []int {
+	var codes []int
+	for i := 0; i < n; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+		codes = append(codes, w.Code)
+	}
+	return codes
+}
+
+func TestRateLimit_InMemory(t *testing.T) {
+	handler := middleware.RateLimit(rateLimitConfig(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	assert.Equal(t, []int{200, 200, 429}, statuses(handler, 3))
+}
+
+func TestRateLimit_RedisSharedAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	var logs bytes.Buffer
+
+	// Two router instances share the bucket, so together they get the configured rate
+	first := redisLimited(t, server.Addr(), false, &logs)
+	second := redisLimited(t, server.Addr(), false, &logs)
+	assert.Equal(t, []int{200}, statuses(first, 1))
+	assert.Equal(t, []int{200, 429}, statuses(second, 2))
+	assert.Equal(t, []int{429}, statuses(first, 1))
+
+	// Tokens refill on the Redis clock
+	server.SetTime(time.Now().Add(time.Second))
+	assert.Equal(t, []int{200, 200, 429}, statuses(first, 3))
+	assert.True(t, server.Exists("ratelimit:route:192.0.2.1"))
+	assert.Empty(t, logs.String())
+}
+
+func TestRateLimit_RedisUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	address := server.Addr()
+	server.Close()
+
+	t.Run("fails open by default", func(t *testing.T) {
+		var logs bytes.Buffer
+		assert.Equal(t, []int{200, 200, 200}, statuses(redisLimited(t, address, false, &logs), 3))
+		assert.Contains(t, logs.String(), "Rate limit store unavailable")
+	})
+
+	t.Run("fails closed when configured", func(t *testing.T) {
+		var logs bytes.Buffer
+		assert.Equal(t, []int{503}, statuses(redisLimited(t, address, true, &logs), 1))
+	})
+}
+
+func TestRateLimit_UserID(t *testing.T) {
+	limit := &models.RateLimitConfig{Enabled: true, Rate: 2, Period: "second", KeyType: "USER_ID"}
+	require.NoError(t, limit.Validate())
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	handler := middleware.RateLimitWithStore(limit, models.NewRateLimiter(limit), false, logger)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// send makes a request as the given user, or unauthenticated when user is empty
+	send := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		if user != "" {
+			req = middleware.WithAuthContext(req, &models.AuthContext{Authenticated: true, UserID: user})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Each authenticated user has their own bucket
+	assert.Equal(t, []int{200, 200, 429}, []int{send("alice"), send("alice"), send("alice")})
+	assert.Equal(t, []int{200, 200, 429}, []int{send("bob"), send("bob"), send("bob")})
+	assert.Empty(t, logs.String())
+
+	// Unauthenticated requests from one IP share a bucket
+	assert.Equal(t, []int{200, 200, 429}, []int{send(""), send(""), send("")})
+	assert.Contains(t, logs.String(), "No authenticated user for USER_ID rate limit")
+}
+
+func TestRateLimitState_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	limiter := models.NewRateLimiter(rateLimitConfig(t))
+	require.True(t, limiter.Allow("10.0.0.1"))
+
+	now := time.Now()
+	require.NoError(t, ratelimit.SaveState(path, map[string]*models.RateLimiter{"api": limiter}, now))
+
+	state, err := ratelimit.LoadState(path, time.Hour, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Contains(t, state, "api")
+	assert.InDelta(t, 1, state["api"].Buckets["10.0.0.1"].Tokens, 0.01)
+
+	_, err = ratelimit.LoadState(path, time.Hour, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ratelimit.ErrStaleState)
+}
+
+func TestRateLimitState_MissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := ratelimit.LoadState(filepath.Join(dir, "missing.json"), time.Hour, time.Now())
+	assert.NoError(t, err, "a missing file is a first start")
+	assert.Nil(t, state)
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte(`{"version":1,"routes":`), 0o600))
+	_, err = ratelimit.LoadState(corrupt, time.Hour, time.Now())
+	assert.ErrorContains(t, err, "corrupt")
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+)
+
+// generatedRequestID runs a request without an X-Request-ID through the middleware
+// and returns the ID the handler and client saw
+func generatedRequestID(t *testing.T, prefix, format string) string {
+	var seen string
+	handler := middleware.RequestID(middleware.RequestIDOptions{Prefix: prefix, Format: format, TrustInbound: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-Request-ID")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+	assert.Equal(t, seen, w.Header().Get("X-Request-ID"), "the response should echo the request's ID")
+	return seen
+}
+
+func TestRequestID_PrefixAndFormat(t *testing.T) {
+	uuidID := regexp.MustCompile(`^tyo1-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	assert.Regexp(t, uuidID, generatedRequestID(t, "tyo1-", middleware.RequestIDFormatUUID))
+	assert.Regexp(t, uuidID, generatedRequestID(t, "tyo1-", ""), "uuid should be the default format")
+
+	hexID := regexp.MustCompile(`^tyo1-[0-9a-f]{32}$`)
+	assert.Regexp(t, hexID, generatedRequestID(t, "tyo1-", middleware.RequestIDFormatHex))
+
+	assert.NotEqual(t, generatedRequestID(t, "", ""), generatedRequestID(t, "", ""))
+}
+
+func TestRequestID_KeepsInboundID(t *testing.T) {
+	var seen string
+	handler := middleware.RequestID(middleware.RequestIDOptions{Prefix: "tyo1-", Format: middleware.RequestIDFormatHex, TrustInbound: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-Request-ID")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set("X-Request-ID", "upstream-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "upstream-123", seen, "an inbound ID should not be prefixed or replaced")
+	assert.Equal(t, "upstream-123", w.Header().Get("X-Request-ID"))
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+)
+
+// shedAll is a Shedder with a fixed decision
+type shedAll bool
+
+// ShouldShed implements middleware.Shedder
+func (s shedAll) ShouldShed() bool {
+	return bool(s)
+}
+
+func TestShed(t *testing.T) {
+	labels := map[string]string{"route": "shed-route"}
+	before := gatherCounter(t, "requests_shed_total", labels)
+
+	var served int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	})
+
+	w := httptest.NewRecorder()
+	middleware.Shed(shedAll(true), "shed-route")(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Zero(t, served)
+	assert.Equal(t, before+1, gatherCounter(t, "requests_shed_total", labels))
+
+	w = httptest.NewRecorder()
+	middleware.Shed(shedAll(false), "shed-route")(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, served)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/lib/tracing"
+)
+
+func TestTracing_ContinuesInboundTrace(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter)
+
+	var forwarded string
+	handler := middleware.Tracing(provider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.Context.TraceID.String(), "the client's trace should continue")
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.String())
+	assert.Equal(t, tracing.SpanKindServer, span.Kind)
+	assert.Equal(t, http.StatusBadGateway, span.Attributes["http.response.status_code"])
+	assert.NotEmpty(t, span.Error, "a 5xx should mark the span as failed")
+	assert.Equal(t, span.Context.Traceparent(), forwarded, "downstream should see the router's span")
+}
+
+func TestTracing_UnsampledTracesAreNotExported(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	handler := middleware.Tracing(tracing.NewProvider(exporter))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Empty(t, exporter.Spans())
+
+	// A request without a valid traceparent starts a new, sampled trace
+	req.Header.Set("traceparent", "00-not-a-trace-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.Len(t, exporter.Spans(), 1)
+	assert.Equal(t, tracing.SpanID{}, exporter.Spans()[0].Parent)
+}
+
+func TestOTLPExporter_PostsSpansOnShutdown(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received <- body
+	}))
+	defer collector.Close()
+
+	provider := tracing.NewProvider(tracing.NewOTLPExporter(collector.URL, "edge-router", slog.New(slog.NewTextHandler(io.Discard, nil))))
+	_, span := provider.Start(context.Background(), "HTTP GET", tracing.SpanKindServer)
+	span.SetAttribute("http.response.status_code", 200)
+	span.End()
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	var body map[string]any
+	select {
+	case body = <-received:
+	default:
+		t.Fatal("spans should be exported on shutdown")
+	}
+	resourceSpans := body["resourceSpans"].([]any)[0].(map[string]any)
+	resource := resourceSpans["resource"].(map[string]any)["attributes"].([]any)[0].(map[string]any)
+	assert.Equal(t, "service.name", resource["key"])
+	assert.Equal(t, "edge-router", resource["value"].(map[string]any)["stringValue"])
+
+	exported := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	assert.Equal(t, span.SpanContext().TraceID.String(), exported["traceId"])
+	assert.Equal(t, "HTTP GET", exported["name"])
+	assert.EqualValues(t, tracing.SpanKindServer, exported["kind"])
+	attribute := exported["attributes"].([]any)[0].(map[string]any)
+	assert.Equal(t, "200", attribute["value"].(map[string]any)["intValue"])
+}
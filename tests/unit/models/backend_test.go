@@ -0,0 +1,114 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+func TestBackendService_ProtocolValidation(t *testing.T) {
+	newBackend := func(protocol, url string) *models.BackendService {
+		return &models.BackendService{
+			ID:       "backend",
+			Name:     "backend",
+			Protocol: protocol,
+			Endpoints: []models.EndpointConfig{
+				{URL: url, Weight: 1},
+			},
+			HealthCheck: models.HealthCheckConfig{Enabled: false},
+		}
+	}
+
+	backend := newBackend("", "http://localhost:8080")
+	assert.NoError(t, backend.Validate())
+	assert.Equal(t, "http", backend.Protocol, "protocol should default to http")
+	assert.False(t, backend.IsH2C())
+
+	backend = newBackend("h2c", "http://localhost:50051")
+	assert.NoError(t, backend.Validate())
+	assert.True(t, backend.IsH2C())
+
+	assert.Error(t, newBackend("h2c", "https://localhost:50051").Validate(), "h2c is cleartext only")
+	assert.Error(t, newBackend("h3", "http://localhost:8080").Validate())
+}
+
+func TestBackendService_TLSValidation(t *testing.T) {
+	backend := &models.BackendService{
+		ID:   "backend",
+		Name: "backend",
+		Endpoints: []models.EndpointConfig{
+			{URL: "https://internal.example.com", Weight: 1},
+		},
+		TLS: &models.TLSConfig{CAFile: "/etc/ssl/internal-ca.pem", ServerName: "internal.example.com"},
+	}
+	assert.NoError(t, backend.Validate())
+
+	backend.TLS.CertFile = "/etc/ssl/client.pem"
+	assert.Error(t, backend.Validate(), "client cert requires a key")
+
+	backend.TLS.KeyFile = "/etc/ssl/client-key.pem"
+	assert.NoError(t, backend.Validate())
+
+	backend.Protocol = "h2c"
+	backend.Endpoints[0].URL = "http://internal.example.com"
+	assert.Error(t, backend.Validate(), "tls settings don't apply to h2c")
+}
+
+func TestBackendService_TransportValidation(t *testing.T) {
+	backend := &models.BackendService{
+		ID:   "backend",
+		Name: "backend",
+		Endpoints: []models.EndpointConfig{
+			{URL: "http://internal.example.com", Weight: 1},
+		},
+		Transport: &models.TransportConfig{MaxIdleConnsPerHost: 64, IdleConnTimeout: 90 * time.Second},
+	}
+	assert.NoError(t, backend.Validate())
+
+	backend.Transport.MaxIdleConns = -1
+	assert.Error(t, backend.Validate(), "idle limits cannot be negative")
+
+	backend.Transport.MaxIdleConns = 0
+	backend.Transport.ResponseHeaderTimeout = -time.Second
+	assert.Error(t, backend.Validate(), "timeouts cannot be negative")
+}
+
+func TestLoadBalancerConfig_HashKeyValidation(t *testing.T) {
+	for _, key := range []string{"", "path", "header:X-Cache-Key", "query:key"} {
+		lb := &models.LoadBalancerConfig{Algorithm: "consistent-hash", HashKey: key}
+		assert.NoError(t, lb.Validate(), key)
+	}
+
+	for _, key := range []string{"cookie:id", "header:", "query", "host"} {
+		lb := &models.LoadBalancerConfig{Algorithm: "consistent-hash", HashKey: key}
+		assert.Error(t, lb.Validate(), key)
+	}
+
+	lb := &models.LoadBalancerConfig{Algorithm: "consistent-hash", VirtualNodes: -1}
+	assert.Error(t, lb.Validate(), "virtual nodes cannot be negative")
+}
+
+func TestLoadBalancerConfig_LatencyDecayValidation(t *testing.T) {
+	lb := &models.LoadBalancerConfig{Algorithm: "least-latency", LatencyDecay: 5 * time.Second}
+	assert.NoError(t, lb.Validate())
+
+	lb.LatencyDecay = -time.Second
+	assert.Error(t, lb.Validate(), "latency decay cannot be negative")
+}
+
+func TestBackendService_HeaderLimitsValidation(t *testing.T) {
+	backend := &models.BackendService{
+		ID:   "backend",
+		Name: "backend",
+		Endpoints: []models.EndpointConfig{
+			{URL: "http://internal.example.com", Weight: 1},
+		},
+		HeaderLimits: &models.HeaderLimitsConfig{MaxTotalBytes: 8192, MaxValueBytes: 4096, MaxCount: 100},
+	}
+	assert.NoError(t, backend.Validate())
+
+	backend.HeaderLimits.MaxCount = -1
+	assert.Error(t, backend.Validate(), "limits cannot be negative")
+}
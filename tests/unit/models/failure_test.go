@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// timeoutError is a net.Error that timed out without a context deadline
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// dialError wraps err the way a failed dial reaches a client
+func dialError(err error) error {
+	return &url.Error{Op: "Get", URL: "http://backend:8080/health", Err: &net.OpError{Op: "dial", Net: "tcp", Err: err}}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want models.FailureReason
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "dns not found", err: dialError(&net.DNSError{Err: "no such host", Name: "backend", IsNotFound: true}), want: models.FailureDNS},
+		{name: "dns timeout", err: dialError(&net.DNSError{Err: "i/o timeout", Name: "backend", IsTimeout: true}), want: models.FailureDNS},
+		{name: "connection refused", err: dialError(os.NewSyscallError("connect", syscall.ECONNREFUSED)), want: models.FailureConnectionRefused},
+		{name: "connection reset", err: dialError(os.NewSyscallError("read", syscall.ECONNRESET)), want: models.FailureConnectionReset},
+		{name: "broken pipe", err: dialError(os.NewSyscallError("write", syscall.EPIPE)), want: models.FailureConnectionReset},
+		{name: "unknown authority", err: dialError(x509.UnknownAuthorityError{}), want: models.FailureTLS},
+		{name: "hostname mismatch", err: dialError(x509.HostnameError{Host: "backend"}), want: models.FailureTLS},
+		{name: "expired certificate", err: dialError(x509.CertificateInvalidError{Reason: x509.Expired}), want: models.FailureTLS},
+		{name: "certificate verification", err: dialError(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), want: models.FailureTLS},
+		{name: "tls record header", err: dialError(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), want: models.FailureTLS},
+		{name: "tls alert", err: dialError(tls.AlertError(40)), want: models.FailureTLS},
+		{name: "context deadline", err: fmt.Errorf("health check: %w", context.DeadlineExceeded), want: models.FailureTimeout},
+		{name: "network timeout", err: dialError(timeoutError{}), want: models.FailureTimeout},
+		{name: "context canceled", err: dialError(context.Canceled), want: models.FailureCanceled},
+		{name: "unexpected status", err: fmt.Errorf("%w %d", models.ErrUnexpectedStatus, 503), want: models.FailureBadStatus},
+		{name: "other", err: errors.New("malformed HTTP response"), want: models.FailureOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.ClassifyFailure(tt.err))
+		})
+	}
+}
+
+func TestHealthStatus_RecentErrors(t *testing.T) {
+	status := &models.HealthStatus{}
+	assert.Equal(t, models.FailureReason(""), status.DominantReason())
+
+	for i := 0; i < 25; i++ {
+		status.RecordError(models.HealthError{Endpoint: fmt.Sprint(i), Reason: models.FailureDNS})
+	}
+	assert.Len(t, status.RecentErrors, 20, "recent errors should be capped")
+	assert.Equal(t, "5", status.RecentErrors[0].Endpoint, "the oldest errors should be dropped")
+
+	for i := 0; i < 10; i++ {
+		status.RecordError(models.HealthError{Reason: models.FailureTimeout})
+	}
+	assert.Equal(t, models.FailureTimeout, status.DominantReason(), "a tie should go to the latest reason")
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+func TestHealthCheckConfig_Type(t *testing.T) {
+	config := models.HealthCheckConfig{Enabled: true}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, "http", config.Type, "type should default to http")
+
+	for _, checkType := range []string{"http", "tcp", "grpc"} {
+		config := models.HealthCheckConfig{Enabled: true, Type: checkType}
+		assert.NoError(t, config.Validate(), checkType)
+	}
+
+	config = models.HealthCheckConfig{Enabled: true, Type: "icmp"}
+	assert.Error(t, config.Validate())
+}
+
+func TestHealthCheckConfig_EffectiveInterval(t *testing.T) {
+	config := models.HealthCheckConfig{Interval: 10 * time.Second, BackoffAfter: 3, MaxInterval: time.Minute}
+
+	assert.Equal(t, 10*time.Second, config.EffectiveInterval(0))
+	assert.Equal(t, 10*time.Second, config.EffectiveInterval(2))
+	assert.Equal(t, 20*time.Second, config.EffectiveInterval(3))
+	assert.Equal(t, 40*time.Second, config.EffectiveInterval(4))
+	assert.Equal(t, time.Minute, config.EffectiveInterval(5))
+	assert.Equal(t, time.Minute, config.EffectiveInterval(500))
+
+	config.BackoffAfter = 0
+	assert.Equal(t, 10*time.Second, config.EffectiveInterval(500), "backoff is disabled by default")
+}
+
+func TestHealthCheckConfig_BackoffValidation(t *testing.T) {
+	config := models.HealthCheckConfig{Enabled: true, BackoffAfter: 3}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, 5*time.Minute, config.MaxInterval, "max interval should default to 5m")
+
+	config = models.HealthCheckConfig{Enabled: true, BackoffAfter: -1}
+	assert.Error(t, config.Validate())
+
+	config = models.HealthCheckConfig{Enabled: true, BackoffAfter: 3, Interval: time.Minute, MaxInterval: 30 * time.Second}
+	assert.Error(t, config.Validate(), "max interval below the interval should be rejected")
+}
+
+func TestPassiveHealthCheckConfig_Validate(t *testing.T) {
+	config := models.PassiveHealthCheckConfig{Enabled: true}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, 5, config.ConsecutiveErrors, "consecutive errors should default to 5")
+	assert.Equal(t, 30*time.Second, config.EjectionTime, "ejection time should default to 30s")
+
+	config = models.PassiveHealthCheckConfig{Enabled: true, ConsecutiveErrors: -1}
+	assert.Error(t, config.Validate())
+
+	config = models.PassiveHealthCheckConfig{Enabled: true, EjectionTime: -time.Second}
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+// allowed counts how many of n back-to-back requests the limiter lets through
+func allowed(limiter *models.RateLimiter, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if limiter.Allow("client") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRateLimiter_BurstByStrategy(t *testing.T) {
+	newLimiter := func(strategy string) *models.RateLimiter {
+		config := &models.RateLimitConfig{Enabled: true, Rate: 5, Period: "minute", BurstSize: 20, Strategy: strategy}
+		require.NoError(t, config.Validate())
+		return models.NewRateLimiter(config)
+	}
+
+	assert.Equal(t, 20, allowed(newLimiter(models.StrategyTokenBucket), 50),
+		"token bucket should allow an initial burst up to BurstSize")
+	assert.Equal(t, 5, allowed(newLimiter(models.StrategySlidingWindow), 50),
+		"sliding window should allow only Rate requests per Period")
+}
+
+func TestRateLimiter_SlidingWindowTrailingPeriod(t *testing.T) {
+	config := &models.RateLimitConfig{Enabled: true, Rate: 3, Period: "second", Strategy: models.StrategySlidingWindow}
+	require.NoError(t, config.Validate())
+	limiter := models.NewRateLimiter(config)
+
+	assert.Equal(t, 2, allowed(limiter, 2))
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, 1, allowed(limiter, 5), "the first two requests are still in the window")
+
+	// The first two requests leave the window, the third is still in it
+	time.Sleep(600 * time.Millisecond)
+	assert.Equal(t, 2, allowed(limiter, 5))
+
+	assert.True(t, limiter.AllowN("other", 3))
+	assert.False(t, limiter.AllowN("other", 1))
+	assert.False(t, limiter.AllowN("another", 4), "more than Rate requests never fit")
+}
+
+func TestRateLimitConfig_StrategyValidation(t *testing.T) {
+	config := &models.RateLimitConfig{Enabled: true, Rate: 5, Period: "minute"}
+	require.NoError(t, config.Validate())
+	assert.Equal(t, models.StrategyTokenBucket, config.Strategy, "token bucket is the default")
+
+	config.Strategy = models.StrategySlidingWindow
+	assert.NoError(t, config.Validate())
+
+	config.Strategy = "leaky_bucket"
+	assert.Error(t, config.Validate())
+}
+
+func TestRateLimiter_RestoreAccountsForDowntime(t *testing.T) {
+	config := &models.RateLimitConfig{Enabled: true, Rate: 60, Period: "minute", BurstSize: 10}
+	require.NoError(t, config.Validate())
+
+	before := models.NewRateLimiter(config)
+	require.Equal(t, 10, allowed(before, 20))
+	state := before.Snapshot()
+
+	// Restarting straight away gives no fresh burst
+	restarted := models.NewRateLimiter(config)
+	restarted.Restore(state, time.Now().Add(-time.Hour))
+	assert.Equal(t, 0, allowed(restarted, 5), "a restart should not refill the bucket")
+
+	// Three seconds of downtime accrue three tokens at one per second
+	bucket := state.Buckets["client"]
+	bucket.LastFill = bucket.LastFill.Add(-3 * time.Second)
+	state.Buckets["client"] = bucket
+	restarted = models.NewRateLimiter(config)
+	restarted.Restore(state, time.Now().Add(-time.Hour))
+	assert.Equal(t, 3, allowed(restarted, 5), "tokens accrued while down should be available")
+}
+
+func TestRateLimiter_RestoreSlidingWindow(t *testing.T) {
+	config := &models.RateLimitConfig{Enabled: true, Rate: 3, Period: "minute", Strategy: models.StrategySlidingWindow}
+	require.NoError(t, config.Validate())
+
+	before := models.NewRateLimiter(config)
+	require.Equal(t, 2, allowed(before, 2))
+
+	restarted := models.NewRateLimiter(config)
+	restarted.Restore(before.Snapshot(), time.Now().Add(-time.Hour))
+	assert.Equal(t, 1, allowed(restarted, 5), "requests before the restart should still count against the window")
+}
+
+func TestRateLimiter_RestoreSkipsOldEntries(t *testing.T) {
+	config := &models.RateLimitConfig{Enabled: true, Rate: 60, Period: "minute", BurstSize: 10}
+	require.NoError(t, config.Validate())
+
+	state := models.RateLimitState{Buckets: map[string]models.TokenBucketState{
+		"client": {Tokens: 0, LastFill: time.Now().Add(-2 * time.Second)},
+	}}
+	limiter := models.NewRateLimiter(config)
+	limiter.Restore(state, time.Now().Add(-time.Second))
+	assert.Equal(t, 10, allowed(limiter, 20), "a bucket older than the cutoff should start full")
+
+	// A smaller burst caps the restored tokens
+	config.BurstSize = 2
+	state.Buckets["client"] = models.TokenBucketState{Tokens: 8, LastFill: time.Now()}
+	limiter = models.NewRateLimiter(config)
+	limiter.Restore(state, time.Now().Add(-time.Hour))
+	assert.Equal(t, 2, allowed(limiter, 20))
+}
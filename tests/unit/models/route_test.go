@@ -0,0 +1,359 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/ryohi-router/src/models"
+)
+
+func TestRouteConfig_CanaryValidation(t *testing.T) {
+	route := models.RouteConfig{
+		ID:            "route",
+		Path:          "/api/*",
+		Method:        []string{"GET"},
+		Backend:       "primary",
+		CanaryBackend: "canary",
+		CanaryWeight:  101,
+	}
+	assert.Error(t, route.Validate())
+
+	route.CanaryWeight = 5
+	route.CanaryBackend = ""
+	assert.Error(t, route.Validate())
+
+	route.CanaryBackend = "primary"
+	assert.Error(t, route.Validate())
+
+	route.CanaryBackend = "canary"
+	assert.NoError(t, route.Validate())
+}
+
+func TestRouteConfig_RewriteValidation(t *testing.T) {
+	route := models.RouteConfig{
+		ID:          "route",
+		Path:        "/api/v1/*",
+		Method:      []string{"GET"},
+		Backend:     "primary",
+		StripPrefix: "api/v1",
+	}
+	assert.Error(t, route.Validate(), "strip prefix must be absolute")
+
+	route.StripPrefix = "/api/v1"
+	route.RewritePattern = "^/users/(.*"
+	route.Rewrite = "/v2/$1"
+	assert.Error(t, route.Validate(), "invalid regex should be rejected")
+
+	route.RewritePattern = "^/users/(.*)$"
+	route.Rewrite = ""
+	assert.Error(t, route.Validate(), "pattern without rewrite should be rejected")
+
+	route.Rewrite = "/v2/members/$1"
+	assert.NoError(t, route.Validate())
+
+	route.RewriteRules = []models.RewriteRule{{Pattern: "^/legacy/(.*)$", Replacement: "/v2/$1"}}
+	assert.NoError(t, route.Validate())
+
+	route.RewriteRules = append(route.RewriteRules, models.RewriteRule{Pattern: "^/old/(.*", Replacement: "/$1"})
+	assert.Error(t, route.Validate(), "invalid rule regex should be rejected")
+
+	route.RewriteRules = []models.RewriteRule{{Replacement: "/v2"}}
+	assert.Error(t, route.Validate(), "rule without a pattern should be rejected")
+}
+
+func TestRouteConfig_MatchHost(t *testing.T) {
+	newRoute := func(host string) *models.RouteConfig {
+		return &models.RouteConfig{
+			ID:      "route",
+			Path:    "/api/*",
+			Host:    host,
+			Method:  []string{"GET"},
+			Backend: "backend",
+			Enabled: true,
+		}
+	}
+
+	t.Run("exact host", func(t *testing.T) {
+		route := newRoute("api.example.com")
+		assert.True(t, route.Match("api.example.com", "/api/users", "GET", nil))
+		assert.True(t, route.Match("API.Example.com:8080", "/api/users", "GET", nil), "case and port should be ignored")
+		assert.False(t, route.Match("admin.example.com", "/api/users", "GET", nil))
+		assert.False(t, route.Match("example.com", "/api/users", "GET", nil))
+	})
+
+	t.Run("wildcard host", func(t *testing.T) {
+		route := newRoute("*.example.com")
+		assert.True(t, route.Match("api.example.com", "/api/users", "GET", nil))
+		assert.True(t, route.Match("eu.api.example.com:443", "/api/users", "GET", nil))
+		assert.False(t, route.Match("example.com", "/api/users", "GET", nil), "wildcard should not match the apex")
+		assert.False(t, route.Match("api.example.org", "/api/users", "GET", nil))
+		assert.False(t, route.Match("badexample.com", "/api/users", "GET", nil))
+	})
+
+	t.Run("no host matches any host", func(t *testing.T) {
+		route := newRoute("")
+		assert.True(t, route.Match("api.example.com", "/api/users", "GET", nil))
+		assert.True(t, route.Match("[::1]:8080", "/api/users", "GET", nil))
+		assert.True(t, route.Match("", "/api/users", "GET", nil))
+	})
+
+	t.Run("validates host", func(t *testing.T) {
+		for _, host := range []string{"api.example.com", "*.example.com"} {
+			assert.NoError(t, newRoute(host).Validate(), host)
+		}
+		for _, host := range []string{"*", "api.*.com", "example.com:8080", "http://example.com"} {
+			assert.Error(t, newRoute(host).Validate(), host)
+		}
+	})
+}
+
+func TestRouteCollection_FindRouteByHost(t *testing.T) {
+	api := &models.RouteConfig{ID: "api", Path: "/*", Host: "api.example.com", Method: []string{"GET"}, Enabled: true}
+	admin := &models.RouteConfig{ID: "admin", Path: "/*", Host: "*.admin.example.com", Method: []string{"GET"}, Enabled: true}
+	other := &models.RouteConfig{ID: "other", Path: "/*", Method: []string{"GET"}, Enabled: true}
+	rc := &models.RouteCollection{Routes: []*models.RouteConfig{other, api, admin}}
+
+	assert.Equal(t, "api", rc.FindRoute("api.example.com", "/users", "GET", nil).ID)
+	assert.Equal(t, "admin", rc.FindRoute("eu.admin.example.com", "/users", "GET", nil).ID)
+	assert.Equal(t, "other", rc.FindRoute("www.example.com", "/users", "GET", nil).ID)
+}
+
+func TestRouteConfig_UpstreamPathValidation(t *testing.T) {
+	route := models.RouteConfig{
+		ID:           "route",
+		Path:         "/users/{id}/avatar",
+		Method:       []string{"GET"},
+		Backend:      "backend",
+		UpstreamPath: "/internal/avatars?user={id}",
+	}
+	assert.NoError(t, route.Validate())
+
+	route.UpstreamPath = "/internal/avatars?user={user}"
+	assert.Error(t, route.Validate(), "placeholders must exist in the route path")
+
+	route.UpstreamPath = "internal/avatars"
+	assert.Error(t, route.Validate(), "upstream path must be absolute")
+
+	route.UpstreamPath = "/internal/avatars"
+	route.StripPrefix = "/users"
+	assert.Error(t, route.Validate(), "upstream path excludes strip prefix")
+
+	route.StripPrefix = ""
+	route.Path = "/users/{id}/friends/{id}"
+	assert.Error(t, route.Validate(), "duplicate parameters should be rejected")
+}
+
+func TestRouteConfig_MatchPathParams(t *testing.T) {
+	route := &models.RouteConfig{Path: "/users/{id}/avatar", Method: []string{"GET"}, Enabled: true}
+	assert.True(t, route.Match("", "/users/42/avatar", "GET", nil))
+	assert.False(t, route.Match("", "/users/42/7/avatar", "GET", nil))
+	assert.False(t, route.Match("", "/users//avatar", "GET", nil))
+}
+
+func TestRouteConfig_PathParamValues(t *testing.T) {
+	route := &models.RouteConfig{Path: "/api/v1/users/{id}/orders/{order_id}"}
+	assert.Equal(t, map[string]string{"id": "42", "order_id": "a-7"}, route.PathParamValues("/api/v1/users/42/orders/a-7"))
+	assert.Nil(t, route.PathParamValues("/api/v1/users/42/orders"))
+	assert.Nil(t, route.PathParamValues("/api/v1/users/42/x/orders/7"), "a parameter matches exactly one segment")
+
+	wildcard := &models.RouteConfig{Path: "/api/*", Method: []string{"GET"}, Enabled: true}
+	assert.Nil(t, wildcard.PathParamValues("/api/users/42"))
+	assert.True(t, wildcard.Match("", "/api/users/42", "GET", nil), "wildcards still match any number of segments")
+}
+
+func TestRouteConfig_PathParamValidation(t *testing.T) {
+	newRoute := func(path string) models.RouteConfig {
+		return models.RouteConfig{ID: "route", Path: path, Method: []string{"GET"}, Backend: "backend"}
+	}
+
+	for _, path := range []string{"/users/{id}", "/users/{user_id}/orders/{_order2}", "/files/*"} {
+		route := newRoute(path)
+		assert.NoError(t, route.Validate(), path)
+	}
+	for _, path := range []string{"/users/{}", "/users/{1id}", "/users/{id-x}", "/users/{id", "/users/id}", "/users/{a}/{a}"} {
+		route := newRoute(path)
+		assert.Error(t, route.Validate(), path)
+	}
+}
+
+func TestRouteConfig_MatchHeaders(t *testing.T) {
+	route := &models.RouteConfig{
+		ID:      "route",
+		Path:    "/api/*",
+		Method:  []string{"GET"},
+		Backend: "backend",
+		Headers: map[string]string{
+			"x-canary":  "true",
+			"X-Version": "~^v2\\.",
+		},
+		Enabled: true,
+	}
+	assert.NoError(t, route.Validate())
+
+	header := http.Header{}
+	header.Set("X-Canary", "true")
+	header.Set("X-Version", "v2.1")
+	assert.True(t, route.Match("", "/api/users", "GET", header), "header names should be case-insensitive")
+
+	header.Set("X-Version", "v1.9")
+	assert.False(t, route.Match("", "/api/users", "GET", header), "regex condition should fail")
+
+	header.Set("X-Version", "v2.0")
+	header.Set("X-Canary", "TRUE")
+	assert.False(t, route.Match("", "/api/users", "GET", header), "exact values are case-sensitive")
+
+	assert.False(t, route.Match("", "/api/users", "GET", nil), "all headers are required")
+
+	route.Headers = map[string]string{"X-Version": "~^v2\\.("}
+	assert.Error(t, route.Validate(), "invalid regex should be rejected")
+	assert.False(t, route.Match("", "/api/users", "GET", header), "an invalid regex never matches")
+}
+
+func TestRouteCollection_FindRoutePrefersHeaderMatch(t *testing.T) {
+	generic := &models.RouteConfig{ID: "generic", Path: "/api/*", Method: []string{"GET"}, Priority: 100, Enabled: true}
+	canary := &models.RouteConfig{
+		ID:       "canary",
+		Path:     "/api/*",
+		Method:   []string{"GET"},
+		Headers:  map[string]string{"X-Canary": "true"},
+		Priority: 100,
+		Enabled:  true,
+	}
+	rc := &models.RouteCollection{Routes: []*models.RouteConfig{generic, canary}}
+
+	header := http.Header{}
+	assert.Equal(t, "generic", rc.FindRoute("", "/api/users", "GET", header).ID)
+
+	header.Set("X-Canary", "true")
+	assert.Equal(t, "canary", rc.FindRoute("", "/api/users", "GET", header).ID)
+
+	generic.Priority = 200
+	assert.Equal(t, "generic", rc.FindRoute("", "/api/users", "GET", header).ID, "priority still wins over specificity")
+}
+
+func TestRouteCollection_FindRouteOverlappingPaths(t *testing.T) {
+	broad := &models.RouteConfig{ID: "broad", Path: "/api/*", Method: []string{"GET"}, Priority: 100, Enabled: true}
+	admin := &models.RouteConfig{ID: "admin", Path: "/api/v1/admin/*", Method: []string{"GET"}, Priority: 100, Enabled: true}
+	user := &models.RouteConfig{ID: "user", Path: "/api/v1/{section}/*", Method: []string{"GET"}, Priority: 100, Enabled: true}
+	rc := &models.RouteCollection{Routes: []*models.RouteConfig{broad, user, admin}}
+
+	assert.Equal(t, "admin", rc.FindRoute("", "/api/v1/admin/users", "GET", nil).ID, "longest literal prefix wins a tie")
+	assert.Equal(t, "user", rc.FindRoute("", "/api/v1/orders/1", "GET", nil).ID)
+	assert.Equal(t, "broad", rc.FindRoute("", "/api/v2", "GET", nil).ID)
+
+	broad.Priority = 200
+	assert.Equal(t, "broad", rc.FindRoute("", "/api/v1/admin/users", "GET", nil).ID, "priority wins over prefix length")
+
+	assert.Nil(t, rc.FindRoute("", "/api/v1/admin/users", "POST", nil))
+	assert.Equal(t, []string{"GET"}, rc.AllowedMethods("", "/api/v1/admin/users", nil))
+	assert.Empty(t, rc.AllowedMethods("", "/other", nil))
+}
+
+func TestRouteConfig_LiteralPrefix(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/api/*":             "/api/",
+		"/users/{id}/avatar": "/users/",
+		"/health":            "/health",
+		"/*":                 "/",
+	} {
+		route := &models.RouteConfig{Path: path}
+		assert.Equal(t, expected, route.LiteralPrefix(), path)
+	}
+}
+
+func TestRouteConfig_ForwardHeadersValidation(t *testing.T) {
+	route := models.RouteConfig{
+		ID:             "route",
+		Path:           "/api/*",
+		Method:         []string{"GET"},
+		Backend:        "primary",
+		ForwardHeaders: &models.ForwardHeadersConfig{Remove: []string{"Authorization"}, Allow: []string{"Accept"}},
+	}
+	assert.NoError(t, route.Validate())
+
+	route.ForwardHeaders.Remove = []string{"Bad Header"}
+	assert.Error(t, route.Validate())
+
+	route.ForwardHeaders.Remove = nil
+	route.ForwardHeaders.Allow = []string{""}
+	assert.Error(t, route.Validate())
+}
+
+func TestRouteConfig_ResponseHeadersValidation(t *testing.T) {
+	route := models.RouteConfig{
+		ID:              "route",
+		Path:            "/api/*",
+		Method:          []string{"GET"},
+		Backend:         "primary",
+		ResponseHeaders: &models.ResponseHeadersConfig{Remove: []string{"X-Internal-*"}, Allow: []string{"Cache-Control"}},
+	}
+	assert.NoError(t, route.Validate())
+
+	route.ResponseHeaders.Remove = []string{"*"}
+	assert.Error(t, route.Validate(), "a bare wildcard is not a header name")
+
+	route.ResponseHeaders.Remove = []string{"Bad Header*"}
+	assert.Error(t, route.Validate())
+}
+
+func TestRouteConfig_VersionValidation(t *testing.T) {
+	route := models.RouteConfig{
+		ID:      "route",
+		Path:    "/api/*/users",
+		Method:  []string{"GET"},
+		Backend: "primary",
+		Version: &models.VersionConfig{Versions: []string{"1", "2"}, Segment: 2},
+	}
+	assert.NoError(t, route.Validate())
+
+	route.Version.Header = "X-API-Version"
+	assert.Error(t, route.Validate(), "only one version source may be set")
+
+	route.Version.Segment = 0
+	assert.NoError(t, route.Validate())
+
+	route.Version.Versions = []string{"v1"}
+	assert.Error(t, route.Validate(), "versions are listed without the v prefix")
+
+	route.Version.Versions = nil
+	assert.Error(t, route.Validate())
+}
+
+func TestRouteConfig_MatchVersion(t *testing.T) {
+	byPath := &models.RouteConfig{
+		Path:    "/api/*",
+		Method:  []string{"GET"},
+		Enabled: true,
+		Version: &models.VersionConfig{Versions: []string{"1", "2"}, Segment: 2},
+	}
+	assert.True(t, byPath.Match("", "/api/v1/users", "GET", nil))
+	assert.True(t, byPath.Match("", "/api/2/users", "GET", nil))
+	assert.False(t, byPath.Match("", "/api/v3/users", "GET", nil))
+	assert.False(t, byPath.Match("", "/api", "GET", nil))
+	assert.Equal(t, "2", byPath.APIVersion("/api/v2/users", nil))
+
+	byHeader := &models.RouteConfig{
+		Path:    "/api/*",
+		Method:  []string{"GET"},
+		Enabled: true,
+		Version: &models.VersionConfig{Versions: []string{"2"}, Header: "X-API-Version"},
+	}
+	assert.True(t, byHeader.Match("", "/api/users", "GET", http.Header{"X-Api-Version": {"2"}}))
+	assert.False(t, byHeader.Match("", "/api/users", "GET", http.Header{"X-Api-Version": {"1"}}))
+	assert.False(t, byHeader.Match("", "/api/users", "GET", http.Header{}))
+
+	// The versioned route wins over an unversioned one on the same path
+	unversioned := &models.RouteConfig{Path: "/api/*", Method: []string{"GET"}, Enabled: true}
+	collection := &models.RouteCollection{Routes: []*models.RouteConfig{unversioned, byHeader}}
+	assert.Same(t, byHeader, collection.FindRoute("", "/api/users", "GET", http.Header{"X-Api-Version": {"2"}}))
+	assert.Same(t, unversioned, collection.FindRoute("", "/api/users", "GET", http.Header{}))
+}
+
+func TestRouteConfig_UsesBackend(t *testing.T) {
+	route := models.RouteConfig{Backend: "primary", CanaryBackend: "canary", FallbackBackend: "fallback"}
+	for _, id := range []string{"primary", "canary", "fallback"} {
+		assert.True(t, route.UsesBackend(id), id)
+	}
+	assert.False(t, route.UsesBackend("other"))
+}
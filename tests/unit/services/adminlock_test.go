@@ -0,0 +1,127 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/adminlock"
+	"github.com/your-org/ryohi-router/src/services/events"
+)
+
+// newTestLock creates a read-only lock on a virtual clock, logging and publishing audit entries
+func newTestLock(t *testing.T, window time.Duration) (*adminlock.Lock, *clock.Virtual, *syncBuffer, *events.Subscription) {
+	var logs syncBuffer
+	bus := events.NewBus(16, 4)
+	t.Cleanup(bus.Close)
+	sub, _, err := bus.Subscribe([]string{events.TopicAdminLock}, 0)
+	require.NoError(t, err)
+
+	virtual := clock.NewVirtual(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	lock := adminlock.New(true, window, slog.New(slog.NewTextHandler(&logs, nil)))
+	lock.SetClock(virtual)
+	lock.SetEventBus(bus)
+	t.Cleanup(lock.Stop)
+	return lock, virtual, &logs, sub
+}
+
+// nextLockEvent returns the next audit event published for the lock
+func nextLockEvent(t *testing.T, sub *events.Subscription) map[string]interface{} {
+	select {
+	case event := <-sub.Events():
+		return event.Data.(map[string]interface{})
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for admin lock event")
+		return nil
+	}
+}
+
+func TestAdminLock_RejectsMutationsWhileLocked(t *testing.T) {
+	lock, _, logs, _ := newTestLock(t, time.Minute)
+	handler := adminlock.Middleware(lock, "/admin/unlock")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serveAdmin := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, serveAdmin(http.MethodGet, "/admin/routes").Code, "reads should be allowed")
+	assert.Equal(t, http.StatusNoContent, serveAdmin(http.MethodPost, "/admin/unlock").Code, "exempt paths should be allowed")
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		w := serveAdmin(method, "/admin/routes/api")
+		require.Equal(t, http.StatusForbidden, w.Code, method)
+
+		var body models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "admin_locked", body.Code)
+		assert.Equal(t, map[string]interface{}{"read_only": true, "locked": true}, body.Details["lock"])
+	}
+	assert.Contains(t, logs.String(), "Admin mutation rejected while locked")
+
+	lock.Unlock(adminlock.Actor{})
+	assert.Equal(t, http.StatusNoContent, serveAdmin(http.MethodDelete, "/admin/routes/api").Code, "mutations should be allowed once unlocked")
+}
+
+func TestAdminLock_UnlockWindowExpires(t *testing.T) {
+	lock, virtual, logs, sub := newTestLock(t, 10*time.Minute)
+	require.True(t, lock.Locked(), "a read-only lock should start locked")
+
+	status := lock.Unlock(adminlock.Actor{RemoteAddr: "10.0.0.1:4242", RequestID: "req-1"})
+	assert.False(t, status.Locked)
+	require.NotNil(t, status.UnlockedUntil)
+	assert.Equal(t, virtual.Now().Add(10*time.Minute), *status.UnlockedUntil)
+
+	unlocked := nextLockEvent(t, sub)
+	assert.Equal(t, "unlock", unlocked["action"])
+	assert.Equal(t, "10.0.0.1:4242", unlocked["remote_addr"])
+	assert.Equal(t, "req-1", unlocked["request_id"])
+	assert.Contains(t, logs.String(), "Admin API unlocked")
+
+	virtual.Advance(9 * time.Minute)
+	assert.False(t, lock.Locked(), "mutations should stay allowed within the window")
+
+	virtual.Advance(time.Minute)
+	assert.True(t, lock.Locked(), "the lock should revert once the window has passed")
+	assert.Nil(t, lock.Status().UnlockedUntil)
+
+	expired := nextLockEvent(t, sub)
+	assert.Equal(t, "expire", expired["action"])
+	assert.Contains(t, logs.String(), "Admin API locked")
+}
+
+func TestAdminLock_Relock(t *testing.T) {
+	lock, _, _, sub := newTestLock(t, 10*time.Minute)
+
+	lock.Unlock(adminlock.Actor{})
+	nextLockEvent(t, sub)
+
+	status := lock.Relock(adminlock.Actor{RequestID: "req-2"})
+	assert.True(t, status.Locked)
+
+	relocked := nextLockEvent(t, sub)
+	assert.Equal(t, "relock", relocked["action"])
+	assert.Equal(t, "req-2", relocked["request_id"])
+}
+
+func TestAdminLock_NotReadOnly(t *testing.T) {
+	lock := adminlock.New(false, time.Minute, slog.Default())
+	assert.False(t, lock.Locked())
+
+	handler := adminlock.Middleware(lock)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/routes/api", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/server"
+)
+
+// Allocation budgets for a proxied GET, counted across the router and the test backend.
+// They sit about 1.5x above what was measured when they were set (118 allocs and 47 KB
+// for the router, 164 allocs and 51 KB for the main handler), so they catch a regression
+// such as a new per-request buffer or map without failing on noise. Lower them when the
+// hot path gets cheaper; run the benchmarks with -benchmem for the current numbers.
+const (
+	routerAllocBudget      = 180
+	routerBytesBudget      = 72 << 10
+	mainHandlerAllocBudget = 250
+	mainHandlerBytesBudget = 78 << 10
+)
+
+// okBackend is a backend answering every request with a short body
+func okBackend(tb testing.TB) *httptest.Server {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	}))
+	tb.Cleanup(backend.Close)
+	return backend
+}
+
+// routerHotPath is the route handler alone
+func routerHotPath(tb testing.TB) http.Handler {
+	return transportHandler(tb, okBackend(tb).URL, nil)
+}
+
+// mainHandlerHotPath is the main server handler, with the middleware chain in front of the route
+func mainHandlerHotPath(tb testing.TB) http.Handler {
+	backend := okBackend(tb)
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+
+	srv, err := server.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(tb, err)
+	return srv.GetMainHandler()
+}
+
+// benchmarkHotPath sends b.N GETs through the handler one at a time
+func benchmarkHotPath(b *testing.B, handler http.Handler) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
+
+func BenchmarkRouter_HotPath(b *testing.B) {
+	benchmarkHotPath(b, routerHotPath(b))
+}
+
+func BenchmarkServer_MainHandler(b *testing.B) {
+	benchmarkHotPath(b, mainHandlerHotPath(b))
+}
+
+func TestHotPath_AllocationBudgets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocation budgets run benchmarks")
+	}
+
+	budgets := []struct {
+		name    string
+		handler func(testing.TB) http.Handler
+		allocs  int64
+		bytes   int64
+	}{
+		{name: "router", handler: routerHotPath, allocs: routerAllocBudget, bytes: routerBytesBudget},
+		{name: "main handler", handler: mainHandlerHotPath, allocs: mainHandlerAllocBudget, bytes: mainHandlerBytesBudget},
+	}
+
+	for _, tt := range budgets {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handler(t)
+			result := testing.Benchmark(func(b *testing.B) {
+				benchmarkHotPath(b, handler)
+			})
+
+			t.Logf("%d allocs/op, %d B/op", result.AllocsPerOp(), result.AllocedBytesPerOp())
+			assert.LessOrEqual(t, result.AllocsPerOp(), tt.allocs, "allocations per request exceed the budget")
+			assert.LessOrEqual(t, result.AllocedBytesPerOp(), tt.bytes, "bytes allocated per request exceed the budget")
+		})
+	}
+}
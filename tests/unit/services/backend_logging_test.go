@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// debugLogLines returns the decoded backend debug log lines
+func debugLogLines(t *testing.T, logs string) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if strings.HasPrefix(entry["msg"].(string), "Backend debug") {
+			lines = append(lines, entry)
+		}
+	}
+	return lines
+}
+
+func TestRouter_BackendDebugLogging(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		io.WriteString(w, `{"result":"ok"}`)
+	}))
+	defer backend.Close()
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Backends[0].Logging = &models.BackendLoggingConfig{Debug: true, LogBody: true, MaxBodyBytes: 16}
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Method = []string{"POST"}
+	quiet := cfg.Routes[0]
+	quiet.ID = "quiet-route"
+	quiet.Backend = "canary"
+	cfg.Routes = append(cfg.Routes, quiet)
+
+	var logs syncBuffer
+	r, err := router.New(cfg, slog.New(slog.NewJSONHandler(&logs, nil)))
+	require.NoError(t, err)
+
+	send := func(route *models.RouteConfig) {
+		req := httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader(`{"name":"a fairly long request body"}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Request-ID", "debug-1")
+		w := httptest.NewRecorder()
+		r.CreateHandler(route).ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	t.Run("the flagged backend logs requests and responses", func(t *testing.T) {
+		send(&cfg.Routes[0])
+
+		lines := debugLogLines(t, logs.String())
+		require.Len(t, lines, 2)
+
+		request, response := lines[0], lines[1]
+		assert.Equal(t, "Backend debug request", request["msg"])
+		assert.Equal(t, "primary", request["backend"])
+		assert.Equal(t, "POST", request["method"])
+		assert.Equal(t, "debug-1", request["request_id"])
+		assert.Equal(t, `{"name":"a fairl...(truncated)`, request["body"], "bodies are cut at max_body_bytes")
+
+		assert.Equal(t, "Backend debug response", response["msg"])
+		assert.Equal(t, "primary", response["backend"])
+		assert.Equal(t, float64(http.StatusOK), response["status"])
+		assert.Equal(t, `{"result":"ok"}`, response["body"])
+		assert.Equal(t, float64(len(`{"result":"ok"}`)), response["bytes"])
+
+		assert.NotContains(t, logs.String(), "secret", "credentials are redacted")
+		assert.Contains(t, logs.String(), "[REDACTED]")
+	})
+
+	t.Run("other backends stay quiet", func(t *testing.T) {
+		before := len(debugLogLines(t, logs.String()))
+		send(&cfg.Routes[1])
+		assert.Len(t, debugLogLines(t, logs.String()), before)
+	})
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// newBodyLimitRoute starts a backend answering with the size of the body it received
+// and returns a POST route handler for it, configured by configure
+func newBodyLimitRoute(t *testing.T, configure func(cfg *config.Config)) (http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, strconv.Itoa(len(body)))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createCanaryConfig(server.URL, server.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Method = []string{"POST"}
+	configure(cfg)
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return r.CreateHandler(&cfg.Routes[0]), &calls
+}
+
+// post sends a body through the handler; chunked hides its length as a streamed upload does
+func post(handler http.Handler, size int, chunked bool) *httptest.ResponseRecorder {
+	var body io.Reader = strings.NewReader(strings.Repeat("x", size))
+	if chunked {
+		body = io.MultiReader(body) // not a type NewRequest can measure
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", body)
+	if chunked {
+		req.ContentLength = -1
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRouter_MaxBodyBytes(t *testing.T) {
+	handler, calls := newBodyLimitRoute(t, func(cfg *config.Config) {
+		cfg.Routes[0].MaxBodyBytes = 1024
+	})
+
+	t.Run("forwards a body at the limit", func(t *testing.T) {
+		w := post(handler, 1024, false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1024", w.Body.String())
+	})
+
+	t.Run("rejects a declared body over the limit before forwarding", func(t *testing.T) {
+		before := calls.Load()
+		w := post(handler, 1025, false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "body_too_large")
+		assert.Equal(t, before, calls.Load(), "the backend should not be called")
+	})
+
+	t.Run("forwards a streamed body at the limit", func(t *testing.T) {
+		w := post(handler, 1024, true)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1024", w.Body.String())
+	})
+
+	t.Run("cuts off a streamed body over the limit", func(t *testing.T) {
+		w := post(handler, 1025, true)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), `"max_bytes":1024`)
+	})
+}
+
+func TestRouter_MaxBodyBytesDefault(t *testing.T) {
+	handler, _ := newBodyLimitRoute(t, func(cfg *config.Config) {
+		cfg.Router.MaxBodyBytes = 100
+	})
+	assert.Equal(t, http.StatusOK, post(handler, 100, false).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(handler, 101, false).Code)
+
+	overridden, _ := newBodyLimitRoute(t, func(cfg *config.Config) {
+		cfg.Router.MaxBodyBytes = 100
+		cfg.Routes[0].MaxBodyBytes = 200
+	})
+	assert.Equal(t, http.StatusOK, post(overridden, 200, false).Code, "the route's limit replaces the default")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(overridden, 201, false).Code)
+
+	unlimited, _ := newBodyLimitRoute(t, func(cfg *config.Config) {})
+	assert.Equal(t, http.StatusOK, post(unlimited, 1<<20, false).Code)
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// newCachedRoute starts a counting backend and returns a cached route handler for it
+func newCachedRoute(t *testing.T, cache *models.CacheConfig, backend http.HandlerFunc) (*router.Router, http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		backend(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createCanaryConfig(server.URL, server.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	require.NoError(t, cache.Validate())
+	cfg.Routes[0].Cache = cache
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return r, r.CreateHandler(&cfg.Routes[0]), &calls
+}
+
+// get sends a GET request through the handler
+func get(handler http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRouter_ResponseCache(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, r.URL.RawQuery+"|"+r.Header.Get("Accept-Language"))
+	}
+
+	t.Run("stores responses without Cache-Control", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "rows")
+		})
+
+		get(handler, "/api/rows", nil)
+		w := get(handler, "/api/rows", nil)
+		assert.Empty(t, w.Header().Get("Cache-Control"))
+		assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("serves repeated GETs from the cache", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, echo)
+
+		first := get(handler, "/api/rows?page=1", nil)
+		assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+
+		second := get(handler, "/api/rows?page=1", nil)
+		assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "text/plain", second.Header().Get("Content-Type"))
+		assert.Equal(t, int32(1), calls.Load())
+
+		other := get(handler, "/api/rows?page=2", nil)
+		assert.Equal(t, "MISS", other.Header().Get("X-Cache"))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("keys on configured vary headers", func(t *testing.T) {
+		cache := &models.CacheConfig{Enabled: true, VaryHeaders: []string{"Accept-Language"}}
+		_, handler, calls := newCachedRoute(t, cache, echo)
+
+		get(handler, "/api/rows", http.Header{"Accept-Language": {"ja"}})
+		en := get(handler, "/api/rows", http.Header{"Accept-Language": {"en"}})
+		assert.Equal(t, "MISS", en.Header().Get("X-Cache"))
+		assert.Equal(t, "|en", en.Body.String())
+
+		ja := get(handler, "/api/rows", http.Header{"Accept-Language": {"ja"}})
+		assert.Equal(t, "HIT", ja.Header().Get("X-Cache"))
+		assert.Equal(t, "|ja", ja.Body.String())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("expires entries after the TTL", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true, TTL: 50 * time.Millisecond}, echo)
+
+		get(handler, "/api/rows", nil)
+		time.Sleep(100 * time.Millisecond)
+		w := get(handler, "/api/rows", nil)
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true, MaxEntries: 2}, echo)
+
+		get(handler, "/api/rows?page=1", nil)
+		get(handler, "/api/rows?page=2", nil)
+		get(handler, "/api/rows?page=1", nil) // page=1 is now most recently used
+		get(handler, "/api/rows?page=3", nil) // evicts page=2
+
+		assert.Equal(t, "HIT", get(handler, "/api/rows?page=1", nil).Header().Get("X-Cache"))
+		assert.Equal(t, "MISS", get(handler, "/api/rows?page=2", nil).Header().Get("X-Cache"))
+		assert.Equal(t, int32(4), calls.Load())
+	})
+
+	t.Run("does not store no-store or error responses", func(t *testing.T) {
+		var status atomic.Int32
+		status.Store(http.StatusOK)
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("store") == "no" {
+				w.Header().Set("Cache-Control", "no-store")
+			}
+			w.WriteHeader(int(status.Load()))
+		})
+
+		get(handler, "/api/rows?store=no", nil)
+		get(handler, "/api/rows?store=no", nil)
+		assert.Equal(t, int32(2), calls.Load())
+
+		status.Store(http.StatusInternalServerError)
+		get(handler, "/api/rows", nil)
+		w := get(handler, "/api/rows", nil)
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(4), calls.Load())
+	})
+
+	t.Run("purges a route's cache", func(t *testing.T) {
+		r, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, echo)
+
+		for i := 0; i < 3; i++ {
+			get(handler, fmt.Sprintf("/api/rows?page=%d", i), nil)
+		}
+
+		purged, exists := r.PurgeCache("canary-route")
+		assert.True(t, exists)
+		assert.Equal(t, 3, purged)
+
+		get(handler, "/api/rows?page=0", nil)
+		assert.Equal(t, int32(4), calls.Load())
+
+		_, exists = r.PurgeCache("unknown-route")
+		assert.False(t, exists)
+	})
+
+	t.Run("answers a matching If-None-Match with 304", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, echo)
+
+		get(handler, "/api/rows", nil)
+		cached := get(handler, "/api/rows", nil)
+		etag := cached.Header().Get("ETag")
+		require.NotEmpty(t, etag, "cached responses should carry an ETag")
+
+		w := get(handler, "/api/rows", http.Header{"If-None-Match": {`"stale", ` + etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+
+		w = get(handler, "/api/rows", http.Header{"If-None-Match": {`"stale"`}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, cached.Body.String(), w.Body.String())
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("keeps the backend's ETag", func(t *testing.T) {
+		_, handler, _ := newCachedRoute(t, &models.CacheConfig{Enabled: true}, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v42"`)
+			io.WriteString(w, "rows")
+		})
+
+		get(handler, "/api/rows", nil)
+		w := get(handler, "/api/rows", http.Header{"If-None-Match": {`W/"v42"`}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, `"v42"`, w.Header().Get("ETag"))
+	})
+}
+
+func TestRouter_StaleCache(t *testing.T) {
+	t.Run("serves a stale response when the backend errors after expiry", func(t *testing.T) {
+		var failing atomic.Bool
+		cache := &models.CacheConfig{Enabled: true, TTL: 50 * time.Millisecond, StaleIfError: time.Minute}
+		_, handler, calls := newCachedRoute(t, cache, func(w http.ResponseWriter, r *http.Request) {
+			if failing.Load() {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			io.WriteString(w, "rows")
+		})
+
+		get(handler, "/api/rows", nil)
+		time.Sleep(100 * time.Millisecond)
+		failing.Store(true)
+
+		w := get(handler, "/api/rows", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "rows", w.Body.String())
+		assert.Equal(t, "STALE", w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(2), calls.Load(), "the backend should still be tried")
+
+		// Once the backend recovers its response replaces the stale entry
+		failing.Store(false)
+		w = get(handler, "/api/rows", nil)
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Equal(t, "HIT", get(handler, "/api/rows", nil).Header().Get("X-Cache"))
+	})
+
+	t.Run("does not serve stale on error without stale_if_error", func(t *testing.T) {
+		var failing atomic.Bool
+		cache := &models.CacheConfig{Enabled: true, TTL: 50 * time.Millisecond}
+		_, handler, _ := newCachedRoute(t, cache, func(w http.ResponseWriter, r *http.Request) {
+			if failing.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			io.WriteString(w, "rows")
+		})
+
+		get(handler, "/api/rows", nil)
+		time.Sleep(100 * time.Millisecond)
+		failing.Store(true)
+
+		w := get(handler, "/api/rows", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	})
+
+	t.Run("serves stale while revalidating in the background", func(t *testing.T) {
+		var version atomic.Int32
+		cache := &models.CacheConfig{Enabled: true, TTL: 50 * time.Millisecond, StaleWhileRevalidate: time.Minute}
+		_, handler, calls := newCachedRoute(t, cache, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "v%d", version.Load())
+		})
+
+		get(handler, "/api/rows", nil)
+		time.Sleep(100 * time.Millisecond)
+		version.Store(1)
+
+		w := get(handler, "/api/rows", nil)
+		assert.Equal(t, "v0", w.Body.String())
+		assert.Equal(t, "STALE", w.Header().Get("X-Cache"))
+
+		assert.Eventually(t, func() bool {
+			w := get(handler, "/api/rows", nil)
+			return w.Header().Get("X-Cache") == "HIT" && w.Body.String() == "v1"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), calls.Load(), "stale requests should share one refresh")
+	})
+}
+
+func TestRouter_CacheServesWhileCircuitOpen(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.WriteString(w, "rows")
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createCanaryConfig(server.URL, server.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Cache = &models.CacheConfig{Enabled: true, TTL: 50 * time.Millisecond}
+	require.NoError(t, cfg.Routes[0].Cache.Validate())
+	cfg.Backends[0].CircuitBreaker = models.CircuitBreakerConfig{Enabled: true, MinimumRequests: 1, FailureRatio: 0.5, Interval: time.Minute, Timeout: time.Minute}
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	get(handler, "/api/rows", nil)
+	time.Sleep(100 * time.Millisecond)
+
+	breaker, _ := r.GetCircuitBreaker("primary")
+	breaker.RecordResult(false)
+	require.Equal(t, models.StateOpen, breaker.GetState())
+
+	w := get(handler, "/api/rows", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "rows", w.Body.String(), "the last known good response should be served")
+	assert.Equal(t, "STALE", w.Header().Get("X-Cache"))
+	assert.Equal(t, int32(1), calls.Load())
+
+	w = get(handler, "/api/other", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "uncached requests still fail fast")
+
+	// Once the circuit closes expired entries are refreshed as usual
+	breaker.Reset()
+	w = get(handler, "/api/rows", nil)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRouter_CacheBypassedForCredentials(t *testing.T) {
+	whoami := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Authorization")+r.Header.Get("X-API-Key"))
+	}
+
+	t.Run("requests with different bearer tokens don't share an entry", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, whoami)
+
+		alice := get(handler, "/api/me", http.Header{"Authorization": {"Bearer alice"}})
+		bob := get(handler, "/api/me", http.Header{"Authorization": {"Bearer bob"}})
+		assert.Equal(t, "Bearer alice", alice.Body.String())
+		assert.Equal(t, "Bearer bob", bob.Body.String())
+		assert.Empty(t, bob.Header().Get("X-Cache"))
+
+		anonymous := get(handler, "/api/me", nil)
+		assert.Equal(t, "MISS", anonymous.Header().Get("X-Cache"), "authenticated responses should not be stored")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("requests with an API key bypass the cache", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, whoami)
+
+		get(handler, "/api/me", http.Header{"X-API-Key": {"key-1"}})
+		w := get(handler, "/api/me", http.Header{"X-API-Key": {"key-2"}})
+		assert.Equal(t, "key-2", w.Body.String())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("routes with auth bypass the cache", func(t *testing.T) {
+		for name, configure := range map[string]func(route *models.RouteConfig){
+			"auth":        func(route *models.RouteConfig) { route.Auth = &models.AuthConfig{Enabled: true, Type: "bearer"} },
+			"auth policy": func(route *models.RouteConfig) { route.AuthPolicy = "tenant-a" },
+		} {
+			t.Run(name, func(t *testing.T) {
+				server := newNamedBackend(t, "rows")
+				cfg := createCanaryConfig(server.URL, server.URL, 0)
+				cfg.Routes[0].CanaryBackend = ""
+				cfg.Routes[0].Cache = &models.CacheConfig{Enabled: true}
+				require.NoError(t, cfg.Routes[0].Cache.Validate())
+				configure(&cfg.Routes[0])
+
+				r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+				require.NoError(t, err)
+				handler := r.CreateHandler(&cfg.Routes[0])
+				get(handler, "/api/rows", nil)
+				assert.Empty(t, get(handler, "/api/rows", nil).Header().Get("X-Cache"))
+			})
+		}
+	})
+
+	t.Run("a route may opt in to sharing authenticated responses", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true, Authenticated: true}, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "catalog")
+		})
+
+		get(handler, "/api/catalog", http.Header{"Authorization": {"Bearer alice"}})
+		w := get(handler, "/api/catalog", http.Header{"Authorization": {"Bearer bob"}})
+		assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("private responses are not stored", func(t *testing.T) {
+		_, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "private, max-age=60")
+			io.WriteString(w, "rows")
+		})
+
+		get(handler, "/api/rows", nil)
+		assert.Equal(t, "MISS", get(handler, "/api/rows", nil).Header().Get("X-Cache"))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// gatherBackendGauge returns a backend's value of a gauge in the default registry
+func gatherBackendGauge(t *testing.T, name, backend string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == "backend" && pair.GetValue() == backend {
+					return metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestRouter_ConcurrencyLimit(t *testing.T) {
+	// The backend holds each request until it is released
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer backend.Close()
+
+	var logs syncBuffer
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Backends[0].MaxConcurrentRequests = 1
+	cfg.Backends[0].QueueTimeout = 50 * time.Millisecond
+	require.NoError(t, cfg.Backends[0].Validate())
+
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	r, err := router.New(cfg, logger)
+	require.NoError(t, err)
+	handler := middleware.Logger(logger)(r.CreateHandler(&cfg.Routes[0]))
+
+	send := func() <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+			done <- w
+		}()
+		return done
+	}
+
+	rejectedBefore := gatherCounter(t, "backend_queue_rejections_total", map[string]string{"backend": "primary"})
+
+	first := send()
+	<-arrived
+	assert.Equal(t, 1.0, gatherBackendGauge(t, "backend_requests_in_flight", "primary"))
+
+	t.Run("requests over the limit are rejected after the queue timeout", func(t *testing.T) {
+		start := time.Now()
+		w := <-send()
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		var body models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "concurrency_limit", body.Details["error_class"])
+
+		assert.Equal(t, rejectedBefore+1, gatherCounter(t, "backend_queue_rejections_total", map[string]string{"backend": "primary"}))
+		assert.Contains(t, logs.String(), `msg="Backend concurrency limit reached"`)
+	})
+
+	t.Run("queued requests take the slot when it frees up", func(t *testing.T) {
+		queued := send()
+		time.Sleep(10 * time.Millisecond)
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, (<-first).Code)
+
+		<-arrived
+		release <- struct{}{}
+		assert.Equal(t, http.StatusOK, (<-queued).Code)
+		assert.Contains(t, logs.String(), "queue_wait=", "the request log should include the queue wait")
+	})
+
+	assert.Zero(t, gatherBackendGauge(t, "backend_requests_in_flight", "primary"))
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// gatherCounter returns the value of a counter in the default registry with the given labels
+func gatherCounter(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if value, ok := labels[pair.GetName()]; ok && value != pair.GetValue() {
+					continue metrics
+				}
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent log writes
+type syncBuffer struct {
+	buf   bytes.Buffer
+	mutex sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestRouter_ClientDisconnect(t *testing.T) {
+	// The backend reports when its request context is cancelled
+	upstreamCancelled := make(chan time.Time, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") == "true" {
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "partial")
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			upstreamCancelled <- time.Now()
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer backend.Close()
+
+	var logs syncBuffer
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].ID = "disconnect-route"
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+
+	routerServer := httptest.NewServer(r.CreateHandler(&cfg.Routes[0]))
+	defer routerServer.Close()
+
+	disconnect := func(t *testing.T, target string, readHeaders bool) time.Time {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, routerServer.URL+target, nil)
+		require.NoError(t, err)
+
+		if readHeaders {
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			buf := make([]byte, len("partial"))
+			_, err = io.ReadFull(resp.Body, buf)
+			require.NoError(t, err)
+		} else {
+			time.AfterFunc(50*time.Millisecond, cancel)
+			_, err := http.DefaultClient.Do(req)
+			require.Error(t, err)
+		}
+
+		cancel()
+		return time.Now()
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		readHeaders bool
+		phase       string
+	}{
+		{name: "while waiting for the backend", target: "/api/slow", phase: "upstream"},
+		{name: "while streaming the response", target: "/api/slow?stream=true", readHeaders: true, phase: "response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := map[string]string{"route": "disconnect-route", "phase": tt.phase}
+			before := gatherCounter(t, "client_disconnects_total", labels)
+
+			abortedAt := disconnect(t, tt.target, tt.readHeaders)
+
+			select {
+			case cancelledAt := <-upstreamCancelled:
+				assert.Less(t, cancelledAt.Sub(abortedAt), 250*time.Millisecond, "upstream request should be cancelled promptly")
+			case <-time.After(2 * time.Second):
+				t.Fatal("upstream request was not cancelled")
+			}
+
+			assert.Eventually(t, func() bool {
+				return gatherCounter(t, "client_disconnects_total", labels) == before+1
+			}, time.Second, 10*time.Millisecond)
+			assert.Contains(t, logs.String(), "phase="+tt.phase)
+		})
+	}
+
+	assert.Contains(t, logs.String(), `msg="Client disconnected"`)
+	assert.NotContains(t, logs.String(), "Proxy error", "disconnects should not be logged as proxy errors")
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/services/drift"
+)
+
+const driftConfig = `
+router:
+  port: 8080
+backends:
+  - id: users
+    name: users
+    enabled: true
+    endpoints:
+      - url: http://localhost:3000
+        weight: 1
+routes:
+  - id: users-route
+    path: /users
+    method: [GET]
+    backend: users
+    enabled: true
+`
+
+func TestDriftDetector_PeriodicCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(driftConfig), 0o644))
+
+	running, err := config.Load(path)
+	require.NoError(t, err)
+	require.NoError(t, running.Validate())
+
+	var logs syncBuffer
+	detector := drift.NewDetector(path, running, 10*time.Millisecond, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	detector.Start(ctx)
+	defer detector.Stop()
+
+	assert.Eventually(t, func() bool {
+		return !detector.Status().CheckedAt.IsZero()
+	}, time.Second, 5*time.Millisecond)
+	assert.False(t, detector.Status().Drifted)
+
+	// Editing the file without reloading drifts
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(driftConfig, "/users", "/people", 1)), 0o644))
+	assert.Eventually(t, func() bool {
+		return detector.Status().Drifted
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []config.Change{{Path: "routes.users-route", Type: config.ChangeChanged}}, detector.Status().Changes)
+	assert.Contains(t, logs.String(), `msg="Config drift detected"`)
+	assert.Contains(t, logs.String(), "routes.users-route changed")
+
+	// Restoring the file resolves it
+	require.NoError(t, os.WriteFile(path, []byte(driftConfig), 0o644))
+	assert.Eventually(t, func() bool {
+		return !detector.Status().Drifted
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, logs.String(), `msg="Config drift resolved"`)
+	assert.Equal(t, 1, strings.Count(logs.String(), "Config drift detected"), "drift should be logged once when it appears")
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// endpointList builds a healthy endpoint of weight 1 for each URL
+func endpointList(urls ...string) []models.EndpointConfig {
+	endpoints := make([]models.EndpointConfig, len(urls))
+	for i, url := range urls {
+		endpoints[i] = models.EndpointConfig{URL: url, Weight: 1, Healthy: true}
+	}
+	return endpoints
+}
+
+func TestRouter_UpdateBackendEndpoints(t *testing.T) {
+	first := newNamedBackend(t, "first")
+	second := newNamedBackend(t, "second")
+	canary := newNamedBackend(t, "canary")
+
+	cfg := createCanaryConfig(first.URL, canary.URL, 0)
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	route := cfg.Routes[0]
+	handler := r.CreateHandler(&route)
+	backend, _ := r.GetBackend("primary")
+	breaker := backend.CircuitBreaker
+
+	changes, err := r.UpdateBackendEndpoints("primary", endpointList(first.URL, second.URL))
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.URL}, changes.Added)
+	assert.Empty(t, changes.Removed)
+	assert.Empty(t, changes.Updated)
+
+	updated, _ := r.GetBackend("primary")
+	require.Same(t, backend, updated, "the backend should be updated in place")
+	assert.Same(t, breaker, updated.CircuitBreaker)
+
+	served := map[string]int{}
+	for i := 0; i < 4; i++ {
+		body, _ := serve(t, handler, fmt.Sprintf("request-%d", i))
+		served[body]++
+	}
+	assert.Equal(t, map[string]int{"first": 2, "second": 2}, served)
+
+	// The drain state of an endpoint that stays is kept
+	require.True(t, backend.SetDraining(second.URL, true))
+	weighted := endpointList(first.URL, second.URL)
+	weighted[1].Weight = 3
+	changes, err = r.UpdateBackendEndpoints("primary", weighted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.URL}, changes.Updated)
+	runtime := backend.Runtime()
+	require.Len(t, runtime.Endpoints, 2)
+	assert.True(t, runtime.Endpoints[1].Draining)
+
+	require.True(t, backend.SetDraining(second.URL, false))
+	changes, err = r.UpdateBackendEndpoints("primary", endpointList(second.URL))
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.URL}, changes.Removed)
+	for i := 0; i < 4; i++ {
+		body, _ := serve(t, handler, fmt.Sprintf("after-removal-%d", i))
+		assert.Equal(t, "second", body)
+	}
+}
+
+func TestRouter_UpdateBackendEndpointsRejectsInvalidLists(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	r, err := router.New(createCanaryConfig(primary.URL, primary.URL, 0), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = r.UpdateBackendEndpoints("unknown", endpointList(primary.URL))
+	assert.ErrorIs(t, err, router.ErrBackendNotFound)
+
+	for name, endpoints := range map[string][]models.EndpointConfig{
+		"empty":      {},
+		"duplicate":  endpointList(primary.URL, primary.URL),
+		"bad url":    endpointList("ftp://files:21"),
+		"bad weight": {{URL: "http://new:3000", Weight: 0, Healthy: true}},
+	} {
+		_, err := r.UpdateBackendEndpoints("primary", endpoints)
+		assert.Error(t, err, name)
+	}
+
+	backend, _ := r.GetBackend("primary")
+	runtime := backend.Runtime()
+	require.Len(t, runtime.Endpoints, 1, "a rejected list should change nothing")
+	assert.Equal(t, primary.URL, runtime.Endpoints[0].URL)
+}
+
+func TestRouter_UpdateBackendEndpointsFinishesRemovedRequests(t *testing.T) {
+	slow, started, release := newSlowBackend(t, "slow")
+	other := newNamedBackend(t, "other")
+
+	cfg := createCanaryConfig(slow.URL, slow.URL, 0)
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	inFlight := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+		inFlight <- rec
+	}()
+	<-started
+
+	_, err = r.UpdateBackendEndpoints("primary", endpointList(other.URL))
+	require.NoError(t, err)
+	body, _ := serve(t, handler, "after-update")
+	assert.Equal(t, "other", body)
+
+	close(release)
+	rec := <-inFlight
+	assert.Equal(t, http.StatusOK, rec.Code, "a request on a removed endpoint should finish")
+	assert.Equal(t, "slow", rec.Body.String())
+}
+
+func TestRouter_ReloadKeepsUpdatedEndpoints(t *testing.T) {
+	first := newNamedBackend(t, "first")
+	second := newNamedBackend(t, "second")
+
+	cfg := createCanaryConfig(first.URL, first.URL, 0)
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	backend, _ := r.GetBackend("primary")
+
+	_, err = r.UpdateBackendEndpoints("primary", endpointList(first.URL, second.URL))
+	require.NoError(t, err)
+
+	// A configuration listing the same endpoints leaves the backend alone
+	reloaded := createCanaryConfig(first.URL, first.URL, 0)
+	reloaded.Backends[0].Endpoints = endpointList(first.URL, second.URL)
+	require.NoError(t, r.Reload(reloaded))
+	kept, _ := r.GetBackend("primary")
+	assert.Same(t, backend, kept)
+
+	// One listing other endpoints rebuilds it with those
+	require.NoError(t, r.Reload(createCanaryConfig(first.URL, first.URL, 0)))
+	rebuilt, _ := r.GetBackend("primary")
+	assert.NotSame(t, backend, rebuilt)
+	assert.Len(t, rebuilt.Runtime().Endpoints, 1)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/flags"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+func TestFlagStore_Precedence(t *testing.T) {
+	store := flags.NewStore(map[string]bool{"new_middleware": true, flags.CanaryRouting: false})
+
+	assert.True(t, store.Enabled("new_middleware"), "configured value applies")
+	assert.False(t, store.Enabled(flags.CanaryRouting), "configuration overrides built-in defaults")
+	assert.True(t, store.Enabled(flags.ResponseCache), "built-in default applies when not configured")
+	assert.False(t, store.Enabled("unknown"), "unknown flags are off")
+
+	store.Set("new_middleware", false)
+	assert.False(t, store.Enabled("new_middleware"), "override takes precedence")
+
+	assert.True(t, store.Reset("new_middleware"))
+	assert.True(t, store.Enabled("new_middleware"), "reset restores the configured value")
+	assert.False(t, store.Reset("new_middleware"))
+
+	var nilStore *flags.Store
+	assert.True(t, nilStore.Enabled(flags.CanaryRouting))
+}
+
+func TestRouter_CanaryFlag(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	canary := newNamedBackend(t, "canary")
+
+	cfg := createCanaryConfig(primary.URL, canary.URL, 100)
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	store := flags.NewStore(nil)
+	r.SetFlags(store)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	served, _ := serve(t, handler, "req-1")
+	assert.Equal(t, "canary", served)
+
+	store.Set(flags.CanaryRouting, false)
+	served, _ = serve(t, handler, "req-1")
+	assert.Equal(t, "primary", served, "disabling the flag stops canary routing")
+
+	store.Reset(flags.CanaryRouting)
+	served, _ = serve(t, handler, "req-1")
+	assert.Equal(t, "canary", served)
+}
+
+func TestFlagStore_RolloutBucketing(t *testing.T) {
+	store := flags.NewStore(nil)
+	store.SetRollouts(map[string]models.FlagRollout{"new_compression": {Percent: 5}})
+
+	on := 0
+	for i := 0; i < 10000; i++ {
+		target := flags.Target{ClientIP: fmt.Sprintf("10.0.%d.%d", i/256, i%256)}
+		first := store.EnabledFor("new_compression", target)
+		for j := 0; j < 3; j++ {
+			require.Equal(t, first, store.EnabledFor("new_compression", target), "a client must stay on one side of the rollout")
+		}
+		if first {
+			on++
+		}
+	}
+	assert.InDelta(t, 500, on, 100, "about 5%% of clients should be in the rollout")
+
+	// Buckets depend only on the flag and client, so every router instance agrees
+	target := flags.Target{APIKey: "key-123", ClientIP: "10.0.0.1"}
+	assert.Equal(t, flags.Bucket("new_compression", target), flags.Bucket("new_compression", flags.Target{APIKey: "key-123", ClientIP: "192.0.2.1"}),
+		"clients with an API key are bucketed by the key")
+	assert.NotEqual(t, flags.Bucket("new_compression", target), flags.Bucket("openapi_validation", target),
+		"each flag buckets clients independently")
+
+	store.SetRollouts(map[string]models.FlagRollout{"none": {Percent: 0}, "all": {Percent: 100}})
+	assert.False(t, store.EnabledFor("none", target))
+	assert.True(t, store.EnabledFor("all", target))
+}
+
+func TestFlagStore_RolloutScopeAndAllowList(t *testing.T) {
+	store := flags.NewStore(nil)
+	store.SetRollouts(map[string]models.FlagRollout{
+		"openapi_validation": {
+			Percent:      0,
+			Routes:       []string{"users-route"},
+			AllowAPIKeys: []string{"tester-key"},
+			AllowIPs:     []string{"192.0.2.10", "10.1.0.0/16"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		target   flags.Target
+		expected bool
+	}{
+		{"outside the allow-list", flags.Target{Route: "users-route", ClientIP: "203.0.113.5"}, false},
+		{"allowed API key", flags.Target{Route: "users-route", APIKey: "tester-key", ClientIP: "203.0.113.5"}, true},
+		{"allowed address", flags.Target{Route: "users-route", ClientIP: "192.0.2.10"}, true},
+		{"allowed network", flags.Target{Route: "users-route", ClientIP: "10.1.44.7"}, true},
+		{"IPv4-mapped address in an allowed network", flags.Target{Route: "users-route", ClientIP: "::ffff:10.1.0.1"}, true},
+		{"allowed client on another route", flags.Target{Route: "orders-route", APIKey: "tester-key"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, store.EnabledFor("openapi_validation", tt.target))
+		})
+	}
+
+	store.Set("openapi_validation", false)
+	assert.False(t, store.EnabledFor("openapi_validation", flags.Target{Route: "users-route", APIKey: "tester-key"}),
+		"turning the flag off overrides the allow-list")
+}
+
+func TestFlagStore_RuntimeRollout(t *testing.T) {
+	store := flags.NewStore(map[string]bool{"new_compression": false})
+	store.SetRollouts(map[string]models.FlagRollout{"new_compression": {Percent: 100}})
+
+	target := flags.Target{ClientIP: "10.0.0.1"}
+	assert.False(t, store.EnabledFor("new_compression", target), "the configured switch keeps a rollout off")
+
+	zero := 0
+	store.Override("new_compression", true, &zero)
+	assert.False(t, store.EnabledFor("new_compression", target), "the override's percent replaces the configured one")
+	flag := store.Get("new_compression")
+	assert.True(t, flag.Enabled)
+	assert.True(t, flag.Overridden)
+	require.NotNil(t, flag.Percent)
+	assert.Equal(t, 0, *flag.Percent)
+
+	store.Override("new_compression", true, nil)
+	assert.True(t, store.EnabledFor("new_compression", target), "without a percent the configured rollout applies")
+
+	store.Reset("new_compression")
+	assert.False(t, store.EnabledFor("new_compression", target))
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// forwardedHeaders sends a request with the given headers through a route and returns
+// the headers the echo backend received
+func forwardedHeaders(t *testing.T, policy *models.ForwardHeadersConfig, header http.Header) http.Header {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(r.Header)
+	}))
+	t.Cleanup(backend.Close)
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].ForwardHeaders = policy
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header = header.Clone()
+	w := httptest.NewRecorder()
+	r.CreateHandler(&cfg.Routes[0]).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var received http.Header
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &received))
+	return received
+}
+
+func TestRouter_ForwardHeaders(t *testing.T) {
+	header := http.Header{
+		"Connection":    {"keep-alive, X-Hop"},
+		"Keep-Alive":    {"timeout=5"},
+		"X-Hop":         {"hop"},
+		"Authorization": {"Bearer secret"},
+		"X-Api-Key":     {"router-key"},
+		"Accept":        {"application/json"},
+		"X-Request-Id":  {"req-1"},
+	}
+
+	t.Run("hop-by-hop headers are always stripped", func(t *testing.T) {
+		received := forwardedHeaders(t, nil, header)
+		for _, name := range []string{"Connection", "Keep-Alive", "X-Hop"} {
+			assert.Empty(t, received.Get(name), name)
+		}
+	})
+
+	t.Run("everything else is forwarded by default", func(t *testing.T) {
+		received := forwardedHeaders(t, nil, header)
+		assert.Equal(t, "Bearer secret", received.Get("Authorization"))
+		assert.Equal(t, "router-key", received.Get("X-API-Key"))
+		assert.Equal(t, "application/json", received.Get("Accept"))
+	})
+
+	t.Run("denylist", func(t *testing.T) {
+		received := forwardedHeaders(t, &models.ForwardHeadersConfig{Remove: []string{"authorization", "X-API-Key"}}, header)
+		assert.Empty(t, received.Get("Authorization"))
+		assert.Empty(t, received.Get("X-API-Key"))
+		assert.Equal(t, "application/json", received.Get("Accept"))
+	})
+
+	t.Run("allowlist", func(t *testing.T) {
+		received := forwardedHeaders(t, &models.ForwardHeadersConfig{Allow: []string{"Accept", "Authorization"}, Remove: []string{"Authorization"}}, header)
+		assert.Equal(t, "application/json", received.Get("Accept"))
+		assert.Equal(t, "req-1", received.Get("X-Request-ID"), "the request ID is always forwarded")
+		assert.Empty(t, received.Get("Authorization"), "the denylist applies to allowed headers too")
+		assert.Empty(t, received.Get("X-API-Key"))
+		assert.NotEmpty(t, received.Get("X-Forwarded-For"), "headers added by the proxy are kept")
+	})
+}
+
+func TestRouter_StripsHopByHopHeaders(t *testing.T) {
+	header := http.Header{
+		"Connection":          {"close, Upgrade, X-Session-Hop"},
+		"Proxy-Connection":    {"keep-alive"},
+		"Keep-Alive":          {"timeout=5"},
+		"Proxy-Authenticate":  {"Basic"},
+		"Proxy-Authorization": {"Basic cHJveHk6c2VjcmV0"},
+		"Te":                  {"gzip"},
+		"Trailer":             {"X-Checksum"},
+		"Upgrade":             {"h2c"}, // only WebSocket upgrades are forwarded
+		"X-Session-Hop":       {"hop"},
+		"Accept":              {"application/json"},
+	}
+
+	received := forwardedHeaders(t, nil, header)
+	for name := range header {
+		if name == "Accept" {
+			continue
+		}
+		assert.Empty(t, received.Get(name), name)
+	}
+	assert.Equal(t, "application/json", received.Get("Accept"))
+}
+
+func TestRouter_PreservesWebSocketUpgrade(t *testing.T) {
+	header := http.Header{
+		"Connection":            {"keep-alive, Upgrade"},
+		"Upgrade":               {"websocket"},
+		"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+		"Sec-Websocket-Version": {"13"},
+		"Keep-Alive":            {"timeout=5"},
+	}
+
+	for name, policy := range map[string]*models.ForwardHeadersConfig{
+		"default":   nil,
+		"allowlist": {Allow: []string{"Sec-WebSocket-Key", "Sec-WebSocket-Version"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			received := forwardedHeaders(t, policy, header)
+			assert.Equal(t, "Upgrade", received.Get("Connection"))
+			assert.Equal(t, "websocket", received.Get("Upgrade"))
+			assert.Equal(t, "13", received.Get("Sec-WebSocket-Version"))
+			assert.Empty(t, received.Get("Keep-Alive"))
+		})
+	}
+}
+
+// returnedHeaders proxies a request to a backend answering with the given headers
+// and returns the headers the client receives
+func returnedHeaders(t *testing.T, policy *models.ResponseHeadersConfig, header http.Header) http.Header {
+	return returnedRouteHeaders(t, func(route *models.RouteConfig) { route.ResponseHeaders = policy }, header)
+}
+
+// returnedRouteHeaders is returnedHeaders for a route set up by configure
+func returnedRouteHeaders(t *testing.T, configure func(*models.RouteConfig), header http.Header) http.Header {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(backend.Close)
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	configure(&cfg.Routes[0])
+	require.NoError(t, cfg.Routes[0].Validate())
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	r.CreateHandler(&cfg.Routes[0]).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Header()
+}
+
+func TestRouter_ResponseHeaders(t *testing.T) {
+	header := http.Header{
+		"Server":        {"nginx/1.25.3"},
+		"X-Powered-By":  {"Express"},
+		"X-Debug-Trace": {"db=12ms"},
+		"X-Internal-Id": {"node-7"},
+		"X-Custom":      {"value"},
+		"Content-Type":  {"text/plain"},
+		"Cache-Control": {"no-store"},
+	}
+
+	t.Run("default denylist strips internal headers", func(t *testing.T) {
+		received := returnedHeaders(t, nil, header)
+		for _, name := range []string{"Server", "X-Powered-By", "X-Debug-Trace"} {
+			assert.Empty(t, received.Get(name), name)
+		}
+		assert.Equal(t, "node-7", received.Get("X-Internal-Id"))
+		assert.Equal(t, "value", received.Get("X-Custom"))
+		assert.Equal(t, "text/plain", received.Get("Content-Type"))
+	})
+
+	t.Run("denylist adds to the defaults", func(t *testing.T) {
+		received := returnedHeaders(t, &models.ResponseHeadersConfig{Remove: []string{"x-internal-*"}}, header)
+		assert.Empty(t, received.Get("X-Internal-Id"))
+		assert.Empty(t, received.Get("Server"))
+		assert.Equal(t, "value", received.Get("X-Custom"))
+	})
+
+	t.Run("allowlist", func(t *testing.T) {
+		received := returnedHeaders(t, &models.ResponseHeadersConfig{Allow: []string{"Cache-Control", "Server"}}, header)
+		assert.Equal(t, "no-store", received.Get("Cache-Control"))
+		assert.Equal(t, "text/plain", received.Get("Content-Type"), "content headers are always returned")
+		assert.Empty(t, received.Get("X-Custom"))
+		assert.Empty(t, received.Get("Server"), "the default denylist applies to allowed headers too")
+	})
+
+	t.Run("defaults can be disabled", func(t *testing.T) {
+		received := returnedHeaders(t, &models.ResponseHeadersConfig{DisableDefaults: true}, header)
+		assert.Equal(t, "nginx/1.25.3", received.Get("Server"))
+		assert.Equal(t, "db=12ms", received.Get("X-Debug-Trace"))
+	})
+}
+
+func TestRouter_SetResponseHeaders(t *testing.T) {
+	header := http.Header{
+		"Cache-Control":   {"max-age=60"},
+		"X-Frame-Options": {"SAMEORIGIN"},
+		"Server":          {"nginx/1.25.3"},
+	}
+	set := map[string]models.SetHeader{
+		// Viper lowercases map keys, so names are canonicalized
+		"cache-control":   {Value: "no-store", Override: true},
+		"x-frame-options": {Value: "DENY", Override: false},
+		"x-api-version":   {Value: "2"},
+		"server":          {Value: "ryohi"},
+	}
+
+	t.Run("set headers follow the override flag", func(t *testing.T) {
+		received := returnedRouteHeaders(t, func(route *models.RouteConfig) { route.SetResponseHeaders = set }, header)
+		assert.Equal(t, "no-store", received.Get("Cache-Control"), "override replaces the backend's value")
+		assert.Equal(t, "SAMEORIGIN", received.Get("X-Frame-Options"), "without override the backend's value wins")
+		assert.Equal(t, "2", received.Get("X-Api-Version"))
+		assert.Equal(t, "ryohi", received.Get("Server"), "headers stripped by the denylist can be set")
+	})
+
+	t.Run("set headers are added after the allowlist", func(t *testing.T) {
+		received := returnedRouteHeaders(t, func(route *models.RouteConfig) {
+			route.ResponseHeaders = &models.ResponseHeadersConfig{Allow: []string{"Cache-Control"}}
+			route.SetResponseHeaders = set
+		}, header)
+		assert.Equal(t, "no-store", received.Get("Cache-Control"))
+		assert.Equal(t, "DENY", received.Get("X-Frame-Options"), "a header filtered out counts as not sent")
+		assert.Equal(t, "2", received.Get("X-Api-Version"))
+	})
+
+	t.Run("invalid header names are rejected", func(t *testing.T) {
+		route := models.RouteConfig{
+			ID: "route", Path: "/api/test", Method: []string{"GET"}, Backend: "backend", Enabled: true,
+			SetResponseHeaders: map[string]models.SetHeader{"bad header": {Value: "x"}},
+		}
+		assert.ErrorContains(t, route.Validate(), "invalid response header name")
+	})
+}
+
+// headerLimitsHandler returns a handler for a route to an echo backend with the given header limits
+func headerLimitsHandler(t *testing.T, limits *models.HeaderLimitsConfig, policy *models.ForwardHeadersConfig) http.Handler {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(r.Header)
+	}))
+	t.Cleanup(backend.Close)
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].ForwardHeaders = policy
+	cfg.Backends[0].ID = "limited"
+	cfg.Routes[0].Backend = "limited"
+	cfg.Backends[0].HeaderLimits = limits
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return r.CreateHandler(&cfg.Routes[0])
+}
+
+func TestRouter_HeaderLimits(t *testing.T) {
+	limits := &models.HeaderLimitsConfig{MaxTotalBytes: 4096, MaxValueBytes: 1024, MaxCount: 50}
+	handler := headerLimitsHandler(t, limits, nil)
+
+	send := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		req.Header = header
+		req.Header.Set("X-Request-ID", "req-431")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	rejected := func(t *testing.T, w *httptest.ResponseRecorder, header, limit string) {
+		t.Helper()
+		require.Equal(t, http.StatusRequestHeaderFieldsTooLarge, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var body models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "request_header_fields_too_large", body.Code)
+		assert.Equal(t, "req-431", body.RequestID)
+		assert.Equal(t, header, body.Details["header"])
+		assert.Equal(t, limit, body.Details["limit"])
+		assert.NotContains(t, w.Body.String(), "secret", "header values must not be echoed")
+	}
+
+	t.Run("headers within the limits are forwarded", func(t *testing.T) {
+		w := send(http.Header{"Cookie": {strings.Repeat("a", 1000)}})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("an oversized single header is rejected", func(t *testing.T) {
+		before := gatherCounter(t, "backend_header_limit_violations_total", map[string]string{"backend": "limited", "limit": "max_value_bytes", "action": "rejected"})
+
+		w := send(http.Header{"Cookie": {"session=secret" + strings.Repeat("x", 1100)}})
+		rejected(t, w, "Cookie", "max_value_bytes")
+
+		after := gatherCounter(t, "backend_header_limit_violations_total", map[string]string{"backend": "limited", "limit": "max_value_bytes", "action": "rejected"})
+		assert.Equal(t, before+1, after)
+	})
+
+	t.Run("many small headers are rejected", func(t *testing.T) {
+		header := http.Header{}
+		for i := 0; i < 60; i++ {
+			header.Add("X-Tag", "secret")
+		}
+		rejected(t, send(header), "X-Tag", "max_count")
+	})
+
+	t.Run("too many header bytes are rejected", func(t *testing.T) {
+		header := http.Header{}
+		for i := 0; i < 6; i++ {
+			header.Set(fmt.Sprintf("X-Blob-%d", i), strings.Repeat("s", 900))
+		}
+		header.Set("X-Blob-Largest", "secret"+strings.Repeat("s", 1000))
+		rejected(t, send(header), "X-Blob-Largest", "max_total_bytes")
+	})
+
+	t.Run("hop-by-hop headers don't count", func(t *testing.T) {
+		w := send(http.Header{"Keep-Alive": {strings.Repeat("k", 2000)}})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestRouter_HeaderLimitsStripOverLimit(t *testing.T) {
+	limits := &models.HeaderLimitsConfig{MaxValueBytes: 1024}
+	handler := headerLimitsHandler(t, limits, &models.ForwardHeadersConfig{StripOverLimit: []string{"cookie"}})
+
+	send := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		req.Header = header
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("an oversized stripped header is dropped and the request continues", func(t *testing.T) {
+		before := gatherCounter(t, "backend_header_limit_violations_total", map[string]string{"backend": "limited", "action": "stripped"})
+
+		w := send(http.Header{"Cookie": {strings.Repeat("c", 2000)}, "X-Keep": {"yes"}})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var received http.Header
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &received))
+		assert.Empty(t, received.Get("Cookie"))
+		assert.Equal(t, "yes", received.Get("X-Keep"))
+
+		after := gatherCounter(t, "backend_header_limit_violations_total", map[string]string{"backend": "limited", "action": "stripped"})
+		assert.Equal(t, before+1, after)
+	})
+
+	t.Run("a small stripped header is kept", func(t *testing.T) {
+		w := send(http.Header{"Cookie": {"session=1"}})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var received http.Header
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &received))
+		assert.Equal(t, "session=1", received.Get("Cookie"))
+	})
+
+	t.Run("other oversized headers are still rejected", func(t *testing.T) {
+		w := send(http.Header{"Authorization": {strings.Repeat("a", 2000)}})
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, w.Code)
+	})
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/health"
+	"github.com/your-org/ryohi-router/src/services/router"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// firstHealthEvent runs a TCP health check against the endpoint and returns the first transition
+func firstHealthEvent(t *testing.T, endpointURL string) map[string]interface{} {
+	return firstBackendHealthEvent(t, models.BackendService{
+		ID:      "tcp-backend",
+		Name:    "tcp-backend",
+		Enabled: true,
+		Endpoints: []models.EndpointConfig{
+			{URL: endpointURL, Weight: 1, Healthy: true},
+		},
+		HealthCheck: models.HealthCheckConfig{
+			Enabled:  true,
+			Type:     "tcp",
+			Interval: 1 * time.Second,
+			Timeout:  500 * time.Millisecond,
+		},
+	})
+}
+
+// firstBackendHealthEvent runs health checks for the backend and returns the first transition
+func firstBackendHealthEvent(t *testing.T, backend models.BackendService) map[string]interface{} {
+	cfg := &config.Config{
+		Backends: []models.BackendService{backend},
+	}
+
+	bus := events.NewBus(16, 4)
+	defer bus.Close()
+	sub, _, err := bus.Subscribe([]string{events.TopicHealth}, 0)
+	require.NoError(t, err)
+
+	checker := health.NewChecker(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	checker.SetEventBus(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+
+	select {
+	case event := <-sub.Events():
+		return event.Data.(map[string]interface{})
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for health check")
+		return nil
+	}
+}
+
+func TestHealthChecker_TCPAcceptingListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	data := firstHealthEvent(t, "tcp://"+listener.Addr().String())
+	assert.Equal(t, "healthy", data["status"])
+	assert.Empty(t, data["error"])
+}
+
+func TestHealthChecker_TCPRefusedConnection(t *testing.T) {
+	// Grab a free port and close it so connections are refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	data := firstHealthEvent(t, address)
+	assert.Equal(t, "unhealthy", data["status"])
+	assert.Contains(t, data["error"], "refused")
+	assert.Equal(t, "connection_refused", data["reason"])
+}
+
+func TestHealthChecker_FailureReasons(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Backends: []models.BackendService{{
+			ID:      "failing-backend",
+			Name:    "failing-backend",
+			Enabled: true,
+			Endpoints: []models.EndpointConfig{
+				{URL: server.URL, Weight: 1, Healthy: true},
+			},
+			HealthCheck: models.HealthCheckConfig{
+				Enabled:        true,
+				Type:           "http",
+				Path:           "/health",
+				Interval:       20 * time.Millisecond,
+				Timeout:        time.Second,
+				ExpectedStatus: []int{200},
+			},
+		}},
+	}
+
+	labels := map[string]string{"backend": "failing-backend", "reason": "bad_status"}
+	before := gatherCounter(t, "backend_health_check_failures_total", labels)
+
+	checker := health.NewChecker(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		endpoint, _ := checker.GetEndpointHealth("failing-backend", server.URL)
+		return endpoint.ConsecutiveFail >= 2
+	}, 3*time.Second, 5*time.Millisecond)
+
+	endpoint, _ := checker.GetEndpointHealth("failing-backend", server.URL)
+	assert.Equal(t, models.FailureBadStatus, endpoint.Reason)
+	assert.Contains(t, endpoint.Error, "unexpected status 503")
+	assert.Equal(t, models.FailureBadStatus, checker.DominantFailureReason("failing-backend"))
+	assert.GreaterOrEqual(t, gatherCounter(t, "backend_health_check_failures_total", labels), before+2)
+}
+
+// grpcHealthServer starts an h2c server answering grpc.health.v1 checks with the given status
+func grpcHealthServer(t *testing.T, servingStatus byte) *httptest.Server {
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != "/grpc.health.v1.Health/Check" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		// HealthCheckResponse{status: servingStatus} in a length-prefixed gRPC frame
+		w.Write([]byte{0, 0, 0, 0, 2, 0x08, servingStatus})
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHealthChecker_GRPCOverH2C(t *testing.T) {
+	newBackend := func(url string) models.BackendService {
+		return models.BackendService{
+			ID:       "grpc-backend",
+			Name:     "grpc-backend",
+			Enabled:  true,
+			Protocol: "h2c",
+			Endpoints: []models.EndpointConfig{
+				{URL: url, Weight: 1, Healthy: true},
+			},
+			HealthCheck: models.HealthCheckConfig{
+				Enabled:  true,
+				Type:     "grpc",
+				Interval: 1 * time.Second,
+				Timeout:  500 * time.Millisecond,
+			},
+		}
+	}
+
+	t.Run("serving backend is healthy", func(t *testing.T) {
+		data := firstBackendHealthEvent(t, newBackend(grpcHealthServer(t, 1).URL))
+		assert.Equal(t, "healthy", data["status"])
+	})
+
+	t.Run("not serving backend is unhealthy", func(t *testing.T) {
+		data := firstBackendHealthEvent(t, newBackend(grpcHealthServer(t, 2).URL))
+		assert.Equal(t, "unhealthy", data["status"])
+		assert.Contains(t, data["error"], "not serving")
+	})
+}
+
+func TestHealthChecker_BackoffForFailingEndpoint(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var mutex sync.Mutex
+	var hits []time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		hits = append(hits, time.Now())
+		mutex.Unlock()
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// gaps returns the time between the last n checks
+	gaps := func(n int) []time.Duration {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var result []time.Duration
+		for i := max(len(hits)-n, 1); i < len(hits); i++ {
+			result = append(result, hits[i].Sub(hits[i-1]))
+		}
+		return result
+	}
+
+	// Intervals are set directly, below the validated minimum, to keep the test fast
+	const interval = 20 * time.Millisecond
+	const maxInterval = 160 * time.Millisecond
+	cfg := &config.Config{
+		Backends: []models.BackendService{{
+			ID:      "flapping-backend",
+			Name:    "flapping-backend",
+			Enabled: true,
+			Endpoints: []models.EndpointConfig{
+				{URL: server.URL, Weight: 1, Healthy: true},
+			},
+			HealthCheck: models.HealthCheckConfig{
+				Enabled:        true,
+				Type:           "http",
+				Path:           "/health",
+				Interval:       interval,
+				Timeout:        time.Second,
+				ExpectedStatus: []int{200},
+				BackoffAfter:   2,
+				MaxInterval:    maxInterval,
+			},
+		}},
+	}
+
+	checker := health.NewChecker(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+
+	effectiveInterval := func() time.Duration {
+		endpoint, _ := checker.GetEndpointHealth("flapping-backend", server.URL)
+		return endpoint.EffectiveInterval
+	}
+
+	// A long failure streak stretches the cadence up to the ceiling
+	require.Eventually(t, func() bool {
+		endpoint, checked := checker.GetEndpointHealth("flapping-backend", server.URL)
+		return checked && endpoint.ConsecutiveFail >= 6
+	}, 3*time.Second, 5*time.Millisecond)
+	assert.Equal(t, maxInterval, effectiveInterval())
+	for _, gap := range gaps(2) {
+		assert.GreaterOrEqual(t, gap, maxInterval-10*time.Millisecond, "checks should back off to the max interval")
+	}
+
+	// The first success snaps the cadence back
+	failing.Store(false)
+	require.Eventually(t, func() bool {
+		endpoint, _ := checker.GetEndpointHealth("flapping-backend", server.URL)
+		return endpoint.ConsecutiveOK >= 4
+	}, 3*time.Second, 5*time.Millisecond)
+	assert.Equal(t, interval, effectiveInterval())
+	for _, gap := range gaps(3) {
+		assert.Less(t, gap, maxInterval/2, "checks should return to the configured interval")
+	}
+}
+
+func TestRouter_CanaryFallsBackOnFailingHealthCheck(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	var canaryHealthy atomic.Bool
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			if !canaryHealthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		io.WriteString(w, "canary")
+	}))
+	t.Cleanup(canary.Close)
+
+	cfg := createCanaryConfig(primary.URL, canary.URL, 100)
+	cfg.Backends[1].HealthCheck = models.HealthCheckConfig{
+		Enabled:        true,
+		Type:           "http",
+		Path:           "/health",
+		Interval:       20 * time.Millisecond,
+		Timeout:        time.Second,
+		ExpectedStatus: []int{http.StatusOK},
+	}
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	checker := health.NewChecker(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	checker.SetEndpointHealthHandler(r.SetEndpointHealth)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		body, _ := serve(t, handler, "")
+		return body == "primary"
+	}, 2*time.Second, 10*time.Millisecond, "requests should fall back to the primary once the canary fails its check")
+	backend, _ := r.GetBackend("canary")
+	assert.False(t, backend.Runtime().Endpoints[0].BalancerHealthy)
+
+	canaryHealthy.Store(true)
+	assert.Eventually(t, func() bool {
+		body, _ := serve(t, handler, "")
+		return body == "canary"
+	}, 2*time.Second, 10*time.Millisecond, "the canary should take requests again once it recovers")
+}
+
+func TestHealthChecker_UpdateFollowsReloadedBackends(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(healthy.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	checked := func(id, endpointURL string) models.BackendService {
+		return models.BackendService{
+			ID:        id,
+			Name:      id,
+			Enabled:   true,
+			Endpoints: []models.EndpointConfig{{URL: endpointURL, Weight: 1, Healthy: true}},
+			HealthCheck: models.HealthCheckConfig{
+				Enabled:        true,
+				Type:           "http",
+				Path:           "/health",
+				Interval:       time.Hour,
+				Timeout:        time.Second,
+				ExpectedStatus: []int{http.StatusOK},
+			},
+		}
+	}
+
+	type result struct {
+		backend, endpoint string
+		healthy           bool
+	}
+	results := make(chan result, 16)
+	next := func() result {
+		select {
+		case r := <-results:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a health check result")
+			return result{}
+		}
+	}
+
+	cfg := &config.Config{Backends: []models.BackendService{checked("a", healthy.URL)}}
+	checker := health.NewChecker(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	checker.SetEndpointHealthHandler(func(backendID, endpointURL string, healthy bool) {
+		results <- result{backendID, endpointURL, healthy}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checker.Start(ctx)
+	assert.Equal(t, result{"a", healthy.URL, true}, next())
+
+	// An added backend is checked right away; the unchanged one repeats its last result
+	checker.Update(&config.Config{Backends: []models.BackendService{checked("a", healthy.URL), checked("b", failing.URL)}})
+	assert.ElementsMatch(t, []result{{"a", healthy.URL, true}, {"b", failing.URL, false}}, []result{next(), next()})
+
+	// A changed backend starts over, and a removed one is no longer reported
+	checker.Update(&config.Config{Backends: []models.BackendService{checked("b", healthy.URL)}})
+	assert.Equal(t, result{"b", healthy.URL, true}, next())
+	statuses := checker.GetAllStatuses()
+	assert.NotContains(t, statuses, "a")
+	assert.Contains(t, statuses, "b")
+	assert.Equal(t, "unknown", checker.GetStatus("a").Status)
+}
@@ -0,0 +1,583 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/loadbalancer"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+func TestRoundRobin_EvenDistributionAfterMarkUnhealthy(t *testing.T) {
+	lb := loadbalancer.NewRoundRobin([]models.EndpointConfig{
+		{URL: "http://a:3000", Weight: 1, Healthy: true},
+		{URL: "http://b:3000", Weight: 1, Healthy: true},
+		{URL: "http://c:3000", Weight: 1, Healthy: true},
+		{URL: "http://d:3000", Weight: 1, Healthy: true},
+	})
+
+	// Mark an endpoint unhealthy partway through a rotation
+	lb.Next()
+	lb.Next()
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://b:3000"})
+
+	served := map[string]int{}
+	for i := 0; i < 300; i++ {
+		served[lb.Next().URL]++
+	}
+	assert.Equal(t, map[string]int{"http://a:3000": 100, "http://c:3000": 100, "http://d:3000": 100}, served)
+
+	// A recovered endpoint rejoins the rotation in turn
+	lb.MarkHealthy(&models.EndpointConfig{URL: "http://b:3000"})
+	served = map[string]int{}
+	for i := 0; i < 400; i++ {
+		served[lb.Next().URL]++
+	}
+	assert.Len(t, served, 4)
+	for url, count := range served {
+		assert.Equal(t, 100, count, url)
+	}
+}
+
+func TestRoundRobin_ReturnsStableEndpoints(t *testing.T) {
+	lb := loadbalancer.NewRoundRobin([]models.EndpointConfig{
+		{URL: "http://a:3000", Weight: 1, Healthy: true},
+		{URL: "http://b:3000", Weight: 1, Healthy: true},
+	})
+
+	first := lb.Next()
+	lb.Next()
+	assert.Same(t, first, lb.Next(), "the same endpoint should come back as the same pointer")
+
+	lb.MarkUnhealthy(first)
+	assert.False(t, first.Healthy, "the returned endpoint should reflect health changes")
+	assert.Equal(t, "http://b:3000", lb.Next().URL)
+	assert.Equal(t, "http://b:3000", lb.Next().URL)
+
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://b:3000"})
+	assert.Nil(t, lb.Next())
+}
+
+func TestRandom_UniformDistribution(t *testing.T) {
+	lb := loadbalancer.NewRandom([]models.EndpointConfig{
+		{URL: "http://a:3000", Weight: 1, Healthy: true},
+		{URL: "http://b:3000", Weight: 1, Healthy: true},
+		{URL: "http://c:3000", Weight: 1, Healthy: true},
+		{URL: "http://down:3000", Weight: 1, Healthy: false},
+	})
+
+	const selections = 30000
+	served := map[string]int{}
+	for i := 0; i < selections; i++ {
+		served[lb.Next().URL]++
+	}
+
+	// The standard deviation of each count is about 82, so the tolerance is over ten of them
+	require.Len(t, served, 3, "unhealthy endpoints should never be selected")
+	for url, count := range served {
+		assert.InDelta(t, selections/3, count, selections*0.03, url)
+	}
+}
+
+func TestRandom_NotSequential(t *testing.T) {
+	lb := loadbalancer.NewRandom([]models.EndpointConfig{
+		{URL: "http://a:3000", Weight: 1, Healthy: true},
+		{URL: "http://b:3000", Weight: 1, Healthy: true},
+	})
+
+	// A counter alternates strictly; a random source repeats an endpoint sooner or later
+	repeated := false
+	previous := lb.Next().URL
+	for i := 0; i < 100 && !repeated; i++ {
+		current := lb.Next().URL
+		repeated = current == previous
+		previous = current
+	}
+	assert.True(t, repeated, "selection should not rotate like round-robin")
+}
+
+func TestRandom_InstancesAreIndependent(t *testing.T) {
+	endpoints := []models.EndpointConfig{
+		{URL: "http://a:3000", Weight: 1, Healthy: true},
+		{URL: "http://b:3000", Weight: 1, Healthy: true},
+	}
+
+	// Balancers created back to back must not share a sequence. Independent picks
+	// agree half the time; the standard deviation of the count is about 32.
+	const selections = 1000
+	for round := 0; round < 10; round++ {
+		first, second := loadbalancer.NewRandom(endpoints), loadbalancer.NewRandom(endpoints)
+		agreed := 0
+		for i := 0; i < selections; i++ {
+			if first.Next().URL == second.Next().URL {
+				agreed++
+			}
+		}
+		assert.InDelta(t, selections/2, agreed, 200, "round %d", round)
+	}
+}
+
+func TestRandom_ConcurrentSelection(t *testing.T) {
+	lb := loadbalancer.NewRandom([]models.EndpointConfig{
+		{URL: "http://a:3000", Weight: 1, Healthy: true},
+		{URL: "http://b:3000", Weight: 1, Healthy: true},
+	})
+
+	var wg sync.WaitGroup
+	var picked atomic.Int64
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if lb.Next() != nil {
+					picked.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(8000), picked.Load())
+}
+
+func leastResponseTimeBalancer(t *testing.T) loadbalancer.LoadBalancer {
+	lb, err := loadbalancer.New(&models.LoadBalancerConfig{Algorithm: "least-response-time"}, []models.EndpointConfig{
+		{URL: "http://slow:3000", Weight: 1, Healthy: true},
+		{URL: "http://fast:3000", Weight: 1, Healthy: true},
+	})
+	require.NoError(t, err)
+	return lb
+}
+
+func TestLeastResponseTime_PrefersFasterEndpoint(t *testing.T) {
+	lb := leastResponseTimeBalancer(t)
+
+	for i := 0; i < 5; i++ {
+		lb.Observe("http://slow:3000", 200*time.Millisecond, nil)
+		lb.Observe("http://fast:3000", 20*time.Millisecond, nil)
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "http://fast:3000", lb.Next().URL)
+	}
+}
+
+func TestLeastResponseTime_TriesUnsampledEndpoints(t *testing.T) {
+	lb := leastResponseTimeBalancer(t)
+
+	lb.Observe("http://slow:3000", 200*time.Millisecond, nil)
+	assert.Equal(t, "http://fast:3000", lb.Next().URL, "an endpoint without samples should be tried")
+}
+
+func TestLeastResponseTime_AdaptsToSlowdown(t *testing.T) {
+	lb := leastResponseTimeBalancer(t)
+
+	lb.Observe("http://slow:3000", 100*time.Millisecond, nil)
+	lb.Observe("http://fast:3000", 20*time.Millisecond, nil)
+	require.Equal(t, "http://fast:3000", lb.Next().URL)
+
+	// A single spike is smoothed out by the moving average
+	lb.Observe("http://fast:3000", 250*time.Millisecond, nil)
+	assert.Equal(t, "http://fast:3000", lb.Next().URL)
+
+	// A sustained slowdown moves traffic away
+	for i := 0; i < 5; i++ {
+		lb.Observe("http://fast:3000", 500*time.Millisecond, nil)
+	}
+	assert.Equal(t, "http://slow:3000", lb.Next().URL)
+}
+
+func TestLeastResponseTime_SkipsUnhealthyEndpoints(t *testing.T) {
+	lb := leastResponseTimeBalancer(t)
+
+	lb.Observe("http://slow:3000", 200*time.Millisecond, nil)
+	lb.Observe("http://fast:3000", 20*time.Millisecond, nil)
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://fast:3000"})
+	assert.Equal(t, "http://slow:3000", lb.Next().URL)
+
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://slow:3000"})
+	assert.Nil(t, lb.Next())
+}
+
+func TestRouter_LeastResponseTimeUsesObservedLatency(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		io.WriteString(w, "slow")
+	}))
+	defer slow.Close()
+	fast := newNamedBackend(t, "fast")
+
+	cfg := createCanaryConfig(slow.URL, slow.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Backends[0].LoadBalancer.Algorithm = "least-response-time"
+	cfg.Backends[0].Endpoints = []models.EndpointConfig{
+		{URL: slow.URL, Weight: 1, Healthy: true},
+		{URL: fast.URL, Weight: 1, Healthy: true},
+	}
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	served := map[string]int{}
+	for i := 0; i < 20; i++ {
+		body, _ := serve(t, handler, "")
+		served[body]++
+	}
+	assert.GreaterOrEqual(t, served["fast"], 18, "the faster endpoint should take almost all requests")
+}
+
+// leastLatencyBalancer creates a least-latency balancer on a virtual clock
+func leastLatencyBalancer(decay time.Duration, urls ...string) (*loadbalancer.LeastLatency, *clock.Virtual) {
+	endpoints := make([]models.EndpointConfig, len(urls))
+	for i, url := range urls {
+		endpoints[i] = models.EndpointConfig{URL: url, Weight: 1, Healthy: true}
+	}
+	lb := loadbalancer.NewLeastLatency(endpoints, decay)
+	virtual := clock.NewVirtual(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	lb.SetClock(virtual)
+	return lb, virtual
+}
+
+func TestLeastLatency_SlowerEndpointsGetLessTraffic(t *testing.T) {
+	latencies := map[string]time.Duration{
+		"http://fast:3000":   10 * time.Millisecond,
+		"http://medium:3000": 20 * time.Millisecond,
+		"http://slow:3000":   80 * time.Millisecond,
+	}
+	lb, virtual := leastLatencyBalancer(time.Second, "http://fast:3000", "http://medium:3000", "http://slow:3000")
+
+	const requests = 3000
+	served := map[string]int{}
+	for i := 0; i < requests; i++ {
+		endpoint := lb.Next()
+		require.NotNil(t, endpoint)
+		served[endpoint.URL]++
+		virtual.Advance(latencies[endpoint.URL])
+		lb.Observe(endpoint.URL, latencies[endpoint.URL], nil)
+	}
+
+	assert.Greater(t, served["http://fast:3000"], served["http://medium:3000"])
+	assert.Greater(t, served["http://medium:3000"], served["http://slow:3000"])
+	assert.Less(t, float64(served["http://slow:3000"])/requests, 0.05, "the slow endpoint should get only occasional probes")
+	assert.Greater(t, served["http://slow:3000"], 1, "decay should bring the slow endpoint back for probes")
+}
+
+func TestLeastLatency_PenalizesErrors(t *testing.T) {
+	lb, virtual := leastLatencyBalancer(time.Second, "http://a:3000", "http://b:3000")
+
+	lb.Observe("http://a:3000", 5*time.Millisecond, errors.New("connection refused"))
+	lb.Observe("http://b:3000", 50*time.Millisecond, nil)
+	assert.Equal(t, "http://b:3000", lb.Next().URL, "a fast failure should score worse than a slow success")
+
+	// Keep b in use until a's penalty has decayed below b's latency
+	for i := 0; i < 500 && lb.Next().URL == "http://b:3000"; i++ {
+		virtual.Advance(50 * time.Millisecond)
+		lb.Observe("http://b:3000", 50*time.Millisecond, nil)
+	}
+	assert.Equal(t, "http://a:3000", lb.Next().URL, "the failing endpoint should be retried once its penalty decays")
+}
+
+func TestLeastLatency_RecoversWhenEndpointSpeedsUp(t *testing.T) {
+	lb, virtual := leastLatencyBalancer(time.Second, "http://a:3000", "http://b:3000")
+
+	lb.Observe("http://a:3000", 200*time.Millisecond, nil)
+	lb.Observe("http://b:3000", 20*time.Millisecond, nil)
+
+	// a is now as fast as b; its probes should win it back a share of the traffic
+	served := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		endpoint := lb.Next()
+		served[endpoint.URL]++
+		virtual.Advance(20 * time.Millisecond)
+		lb.Observe(endpoint.URL, 20*time.Millisecond, nil)
+	}
+	assert.Greater(t, served["http://a:3000"], 300)
+}
+
+func TestLeastLatency_SkipsUnhealthyEndpoints(t *testing.T) {
+	lb, _ := leastLatencyBalancer(0, "http://slow:3000", "http://fast:3000")
+
+	lb.Observe("http://slow:3000", 200*time.Millisecond, nil)
+	lb.Observe("http://fast:3000", 20*time.Millisecond, nil)
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://fast:3000"})
+	assert.Equal(t, "http://slow:3000", lb.Next().URL)
+
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://slow:3000"})
+	assert.Nil(t, lb.Next())
+}
+
+func consistentHashEndpoints(n int) []models.EndpointConfig {
+	endpoints := make([]models.EndpointConfig, n)
+	for i := range endpoints {
+		endpoints[i] = models.EndpointConfig{URL: fmt.Sprintf("http://cache-%d:6379", i), Weight: 1, Healthy: true}
+	}
+	return endpoints
+}
+
+// assignKeys maps each of count keys to the URL of the endpoint that owns it
+func assignKeys(t *testing.T, lb *loadbalancer.ConsistentHash, count int) map[string]string {
+	owners := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		key := fmt.Sprintf("user:%d", i)
+		endpoint := lb.NextForKey(key)
+		require.NotNil(t, endpoint)
+		owners[key] = endpoint.URL
+	}
+	return owners
+}
+
+func TestConsistentHash_SameKeySameEndpoint(t *testing.T) {
+	lb, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(4), "", 0)
+	require.NoError(t, err)
+
+	first := assignKeys(t, lb, 1000)
+	assert.Equal(t, first, assignKeys(t, lb, 1000))
+
+	served := map[string]int{}
+	for _, url := range first {
+		served[url]++
+	}
+	assert.Len(t, served, 4)
+	for url, count := range served {
+		assert.InDelta(t, 250, count, 100, "%s should own about a quarter of the keys", url)
+	}
+}
+
+func TestConsistentHash_MinimalRemapping(t *testing.T) {
+	const keys = 10000
+
+	four, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(4), "", 0)
+	require.NoError(t, err)
+	five, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(5), "", 0)
+	require.NoError(t, err)
+
+	before := assignKeys(t, four, keys)
+	after := assignKeys(t, five, keys)
+
+	t.Run("adding an endpoint moves only its share of keys to it", func(t *testing.T) {
+		moved := 0
+		for key, url := range after {
+			if before[key] != url {
+				moved++
+				assert.Equal(t, "http://cache-4:6379", url, "keys should only move to the new endpoint")
+			}
+		}
+		assert.InDelta(t, keys/5, moved, keys/10, "about 1/5 of the keys should move")
+	})
+
+	t.Run("removing an endpoint moves only its keys", func(t *testing.T) {
+		moved := 0
+		for key, url := range before {
+			if after[key] != url {
+				moved++
+			}
+		}
+		removed := 0
+		for _, url := range after {
+			if url == "http://cache-4:6379" {
+				removed++
+			}
+		}
+		assert.Equal(t, removed, moved, "only the removed endpoint's keys should move")
+	})
+}
+
+func TestConsistentHash_SkipsUnhealthyEndpoints(t *testing.T) {
+	lb, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(4), "", 0)
+	require.NoError(t, err)
+	before := assignKeys(t, lb, 2000)
+
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://cache-1:6379"})
+	during := assignKeys(t, lb, 2000)
+	for key, url := range during {
+		assert.NotEqual(t, "http://cache-1:6379", url)
+		if before[key] != "http://cache-1:6379" {
+			assert.Equal(t, before[key], url, "keys of healthy endpoints should stay put")
+		}
+	}
+
+	// A recovered endpoint gets its own keys back
+	lb.MarkHealthy(&models.EndpointConfig{URL: "http://cache-1:6379"})
+	assert.Equal(t, before, assignKeys(t, lb, 2000))
+
+	for _, ep := range consistentHashEndpoints(4) {
+		lb.MarkUnhealthy(&ep)
+	}
+	assert.Nil(t, lb.NextForKey("user:1"))
+}
+
+func TestConsistentHash_KeySources(t *testing.T) {
+	request := func(path, header, query string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, path+"?shard="+query, nil)
+		req.Header.Set("X-Cache-Key", header)
+		return req
+	}
+
+	tests := []struct {
+		hashKey string
+		same    [2]*http.Request
+	}{
+		{"path", [2]*http.Request{request("/items/1", "a", "a"), request("/items/1", "b", "b")}},
+		{"header:X-Cache-Key", [2]*http.Request{request("/items/1", "k", "a"), request("/items/2", "k", "b")}},
+		{"query:shard", [2]*http.Request{request("/items/1", "a", "k"), request("/items/2", "b", "k")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hashKey, func(t *testing.T) {
+			lb, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(8), tt.hashKey, 0)
+			require.NoError(t, err)
+			for i := 0; i < 50; i++ {
+				assert.Equal(t, lb.NextFor(tt.same[0]).URL, lb.NextFor(tt.same[1]).URL)
+			}
+
+			owners := map[string]bool{}
+			for i := 0; i < 100; i++ {
+				v := strconv.Itoa(i)
+				owners[lb.NextFor(request("/items/"+v, v, v)).URL] = true
+			}
+			assert.Greater(t, len(owners), 1, "different keys should spread over endpoints")
+		})
+	}
+
+	_, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(2), "cookie:id", 0)
+	assert.Error(t, err)
+}
+
+func TestRouter_ConsistentHashByHeader(t *testing.T) {
+	a := newNamedBackend(t, "a")
+	b := newNamedBackend(t, "b")
+
+	cfg := createCanaryConfig(a.URL, a.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Backends[0].LoadBalancer = models.LoadBalancerConfig{Algorithm: "consistent-hash", HashKey: "header:X-Cache-Key"}
+	cfg.Backends[0].Endpoints = []models.EndpointConfig{
+		{URL: a.URL, Weight: 1, Healthy: true},
+		{URL: b.URL, Weight: 1, Healthy: true},
+	}
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	served := map[string]map[string]bool{}
+	for i := 0; i < 40; i++ {
+		key := strconv.Itoa(i % 10)
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		req.Header.Set("X-Cache-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		if served[key] == nil {
+			served[key] = map[string]bool{}
+		}
+		served[key][w.Body.String()] = true
+	}
+	for key, bodies := range served {
+		assert.Len(t, bodies, 1, "key %s should always reach the same endpoint", key)
+	}
+}
+
+func TestLoadBalancers_SkipDrainingEndpoints(t *testing.T) {
+	algorithms := []string{"round-robin", "weighted", "least-conn", "random", "least-response-time", "least-latency", "consistent-hash"}
+	for _, algorithm := range algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			lb, err := loadbalancer.New(&models.LoadBalancerConfig{Algorithm: algorithm}, []models.EndpointConfig{
+				{URL: "http://a:3000", Weight: 1, Healthy: true},
+				{URL: "http://b:3000", Weight: 1, Healthy: true},
+			})
+			require.NoError(t, err)
+
+			lb.MarkDraining(&models.EndpointConfig{URL: "http://a:3000"}, true)
+			for i := 0; i < 50; i++ {
+				endpoint := lb.Next()
+				require.NotNil(t, endpoint)
+				assert.Equal(t, "http://b:3000", endpoint.URL, "a draining endpoint should get no new requests")
+			}
+
+			// Draining is separate from health: the endpoint stays healthy, and an
+			// endpoint that is both draining and unhealthy leaves nothing to pick
+			endpoints := lb.Endpoints()
+			assert.True(t, endpoints[0].Healthy)
+			assert.True(t, endpoints[0].Draining)
+			lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://b:3000"})
+			assert.Nil(t, lb.Next())
+
+			lb.MarkHealthy(&models.EndpointConfig{URL: "http://b:3000"})
+			lb.MarkDraining(&models.EndpointConfig{URL: "http://a:3000"}, false)
+			lb.MarkDraining(&models.EndpointConfig{URL: "http://b:3000"}, true)
+			endpoint := lb.Next()
+			require.NotNil(t, endpoint)
+			assert.Equal(t, "http://a:3000", endpoint.URL, "an undrained endpoint should be selected again")
+		})
+	}
+}
+
+func TestLoadBalancers_AddRemoveEndpoints(t *testing.T) {
+	algorithms := []string{"round-robin", "weighted", "least-conn", "random", "least-response-time", "least-latency", "consistent-hash"}
+	for _, algorithm := range algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			lb, err := loadbalancer.New(&models.LoadBalancerConfig{Algorithm: algorithm}, []models.EndpointConfig{
+				{URL: "http://a:3000", Weight: 1, Healthy: true},
+				{URL: "http://b:3000", Weight: 1, Healthy: true},
+			})
+			require.NoError(t, err)
+
+			// Updating an endpoint changes its weight but keeps its live state
+			lb.MarkDraining(&models.EndpointConfig{URL: "http://b:3000"}, true)
+			lb.AddEndpoint(models.EndpointConfig{URL: "http://b:3000", Weight: 5, Healthy: true})
+			endpoints := lb.Endpoints()
+			require.Len(t, endpoints, 2)
+			assert.Equal(t, 5, endpoints[1].Weight)
+			assert.True(t, endpoints[1].Draining, "an update should keep the drain state")
+
+			lb.AddEndpoint(models.EndpointConfig{URL: "http://c:3000", Weight: 1, Healthy: true})
+			assert.True(t, lb.RemoveEndpoint("http://a:3000"))
+			assert.False(t, lb.RemoveEndpoint("http://a:3000"), "a removed endpoint is gone")
+
+			for i := 0; i < 50; i++ {
+				endpoint := lb.Next()
+				require.NotNil(t, endpoint)
+				assert.Equal(t, "http://c:3000", endpoint.URL, "only the added endpoint is available")
+			}
+
+			assert.True(t, lb.RemoveEndpoint("http://c:3000"))
+			assert.Nil(t, lb.Next())
+			assert.Len(t, lb.Endpoints(), 1)
+		})
+	}
+}
+
+func TestConsistentHash_AddEndpointOnlyMovesItsKeys(t *testing.T) {
+	lb, err := loadbalancer.NewConsistentHash(consistentHashEndpoints(4), "", 0)
+	require.NoError(t, err)
+	before := assignKeys(t, lb, 2000)
+
+	lb.AddEndpoint(models.EndpointConfig{URL: "http://added:3000", Weight: 1, Healthy: true})
+	after := assignKeys(t, lb, 2000)
+
+	moved := 0
+	for key, endpoint := range after {
+		if endpoint != before[key] {
+			moved++
+			assert.Equal(t, "http://added:3000", endpoint, "keys should only move to the added endpoint")
+		}
+	}
+	assert.NotZero(t, moved)
+
+	require.True(t, lb.RemoveEndpoint("http://added:3000"))
+	assert.Equal(t, before, assignKeys(t, lb, 2000), "removing it should restore the original assignment")
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/memory"
+)
+
+// fakeMemory is an injectable memory reader reporting a settable usage
+type fakeMemory struct {
+	usage atomic.Uint64
+	fail  atomic.Bool
+}
+
+// read implements memory.Reader
+func (f *fakeMemory) read() (memory.Sample, error) {
+	if f.fail.Load() {
+		return memory.Sample{}, errors.New("statm unavailable")
+	}
+	return memory.Sample{Heap: f.usage.Load() / 2, RSS: f.usage.Load()}, nil
+}
+
+// gatherGauge returns the value of an unlabelled gauge in the default registry
+func gatherGauge(t *testing.T, name string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) > 0 {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+// newTestWatchdog creates a watchdog over a 1000 byte limit shedding between 80% and 90%
+func newTestWatchdog() (*memory.Watchdog, *fakeMemory) {
+	mem := &fakeMemory{}
+	watchdog := memory.NewWatchdog(1000, 0.9, 0.8, mem.read, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return watchdog, mem
+}
+
+func TestWatchdog_SheddingHysteresis(t *testing.T) {
+	watchdog, mem := newTestWatchdog()
+	bus := events.NewBus(16, 4)
+	defer bus.Close()
+	sub, _, err := bus.Subscribe([]string{events.TopicMemory}, 0)
+	require.NoError(t, err)
+	watchdog.SetEventBus(bus)
+
+	steps := []struct {
+		usage    uint64
+		shedding bool
+		fraction float64
+	}{
+		{usage: 850, shedding: false, fraction: 0},   // below high water
+		{usage: 950, shedding: true, fraction: 0.75}, // crosses high water
+		{usage: 1200, shedding: true, fraction: 1},   // over the limit sheds everything
+		{usage: 850, shedding: true, fraction: 0.25}, // still above low water
+		{usage: 790, shedding: false, fraction: 0},   // recovered
+		{usage: 880, shedding: false, fraction: 0},   // below high water again
+	}
+	for _, step := range steps {
+		mem.usage.Store(step.usage)
+		status := watchdog.Check()
+		assert.Equal(t, step.shedding, status.Shedding, "usage %d", step.usage)
+		assert.Equal(t, step.shedding, watchdog.Shedding(), "usage %d", step.usage)
+		assert.InDelta(t, step.fraction, status.ShedFraction, 1e-9, "usage %d", step.usage)
+		assert.Equal(t, step.usage, status.Usage)
+	}
+
+	assert.Equal(t, 1000.0, gatherGauge(t, "memory_limit_bytes"))
+	assert.Equal(t, 880.0, gatherGauge(t, "memory_usage_bytes"))
+	assert.Zero(t, gatherGauge(t, "memory_shed_fraction"))
+
+	// Only the two transitions are published
+	var transitions []bool
+	for len(transitions) < 2 {
+		select {
+		case event := <-sub.Events():
+			transitions = append(transitions, event.Data.(map[string]interface{})["shedding"].(bool))
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for memory events")
+		}
+	}
+	assert.Equal(t, []bool{true, false}, transitions)
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("unexpected event %v", event.Data)
+	default:
+	}
+}
+
+func TestWatchdog_ReadFailureKeepsState(t *testing.T) {
+	watchdog, mem := newTestWatchdog()
+	mem.usage.Store(950)
+	watchdog.Check()
+
+	mem.fail.Store(true)
+	status := watchdog.Check()
+	assert.True(t, status.Shedding, "a failed read should not stop shedding")
+	assert.Equal(t, uint64(950), status.Usage)
+}
+
+func TestWatchdog_ShouldShed(t *testing.T) {
+	watchdog, mem := newTestWatchdog()
+
+	shed := func() int {
+		count := 0
+		for i := 0; i < 2000; i++ {
+			if watchdog.ShouldShed() {
+				count++
+			}
+		}
+		return count
+	}
+
+	mem.usage.Store(500)
+	watchdog.Check()
+	assert.Zero(t, shed())
+
+	mem.usage.Store(900)
+	watchdog.Check()
+	assert.InDelta(t, 1000, shed(), 150, "half of requests should be shed at 90%")
+
+	mem.usage.Store(1000)
+	watchdog.Check()
+	assert.Equal(t, 2000, shed())
+
+	var nilWatchdog *memory.Watchdog
+	assert.False(t, nilWatchdog.ShouldShed())
+}
+
+func TestCgroupLimit(t *testing.T) {
+	write := func(t *testing.T, root, name, content string) {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		limit   uint64
+		found   bool
+	}{
+		{name: "cgroup v2 limit", file: "memory.max", content: "536870912\n", limit: 536870912, found: true},
+		{name: "cgroup v2 unlimited", file: "memory.max", content: "max\n"},
+		{name: "cgroup v1 limit", file: "memory/memory.limit_in_bytes", content: "268435456\n", limit: 268435456, found: true},
+		{name: "cgroup v1 unlimited", file: "memory/memory.limit_in_bytes", content: "9223372036854771712\n"},
+		{name: "no cgroup"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if tt.file != "" {
+				write(t, root, tt.file, tt.content)
+			}
+			limit, found := memory.CgroupLimit(root)
+			assert.Equal(t, tt.found, found)
+			if tt.found {
+				assert.Equal(t, tt.limit, limit)
+			}
+		})
+	}
+}
+
+func TestRouter_CacheBypassedWhileShedding(t *testing.T) {
+	r, handler, calls := newCachedRoute(t, &models.CacheConfig{Enabled: true}, func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "ok")
+	})
+	watchdog, mem := newTestWatchdog()
+	r.SetWatchdog(watchdog)
+
+	mem.usage.Store(950)
+	watchdog.Check()
+	for i := 0; i < 3; i++ {
+		assert.Empty(t, get(handler, "/api/rows", nil).Header().Get("X-Cache"))
+	}
+	assert.Equal(t, int32(3), calls.Load(), "nothing should be cached while shedding")
+
+	mem.usage.Store(500)
+	watchdog.Check()
+	assert.Equal(t, "MISS", get(handler, "/api/rows", nil).Header().Get("X-Cache"))
+	assert.Equal(t, "HIT", get(handler, "/api/rows", nil).Header().Get("X-Cache"))
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/services"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// backendExemplarTraces returns the trace IDs of exemplars on an endpoint's latency histogram
+func backendExemplarTraces(t *testing.T, endpointURL string) []string {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	var traces []string
+	for _, family := range families {
+		if family.GetName() != "backend_request_duration_seconds" {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == "endpoint" && pair.GetValue() != endpointURL {
+					continue metrics
+				}
+			}
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				for _, label := range bucket.GetExemplar().GetLabel() {
+					if label.GetName() == "trace_id" {
+						traces = append(traces, label.GetValue())
+					}
+				}
+			}
+		}
+	}
+	return traces
+}
+
+func TestRouter_BackendLatencyExemplars(t *testing.T) {
+	services.SetExemplarsEnabled(true)
+	t.Cleanup(func() { services.SetExemplarsEnabled(false) })
+
+	backend := newNamedBackend(t, "primary")
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	send := func(traceparent string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		req.Header.Set("traceparent", traceparent)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	send("00-0af7651916cd43dd8448eb211c80319c-00f067aa0ba902b7-00")
+	assert.Empty(t, backendExemplarTraces(t, backend.URL), "unsampled requests should not attach exemplars")
+
+	send("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.Equal(t, []string{"4bf92f3577b34da6a3ce929d0e0e4736"}, backendExemplarTraces(t, backend.URL))
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/events"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// newFlakyBackend is a backend answering 500 while failing is set
+func newFlakyBackend(t *testing.T, name string) (*httptest.Server, *atomic.Bool) {
+	failing := &atomic.Bool{}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(name))
+	}))
+	t.Cleanup(backend.Close)
+	return backend, failing
+}
+
+// passiveRouter creates a router whose primary backend has the given endpoints, ejects an
+// endpoint after 3 errors in a row for 30s and runs on a virtual clock
+func passiveRouter(t *testing.T, urls ...string) (*router.Router, http.Handler, *clock.Virtual) {
+	cfg := createCanaryConfig(urls[0], urls[0], 0)
+	cfg.Backends[0].Endpoints = endpointList(urls...)
+	cfg.Backends[0].PassiveHealthCheck = &models.PassiveHealthCheckConfig{
+		Enabled:           true,
+		ConsecutiveErrors: 3,
+		EjectionTime:      30 * time.Second,
+	}
+	require.NoError(t, cfg.Backends[0].Validate())
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	virtual := clock.NewVirtual(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	r.SetClock(virtual)
+	return r, r.CreateHandler(&cfg.Routes[0]), virtual
+}
+
+// sendRequests sends n requests and counts the responses by status and body
+func sendRequests(handler http.Handler, n int) map[string]int {
+	responses := map[string]int{}
+	for i := 0; i < n; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+		responses[http.StatusText(w.Code)+" "+w.Body.String()]++
+	}
+	return responses
+}
+
+// endpointEjected reports whether the primary backend's endpoint is ejected
+func endpointEjected(t *testing.T, r *router.Router, endpointURL string) bool {
+	backend, _ := r.GetBackend("primary")
+	for _, endpoint := range backend.Runtime().Endpoints {
+		if endpoint.URL == endpointURL {
+			assert.Equal(t, !endpoint.Ejected, endpoint.BalancerHealthy)
+			return endpoint.Ejected
+		}
+	}
+	t.Fatalf("endpoint %s not found", endpointURL)
+	return false
+}
+
+func TestRouter_PassiveHealthCheckEjectsAndReadmits(t *testing.T) {
+	flaky, failing := newFlakyBackend(t, "flaky")
+	healthy := newNamedBackend(t, "healthy")
+	r, handler, virtual := passiveRouter(t, flaky.URL, healthy.URL)
+
+	bus := events.NewBus(16, 4)
+	t.Cleanup(bus.Close)
+	sub, _, err := bus.Subscribe([]string{events.TopicHealth}, 0)
+	require.NoError(t, err)
+	r.SetEventBus(bus)
+	ejections := gatherCounter(t, "backend_endpoint_ejections_total", map[string]string{"backend": "primary", "endpoint": flaky.URL})
+
+	// Round-robin alternates, so the third failure comes with the sixth request
+	failing.Store(true)
+	assert.Equal(t, map[string]int{"Internal Server Error flaky": 3, "OK healthy": 3}, sendRequests(handler, 6))
+	assert.True(t, endpointEjected(t, r, flaky.URL))
+	assert.Equal(t, ejections+1, gatherCounter(t, "backend_endpoint_ejections_total", map[string]string{"backend": "primary", "endpoint": flaky.URL}))
+
+	event := <-sub.Events()
+	assert.Equal(t, map[string]interface{}{
+		"backend":  "primary",
+		"endpoint": flaky.URL,
+		"previous": "healthy",
+		"status":   "unhealthy",
+		"source":   "passive",
+	}, event.Data)
+
+	assert.Equal(t, map[string]int{"OK healthy": 4}, sendRequests(handler, 4), "an ejected endpoint gets no traffic")
+	virtual.Advance(29 * time.Second)
+	assert.Equal(t, map[string]int{"OK healthy": 4}, sendRequests(handler, 4))
+
+	// After the cool-down it is probed again, and a single failure ejects it again
+	virtual.Advance(time.Second)
+	assert.False(t, endpointEjected(t, r, flaky.URL))
+	event = <-sub.Events()
+	assert.Equal(t, "healthy", event.Data.(map[string]interface{})["status"])
+	assert.Equal(t, map[string]int{"Internal Server Error flaky": 1, "OK healthy": 3}, sendRequests(handler, 4))
+	assert.True(t, endpointEjected(t, r, flaky.URL))
+
+	// Once it recovers it is re-admitted for good
+	failing.Store(false)
+	virtual.Advance(30 * time.Second)
+	assert.Equal(t, map[string]int{"OK flaky": 2, "OK healthy": 2}, sendRequests(handler, 4))
+
+	failing.Store(true)
+	sendRequests(handler, 4)
+	assert.False(t, endpointEjected(t, r, flaky.URL), "a recovered endpoint needs the full error count again")
+}
+
+func TestRouter_PassiveHealthCheckKeepsLastEndpoint(t *testing.T) {
+	flaky, failing := newFlakyBackend(t, "flaky")
+	r, handler, _ := passiveRouter(t, flaky.URL)
+
+	failing.Store(true)
+	assert.Equal(t, map[string]int{"Internal Server Error flaky": 5}, sendRequests(handler, 5))
+	assert.False(t, endpointEjected(t, r, flaky.URL), "the only available endpoint should not be ejected")
+}
+
+func TestRouter_PassiveHealthCheckCountsConnectionErrors(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	healthy := newNamedBackend(t, "healthy")
+	r, handler, _ := passiveRouter(t, down.URL, healthy.URL)
+
+	responses := sendRequests(handler, 6)
+	assert.Equal(t, 3, responses["OK healthy"])
+	assert.True(t, endpointEjected(t, r, down.URL))
+	assert.Equal(t, map[string]int{"OK healthy": 4}, sendRequests(handler, 4))
+}
+
+func TestRouter_EjectionEndKeepsActiveHealthCheckFailures(t *testing.T) {
+	flaky, failing := newFlakyBackend(t, "flaky")
+	healthy := newNamedBackend(t, "healthy")
+	r, handler, virtual := passiveRouter(t, flaky.URL, healthy.URL)
+
+	failing.Store(true)
+	sendRequests(handler, 6)
+	require.True(t, endpointEjected(t, r, flaky.URL))
+
+	// The active check reporting it healthy doesn't cut the ejection short
+	r.SetEndpointHealth("primary", flaky.URL, true)
+	assert.True(t, endpointEjected(t, r, flaky.URL))
+
+	// One failing the active check stays out once the ejection ends
+	r.SetEndpointHealth("primary", flaky.URL, false)
+	virtual.Advance(30 * time.Second)
+	assert.Equal(t, map[string]int{"OK healthy": 4}, sendRequests(handler, 4))
+
+	failing.Store(false)
+	r.SetEndpointHealth("primary", flaky.URL, true)
+	assert.Equal(t, map[string]int{"OK flaky": 2, "OK healthy": 2}, sendRequests(handler, 4))
+}
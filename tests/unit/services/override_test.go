@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+func TestRouter_BackendOverride(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	var stagingHeader http.Header
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stagingHeader = r.Header.Clone()
+		io.WriteString(w, "staging")
+	}))
+	t.Cleanup(staging.Close)
+
+	cfg := createCanaryConfig(primary.URL, staging.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Admin.Enabled = true
+	cfg.Admin.APIKey = "admin-key"
+	cfg.Router.OverrideTokens = []string{"old-token", "override-token"}
+	cfg.Backends[1].ID = "staging"
+	cfg.Backends[1].Overridable = true
+
+	var logs bytes.Buffer
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	send := func(header http.Header) *httptest.ResponseRecorder {
+		logs.Reset()
+		return get(handler, "/api/test", header)
+	}
+
+	t.Run("routes an authorized override to an overridable backend", func(t *testing.T) {
+		w := send(http.Header{
+			router.BackendOverrideHeader:      {"staging"},
+			router.BackendOverrideTokenHeader: {"override-token"},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "staging", w.Body.String())
+		assert.Equal(t, "staging", w.Header().Get(router.BackendOverrideAppliedHeader))
+		assert.Empty(t, stagingHeader.Get(router.BackendOverrideTokenHeader), "the token must not reach the backend")
+		assert.Empty(t, stagingHeader.Get(router.BackendOverrideHeader), "the override must not reach the backend")
+		assert.Contains(t, logs.String(), "Backend override")
+		assert.Contains(t, logs.String(), "outcome=applied")
+	})
+
+	t.Run("rejects an override of a backend that isn't overridable", func(t *testing.T) {
+		w := send(http.Header{
+			router.BackendOverrideHeader:      {"primary"},
+			router.BackendOverrideTokenHeader: {"override-token"},
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "override_not_allowed")
+		assert.Empty(t, w.Header().Get(router.BackendOverrideAppliedHeader))
+		assert.Contains(t, logs.String(), "outcome=rejected")
+
+		w = send(http.Header{
+			router.BackendOverrideHeader:      {"unknown"},
+			router.BackendOverrideTokenHeader: {"override-token"},
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("ignores the header without a token", func(t *testing.T) {
+		w := send(http.Header{router.BackendOverrideHeader: {"staging"}})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "primary", w.Body.String())
+		assert.Empty(t, w.Header().Get(router.BackendOverrideAppliedHeader))
+		assert.NotContains(t, logs.String(), "Backend override")
+	})
+
+	t.Run("audits an invalid token and serves the route normally", func(t *testing.T) {
+		w := send(http.Header{
+			router.BackendOverrideHeader:      {"staging"},
+			router.BackendOverrideTokenHeader: {"guessed"},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "primary", w.Body.String())
+		assert.Empty(t, w.Header().Get(router.BackendOverrideAppliedHeader))
+		assert.Contains(t, logs.String(), "Backend override refused")
+		assert.Contains(t, logs.String(), "outcome=invalid_token")
+		assert.NotContains(t, logs.String(), "guessed", "the attempted token must not be logged")
+
+		missing := send(http.Header{router.BackendOverrideHeader: {"staging"}})
+		assert.Equal(t, missing.Code, w.Code, "a bad token should be answered like a missing one")
+		assert.Equal(t, missing.Header(), w.Header())
+	})
+
+	t.Run("never accepts the admin API key", func(t *testing.T) {
+		w := send(http.Header{
+			router.BackendOverrideHeader:      {"staging"},
+			router.BackendOverrideTokenHeader: {"admin-key"},
+		})
+		assert.Equal(t, "primary", w.Body.String())
+		assert.Contains(t, logs.String(), "outcome=invalid_token")
+	})
+
+	t.Run("accepts any configured token", func(t *testing.T) {
+		w := send(http.Header{
+			router.BackendOverrideHeader:      {"staging"},
+			router.BackendOverrideTokenHeader: {"old-token"},
+		})
+		assert.Equal(t, "staging", w.Body.String())
+	})
+}
+
+func TestRouter_BackendOverrideBypassesCache(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	staging := newNamedBackend(t, "staging")
+
+	cfg := createCanaryConfig(primary.URL, staging.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Cache = &models.CacheConfig{Enabled: true}
+	require.NoError(t, cfg.Routes[0].Cache.Validate())
+	cfg.Router.OverrideTokens = []string{"override-token"}
+	cfg.Backends[1].Overridable = true
+
+	var logs bytes.Buffer
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	get(handler, "/api/test", nil)
+	w := get(handler, "/api/test", http.Header{
+		router.BackendOverrideHeader:      {"canary"},
+		router.BackendOverrideTokenHeader: {"override-token"},
+	})
+	assert.Equal(t, "staging", w.Body.String(), "an override should not be answered from the cache")
+
+	w = get(handler, "/api/test", nil)
+	assert.Equal(t, "primary", w.Body.String())
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+
+	// An invalid token is served like no override at all, from the cache, but still audited
+	logs.Reset()
+	w = get(handler, "/api/test", http.Header{
+		router.BackendOverrideHeader:      {"canary"},
+		router.BackendOverrideTokenHeader: {"guessed"},
+	})
+	assert.Equal(t, "primary", w.Body.String())
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, 1, strings.Count(logs.String(), "outcome=invalid_token"))
+}
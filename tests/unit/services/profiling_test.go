@@ -0,0 +1,157 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/services/profiling"
+)
+
+// newTestProfiler creates a profiler taking short snapshots in quick succession
+func newTestProfiler(t *testing.T, cfg config.ProfilingConfig) *profiling.Profiler {
+	cfg.Interval = 60 * time.Millisecond
+	cfg.CPUDuration = 20 * time.Millisecond
+	cfg.AppName = "ryohi-router"
+	profiler := profiling.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { profiler.Stop() })
+	return profiler
+}
+
+// snapshotFiles returns the snapshot files of a type in the directory
+func snapshotFiles(t *testing.T, dir, kind string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, kind+"-*.pprof"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestProfiler_WritesSnapshotsWithRetention(t *testing.T) {
+	dir := t.TempDir()
+	profiler := newTestProfiler(t, config.ProfilingConfig{Directory: dir, MaxFiles: 2})
+
+	status := profiler.Start(0)
+	assert.True(t, status.Running)
+	assert.Nil(t, status.Until, "profiling without a duration runs until stopped")
+
+	require.Eventually(t, func() bool {
+		return profiler.Status().Snapshots >= 4
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, profiler.Stop())
+
+	status = profiler.Status()
+	assert.False(t, status.Running)
+	assert.Empty(t, status.LastError)
+	assert.NotNil(t, status.LastSnapshot)
+
+	for _, kind := range []string{"cpu", "heap"} {
+		files := snapshotFiles(t, dir, kind)
+		assert.Len(t, files, 2, "only max_files %s snapshots should be kept", kind)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.NotZero(t, info.Size())
+		}
+	}
+	tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	assert.Empty(t, tmp)
+}
+
+func TestProfiler_ExpiresAfterDuration(t *testing.T) {
+	profiler := newTestProfiler(t, config.ProfilingConfig{Directory: t.TempDir()})
+
+	status := profiler.Start(150 * time.Millisecond)
+	assert.True(t, status.Running)
+	require.NotNil(t, status.Until)
+
+	require.Eventually(t, func() bool {
+		return !profiler.Status().Running
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, profiler.Status().Until)
+	assert.False(t, profiler.Stop(), "an expired profiler is already stopped")
+}
+
+func TestProfiler_RestartReplacesExpiry(t *testing.T) {
+	profiler := newTestProfiler(t, config.ProfilingConfig{Directory: t.TempDir()})
+
+	profiler.Start(100 * time.Millisecond)
+	status := profiler.Start(0)
+	assert.Nil(t, status.Until)
+
+	time.Sleep(250 * time.Millisecond)
+	assert.True(t, profiler.Status().Running, "the first expiry should no longer apply")
+}
+
+func TestProfiler_PushesToIngestEndpoint(t *testing.T) {
+	type push struct {
+		query   map[string]string
+		profile int
+	}
+	var mutex sync.Mutex
+	var pushes []push
+	ingest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ingest", r.URL.Path)
+		file, _, err := r.FormFile("profile")
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+
+		mutex.Lock()
+		pushes = append(pushes, push{
+			query: map[string]string{
+				"name":   r.URL.Query().Get("name"),
+				"format": r.URL.Query().Get("format"),
+				"from":   r.URL.Query().Get("from"),
+				"until":  r.URL.Query().Get("until"),
+			},
+			profile: len(data),
+		})
+		mutex.Unlock()
+	}))
+	defer ingest.Close()
+
+	profiler := newTestProfiler(t, config.ProfilingConfig{PushURL: ingest.URL + "/"})
+	profiler.Start(0)
+	require.Eventually(t, func() bool {
+		return profiler.Status().Snapshots >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+	profiler.Stop()
+
+	assert.Empty(t, profiler.Status().LastError)
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.GreaterOrEqual(t, len(pushes), 2, "a snapshot pushes a CPU and a heap profile")
+	for _, p := range pushes {
+		assert.Equal(t, "ryohi-router", p.query["name"])
+		assert.Equal(t, "pprof", p.query["format"])
+		assert.NotEmpty(t, p.query["from"])
+		assert.NotEmpty(t, p.query["until"])
+		assert.NotZero(t, p.profile)
+	}
+}
+
+func TestProfiler_ReportsPushFailures(t *testing.T) {
+	ingest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ingest.Close()
+
+	profiler := newTestProfiler(t, config.ProfilingConfig{PushURL: ingest.URL})
+	profiler.Start(0)
+	require.Eventually(t, func() bool {
+		return profiler.Status().Snapshots >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+	profiler.Stop()
+
+	assert.Contains(t, profiler.Status().LastError, "503")
+}
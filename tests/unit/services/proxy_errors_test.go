@@ -0,0 +1,231 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+func TestRouter_ProxyErrorStatus(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	// A closed server's address refuses connections
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refusedURL := refused.URL
+	refused.Close()
+
+	tests := []struct {
+		name       string
+		backendURL string
+		wantStatus int
+		wantCode   string
+		wantClass  string
+	}{
+		{name: "connection refused", backendURL: refusedURL, wantStatus: http.StatusBadGateway, wantCode: "bad_gateway", wantClass: "connection_refused"},
+		{name: "route timeout", backendURL: slow.URL, wantStatus: http.StatusGatewayTimeout, wantCode: "gateway_timeout", wantClass: "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createCanaryConfig(tt.backendURL, tt.backendURL, 0)
+			cfg.Routes[0].CanaryBackend = ""
+			cfg.Routes[0].Timeout = 50 * time.Millisecond
+
+			var logs syncBuffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+			r, err := router.New(cfg, logger)
+			require.NoError(t, err)
+			handler := middleware.Logger(logger)(r.CreateHandler(&cfg.Routes[0]))
+
+			labels := map[string]string{"backend": "primary", "endpoint": tt.backendURL, "error_class": tt.wantClass}
+			before := gatherCounter(t, "backend_requests_total", labels)
+			errorLabels := map[string]string{"backend": "primary", "reason": tt.wantClass}
+			errorsBefore := gatherCounter(t, "backend_errors_total", errorLabels)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+			req.Header.Set("X-Request-ID", "req-123")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var body models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Code)
+			assert.Equal(t, "req-123", body.RequestID)
+			assert.Equal(t, tt.wantClass, body.Details["error_class"])
+
+			assert.Equal(t, before+1, gatherCounter(t, "backend_requests_total", labels))
+			assert.Equal(t, errorsBefore+1, gatherCounter(t, "backend_errors_total", errorLabels))
+			assert.Contains(t, logs.String(), "endpoint="+tt.backendURL)
+			assert.Contains(t, logs.String(), "error_class="+tt.wantClass)
+		})
+	}
+}
+
+func TestRouter_SuccessfulRequestHasNoErrorClass(t *testing.T) {
+	backend := newNamedBackend(t, "primary")
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	labels := map[string]string{"backend": "primary", "endpoint": backend.URL, "status": "200", "error_class": "none"}
+	before := gatherCounter(t, "backend_requests_total", labels)
+
+	serve(t, r.CreateHandler(&cfg.Routes[0]), "req-1")
+	assert.Equal(t, before+1, gatherCounter(t, "backend_requests_total", labels))
+}
+
+func TestRouter_ErrorPages(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "backend maintenance", http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	pages := map[int]models.ErrorPage{
+		http.StatusServiceUnavailable: {Body: "<h1>Down for maintenance</h1>"},
+		http.StatusGatewayTimeout:     {ContentType: "text/plain", Body: "Try again later"},
+	}
+
+	t.Run("replaces the timeout the router generates", func(t *testing.T) {
+		cfg := createCanaryConfig(slow.URL, slow.URL, 0)
+		cfg.Routes[0].CanaryBackend = ""
+		cfg.Routes[0].Timeout = 50 * time.Millisecond
+		cfg.Routes[0].ErrorPages = pages
+
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.CreateHandler(&cfg.Routes[0]).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+		assert.Equal(t, "Try again later", w.Body.String())
+	})
+
+	t.Run("passes through errors from the backend", func(t *testing.T) {
+		cfg := createCanaryConfig(unavailable.URL, unavailable.URL, 0)
+		cfg.Routes[0].CanaryBackend = ""
+		cfg.Routes[0].ErrorPages = pages
+
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.CreateHandler(&cfg.Routes[0]).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "backend maintenance")
+	})
+
+	t.Run("only gateway errors can be replaced", func(t *testing.T) {
+		assert.NoError(t, models.ValidateErrorPages(pages))
+		assert.Error(t, models.ValidateErrorPages(map[int]models.ErrorPage{http.StatusNotFound: {Body: "missing"}}))
+		assert.Error(t, models.ValidateErrorPages(map[int]models.ErrorPage{http.StatusBadGateway: {}}))
+	})
+}
+
+func TestRouter_HTMLFallback(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	newHandler := func(t *testing.T, fallback *models.HTMLFallbackConfig) http.Handler {
+		cfg := createCanaryConfig(slow.URL, slow.URL, 0)
+		cfg.Routes[0].CanaryBackend = ""
+		cfg.Routes[0].Timeout = 50 * time.Millisecond
+		cfg.Routes[0].ContentType = models.ContentTypeHTML
+		cfg.Routes[0].HTMLFallback = fallback
+		require.NoError(t, cfg.Routes[0].Validate())
+
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+		return r.CreateHandler(&cfg.Routes[0])
+	}
+
+	request := func(accept string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/orders?page=2", nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("X-Request-ID", "req-html-1")
+		return req
+	}
+
+	t.Run("browsers get the fallback page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newHandler(t, &models.HTMLFallbackConfig{}).ServeHTTP(w, request("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"))
+
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Cache-Control"), "no-cache")
+		assert.Contains(t, w.Body.String(), "req-html-1")
+		assert.Contains(t, w.Body.String(), `href="/api/orders?page=2"`)
+	})
+
+	t.Run("API clients on the same route get JSON", func(t *testing.T) {
+		handler := newHandler(t, &models.HTMLFallbackConfig{})
+		for _, accept := range []string{"application/json", "*/*", "text/html;q=0.5, application/json"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request(accept))
+
+			require.Equal(t, http.StatusGatewayTimeout, w.Code, accept)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"), accept)
+			var body models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), accept)
+			assert.Equal(t, "req-html-1", body.RequestID)
+		}
+	})
+
+	t.Run("renders a configured template", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newHandler(t, &models.HTMLFallbackConfig{Template: "<p>{{.Status}} {{.RequestID}}</p>"}).ServeHTTP(w, request("text/html"))
+
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Equal(t, "<p>504 req-html-1</p>", w.Body.String())
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		route := createCanaryConfig(slow.URL, slow.URL, 0).Routes[0]
+		route.CanaryBackend = ""
+
+		route.ContentType = "xml"
+		assert.Error(t, route.Validate())
+
+		route.ContentType = ""
+		route.HTMLFallback = &models.HTMLFallbackConfig{}
+		assert.Error(t, route.Validate(), "html_fallback requires content_type html")
+
+		route.ContentType = models.ContentTypeHTML
+		route.HTMLFallback = &models.HTMLFallbackConfig{Template: "<p>{{.Status</p>"}
+		assert.Error(t, route.Validate())
+	})
+}
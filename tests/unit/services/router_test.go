@@ -0,0 +1,652 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/bodycapture"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/lib/transport"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// newNamedBackend starts a test backend that responds with its name
+func newNamedBackend(t *testing.T, name string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// createCanaryConfig creates a config with a primary and a canary backend on one route
+func createCanaryConfig(primaryURL, canaryURL string, weight int) *config.Config {
+	backend := func(id, url string) models.BackendService {
+		return models.BackendService{
+			ID:      id,
+			Name:    id,
+			Enabled: true,
+			Endpoints: []models.EndpointConfig{
+				{URL: url, Weight: 1, Healthy: true},
+			},
+			LoadBalancer: models.LoadBalancerConfig{Algorithm: "round-robin"},
+		}
+	}
+
+	return &config.Config{
+		Router: config.RouterConfig{Port: 8080, ServedByHeader: true},
+		Backends: []models.BackendService{
+			backend("primary", primaryURL),
+			backend("canary", canaryURL),
+		},
+		Routes: []models.RouteConfig{
+			{
+				ID:            "canary-route",
+				Path:          "/api/*",
+				Method:        []string{"GET"},
+				Backend:       "primary",
+				CanaryBackend: "canary",
+				CanaryWeight:  weight,
+				Timeout:       5 * time.Second,
+				Enabled:       true,
+			},
+		},
+	}
+}
+
+// serve sends a request with the given request ID and returns the serving backend
+func serve(t *testing.T, handler http.Handler, requestID string) (string, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	req.Header.Set("X-Request-ID", requestID)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String(), w
+}
+
+func TestRouter_CanarySplit(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	canary := newNamedBackend(t, "canary")
+
+	cfg := createCanaryConfig(primary.URL, canary.URL, 20)
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	t.Run("splits traffic by weight", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			served, _ := serve(t, handler, fmt.Sprintf("req-%d", i))
+			counts[served]++
+		}
+
+		assert.InDelta(t, 200, counts["canary"], 60, "about 20%% of requests should go to the canary")
+		assert.Equal(t, 1000, counts["primary"]+counts["canary"])
+	})
+
+	t.Run("same request ID is served by the same backend", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			id := fmt.Sprintf("retry-%d", i)
+			first, _ := serve(t, handler, id)
+			for j := 0; j < 3; j++ {
+				again, _ := serve(t, handler, id)
+				assert.Equal(t, first, again)
+			}
+		}
+	})
+
+	t.Run("sets X-Served-By when enabled", func(t *testing.T) {
+		served, w := serve(t, handler, "served-by")
+		assert.Equal(t, served, w.Header().Get("X-Served-By"))
+	})
+}
+
+func TestRouter_CanaryFallsBackToPrimary(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	canary := newNamedBackend(t, "canary")
+
+	cfg := createCanaryConfig(primary.URL, canary.URL, 100)
+	cfg.Backends[1].Endpoints[0].Healthy = false
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	for i := 0; i < 10; i++ {
+		served, _ := serve(t, handler, fmt.Sprintf("req-%d", i))
+		assert.Equal(t, "primary", served)
+	}
+}
+
+func TestRouter_ServedByHeaderDisabled(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	canary := newNamedBackend(t, "canary")
+
+	cfg := createCanaryConfig(primary.URL, canary.URL, 50)
+	cfg.Router.ServedByHeader = false
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, w := serve(t, r.CreateHandler(&cfg.Routes[0]), "req-1")
+	assert.Empty(t, w.Header().Get("X-Served-By"))
+}
+
+// createFallbackConfig creates a config with a primary and a fallback backend on one route
+func createFallbackConfig(primaryURL, fallbackURL string) *config.Config {
+	cfg := createCanaryConfig(primaryURL, fallbackURL, 0)
+	cfg.Backends[1].ID = "fallback"
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].FallbackBackend = "fallback"
+	cfg.Routes[0].Method = []string{"GET", "POST"}
+	return cfg
+}
+
+func TestRouter_Fallback(t *testing.T) {
+	var primaryStatus atomic.Int32
+	primaryStatus.Store(http.StatusServiceUnavailable)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-From", "primary")
+		w.WriteHeader(int(primaryStatus.Load()))
+		io.WriteString(w, "primary")
+	}))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, "fallback:"+string(body))
+	}))
+	defer fallback.Close()
+
+	newHandler := func(cfg *config.Config) http.Handler {
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+		return r.CreateHandler(&cfg.Routes[0])
+	}
+
+	t.Run("retries idempotent requests on 503", func(t *testing.T) {
+		handler := newHandler(createFallbackConfig(primary.URL, fallback.URL))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "fallback:", w.Body.String())
+		assert.Empty(t, w.Header().Get("X-From"), "primary headers should be discarded")
+		assert.Equal(t, "fallback", w.Header().Get("X-Served-By"))
+	})
+
+	t.Run("does not retry non-idempotent requests by default", func(t *testing.T) {
+		handler := newHandler(createFallbackConfig(primary.URL, fallback.URL))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader("payload"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "primary", w.Body.String())
+	})
+
+	t.Run("replays the body for opted-in methods", func(t *testing.T) {
+		cfg := createFallbackConfig(primary.URL, fallback.URL)
+		cfg.Routes[0].FallbackMethods = []string{"POST"}
+		handler := newHandler(cfg)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader("payload"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "fallback:payload", w.Body.String())
+	})
+
+	t.Run("sends bodies over the buffer cap once without fallback", func(t *testing.T) {
+		echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, "primary:"+string(body))
+		}))
+		defer echo.Close()
+
+		cfg := createFallbackConfig(echo.URL, fallback.URL)
+		cfg.Routes[0].FallbackMethods = []string{"POST"}
+		handler := bodycapture.Middleware(bodycapture.Options{MaxBytes: 4})(newHandler(cfg))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader("payload"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "primary:payload", w.Body.String(), "the whole body should reach the primary")
+	})
+
+	t.Run("passes through successful and client error responses", func(t *testing.T) {
+		handler := newHandler(createFallbackConfig(primary.URL, fallback.URL))
+
+		for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError} {
+			primaryStatus.Store(int32(status))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+			assert.Equal(t, status, w.Code)
+			assert.Equal(t, "primary", w.Body.String())
+			assert.Equal(t, "primary", w.Header().Get("X-From"))
+		}
+		primaryStatus.Store(http.StatusServiceUnavailable)
+	})
+
+	t.Run("uses fallback when primary has no healthy endpoints", func(t *testing.T) {
+		cfg := createFallbackConfig(primary.URL, fallback.URL)
+		cfg.Backends[0].Endpoints[0].Healthy = false
+		handler := newHandler(cfg)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "fallback:", w.Body.String())
+	})
+
+	t.Run("returns primary response when fallback is unavailable", func(t *testing.T) {
+		cfg := createFallbackConfig(primary.URL, fallback.URL)
+		cfg.Backends[1].Endpoints[0].Healthy = false
+		handler := newHandler(cfg)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "primary", w.Body.String())
+	})
+}
+
+func TestRouter_PathRewrite(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.RequestURI())
+	}))
+	defer backend.Close()
+
+	request := func(t *testing.T, route models.RouteConfig, target string) string {
+		cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+		route.ID = "rewrite-route"
+		route.Path = "/api/v1/*"
+		route.Method = []string{"GET"}
+		route.Backend = "primary"
+		route.Enabled = true
+		cfg.Routes = []models.RouteConfig{route}
+		require.NoError(t, cfg.Routes[0].Validate())
+
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		r.CreateHandler(&cfg.Routes[0]).ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, target, req.URL.RequestURI(), "client request should not be modified")
+		return w.Body.String()
+	}
+
+	t.Run("strips the prefix", func(t *testing.T) {
+		route := models.RouteConfig{StripPrefix: "/api/v1"}
+		assert.Equal(t, "/users", request(t, route, "/api/v1/users"))
+		assert.Equal(t, "/users/42?active=true", request(t, route, "/api/v1/users/42?active=true"))
+		assert.Equal(t, "/", request(t, route, "/api/v1"))
+	})
+
+	t.Run("only strips on a segment boundary", func(t *testing.T) {
+		route := models.RouteConfig{StripPrefix: "/api/v1"}
+		assert.Equal(t, "/api/v10/users", request(t, route, "/api/v10/users"))
+	})
+
+	t.Run("rewrites the stripped path", func(t *testing.T) {
+		route := models.RouteConfig{StripPrefix: "/api/v1", Rewrite: "/v2$1"}
+		assert.Equal(t, "/v2/users", request(t, route, "/api/v1/users"))
+	})
+
+	t.Run("rewrites with capture groups", func(t *testing.T) {
+		route := models.RouteConfig{
+			RewritePattern: `^/api/v1/users/([^/]+)/orders$`,
+			Rewrite:        "/members/$1/orders",
+		}
+		assert.Equal(t, "/members/42/orders", request(t, route, "/api/v1/users/42/orders"))
+	})
+
+	t.Run("applies rewrite rules in order", func(t *testing.T) {
+		route := models.RouteConfig{
+			StripPrefix: "/api/v1",
+			RewriteRules: []models.RewriteRule{
+				{Pattern: `^/legacy/(.*)$`, Replacement: "/v2/$1"},
+				{Pattern: `^/v2/users/([^/]+)$`, Replacement: "/v2/members/$1"},
+			},
+		}
+		assert.Equal(t, "/v2/members/42", request(t, route, "/api/v1/legacy/users/42"))
+		assert.Equal(t, "/v2/orders", request(t, route, "/api/v1/legacy/orders"))
+		assert.Equal(t, "/other", request(t, route, "/api/v1/other"), "paths no rule matches are left alone")
+	})
+}
+
+func TestRouter_RewriteRuleInvalidPath(t *testing.T) {
+	var called bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer backend.Close()
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].RewriteRules = []models.RewriteRule{
+		{Pattern: `^/api/relative/(.*)$`, Replacement: "$1"},
+		{Pattern: `^/api/up/(.*)$`, Replacement: "/../$1"},
+		{Pattern: `^/api/host/(.*)$`, Replacement: "//$1"},
+	}
+	require.NoError(t, cfg.Routes[0].Validate())
+
+	var logs bytes.Buffer
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+
+	for _, target := range []string{"/api/relative/users", "/api/up/etc/passwd", "/api/host/evil.example.com/x"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code, target)
+	}
+	assert.False(t, called, "invalid paths should not be sent upstream")
+	assert.Contains(t, logs.String(), "Rewrite produced an invalid path")
+	assert.Contains(t, logs.String(), "route=canary-route")
+}
+
+func TestRouter_UpstreamPathTemplate(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.RequestURI())
+	}))
+	defer backend.Close()
+
+	newHandler := func(t *testing.T, upstreamPath string) http.Handler {
+		cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+		cfg.Routes[0].CanaryBackend = ""
+		cfg.Routes[0].Path = "/users/{id}/avatar"
+		cfg.Routes[0].UpstreamPath = upstreamPath
+		require.NoError(t, cfg.Routes[0].Validate())
+
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+		return r.CreateHandler(&cfg.Routes[0])
+	}
+
+	request := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("fills path and query templates", func(t *testing.T) {
+		handler := newHandler(t, "/internal/avatars?user={id}")
+
+		w := request(handler, "/users/42/avatar")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/internal/avatars?user=42", w.Body.String())
+
+		w = request(handler, "/users/42/avatar?size=64")
+		assert.Equal(t, "/internal/avatars?size=64&user=42", w.Body.String())
+	})
+
+	t.Run("injected query parameters override the client's", func(t *testing.T) {
+		handler := newHandler(t, "/internal/avatars?user={id}")
+
+		w := request(handler, "/users/42/avatar?user=1")
+		assert.Equal(t, "/internal/avatars?user=42", w.Body.String())
+	})
+
+	t.Run("escapes query values", func(t *testing.T) {
+		handler := newHandler(t, "/internal/avatars?user={id}")
+
+		w := request(handler, "/users/1&admin=true/avatar")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/internal/avatars?user=1%26admin%3Dtrue", w.Body.String())
+	})
+
+	t.Run("escapes path values", func(t *testing.T) {
+		handler := newHandler(t, "/internal/users/{id}/avatar")
+
+		w := request(handler, "/users/a%2F..%2Fadmin/avatar")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/internal/users/a%2F..%2Fadmin/avatar", w.Body.String())
+	})
+
+	t.Run("rejects dot segments", func(t *testing.T) {
+		handler := newHandler(t, "/internal/users/{id}/avatar")
+
+		for _, target := range []string{"/users/%2e%2e/avatar", "/users/../avatar", "/users/%2E/avatar"} {
+			w := request(handler, target)
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		}
+	})
+
+	t.Run("returns 404 when the path doesn't fit the pattern", func(t *testing.T) {
+		handler := newHandler(t, "/internal/users/{id}/avatar")
+
+		w := request(handler, "/users/a/b/avatar")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRouter_H2CStreamingPassthrough(t *testing.T) {
+	// The backend echoes each request line as soon as it arrives, like a bidirectional gRPC stream
+	backend := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Header().Set("X-Backend-Proto", r.Proto)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			io.WriteString(w, "echo:"+scanner.Text()+"\n")
+			w.(http.Flusher).Flush()
+		}
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer backend.Close()
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Backends[0].Protocol = "h2c"
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Method = []string{"POST"}
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	routerServer := httptest.NewServer(h2c.NewHandler(r.CreateHandler(&cfg.Routes[0]), &http2.Server{}))
+	defer routerServer.Close()
+
+	body, requestWriter := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, routerServer.URL+"/api/stream", body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	client := &http.Client{Transport: transport.NewH2C()}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HTTP/2.0", resp.Header.Get("X-Backend-Proto"), "backend should be reached over HTTP/2")
+
+	reader := bufio.NewReader(resp.Body)
+	for _, message := range []string{"hello", "world"} {
+		_, err := io.WriteString(requestWriter, message+"\n")
+		require.NoError(t, err)
+
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "echo:"+message+"\n", line, "each message should arrive before the stream ends")
+	}
+
+	requestWriter.Close()
+	_, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"), "trailers should be preserved")
+}
+
+func TestRouter_ReloadKeepsUnchangedBackends(t *testing.T) {
+	primary := newNamedBackend(t, "primary")
+	canary := newNamedBackend(t, "canary")
+	movedCanary := newNamedBackend(t, "moved-canary")
+
+	breaker := models.CircuitBreakerConfig{Enabled: true, MinimumRequests: 1, FailureRatio: 0.5, Interval: time.Minute, Timeout: time.Minute}
+	cfg := createCanaryConfig(primary.URL, canary.URL, 0)
+	cfg.Backends[0].CircuitBreaker = breaker
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	before, _ := r.GetBackend("primary")
+	before.CircuitBreaker.RecordResult(false)
+	require.Equal(t, models.StateOpen, before.CircuitBreaker.GetState())
+	oldCanary, _ := r.GetBackend("canary")
+
+	// Only the canary's endpoint changes
+	reloaded := createCanaryConfig(primary.URL, movedCanary.URL, 0)
+	reloaded.Backends[0].CircuitBreaker = breaker
+	require.NoError(t, r.Reload(reloaded))
+
+	after, _ := r.GetBackend("primary")
+	assert.Same(t, before, after, "an unchanged backend should be kept")
+	assert.Equal(t, models.StateOpen, after.CircuitBreaker.GetState())
+
+	newCanary, _ := r.GetBackend("canary")
+	assert.NotSame(t, oldCanary, newCanary, "a changed backend should be rebuilt")
+	assert.Equal(t, movedCanary.URL, newCanary.Config.Endpoints[0].URL)
+}
+
+func TestRouter_DrainStateSurvivesReload(t *testing.T) {
+	first := newNamedBackend(t, "first")
+	second := newNamedBackend(t, "second")
+	canary := newNamedBackend(t, "canary")
+
+	r, err := router.New(createCanaryConfig(first.URL, canary.URL, 0), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	backend, _ := r.GetBackend("primary")
+	require.True(t, backend.SetDraining(first.URL, true))
+	assert.False(t, backend.SetDraining("http://unknown:3000", true))
+
+	// Adding an endpoint rebuilds the backend
+	reloaded := createCanaryConfig(first.URL, canary.URL, 0)
+	reloaded.Backends[0].Endpoints = append(reloaded.Backends[0].Endpoints, models.EndpointConfig{URL: second.URL, Weight: 1, Healthy: true})
+	require.NoError(t, r.Reload(reloaded))
+
+	rebuilt, _ := r.GetBackend("primary")
+	require.NotSame(t, backend, rebuilt)
+	runtime := rebuilt.Runtime()
+	require.Len(t, runtime.Endpoints, 2)
+	assert.True(t, runtime.Endpoints[0].Draining, "the drain state should survive the reload")
+	assert.Equal(t, 0, runtime.Endpoints[0].EffectiveWeight)
+	assert.False(t, runtime.Endpoints[1].Draining)
+
+	route := reloaded.Routes[0]
+	handler := r.CreateHandler(&route)
+	for i := 0; i < 4; i++ {
+		body, _ := serve(t, handler, fmt.Sprintf("request-%d", i))
+		assert.Equal(t, "second", body)
+	}
+}
+
+// newSlowBackend starts a test backend that signals each request on started and
+// answers only once release is closed or the request is cancelled
+func newSlowBackend(t *testing.T, name string) (*httptest.Server, chan struct{}, chan struct{}) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			io.WriteString(w, name)
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+	return server, started, release
+}
+
+func TestRouter_ReloadDrainsInFlightRequests(t *testing.T) {
+	slow, started, release := newSlowBackend(t, "old-primary")
+	moved := newNamedBackend(t, "new-primary")
+	canary := newNamedBackend(t, "canary")
+
+	r, err := router.New(createCanaryConfig(slow.URL, canary.URL, 0), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	route := createCanaryConfig(slow.URL, canary.URL, 0).Routes[0]
+	handler := r.CreateHandler(&route)
+
+	inFlight := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/slow", nil)
+		req.Header.Set("X-Request-ID", "slow-request")
+		handler.ServeHTTP(rec, req)
+		inFlight <- rec
+	}()
+	<-started
+
+	// The primary moves, so the backend serving the slow request is replaced
+	require.NoError(t, r.Reload(createCanaryConfig(moved.URL, canary.URL, 0)))
+	reloadedRoute := createCanaryConfig(moved.URL, canary.URL, 0).Routes[0]
+	body, _ := serve(t, r.CreateHandler(&reloadedRoute), "after-reload")
+	assert.Equal(t, "new-primary", body)
+
+	close(release)
+	rec := <-inFlight
+	assert.Equal(t, http.StatusOK, rec.Code, "the in-flight request should finish on the replaced backend")
+	assert.Equal(t, "old-primary", rec.Body.String())
+}
+
+func TestRouter_ReloadCancelsRequestsPastDrainTimeout(t *testing.T) {
+	slow, started, _ := newSlowBackend(t, "old-primary")
+	moved := newNamedBackend(t, "new-primary")
+	canary := newNamedBackend(t, "canary")
+
+	r, err := router.New(createCanaryConfig(slow.URL, canary.URL, 0), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	route := createCanaryConfig(slow.URL, canary.URL, 0).Routes[0]
+	handler := r.CreateHandler(&route)
+
+	inFlight := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/slow", nil))
+		inFlight <- rec
+	}()
+	<-started
+
+	reloaded := createCanaryConfig(moved.URL, canary.URL, 0)
+	reloaded.Router.DrainTimeout = 50 * time.Millisecond
+	require.NoError(t, r.Reload(reloaded))
+
+	select {
+	case rec := <-inFlight:
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "drain_timeout")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request should be cancelled once the drain timeout passes")
+	}
+}
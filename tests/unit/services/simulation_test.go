@@ -0,0 +1,369 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/clock"
+	"github.com/your-org/ryohi-router/src/lib/config"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// simWindow is a span of virtual time measured from the start of a simulation
+type simWindow struct {
+	from, to time.Duration
+}
+
+// simEndpoint scripts the behavior of one simulated primary endpoint
+type simEndpoint struct {
+	name    string
+	weight  int
+	latency func(rng *rand.Rand) time.Duration
+	failing []simWindow // answers 503 during these windows
+	ejected []simWindow // marked unhealthy in the balancer during these windows
+}
+
+// simScenario describes a simulation run against the real router handler
+type simScenario struct {
+	algorithm string
+	endpoints []simEndpoint
+	breaker   models.CircuitBreakerConfig
+	fallback  bool          // route failed requests to a fallback backend
+	requests  int           // client requests, sent one after another
+	interval  time.Duration // virtual gap between a response and the next request
+	seed      int64
+}
+
+// simResult holds what a simulation observed
+type simResult struct {
+	trace              []string // serving endpoint of each request
+	served             map[string]int
+	servedWhileEjected int
+	servedWhileOpen    int // primary requests that got past an open breaker
+	fallbacks          int // requests retried against the fallback
+	expectedFallbacks  int // requests whose primary failed or was unavailable
+	maxAttempts        int // most backend requests made for one client request
+	breakerOpen        []time.Duration
+}
+
+// inWindows reports whether t falls in any of the windows
+func inWindows(windows []simWindow, t time.Duration) bool {
+	for _, w := range windows {
+		if t >= w.from && t < w.to {
+			return true
+		}
+	}
+	return false
+}
+
+// fixedLatency always takes d
+func fixedLatency(d time.Duration) func(*rand.Rand) time.Duration {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// uniformLatency takes between min and max
+func uniformLatency(min, max time.Duration) func(*rand.Rand) time.Duration {
+	return func(rng *rand.Rand) time.Duration {
+		return min + time.Duration(rng.Int63n(int64(max-min)))
+	}
+}
+
+// simulate drives the router handler with the scenario's requests on a virtual clock.
+// Requests are sent one at a time, so every run with the same seed is identical.
+func simulate(t *testing.T, scenario simScenario) *simResult {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	virtual := clock.NewVirtual(start)
+	rng := rand.New(rand.NewSource(scenario.seed))
+	elapsed := func() time.Duration { return virtual.Now().Sub(start) }
+
+	result := &simResult{served: make(map[string]int)}
+	ejected := make(map[string]bool)
+	var (
+		attempts      int
+		primaryStatus int
+		breakerOpen   bool
+		lastPrimaryAt time.Time
+		r             *router.Router
+	)
+
+	// endpoint starts a backend that advances the virtual clock by its latency
+	endpoint := func(name string, latency func(*rand.Rand) time.Duration, failing []simWindow, primary bool) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			attempts++
+			if ejected[name] {
+				result.servedWhileEjected++
+			}
+			if primary && breakerOpen {
+				if backend, _ := r.GetBackend("primary"); backend.CircuitBreaker.GetState() == models.StateOpen {
+					result.servedWhileOpen++
+				}
+			}
+
+			virtual.Advance(latency(rng))
+			status := http.StatusOK
+			if inWindows(failing, elapsed()) {
+				status = http.StatusServiceUnavailable
+			}
+			if primary {
+				primaryStatus = status
+				lastPrimaryAt = virtual.Now()
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, name)
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+
+	primary := models.BackendService{
+		ID:             "primary",
+		Name:           "primary",
+		Enabled:        true,
+		LoadBalancer:   models.LoadBalancerConfig{Algorithm: scenario.algorithm},
+		CircuitBreaker: scenario.breaker,
+	}
+	urls := make(map[string]string)
+	for _, ep := range scenario.endpoints {
+		url := endpoint(ep.name, ep.latency, ep.failing, true)
+		urls[ep.name] = url
+		primary.Endpoints = append(primary.Endpoints, models.EndpointConfig{URL: url, Weight: ep.weight, Healthy: true})
+	}
+
+	route := models.RouteConfig{
+		ID:      "sim",
+		Path:    "/api/*",
+		Method:  []string{"GET"},
+		Backend: "primary",
+		Timeout: 5 * time.Second,
+		Enabled: true,
+	}
+	cfg := &config.Config{Router: config.RouterConfig{Port: 8080}, Backends: []models.BackendService{primary}}
+	if scenario.fallback {
+		cfg.Backends = append(cfg.Backends, models.BackendService{
+			ID:      "fallback",
+			Name:    "fallback",
+			Enabled: true,
+			Endpoints: []models.EndpointConfig{
+				{URL: endpoint("fallback", fixedLatency(5*time.Millisecond), nil, false), Weight: 1, Healthy: true},
+			},
+			LoadBalancer: models.LoadBalancerConfig{Algorithm: "round-robin"},
+		})
+		route.FallbackBackend = "fallback"
+	}
+	cfg.Routes = []models.RouteConfig{route}
+
+	var err error
+	r, err = router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	r.SetClock(virtual)
+	r.SetSeed(scenario.seed)
+	handler := r.CreateHandler(&cfg.Routes[0])
+	backend, _ := r.GetBackend("primary")
+
+	var openedAt time.Time
+	for i := 0; i < scenario.requests; i++ {
+		// Apply the health script for this moment
+		for _, ep := range scenario.endpoints {
+			if eject := inWindows(ep.ejected, elapsed()); eject != ejected[ep.name] {
+				ejected[ep.name] = eject
+				if eject {
+					backend.LoadBalancer.MarkUnhealthy(&models.EndpointConfig{URL: urls[ep.name]})
+				} else {
+					backend.LoadBalancer.MarkHealthy(&models.EndpointConfig{URL: urls[ep.name]})
+				}
+			}
+		}
+
+		before := backend.CircuitBreaker.GetStats()
+		breakerOpen = before.State == string(models.StateOpen)
+		arrival := virtual.Now()
+		attempts, primaryStatus = 0, 0
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/sim", nil))
+		result.trace = append(result.trace, w.Body.String())
+		result.served[w.Body.String()]++
+		result.maxAttempts = max(result.maxAttempts, attempts)
+
+		primaryFailed := primaryStatus == 0 || primaryStatus == http.StatusServiceUnavailable
+		if scenario.fallback && primaryFailed {
+			result.expectedFallbacks++
+		}
+		if primaryStatus != 0 && attempts == 2 || primaryStatus == 0 && attempts == 1 {
+			result.fallbacks++
+		}
+
+		// The breaker opens on a primary response and stops being open when the next request after its timeout arrives
+		after := backend.CircuitBreaker.GetStats()
+		reopened := after.NextAttemptTime != before.NextAttemptTime
+		if before.State == string(models.StateOpen) && (after.State != string(models.StateOpen) || reopened) {
+			result.breakerOpen = append(result.breakerOpen, arrival.Sub(openedAt))
+		}
+		if after.State == string(models.StateOpen) && (before.State != string(models.StateOpen) || reopened) {
+			openedAt = lastPrimaryAt
+		}
+
+		virtual.Advance(scenario.interval)
+	}
+	return result
+}
+
+func TestSimulation_Distribution(t *testing.T) {
+	endpoints := func(weights ...int) []simEndpoint {
+		var eps []simEndpoint
+		for i, weight := range weights {
+			eps = append(eps, simEndpoint{
+				name:    fmt.Sprintf("ep%d", i),
+				weight:  weight,
+				latency: uniformLatency(time.Duration(i+1)*10*time.Millisecond, time.Duration(i+1)*12*time.Millisecond),
+			})
+		}
+		return eps
+	}
+
+	tests := []struct {
+		algorithm string
+		weights   []int
+		expected  []float64 // share of requests per endpoint
+		tolerance float64
+	}{
+		{algorithm: "round-robin", weights: []int{1, 1, 1}, expected: []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}},
+		{algorithm: "weighted", weights: []int{1, 2, 3}, expected: []float64{1.0 / 6, 2.0 / 6, 3.0 / 6}},
+		{algorithm: "least-conn", weights: []int{1, 1, 1}, expected: []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}},
+		{algorithm: "random", weights: []int{1, 1, 1}, expected: []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, tolerance: 0.03},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			const requests = 3000
+			result := simulate(t, simScenario{
+				algorithm: tt.algorithm,
+				endpoints: endpoints(tt.weights...),
+				requests:  requests,
+				interval:  time.Millisecond,
+				seed:      42,
+			})
+
+			for i, share := range tt.expected {
+				name := fmt.Sprintf("ep%d", i)
+				assert.InDelta(t, share, float64(result.served[name])/requests, tt.tolerance+0.001, name)
+			}
+		})
+	}
+
+	t.Run("least-response-time", func(t *testing.T) {
+		result := simulate(t, simScenario{
+			algorithm: "least-response-time",
+			endpoints: endpoints(1, 1, 1),
+			requests:  1000,
+			interval:  time.Millisecond,
+			seed:      42,
+		})
+
+		// ep0 is always fastest, so it should take nearly everything once each endpoint is sampled
+		assert.Greater(t, result.served["ep0"], 990)
+	})
+
+	t.Run("least-latency", func(t *testing.T) {
+		result := simulate(t, simScenario{
+			algorithm: "least-latency",
+			endpoints: endpoints(1, 1, 1),
+			requests:  3000,
+			interval:  time.Millisecond,
+			seed:      42,
+		})
+
+		// Slower endpoints are only probed as their scores decay, the slowest least often
+		assert.Greater(t, result.served["ep0"], 2900)
+		assert.Greater(t, result.served["ep1"], result.served["ep2"])
+		assert.Greater(t, result.served["ep2"], 1, "the slowest endpoint should still be probed")
+	})
+}
+
+func TestSimulation_FlappingHealthWithBreakerAndFallback(t *testing.T) {
+	breaker := models.CircuitBreakerConfig{
+		Enabled:         true,
+		MaxRequests:     1,
+		Interval:        time.Second,
+		Timeout:         500 * time.Millisecond,
+		FailureRatio:    0.5,
+		MinimumRequests: 5,
+	}
+	const interval = 20 * time.Millisecond
+	const maxLatency = 40 * time.Millisecond
+
+	// Every endpoint fails from 3s to 6s, long enough to trip the breaker several times,
+	// while endpoints are ejected and restored on their own schedules
+	outage := []simWindow{{3 * time.Second, 6 * time.Second}}
+	result := simulate(t, simScenario{
+		algorithm: "round-robin",
+		endpoints: []simEndpoint{
+			{name: "a", weight: 1, latency: uniformLatency(10*time.Millisecond, maxLatency), failing: outage,
+				ejected: []simWindow{{time.Second, 2 * time.Second}, {7 * time.Second, 8 * time.Second}}},
+			{name: "b", weight: 1, latency: uniformLatency(10*time.Millisecond, maxLatency), failing: outage,
+				ejected: []simWindow{{1500 * time.Millisecond, 4 * time.Second}}},
+			{name: "c", weight: 1, latency: uniformLatency(10*time.Millisecond, maxLatency), failing: outage,
+				ejected: []simWindow{{1800 * time.Millisecond, 1900 * time.Millisecond}, {8 * time.Second, 9 * time.Second}}},
+		},
+		breaker:  breaker,
+		fallback: true,
+		requests: 2000,
+		interval: interval,
+		seed:     7,
+	})
+
+	assert.Zero(t, result.servedWhileEjected, "no request should reach an ejected endpoint")
+	assert.Zero(t, result.servedWhileOpen, "no request should reach the primary while its breaker is open")
+
+	// Each client request is retried at most once, and only when the primary failed
+	assert.LessOrEqual(t, result.maxAttempts, 2)
+	assert.Equal(t, result.expectedFallbacks, result.fallbacks)
+	assert.Positive(t, result.fallbacks)
+
+	// The breaker stays open for its timeout, and reopens for the next request after it
+	require.NotEmpty(t, result.breakerOpen, "the outage should trip the breaker")
+	for _, d := range result.breakerOpen {
+		assert.Greater(t, d, breaker.Timeout)
+		assert.LessOrEqual(t, d, breaker.Timeout+interval+maxLatency)
+	}
+
+	// After the outage every endpoint is back in rotation
+	for _, name := range []string{"a", "b", "c"} {
+		assert.Positive(t, result.served[name], name)
+	}
+}
+
+func TestSimulation_Deterministic(t *testing.T) {
+	scenario := simScenario{
+		algorithm: "random",
+		endpoints: []simEndpoint{
+			{name: "a", weight: 1, latency: uniformLatency(time.Millisecond, 50*time.Millisecond),
+				failing: []simWindow{{time.Second, 2 * time.Second}}},
+			{name: "b", weight: 1, latency: uniformLatency(time.Millisecond, 50*time.Millisecond),
+				ejected: []simWindow{{500 * time.Millisecond, 1500 * time.Millisecond}}},
+			{name: "c", weight: 1, latency: uniformLatency(time.Millisecond, 50*time.Millisecond)},
+		},
+		breaker:  models.CircuitBreakerConfig{Enabled: true, MaxRequests: 1, Interval: time.Second, Timeout: 200 * time.Millisecond, FailureRatio: 0.3, MinimumRequests: 3},
+		fallback: true,
+		requests: 500,
+		interval: 5 * time.Millisecond,
+		seed:     99,
+	}
+
+	first := simulate(t, scenario)
+	second := simulate(t, scenario)
+	assert.Equal(t, first.trace, second.trace, "runs with the same seed should route identically")
+	assert.Equal(t, first.breakerOpen, second.breakerOpen)
+
+	scenario.seed = 100
+	assert.NotEqual(t, first.trace, simulate(t, scenario).trace, "a different seed should change the run")
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// maxStreamingOverheadPercent is how much slower a large download through the router may be than direct
+const maxStreamingOverheadPercent = 100
+
+// streamingRouter serves the route through a real HTTP server so flushes reach the client
+func streamingRouter(t testing.TB, backendURL string, streaming *models.StreamingConfig) *httptest.Server {
+	cfg := createCanaryConfig(backendURL, backendURL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Streaming = streaming
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(r.CreateHandler(&cfg.Routes[0]))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRouter_StreamingFlush(t *testing.T) {
+	// The backend sends the first chunk of a sized body, then waits for the client to go away
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", "10")
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer backend.Close()
+
+	tests := []struct {
+		name      string
+		streaming *models.StreamingConfig
+		flushed   bool
+	}{
+		{name: "buffered by default", streaming: nil, flushed: false},
+		{name: "flush interval", streaming: &models.StreamingConfig{FlushInterval: 10 * time.Millisecond}, flushed: true},
+		{name: "unbuffered content type", streaming: &models.StreamingConfig{UnbufferedContentTypes: []string{"application/octet-stream"}}, flushed: true},
+		{name: "other content type stays buffered", streaming: &models.StreamingConfig{UnbufferedContentTypes: []string{"text/event-stream"}}, flushed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := streamingRouter(t, backend.URL, tt.streaming)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			firstChunk := make(chan struct{})
+			go func() {
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/download", nil)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					return
+				}
+				defer resp.Body.Close()
+				buf := make([]byte, len("first"))
+				if _, err := io.ReadFull(resp.Body, buf); err == nil {
+					close(firstChunk)
+				}
+			}()
+
+			select {
+			case <-firstChunk:
+				assert.True(t, tt.flushed, "the first chunk should wait for the rest of the body")
+			case <-time.After(300 * time.Millisecond):
+				assert.False(t, tt.flushed, "the first chunk should reach the client while the backend is still sending")
+			}
+		})
+	}
+}
+
+func TestRouter_ServerSentEvents(t *testing.T) {
+	// The backend sends each event only after the client has read the previous one,
+	// and keeps the stream open well past the route timeout
+	received := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "data: event-%d\n\n", i)
+			w.(http.Flusher).Flush()
+			select {
+			case <-received:
+			case <-r.Context().Done():
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer backend.Close()
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Timeout = 150 * time.Millisecond
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	server := httptest.NewServer(r.CreateHandler(&cfg.Routes[0]))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+	}()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-events:
+			assert.Equal(t, fmt.Sprintf("event-%d", i), event)
+			received <- struct{}{}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d did not arrive while the stream was open", i)
+		}
+	}
+	_, open := <-events
+	assert.False(t, open, "the stream should end when the backend finishes")
+	assert.Greater(t, time.Since(start), cfg.Routes[0].Timeout, "the stream should outlive the route timeout")
+}
+
+func TestRouter_ServerSentEventsWithFallback(t *testing.T) {
+	// The stream outlasts the server's write timeout, which must be lifted through the
+	// writer that holds back failed responses for the fallback
+	const writeTimeout = 200 * time.Millisecond
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(w, "data: event-%d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(2 * writeTimeout)
+		}
+	}))
+	defer backend.Close()
+	fallback := newNamedBackend(t, "fallback")
+
+	cfg := createCanaryConfig(backend.URL, fallback.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].FallbackBackend = "canary"
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(r.CreateHandler(&cfg.Routes[0]))
+	server.Config.WriteTimeout = writeTimeout
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "the stream should not be cut off by the write timeout")
+	assert.Equal(t, "data: event-0\n\ndata: event-1\n\n", string(body))
+}
+
+func TestStreamingConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		streaming models.StreamingConfig
+		wantErr   bool
+	}{
+		{name: "defaults", streaming: models.StreamingConfig{}},
+		{name: "tuned", streaming: models.StreamingConfig{BufferSize: 256 << 10, FlushInterval: -1, UnbufferedContentTypes: []string{"video/mp4"}}},
+		{name: "buffer too small", streaming: models.StreamingConfig{BufferSize: 16}, wantErr: true},
+		{name: "buffer too large", streaming: models.StreamingConfig{BufferSize: 1 << 30}, wantErr: true},
+		{name: "invalid content type", streaming: models.StreamingConfig{UnbufferedContentTypes: []string{"not a type"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.streaming.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// download fetches the URL and discards the body
+func download(b *testing.B, url string, size int) time.Duration {
+	start := time.Now()
+	resp, err := http.Get(url)
+	require.NoError(b, err)
+	n, err := io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	require.NoError(b, err)
+	require.EqualValues(b, size, n)
+	return time.Since(start)
+}
+
+func BenchmarkRouter_StreamingThroughput(b *testing.B) {
+	const size = 100 << 20
+	chunk := bytes.Repeat([]byte("x"), 256<<10)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		for written := 0; written < size; written += len(chunk) {
+			w.Write(chunk)
+		}
+	}))
+	defer backend.Close()
+
+	server := streamingRouter(b, backend.URL, &models.StreamingConfig{BufferSize: 256 << 10})
+
+	var direct, routed time.Duration
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		direct += download(b, backend.URL+"/api/download", size)
+		routed += download(b, server.URL+"/api/download", size)
+	}
+	b.StopTimer()
+
+	overhead := float64(routed-direct) / float64(direct) * 100
+	b.ReportMetric(overhead, "overhead-%")
+	if overhead > maxStreamingOverheadPercent {
+		b.Fatalf("router overhead %.0f%% exceeds %d%%", overhead, maxStreamingOverheadPercent)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services"
+)
+
+// latencyRegistry returns a registry with a route latency histogram like the collector's, and the histogram
+func latencyRegistry() (*prometheus.Registry, *prometheus.HistogramVec) {
+	registry := prometheus.NewRegistry()
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "route_request_duration_seconds",
+		Buckets: services.RouteLatencyBuckets,
+	}, []string{"route"})
+	registry.MustRegister(histogram)
+	return registry, histogram
+}
+
+// observeN records n requests of the given latency for a route
+func observeN(histogram *prometheus.HistogramVec, route string, n int, latency time.Duration) {
+	for i := 0; i < n; i++ {
+		histogram.WithLabelValues(route).Observe(latency.Seconds())
+	}
+}
+
+func cutoffAt(t *testing.T, report services.RouteTimeoutReport, timeout time.Duration) float64 {
+	for _, cutoff := range report.Cutoffs {
+		if cutoff.TimeoutSeconds == timeout.Seconds() {
+			return cutoff.Fraction
+		}
+	}
+	t.Fatalf("no cutoff for %s in %+v", timeout, report.Cutoffs)
+	return 0
+}
+
+func TestTimeoutReport(t *testing.T) {
+	registry, histogram := latencyRegistry()
+
+	// Uniform latency between 10ms and 109ms
+	for i := 0; i < 10000; i++ {
+		observeN(histogram, "uniform", 1, 10*time.Millisecond+time.Duration(i%100)*time.Millisecond)
+	}
+
+	// A fast bulk with a slow tail: 0.9% at 1.2s and 0.1% at 4s
+	observeN(histogram, "tail", 9900, 50*time.Millisecond)
+	observeN(histogram, "tail", 90, 1200*time.Millisecond)
+	observeN(histogram, "tail", 10, 4*time.Second)
+
+	observeN(histogram, "sparse", 10, 20*time.Millisecond)
+
+	histograms, err := services.RouteLatencyHistograms(registry)
+	require.NoError(t, err)
+
+	routes := []models.RouteConfig{
+		{ID: "uniform", Timeout: 30 * time.Second},
+		{ID: "tail", Timeout: time.Second},
+		{ID: "sparse", Timeout: 30 * time.Second},
+		{ID: "idle", Timeout: 30 * time.Second},
+	}
+	reports := services.BuildTimeoutReport(routes, histograms)
+	require.Len(t, reports, 4)
+
+	t.Run("uniform latency", func(t *testing.T) {
+		report := reports[0]
+		assert.Equal(t, uint64(10000), report.Samples)
+		assert.InDelta(t, 0.108, report.P99, 0.02)
+		assert.InDelta(t, 0.109, report.P999, 0.02)
+		assert.Equal(t, 0.2, report.SuggestedTimeout, "p999 x 1.5 rounded up to 100ms")
+		assert.False(t, report.InsufficientData)
+
+		assert.Zero(t, report.ConfiguredCutoff)
+		assert.InDelta(t, 0.1, cutoffAt(t, report, 100*time.Millisecond), 0.03)
+		assert.Zero(t, cutoffAt(t, report, 250*time.Millisecond))
+		assert.Zero(t, cutoffAt(t, report, 200*time.Millisecond), "the suggested timeout is a candidate")
+	})
+
+	t.Run("slow tail", func(t *testing.T) {
+		report := reports[1]
+		assert.InDelta(t, 0.05, report.P99, 0.012)
+		assert.InDelta(t, 1.2, report.P999, 0.3)
+		assert.InDelta(t, 1.8, report.SuggestedTimeout, 0.5)
+		assert.Greater(t, report.SuggestedTimeout, report.ConfiguredTimeout, "the configured timeout is too short for the tail")
+
+		assert.InDelta(t, 0.01, report.ConfiguredCutoff, 0.002, "the configured 1s timeout cuts off the slow tail")
+		assert.InDelta(t, 0.001, cutoffAt(t, report, 2*time.Second), 0.0005)
+		assert.Zero(t, cutoffAt(t, report, 5*time.Second))
+	})
+
+	t.Run("too few samples", func(t *testing.T) {
+		report := reports[2]
+		assert.Equal(t, uint64(10), report.Samples)
+		assert.True(t, report.InsufficientData)
+		assert.Zero(t, report.SuggestedTimeout, "no suggestion from a handful of requests")
+	})
+
+	t.Run("no traffic", func(t *testing.T) {
+		report := reports[3]
+		assert.Zero(t, report.Samples)
+		assert.Zero(t, report.P999)
+		assert.True(t, report.InsufficientData)
+		assert.Zero(t, report.ConfiguredCutoff)
+	})
+}
+
+func TestSuggestTimeout_RoundsUp(t *testing.T) {
+	registry, histogram := latencyRegistry()
+	observeN(histogram, "fast", 5000, 2*time.Millisecond)
+
+	histograms, err := services.RouteLatencyHistograms(registry)
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, services.SuggestTimeout(histograms["fast"]), "suggestions are at least one step")
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// writeCAFile writes the TLS test server's certificate as a CA bundle
+func writeCAFile(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestRouter_BackendTLS(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure")
+	}))
+	defer backend.Close()
+	caFile := writeCAFile(t, backend)
+
+	serveTLS := func(t *testing.T, tlsConfig *models.TLSConfig) int {
+		cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+		cfg.Routes[0].CanaryBackend = ""
+		cfg.Backends[0].TLS = tlsConfig
+		r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.CreateHandler(&cfg.Routes[0]).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+		return w.Code
+	}
+
+	t.Run("trusts the configured CA", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveTLS(t, &models.TLSConfig{CAFile: caFile}))
+	})
+
+	t.Run("rejects unknown CA by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadGateway, serveTLS(t, nil))
+	})
+
+	t.Run("verifies against the SNI override", func(t *testing.T) {
+		// The test certificate is valid for example.com but not for other names
+		assert.Equal(t, http.StatusOK, serveTLS(t, &models.TLSConfig{CAFile: caFile, ServerName: "example.com"}))
+		assert.Equal(t, http.StatusBadGateway, serveTLS(t, &models.TLSConfig{CAFile: caFile, ServerName: "internal.test"}))
+	})
+
+	t.Run("skips verification when allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveTLS(t, &models.TLSConfig{InsecureSkipVerify: true}))
+	})
+
+	t.Run("fails to start with a missing CA file", func(t *testing.T) {
+		cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+		cfg.Backends[0].TLS = &models.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+		_, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		assert.Error(t, err)
+	})
+}
+
+func TestHealthChecker_BackendTLS(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	newBackend := func(tlsConfig *models.TLSConfig) models.BackendService {
+		return models.BackendService{
+			ID:      "tls-backend",
+			Name:    "tls-backend",
+			Enabled: true,
+			TLS:     tlsConfig,
+			Endpoints: []models.EndpointConfig{
+				{URL: backend.URL, Weight: 1, Healthy: true},
+			},
+			HealthCheck: models.HealthCheckConfig{
+				Enabled:        true,
+				Path:           "/health",
+				Interval:       1 * time.Second,
+				Timeout:        500 * time.Millisecond,
+				ExpectedStatus: []int{200},
+			},
+		}
+	}
+
+	t.Run("uses the backend TLS settings", func(t *testing.T) {
+		data := firstBackendHealthEvent(t, newBackend(&models.TLSConfig{CAFile: writeCAFile(t, backend)}))
+		assert.Equal(t, "healthy", data["status"])
+	})
+
+	t.Run("fails like the proxy without them", func(t *testing.T) {
+		data := firstBackendHealthEvent(t, newBackend(nil))
+		assert.Equal(t, "unhealthy", data["status"])
+		assert.Contains(t, data["error"], "certificate")
+	})
+}
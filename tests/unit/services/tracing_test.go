@@ -0,0 +1,85 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/lib/middleware"
+	"github.com/your-org/ryohi-router/src/lib/tracing"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+func TestRouter_TracesBackendCalls(t *testing.T) {
+	received := make(chan string, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer backend.Close()
+
+	exporter := tracing.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter)
+
+	cfg := createCanaryConfig(backend.URL, backend.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	r.SetTracer(provider)
+	handler := middleware.Tracing(provider)(r.CreateHandler(&cfg.Routes[0]))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders", nil))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	child, parent := spans[0], spans[1] // children end first
+	assert.Equal(t, tracing.SpanKindServer, parent.Kind)
+	assert.Equal(t, tracing.SpanKindClient, child.Kind)
+	assert.Equal(t, parent.Context.TraceID, child.Context.TraceID)
+	assert.Equal(t, parent.Context.SpanID, child.Parent)
+
+	assert.Equal(t, "primary", child.Attributes["backend.id"])
+	assert.Equal(t, backend.URL, child.Attributes["endpoint.url"])
+	assert.Equal(t, http.StatusCreated, child.Attributes["http.response.status_code"])
+	assert.Empty(t, child.Error)
+	assert.Equal(t, child.Context.Traceparent(), <-received, "the backend should see the child span")
+}
+
+func TestRouter_TracesBackendErrors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	exporter := tracing.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter)
+
+	cfg := createCanaryConfig(slow.URL, slow.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Routes[0].Timeout = 50 * time.Millisecond
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	r.SetTracer(provider)
+
+	w := httptest.NewRecorder()
+	middleware.Tracing(provider)(r.CreateHandler(&cfg.Routes[0])).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+	require.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	child := spans[0]
+	assert.Equal(t, http.StatusGatewayTimeout, child.Attributes["http.response.status_code"])
+	assert.Equal(t, "timeout", child.Attributes["error.type"])
+	assert.NotEmpty(t, child.Error)
+	assert.NotEmpty(t, spans[1].Error, "the request span should fail too")
+}
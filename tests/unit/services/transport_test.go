@@ -0,0 +1,109 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// countingBackend is a slow backend that counts the connections opened to it
+func countingBackend(t testing.TB) (*httptest.Server, *atomic.Int64) {
+	var conns atomic.Int64
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	backend.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	backend.Start()
+	t.Cleanup(backend.Close)
+	return backend, &conns
+}
+
+// burst sends rounds of concurrent requests through the handler
+func burst(t testing.TB, handler http.Handler, rounds, concurrency int) {
+	for i := 0; i < rounds; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < concurrency; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+				assert.Equal(t, http.StatusOK, w.Code)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// transportHandler creates a router handler for a backend with the given transport settings
+func transportHandler(t testing.TB, backendURL string, transport *models.TransportConfig) http.Handler {
+	cfg := createCanaryConfig(backendURL, backendURL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Backends[0].Transport = transport
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return r.CreateHandler(&cfg.Routes[0])
+}
+
+func TestRouter_TransportTuning(t *testing.T) {
+	const rounds, concurrency = 5, 16
+
+	defaultBackend, defaultConns := countingBackend(t)
+	burst(t, transportHandler(t, defaultBackend.URL, nil), rounds, concurrency)
+
+	tunedBackend, tunedConns := countingBackend(t)
+	tuned := &models.TransportConfig{MaxIdleConns: 100, MaxIdleConnsPerHost: concurrency}
+	burst(t, transportHandler(t, tunedBackend.URL, tuned), rounds, concurrency)
+
+	t.Logf("connections opened: default %d, tuned %d", defaultConns.Load(), tunedConns.Load())
+	assert.LessOrEqual(t, tunedConns.Load(), int64(concurrency), "tuned transport should reuse idle connections across rounds")
+	assert.Less(t, tunedConns.Load(), defaultConns.Load())
+}
+
+func TestRouter_TransportDisableKeepAlives(t *testing.T) {
+	backend, conns := countingBackend(t)
+	handler := transportHandler(t, backend.URL, &models.TransportConfig{DisableKeepAlives: true})
+
+	burst(t, handler, 3, 1)
+	assert.EqualValues(t, 3, conns.Load(), "every request should use a new connection")
+}
+
+func BenchmarkRouter_TransportConnections(b *testing.B) {
+	const concurrency = 16
+
+	benchmarks := []struct {
+		name      string
+		transport *models.TransportConfig
+	}{
+		{name: "default"},
+		{name: "tuned", transport: &models.TransportConfig{MaxIdleConns: 100, MaxIdleConnsPerHost: concurrency}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			backend, conns := countingBackend(b)
+			handler := transportHandler(b, backend.URL, bm.transport)
+
+			b.ResetTimer()
+			burst(b, handler, b.N, concurrency)
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
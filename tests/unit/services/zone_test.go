@@ -0,0 +1,136 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/ryohi-router/src/models"
+	"github.com/your-org/ryohi-router/src/services/loadbalancer"
+	"github.com/your-org/ryohi-router/src/services/router"
+)
+
+// zonedEndpoint creates a healthy endpoint tagged with a zone
+func zonedEndpoint(url, zone string) models.EndpointConfig {
+	return models.EndpointConfig{URL: url, Weight: 1, Healthy: true, Metadata: map[string]string{models.ZoneMetadataKey: zone}}
+}
+
+// pickCounts counts the endpoints a balancer picks over n requests
+func pickCounts(t *testing.T, lb loadbalancer.LoadBalancer, n int) map[string]int {
+	picked := map[string]int{}
+	for i := 0; i < n; i++ {
+		endpoint := lb.Next()
+		require.NotNil(t, endpoint)
+		picked[endpoint.URL]++
+	}
+	return picked
+}
+
+func TestZoneAware_PrefersLocalEndpoints(t *testing.T) {
+	algorithms := []string{"round-robin", "weighted", "least-conn", "random", "least-response-time", "least-latency", "consistent-hash"}
+	for _, algorithm := range algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			lb, err := loadbalancer.NewZoneAware(&models.LoadBalancerConfig{Algorithm: algorithm}, []models.EndpointConfig{
+				zonedEndpoint("http://a:3000", "tokyo-a"),
+				zonedEndpoint("http://b:3000", "osaka-a"),
+				{URL: "http://c:3000", Weight: 1, Healthy: true},
+			}, "tokyo-a", 0)
+			require.NoError(t, err)
+
+			assert.Equal(t, map[string]int{"http://a:3000": 20}, pickCounts(t, lb, 20))
+			assert.Len(t, lb.Endpoints(), 3, "other zones' endpoints are still listed")
+
+			// With no local endpoint left, traffic spills over to the other zones
+			lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://a:3000"})
+			picked := pickCounts(t, lb, 20)
+			assert.NotContains(t, picked, "http://a:3000")
+			assert.NotEmpty(t, picked)
+
+			lb.MarkHealthy(&models.EndpointConfig{URL: "http://a:3000"})
+			assert.Equal(t, map[string]int{"http://a:3000": 20}, pickCounts(t, lb, 20), "traffic should return once the zone recovers")
+
+			lb.MarkDraining(&models.EndpointConfig{URL: "http://a:3000"}, true)
+			assert.NotContains(t, pickCounts(t, lb, 20), "http://a:3000", "a draining endpoint doesn't keep traffic in the zone")
+		})
+	}
+}
+
+func TestZoneAware_SpillsOverBelowMinHealthy(t *testing.T) {
+	lb, err := loadbalancer.NewZoneAware(&models.LoadBalancerConfig{Algorithm: "round-robin"}, []models.EndpointConfig{
+		zonedEndpoint("http://a1:3000", "tokyo-a"),
+		zonedEndpoint("http://a2:3000", "tokyo-a"),
+		zonedEndpoint("http://b:3000", "osaka-a"),
+	}, "tokyo-a", 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"http://a1:3000": 10, "http://a2:3000": 10}, pickCounts(t, lb, 20))
+
+	// One local endpoint is too few; the remaining one shares the load with the other zone
+	lb.MarkUnhealthy(&models.EndpointConfig{URL: "http://a1:3000"})
+	assert.Equal(t, map[string]int{"http://a2:3000": 10, "http://b:3000": 10}, pickCounts(t, lb, 20))
+}
+
+func TestZoneAware_EndpointChangesZone(t *testing.T) {
+	lb, err := loadbalancer.NewZoneAware(&models.LoadBalancerConfig{Algorithm: "round-robin"}, []models.EndpointConfig{
+		zonedEndpoint("http://a:3000", "tokyo-a"),
+		zonedEndpoint("http://b:3000", "osaka-a"),
+	}, "tokyo-a", 0)
+	require.NoError(t, err)
+
+	// An endpoint moved into the zone keeps its drain state
+	lb.MarkDraining(&models.EndpointConfig{URL: "http://b:3000"}, true)
+	lb.AddEndpoint(zonedEndpoint("http://b:3000", "tokyo-a"))
+	assert.Equal(t, map[string]int{"http://a:3000": 10}, pickCounts(t, lb, 10))
+	lb.MarkDraining(&models.EndpointConfig{URL: "http://b:3000"}, false)
+	assert.Equal(t, map[string]int{"http://a:3000": 5, "http://b:3000": 5}, pickCounts(t, lb, 10))
+
+	// Moving the local endpoint out of the zone leaves b as the only local one
+	lb.AddEndpoint(zonedEndpoint("http://a:3000", "osaka-a"))
+	assert.Equal(t, map[string]int{"http://b:3000": 10}, pickCounts(t, lb, 10))
+
+	// Removing b leaves no local endpoint, so a takes the traffic from its zone
+	assert.True(t, lb.RemoveEndpoint("http://b:3000"))
+	assert.Equal(t, map[string]int{"http://a:3000": 10}, pickCounts(t, lb, 10))
+	assert.Len(t, lb.Endpoints(), 1)
+}
+
+func TestRouter_ZoneAwareRouting(t *testing.T) {
+	local := newNamedBackend(t, "local")
+	remote := newNamedBackend(t, "remote")
+
+	cfg := createCanaryConfig(local.URL, local.URL, 0)
+	cfg.Routes[0].CanaryBackend = ""
+	cfg.Router.Zone = "tokyo-a"
+	cfg.Backends[0].Endpoints = []models.EndpointConfig{
+		zonedEndpoint(local.URL, "tokyo-a"),
+		zonedEndpoint(remote.URL, "osaka-a"),
+	}
+
+	r, err := router.New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	handler := r.CreateHandler(&cfg.Routes[0])
+	for i := 0; i < 10; i++ {
+		body, _ := serve(t, handler, "")
+		assert.Equal(t, "local", body)
+	}
+
+	backend, exists := r.GetBackend(cfg.Backends[0].ID)
+	require.True(t, exists)
+	backend.LoadBalancer.MarkUnhealthy(&models.EndpointConfig{URL: local.URL})
+	body, _ := serve(t, handler, "")
+	assert.Equal(t, "remote", body, "requests should spill over to the other zone")
+
+	// Leaving the zone on reload rebuilds the backend to balance across every endpoint
+	reloaded := *cfg
+	reloaded.Router.Zone = ""
+	require.NoError(t, r.Reload(&reloaded))
+	handler = r.CreateHandler(&reloaded.Routes[0])
+	served := map[string]int{}
+	for i := 0; i < 10; i++ {
+		body, _ := serve(t, handler, "")
+		served[body]++
+	}
+	assert.Equal(t, map[string]int{"local": 5, "remote": 5}, served)
+}